@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/gob"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/internal/secure"
 	"gorm.io/gorm"
 )
 
@@ -16,6 +18,16 @@ func init() {
 	gob.Register(&GothSession{})
 	gob.Register(&GothVerificationToken{})
 	gob.Register(&GothCsrfToken{})
+	gob.Register(&GothThrottle{})
+	gob.Register(&GothTeam{})
+	gob.Register(&GothTeamMember{})
+	gob.Register(&GothProviderDomain{})
+	gob.Register(&GothCredential{})
+	gob.Register(&GothTrustedDevice{})
+	gob.Register(&GothInstallation{})
+	gob.Register(&GothInvitation{})
+	gob.Register(&GothNotificationPreference{})
+	gob.Register(&GothNotification{})
 }
 
 // AccountType represents the type of an account.
@@ -24,6 +36,9 @@ type AccountType string
 // ErrUnimplemented is returned when a method is not implemented.
 var ErrUnimplemented = errors.New("not implemented")
 
+// ErrThrottled is returned by AttemptThrottle if the identity has to wait before the next attempt.
+var ErrThrottled = errors.New("throttled")
+
 const (
 	// AccountTypeOAuth2 represents an OAuth2 account type.
 	AccountTypeOAuth2 AccountType = "oauth2"
@@ -35,6 +50,16 @@ const (
 	AccountTypeEmail AccountType = "email"
 	// AccountTypeWebAuthn represents a WebAuthn account type.
 	AccountTypeWebAuthn AccountType = "webauthn"
+	// AccountTypeEthereum represents an Ethereum wallet account type.
+	AccountTypeEthereum AccountType = "ethereum"
+	// AccountTypeProxy represents an account authenticated by an upstream proxy.
+	AccountTypeProxy AccountType = "proxy"
+	// AccountTypeKerberos represents a Kerberos account type.
+	AccountTypeKerberos AccountType = "kerberos"
+	// AccountTypeCertificate represents a client certificate account type.
+	AccountTypeCertificate AccountType = "certificate"
+	// AccountTypeJWT represents a JWT bearer assertion account type.
+	AccountTypeJWT AccountType = "jwt"
 )
 
 // GothAccount represents an account in a third-party identity provider.
@@ -61,6 +86,10 @@ type GothAccount struct {
 	IDToken *string `json:"id_token"`
 	// SessionState is the session state of the account.
 	SessionState string `json:"session_state"`
+	// LastRefreshedAt is the time the token has last been refreshed.
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+	// LastRefreshError is the error of the last failed refresh. It is cleared by a successful refresh.
+	LastRefreshError string `json:"last_refresh_error,omitempty"`
 	// UserID is the user ID of the account.
 	UserID *uuid.UUID `json:"user_id"`
 	//  User is the user of the account.
@@ -85,6 +114,10 @@ type GothUser struct {
 	EmailVerified *bool `json:"email_verified"`
 	// Image is the image URL of the user.
 	Image *string `json:"image" validate:"url"`
+	// Locale is the preferred language of the user (e.g. "de-DE").
+	Locale string `json:"locale"`
+	// Groups are the group identifiers supplied by the provider during sign-in. They are not persisted.
+	Groups []string `json:"groups,omitempty" gorm:"-"`
 	// Password is the password of the user.
 	Accounts []GothAccount `json:"accounts" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	// Sessions are the sessions of the user.
@@ -102,7 +135,8 @@ type GothSession struct {
 	// ID is the unique identifier of the session.
 	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
 	// SessionToken is the token of the session.
-	SessionToken string `json:"session_token"`
+	// Adapters only persist the SHA-256 hash of the token.
+	SessionToken string `json:"session_token" gorm:"uniqueIndex"`
 	// CsrfToken is the CSRF token of the session.
 	CsrfToken GothCsrfToken `json:"csrf_token"`
 	// CsrfTokenID is the CSRF token ID of the session.
@@ -113,6 +147,24 @@ type GothSession struct {
 	User GothUser `json:"user"`
 	// ExpiresAt is the expiry time of the session.
 	ExpiresAt time.Time `json:"expires_at"`
+	// Provider is the ID of the provider the session has been created with.
+	Provider string `json:"provider" gorm:"index"`
+	// IPAddress is the IP address of the client that created the session.
+	IPAddress string `json:"ip_address"`
+	// UserAgentHash is the SHA-256 hash of the user agent of the client that created the session.
+	UserAgentHash string `json:"user_agent_hash"`
+	// CertThumbprint is the SHA-256 thumbprint of the client certificate of the client that created the session.
+	CertThumbprint string `json:"cert_thumbprint"`
+	// Version is incremented on every update to detect concurrent modifications.
+	Version int `json:"version" gorm:"not null;default:1"`
+	// MFAVerifiedAt is the time a second factor has been verified for the session.
+	MFAVerifiedAt *time.Time `json:"mfa_verified_at"`
+	// LastSeenAt is the time of the last request with the session.
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	// ActiveTeamID is the ID of the team the user currently works in.
+	ActiveTeamID *uuid.UUID `json:"active_team_id" gorm:"type:uuid"`
+	// Claims are custom attributes of the user computed at the creation of the session (e.g. the plan).
+	Claims map[string]any `json:"claims,omitempty" gorm:"type:jsonb;serializer:json"`
 	// CreatedAt is the creation time of the session.
 	CreatedAt time.Time `json:"created_at"`
 	// UpdatedAt is the update time of the session.
@@ -147,6 +199,11 @@ func (s *GothSession) IsValid() bool {
 	return s.ExpiresAt.After(time.Now())
 }
 
+// IsMFAVerified returns true if a second factor has been verified for the session.
+func (s *GothSession) IsMFAVerified() bool {
+	return s.MFAVerifiedAt != nil
+}
+
 // GetCsrfToken returns the CSRF token.
 func (s *GothSession) GetCsrfToken() GothCsrfToken {
 	return s.CsrfToken
@@ -157,14 +214,178 @@ func (c GothCsrfToken) HasExpired() bool {
 	return c.ExpiresAt.Before(time.Now())
 }
 
-// IsValid returns true if the token is valid.
+// IsValid returns true if the token is valid. The tokens are compared in constant time.
 func (c GothCsrfToken) IsValid(token string) bool {
-	return c.Token == token
+	return secure.Equal(c.Token, token)
+}
+
+// CredentialType is the type of a second factor credential.
+type CredentialType string
+
+const (
+	// CredentialTypeWebAuthn represents a security key or passkey.
+	CredentialTypeWebAuthn CredentialType = "webauthn"
+)
+
+// GothCredential is a second factor credential of a user (e.g. a security key).
+type GothCredential struct {
+	// ID is the unique identifier of the credential.
+	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
+	// UserID is the user ID of the credential.
+	UserID uuid.UUID `json:"user_id" gorm:"index"`
+	// Type is the type of the credential.
+	Type CredentialType `json:"type"`
+	// Name is the name of the credential given by the user.
+	Name string `json:"name"`
+	// CredentialID is the ID of the credential assigned by the authenticator.
+	CredentialID []byte `json:"-" gorm:"uniqueIndex"`
+	// Data is the serialized credential (e.g. public key and sign count).
+	Data []byte `json:"-"`
+	// LastUsedAt is the time the credential has been used last.
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// CreatedAt is the creation time of the credential.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the credential.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the credential.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+// GothTrustedDevice is a browser on which the second factor is skipped.
+type GothTrustedDevice struct {
+	// ID is the unique identifier of the device.
+	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
+	// UserID is the user ID of the device.
+	UserID uuid.UUID `json:"user_id" gorm:"index"`
+	// UserAgent is the user agent of the browser that has been trusted.
+	UserAgent string `json:"user_agent"`
+	// IPAddress is the IP address of the client that has been trusted.
+	IPAddress string `json:"ip_address"`
+	// ExpiresAt is the expiry time of the trust.
+	ExpiresAt time.Time `json:"expires_at"`
+	// CreatedAt is the creation time of the device.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the device.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the device.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+// IsValid returns true if the trust has not expired.
+func (d *GothTrustedDevice) IsValid() bool {
+	return d.ExpiresAt.After(time.Now())
+}
+
+// GothInstallation is an installation of a provider app (e.g. a GitHub App) linked to a team.
+type GothInstallation struct {
+	// ID is the unique identifier of the installation.
+	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
+	// TeamID is the ID of the team the installation is linked to.
+	TeamID uuid.UUID `json:"team_id" gorm:"type:uuid;uniqueIndex:idx_team_provider"`
+	// Team is the team the installation is linked to.
+	Team GothTeam `json:"-" gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+	// Provider is the ID of the provider of the installation.
+	Provider string `json:"provider" gorm:"uniqueIndex:idx_team_provider"`
+	// InstallationID is the ID of the installation in the provider.
+	InstallationID string `json:"installation_id" gorm:"index"`
+	// AccountLogin is the login of the account (e.g. organization) the app is installed on.
+	AccountLogin string `json:"account_login"`
+	// AccountType is the type of the account the app is installed on (e.g. Organization).
+	AccountType string `json:"account_type"`
+	// CreatedAt is the creation time of the installation.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the installation.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the installation.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+// GothInvitation is an invitation of an email to sign up, optionally into a team.
+type GothInvitation struct {
+	// ID is the unique identifier of the invitation.
+	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
+	// Email is the invited email.
+	Email string `json:"email" gorm:"index" validate:"required,email"`
+	// Token is the hash of the token of the invitation link (see HashToken).
+	Token string `json:"-" gorm:"uniqueIndex"`
+	// TeamID is the ID of the team the invited user joins.
+	TeamID *uuid.UUID `json:"team_id,omitempty" gorm:"type:uuid"`
+	// Team is the team the invited user joins.
+	Team *GothTeam `json:"-" gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+	// Role is the role of the invited user in the team.
+	Role string `json:"role,omitempty"`
+	// InvitedByID is the ID of the user that created the invitation.
+	InvitedByID uuid.UUID `json:"invited_by_id" gorm:"type:uuid"`
+	// ExpiresAt is the expiry time of the invitation.
+	ExpiresAt time.Time `json:"expires_at"`
+	// AcceptedAt is the time the invitation has been accepted.
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	// AcceptedByID is the ID of the user that accepted the invitation.
+	AcceptedByID *uuid.UUID `json:"accepted_by_id,omitempty" gorm:"type:uuid"`
+	// CreatedAt is the creation time of the invitation.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the invitation.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the invitation.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+// IsPending returns true if the invitation has neither been accepted nor expired.
+func (i *GothInvitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.ExpiresAt.After(time.Now())
+}
+
+// NotificationDelivery is how a user is notified about a type of security event.
+type NotificationDelivery string
+
+const (
+	// NotificationOff does not notify the user.
+	NotificationOff NotificationDelivery = "off"
+	// NotificationImmediate sends an alert as soon as the event occurs.
+	NotificationImmediate NotificationDelivery = "immediate"
+	// NotificationDigest collects the events for the next digest.
+	NotificationDigest NotificationDelivery = "digest"
+)
+
+// GothNotificationPreference is the preference of a user how to be notified about a type of security event.
+type GothNotificationPreference struct {
+	// UserID is the ID of the user.
+	UserID uuid.UUID `json:"user_id" gorm:"primaryKey;type:uuid"`
+	// EventType is the type of the event (e.g. "user.password_changed").
+	EventType string `json:"event_type" gorm:"primaryKey"`
+	// Delivery is how the user is notified.
+	Delivery NotificationDelivery `json:"delivery" validate:"oneof=off immediate digest"`
+	// CreatedAt is the creation time of the preference.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the preference.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GothNotification is a security event of a user that is pending for the next digest.
+type GothNotification struct {
+	// ID is the unique identifier of the notification.
+	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
+	// UserID is the ID of the notified user.
+	UserID uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	// EventType is the type of the event.
+	EventType string `json:"event_type"`
+	// Provider is the provider the event relates to.
+	Provider string `json:"provider,omitempty"`
+	// IPAddress is the IP address of the client that caused the event.
+	IPAddress string `json:"ip_address,omitempty"`
+	// OccurredAt is the time the event occurred.
+	OccurredAt time.Time `json:"occurred_at"`
+	// CreatedAt is the creation time of the notification.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the notification.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the notification.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
 }
 
 // GothVerificationToken is a verification token for a user
 type GothVerificationToken struct {
-	// Token is the unique identifier of the token.
+	// Token is the hash of the token (see HashToken).
 	Token string `json:"token" gorm:"primaryKey"`
 	// Identifier is the identifier of the token.
 	Identifier string `json:"identifier"`
@@ -178,14 +399,84 @@ type GothVerificationToken struct {
 	DeletedAt gorm.DeletedAt `json:"deleted_at"`
 }
 
-// Adapter is an interface that defines the methods for interacting with the underlying data storage.
-type Adapter interface {
+// GothTeam is a team (organization) of users.
+type GothTeam struct {
+	// ID is the unique identifier of the team.
+	ID uuid.UUID `json:"id" gorm:"primaryKey;unique;type:uuid;column:id;default:gen_random_uuid()"`
+	// Name is the name of the team.
+	Name string `json:"name" validate:"required,max=255"`
+	// Slug is the unique slug of the team.
+	Slug string `json:"slug" gorm:"unique" validate:"required,max=255"`
+	// Description is the description of the team.
+	Description *string `json:"description"`
+	// SSORequired is true if members of the team must sign in with the SSOProvider.
+	SSORequired bool `json:"sso_required"`
+	// SSOProvider is the ID of the provider members of the team must sign in with.
+	SSOProvider *string `json:"sso_provider"`
+	// Members are the members of the team.
+	Members []GothTeamMember `json:"members" gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+	// CreatedAt is the creation time of the team.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the team.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the team.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+// GothTeamMember is the membership of a user in a team.
+type GothTeamMember struct {
+	// TeamID is the team ID of the membership.
+	TeamID uuid.UUID `json:"team_id" gorm:"primaryKey;type:uuid"`
+	// UserID is the user ID of the membership.
+	UserID uuid.UUID `json:"user_id" gorm:"primaryKey;type:uuid"`
+	// User is the user of the membership.
+	User GothUser `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	// Role is the role of the user in the team.
+	Role string `json:"role"`
+	// CreatedAt is the creation time of the membership.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the membership.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GothProviderDomain maps an email domain to the provider its users sign in with.
+type GothProviderDomain struct {
+	// Domain is the email domain (e.g. "example.com").
+	Domain string `json:"domain" gorm:"primaryKey"`
+	// Provider is the ID of the provider.
+	Provider string `json:"provider" validate:"required"`
+	// CreatedAt is the creation time of the mapping.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the mapping.
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is the deletion time of the mapping.
+	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+}
+
+// GothThrottle tracks the failed sign-in attempts of an identity.
+type GothThrottle struct {
+	// Identifier is the identity the attempts are counted for (e.g. an email).
+	Identifier string `json:"identifier" gorm:"primaryKey"`
+	// Failures is the number of consecutive failed attempts.
+	Failures int `json:"failures"`
+	// LastFailureAt is the time of the last failed attempt.
+	LastFailureAt time.Time `json:"last_failure_at"`
+	// CreatedAt is the creation time of the throttle.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the update time of the throttle.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserStore stores users and their accounts.
+type UserStore interface {
 	// CreateUser creates a new user.
 	CreateUser(ctx context.Context, user GothUser) (GothUser, error)
 	// GetUser retrieves a user by ID.
 	GetUser(ctx context.Context, id uuid.UUID) (GothUser, error)
 	// GetUserByEmail retrieves a user by email.
 	GetUserByEmail(ctx context.Context, email string) (GothUser, error)
+	// GetUserByAccount retrieves a user by the account ID in a provider.
+	GetUserByAccount(ctx context.Context, provider string, providerAccountID string) (GothUser, error)
 	// UpdateUser updates a user.
 	UpdateUser(ctx context.Context, user GothUser) (GothUser, error)
 	// DeleteUser deletes a user by ID.
@@ -194,22 +485,340 @@ type Adapter interface {
 	LinkAccount(ctx context.Context, accountID, userID uuid.UUID) error
 	// UnlinkAccount unlinks an account from a user.
 	UnlinkAccount(ctx context.Context, accountID, userID uuid.UUID) error
-	// CreateSession creates a new session.
-	CreateSession(ctx context.Context, userID uuid.UUID, expires time.Time) (GothSession, error)
+	// CountUsers counts the users matching the filter.
+	CountUsers(ctx context.Context, filter UserFilter) (int64, error)
+	// ListUsers lists a page of the users matching the filter.
+	ListUsers(ctx context.Context, filter UserFilter, page Page) (UserPage, error)
+	// MergeUsers moves the accounts, sessions, team memberships, credentials and trusted devices
+	// of the loser to the winner and deletes the loser.
+	MergeUsers(ctx context.Context, winnerID, loserID uuid.UUID) error
+}
+
+// UserFilter are the criteria to select users. All set criteria must match.
+type UserFilter struct {
+	// Email selects the users whose email contains the string (case-insensitive).
+	Email string `json:"email,omitempty" query:"email"`
+	// Provider selects the users with an account of the provider.
+	Provider string `json:"provider,omitempty" query:"provider"`
+	// CreatedAfter selects the users created after the time.
+	CreatedAfter *time.Time `json:"created_after,omitempty" query:"-"`
+	// CreatedBefore selects the users created before the time.
+	CreatedBefore *time.Time `json:"created_before,omitempty" query:"-"`
+}
+
+// DefaultPageLimit is the default number of items of a page.
+const DefaultPageLimit = 50
+
+// MaxPageLimit is the maximum number of items of a page.
+const MaxPageLimit = 500
+
+// Page selects a page of a cursor paginated list.
+type Page struct {
+	// Cursor is the cursor returned with the previous page. It is empty for the first page.
+	Cursor string `json:"cursor,omitempty" query:"cursor"`
+	// Limit is the maximum number of items of the page.
+	Limit int `json:"limit,omitempty" query:"limit"`
+}
+
+// GetLimit returns the limit of the page within the bounds of DefaultPageLimit and MaxPageLimit.
+func (p Page) GetLimit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+
+	return min(p.Limit, MaxPageLimit)
+}
+
+// UserPage is a page of users.
+type UserPage struct {
+	// Users are the users of the page.
+	Users []GothUser `json:"users"`
+	// NextCursor is the cursor of the next page. It is empty if there are no more users.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SessionStore stores sessions.
+type SessionStore interface {
+	// CreateSession creates a new session for the user, token and expiry of the session.
+	CreateSession(ctx context.Context, session GothSession) (GothSession, error)
 	// GetSession retrieves a session by session token.
 	GetSession(ctx context.Context, sessionToken string) (GothSession, error)
 	// UpdateSession updates a session.
 	UpdateSession(ctx context.Context, session GothSession) (GothSession, error)
 	// RefreshSession refreshes a session.
 	RefreshSession(ctx context.Context, session GothSession) (GothSession, error)
+	// TouchSessions extends the expiry and sets the last activity of the sessions in a batch.
+	// The expiry is never shortened and touches of missing sessions are ignored.
+	TouchSessions(ctx context.Context, touches []SessionTouch) error
 	// DeleteSession deletes a session by session token.
 	DeleteSession(ctx context.Context, sessionToken string) error
-	// CreateVerificationToken creates a new verification token.
+	// DeleteSessionsWhere deletes all sessions matching the filter and returns the number of deleted sessions.
+	DeleteSessionsWhere(ctx context.Context, filter SessionFilter) (int64, error)
+	// CountActiveSessions counts the not expired sessions matching the filter. An empty filter counts all.
+	CountActiveSessions(ctx context.Context, filter SessionFilter) (int64, error)
+	// ListSessions lists a page of the not expired sessions matching the filter, ordered by ID.
+	// The session tokens of the listed sessions are empty.
+	ListSessions(ctx context.Context, filter SessionFilter, page Page) (SessionPage, error)
+}
+
+// SessionPage is a page of sessions.
+type SessionPage struct {
+	// Sessions are the sessions of the page.
+	Sessions []GothSession `json:"sessions"`
+	// NextCursor is the cursor of the next page. It is empty if there are no more sessions.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// SessionTouch is a deferred update of the expiry and the last activity of a session.
+type SessionTouch struct {
+	// SessionToken is the token of the session.
+	SessionToken string
+	// ExpiresAt is the new expiry time of the session.
+	ExpiresAt time.Time
+	// LastSeenAt is the time of the last request with the session.
+	LastSeenAt time.Time
+}
+
+// TokenStore stores verification tokens.
+type TokenStore interface {
+	// CreateVerificationToken creates a new verification token. The token is the hash of the
+	// token handed out (see HashToken), so that a leak of the storage cannot be used to verify.
 	CreateVerificationToken(ctx context.Context, verficationToken GothVerificationToken) (GothVerificationToken, error)
-	// UseVerficationToken uses a verification token.
+	// UseVerficationToken uses a verification token. It must compare and delete the token atomically,
+	// so that the token can be used only once.
 	UseVerficationToken(ctx context.Context, identifier string, token string) (GothVerificationToken, error)
 }
 
+// TeamStore stores teams and their members.
+type TeamStore interface {
+	// CreateTeam creates a new team.
+	CreateTeam(ctx context.Context, team GothTeam) (GothTeam, error)
+	// GetTeam retrieves a team by ID.
+	GetTeam(ctx context.Context, id uuid.UUID) (GothTeam, error)
+	// GetTeamBySlug retrieves a team by slug.
+	GetTeamBySlug(ctx context.Context, slug string) (GothTeam, error)
+	// UpdateTeam updates a team.
+	UpdateTeam(ctx context.Context, team GothTeam) (GothTeam, error)
+	// DeleteTeam deletes a team by ID.
+	DeleteTeam(ctx context.Context, id uuid.UUID) error
+	// AddTeamMember adds a user with a role to a team.
+	AddTeamMember(ctx context.Context, teamID, userID uuid.UUID, role string) error
+	// RemoveTeamMember removes a user from a team.
+	RemoveTeamMember(ctx context.Context, teamID, userID uuid.UUID) error
+	// ListUserTeams lists the teams of a user.
+	ListUserTeams(ctx context.Context, userID uuid.UUID) ([]GothTeam, error)
+}
+
+// ThrottleStore stores the failed sign-in attempts of identities.
+type ThrottleStore interface {
+	// GetThrottle retrieves the failed attempts of an identity.
+	GetThrottle(ctx context.Context, identifier string) (GothThrottle, error)
+	// IncrementThrottle records a failed attempt of an identity.
+	IncrementThrottle(ctx context.Context, identifier string) (GothThrottle, error)
+	// AttemptThrottle atomically checks and records an attempt of an identity as failed attempt.
+	// It returns ErrThrottled without recording the attempt if the delay of the failed attempts
+	// has not passed since the last failed attempt.
+	AttemptThrottle(ctx context.Context, identifier string, delay func(failures int) time.Duration) (GothThrottle, error)
+	// ResetThrottle resets the failed attempts of an identity.
+	ResetThrottle(ctx context.Context, identifier string) error
+}
+
+// DomainStore stores the email domain to provider mappings.
+type DomainStore interface {
+	// CreateProviderDomain creates or updates a domain to provider mapping.
+	CreateProviderDomain(ctx context.Context, domain GothProviderDomain) (GothProviderDomain, error)
+	// GetProviderDomain retrieves the provider mapping of a domain.
+	GetProviderDomain(ctx context.Context, domain string) (GothProviderDomain, error)
+	// DeleteProviderDomain deletes the provider mapping of a domain.
+	DeleteProviderDomain(ctx context.Context, domain string) error
+}
+
+// CredentialStore stores the second factor credentials of users.
+type CredentialStore interface {
+	// CreateCredential creates a new credential.
+	CreateCredential(ctx context.Context, credential GothCredential) (GothCredential, error)
+	// ListCredentials lists the credentials of a user.
+	ListCredentials(ctx context.Context, userID uuid.UUID) ([]GothCredential, error)
+	// UpdateCredential updates the data and last use of a credential.
+	UpdateCredential(ctx context.Context, credential GothCredential) (GothCredential, error)
+	// DeleteCredential deletes a credential of a user.
+	DeleteCredential(ctx context.Context, userID, id uuid.UUID) error
+}
+
+// DeviceStore stores the trusted devices of users.
+type DeviceStore interface {
+	// CreateTrustedDevice creates a new trusted device.
+	CreateTrustedDevice(ctx context.Context, device GothTrustedDevice) (GothTrustedDevice, error)
+	// GetTrustedDevice retrieves a trusted device by ID.
+	GetTrustedDevice(ctx context.Context, id uuid.UUID) (GothTrustedDevice, error)
+	// ListTrustedDevices lists the trusted devices of a user.
+	ListTrustedDevices(ctx context.Context, userID uuid.UUID) ([]GothTrustedDevice, error)
+	// DeleteTrustedDevice deletes a trusted device of a user.
+	DeleteTrustedDevice(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteTrustedDevices deletes all trusted devices of a user and returns the number of deleted devices.
+	DeleteTrustedDevices(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// AccountStore is the interface for storing the provider accounts of users.
+type AccountStore interface {
+	// GetAccount returns the account of a user for a provider.
+	GetAccount(ctx context.Context, userID uuid.UUID, provider string) (GothAccount, error)
+	// UpdateAccount updates the tokens and scope of an account.
+	UpdateAccount(ctx context.Context, account GothAccount) (GothAccount, error)
+	// UpsertAccount creates the account or updates the tokens of the account with the same provider and provider account ID.
+	UpsertAccount(ctx context.Context, account GothAccount) (GothAccount, error)
+	// ListExpiringAccounts returns up to limit accounts with a refresh token whose access token expires before the time.
+	ListExpiringAccounts(ctx context.Context, before time.Time, limit int) ([]GothAccount, error)
+}
+
+// InstallationStore is the interface for storing the app installations of teams.
+type InstallationStore interface {
+	// CreateInstallation links an installation to a team.
+	CreateInstallation(ctx context.Context, installation GothInstallation) (GothInstallation, error)
+	// GetInstallation returns the installation of a provider linked to a team.
+	GetInstallation(ctx context.Context, teamID uuid.UUID, provider string) (GothInstallation, error)
+	// DeleteInstallation unlinks the installation of a provider from a team.
+	DeleteInstallation(ctx context.Context, teamID uuid.UUID, provider string) error
+}
+
+// InvitationStore is the interface for storing invitations.
+type InvitationStore interface {
+	// CreateInvitation creates a new invitation.
+	CreateInvitation(ctx context.Context, invitation GothInvitation) (GothInvitation, error)
+	// GetInvitationByToken returns the invitation with the hash of the token.
+	GetInvitationByToken(ctx context.Context, token string) (GothInvitation, error)
+	// AcceptInvitation marks a pending invitation as accepted by the user. It must fail
+	// if the invitation has already been accepted, so that it can be accepted only once.
+	AcceptInvitation(ctx context.Context, id, userID uuid.UUID) (GothInvitation, error)
+	// DeleteInvitation deletes (revokes) an invitation.
+	DeleteInvitation(ctx context.Context, id uuid.UUID) error
+}
+
+// NotificationStore is the interface for storing the notification preferences and pending digests of users.
+type NotificationStore interface {
+	// ListNotificationPreferences returns the notification preferences of the user.
+	ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]GothNotificationPreference, error)
+	// SetNotificationPreference creates or updates the preference of the user for the event type.
+	SetNotificationPreference(ctx context.Context, preference GothNotificationPreference) (GothNotificationPreference, error)
+	// CreateNotification adds a notification to the next digest of the user.
+	CreateNotification(ctx context.Context, notification GothNotification) (GothNotification, error)
+	// ListPendingNotifications returns up to limit pending notifications, oldest first.
+	ListPendingNotifications(ctx context.Context, limit int) ([]GothNotification, error)
+	// DeleteNotifications deletes the notifications that have been sent in a digest.
+	DeleteNotifications(ctx context.Context, ids ...uuid.UUID) error
+}
+
+// Purger hard-deletes data that is no longer needed.
+type Purger interface {
+	// PurgeDeleted hard-deletes soft-deleted records and expired verification tokens older than the duration.
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) error
+}
+
+// Adapter is an interface that defines the methods for interacting with the underlying data storage.
+// It is the aggregate of all stores.
+type Adapter interface {
+	UserStore
+	SessionStore
+	TokenStore
+	TeamStore
+	ThrottleStore
+	DomainStore
+	CredentialStore
+	DeviceStore
+	AccountStore
+	InstallationStore
+	InvitationStore
+	NotificationStore
+	Purger
+}
+
+var _ Adapter = (*Composite)(nil)
+
+// Composite is an adapter that is composed of individual stores.
+type Composite struct {
+	UserStore
+	SessionStore
+	TokenStore
+	TeamStore
+	ThrottleStore
+	DomainStore
+	CredentialStore
+	DeviceStore
+	AccountStore
+	InstallationStore
+	InvitationStore
+	NotificationStore
+	Purger
+}
+
+// WithSessionStore returns an adapter that stores the sessions in the session store
+// and everything else in the base adapter (e.g. users in Postgres and sessions in Redis).
+func WithSessionStore(base Adapter, sessions SessionStore) *Composite {
+	return &Composite{
+		UserStore:         base,
+		SessionStore:      sessions,
+		TokenStore:        base,
+		TeamStore:         base,
+		ThrottleStore:     base,
+		DomainStore:       base,
+		CredentialStore:   base,
+		DeviceStore:       base,
+		AccountStore:      base,
+		InstallationStore: base,
+		InvitationStore:   base,
+		NotificationStore: base,
+		Purger:            base,
+	}
+}
+
+// ErrEmptyFilter is returned when a filter has no criteria.
+var ErrEmptyFilter = errors.New("filter has no criteria")
+
+// SessionFilter are the criteria to select sessions. All set criteria must match.
+type SessionFilter struct {
+	// ID selects the session with the ID.
+	ID *uuid.UUID `json:"id,omitempty"`
+	// UserID selects the sessions of the user.
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	// Provider selects the sessions created with the provider.
+	Provider string `json:"provider,omitempty"`
+	// CreatedBefore selects the sessions created before the time.
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	// IPPrefix selects the sessions created from an IP address with the prefix (e.g. "10.0.").
+	IPPrefix string `json:"ip_prefix,omitempty"`
+}
+
+// MatchSession returns true if the session matches all criteria of the filter.
+// It is intended for adapters that cannot filter in the underlying storage.
+func MatchSession(filter SessionFilter, session GothSession) bool {
+	if filter.ID != nil && *filter.ID != session.ID {
+		return false
+	}
+
+	if filter.UserID != nil && *filter.UserID != session.UserID {
+		return false
+	}
+
+	if filter.Provider != "" && filter.Provider != session.Provider {
+		return false
+	}
+
+	if filter.CreatedBefore != nil && !session.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+
+	if filter.IPPrefix != "" && !strings.HasPrefix(session.IPAddress, filter.IPPrefix) {
+		return false
+	}
+
+	return true
+}
+
+// IsEmpty returns true if the filter has no criteria.
+func (f SessionFilter) IsEmpty() bool {
+	return f.ID == nil && f.UserID == nil && f.Provider == "" && f.CreatedBefore == nil && f.IPPrefix == ""
+}
+
 var _ Adapter = (*UnimplementedAdapter)(nil)
 
 // UnimplementedAdapter is an adapter that does not implement any of the methods.
@@ -255,8 +864,8 @@ func (a *UnimplementedAdapter) UnlinkAccount(_ context.Context, accountID, userI
 	return ErrUnimplemented
 }
 
-// CreateSession creates a new session.
-func (a *UnimplementedAdapter) CreateSession(_ context.Context, userID uuid.UUID, expires time.Time) (GothSession, error) {
+// CreateSession creates a new session for the user, token and expiry of the session.
+func (a *UnimplementedAdapter) CreateSession(_ context.Context, session GothSession) (GothSession, error) {
 	return GothSession{}, ErrUnimplemented
 }
 
@@ -275,6 +884,11 @@ func (a *UnimplementedAdapter) RefreshSession(_ context.Context, session GothSes
 	return GothSession{}, ErrUnimplemented
 }
 
+// TouchSessions extends the expiry of sessions in a batch.
+func (a *UnimplementedAdapter) TouchSessions(_ context.Context, touches []SessionTouch) error {
+	return ErrUnimplemented
+}
+
 // DeleteSession deletes a session by session token.
 func (a *UnimplementedAdapter) DeleteSession(_ context.Context, sessionToken string) error {
 	return ErrUnimplemented
@@ -289,3 +903,238 @@ func (a *UnimplementedAdapter) CreateVerificationToken(_ context.Context, erfica
 func (a *UnimplementedAdapter) UseVerficationToken(_ context.Context, identifier string, token string) (GothVerificationToken, error) {
 	return GothVerificationToken{}, ErrUnimplemented
 }
+
+// GetThrottle retrieves the failed attempts of an identity.
+func (a *UnimplementedAdapter) GetThrottle(_ context.Context, identifier string) (GothThrottle, error) {
+	return GothThrottle{}, ErrUnimplemented
+}
+
+// IncrementThrottle records a failed attempt of an identity.
+func (a *UnimplementedAdapter) IncrementThrottle(_ context.Context, identifier string) (GothThrottle, error) {
+	return GothThrottle{}, ErrUnimplemented
+}
+
+// AttemptThrottle checks and records an attempt of an identity.
+func (a *UnimplementedAdapter) AttemptThrottle(_ context.Context, identifier string, delay func(failures int) time.Duration) (GothThrottle, error) {
+	return GothThrottle{}, ErrUnimplemented
+}
+
+// ResetThrottle resets the failed attempts of an identity.
+func (a *UnimplementedAdapter) ResetThrottle(_ context.Context, identifier string) error {
+	return ErrUnimplemented
+}
+
+// MergeUsers moves the data of the loser to the winner and deletes the loser.
+func (a *UnimplementedAdapter) MergeUsers(_ context.Context, winnerID, loserID uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// CreateTeam creates a new team.
+func (a *UnimplementedAdapter) CreateTeam(_ context.Context, team GothTeam) (GothTeam, error) {
+	return GothTeam{}, ErrUnimplemented
+}
+
+// GetTeam retrieves a team by ID.
+func (a *UnimplementedAdapter) GetTeam(_ context.Context, id uuid.UUID) (GothTeam, error) {
+	return GothTeam{}, ErrUnimplemented
+}
+
+// GetTeamBySlug retrieves a team by slug.
+func (a *UnimplementedAdapter) GetTeamBySlug(_ context.Context, slug string) (GothTeam, error) {
+	return GothTeam{}, ErrUnimplemented
+}
+
+// UpdateTeam updates a team.
+func (a *UnimplementedAdapter) UpdateTeam(_ context.Context, team GothTeam) (GothTeam, error) {
+	return GothTeam{}, ErrUnimplemented
+}
+
+// DeleteTeam deletes a team by ID.
+func (a *UnimplementedAdapter) DeleteTeam(_ context.Context, id uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// AddTeamMember adds a user with a role to a team.
+func (a *UnimplementedAdapter) AddTeamMember(_ context.Context, teamID, userID uuid.UUID, role string) error {
+	return ErrUnimplemented
+}
+
+// RemoveTeamMember removes a user from a team.
+func (a *UnimplementedAdapter) RemoveTeamMember(_ context.Context, teamID, userID uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// ListUserTeams lists the teams of a user.
+func (a *UnimplementedAdapter) ListUserTeams(_ context.Context, userID uuid.UUID) ([]GothTeam, error) {
+	return nil, ErrUnimplemented
+}
+
+// CreateProviderDomain creates or updates a domain to provider mapping.
+func (a *UnimplementedAdapter) CreateProviderDomain(_ context.Context, domain GothProviderDomain) (GothProviderDomain, error) {
+	return GothProviderDomain{}, ErrUnimplemented
+}
+
+// GetProviderDomain retrieves the provider mapping of a domain.
+func (a *UnimplementedAdapter) GetProviderDomain(_ context.Context, domain string) (GothProviderDomain, error) {
+	return GothProviderDomain{}, ErrUnimplemented
+}
+
+// DeleteProviderDomain deletes the provider mapping of a domain.
+func (a *UnimplementedAdapter) DeleteProviderDomain(_ context.Context, domain string) error {
+	return ErrUnimplemented
+}
+
+// CreateCredential creates a new credential.
+func (a *UnimplementedAdapter) CreateCredential(_ context.Context, credential GothCredential) (GothCredential, error) {
+	return GothCredential{}, ErrUnimplemented
+}
+
+// ListCredentials lists the credentials of a user.
+func (a *UnimplementedAdapter) ListCredentials(_ context.Context, userID uuid.UUID) ([]GothCredential, error) {
+	return nil, ErrUnimplemented
+}
+
+// UpdateCredential updates the data and last use of a credential.
+func (a *UnimplementedAdapter) UpdateCredential(_ context.Context, credential GothCredential) (GothCredential, error) {
+	return GothCredential{}, ErrUnimplemented
+}
+
+// DeleteCredential deletes a credential of a user.
+func (a *UnimplementedAdapter) DeleteCredential(_ context.Context, userID, id uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// CreateTrustedDevice creates a new trusted device.
+func (a *UnimplementedAdapter) CreateTrustedDevice(_ context.Context, device GothTrustedDevice) (GothTrustedDevice, error) {
+	return GothTrustedDevice{}, ErrUnimplemented
+}
+
+// GetTrustedDevice retrieves a trusted device by ID.
+func (a *UnimplementedAdapter) GetTrustedDevice(_ context.Context, id uuid.UUID) (GothTrustedDevice, error) {
+	return GothTrustedDevice{}, ErrUnimplemented
+}
+
+// ListTrustedDevices lists the trusted devices of a user.
+func (a *UnimplementedAdapter) ListTrustedDevices(_ context.Context, userID uuid.UUID) ([]GothTrustedDevice, error) {
+	return nil, ErrUnimplemented
+}
+
+// DeleteTrustedDevice deletes a trusted device of a user.
+func (a *UnimplementedAdapter) DeleteTrustedDevice(_ context.Context, userID, id uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// DeleteTrustedDevices deletes all trusted devices of a user.
+func (a *UnimplementedAdapter) DeleteTrustedDevices(_ context.Context, userID uuid.UUID) (int64, error) {
+	return 0, ErrUnimplemented
+}
+
+// GetAccount returns the account of a user for a provider.
+func (a *UnimplementedAdapter) GetAccount(_ context.Context, userID uuid.UUID, provider string) (GothAccount, error) {
+	return GothAccount{}, ErrUnimplemented
+}
+
+// UpdateAccount updates the tokens and scope of an account.
+func (a *UnimplementedAdapter) UpdateAccount(_ context.Context, account GothAccount) (GothAccount, error) {
+	return GothAccount{}, ErrUnimplemented
+}
+
+// UpsertAccount creates or updates an account.
+func (a *UnimplementedAdapter) UpsertAccount(_ context.Context, account GothAccount) (GothAccount, error) {
+	return GothAccount{}, ErrUnimplemented
+}
+
+// ListExpiringAccounts returns accounts whose access token expires before the time.
+func (a *UnimplementedAdapter) ListExpiringAccounts(_ context.Context, before time.Time, limit int) ([]GothAccount, error) {
+	return nil, ErrUnimplemented
+}
+
+// CreateInstallation links an installation to a team.
+func (a *UnimplementedAdapter) CreateInstallation(_ context.Context, installation GothInstallation) (GothInstallation, error) {
+	return GothInstallation{}, ErrUnimplemented
+}
+
+// GetInstallation returns the installation of a provider linked to a team.
+func (a *UnimplementedAdapter) GetInstallation(_ context.Context, teamID uuid.UUID, provider string) (GothInstallation, error) {
+	return GothInstallation{}, ErrUnimplemented
+}
+
+// DeleteInstallation unlinks the installation of a provider from a team.
+func (a *UnimplementedAdapter) DeleteInstallation(_ context.Context, teamID uuid.UUID, provider string) error {
+	return ErrUnimplemented
+}
+
+// CreateInvitation creates a new invitation.
+func (a *UnimplementedAdapter) CreateInvitation(_ context.Context, invitation GothInvitation) (GothInvitation, error) {
+	return GothInvitation{}, ErrUnimplemented
+}
+
+// GetInvitationByToken returns the invitation with the hash of the token.
+func (a *UnimplementedAdapter) GetInvitationByToken(_ context.Context, token string) (GothInvitation, error) {
+	return GothInvitation{}, ErrUnimplemented
+}
+
+// AcceptInvitation marks a pending invitation as accepted by the user.
+func (a *UnimplementedAdapter) AcceptInvitation(_ context.Context, id, userID uuid.UUID) (GothInvitation, error) {
+	return GothInvitation{}, ErrUnimplemented
+}
+
+// DeleteInvitation deletes (revokes) an invitation.
+func (a *UnimplementedAdapter) DeleteInvitation(_ context.Context, id uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// ListNotificationPreferences returns the notification preferences of the user.
+func (a *UnimplementedAdapter) ListNotificationPreferences(_ context.Context, userID uuid.UUID) ([]GothNotificationPreference, error) {
+	return nil, ErrUnimplemented
+}
+
+// SetNotificationPreference creates or updates the preference of the user for the event type.
+func (a *UnimplementedAdapter) SetNotificationPreference(_ context.Context, preference GothNotificationPreference) (GothNotificationPreference, error) {
+	return GothNotificationPreference{}, ErrUnimplemented
+}
+
+// CreateNotification adds a notification to the next digest of the user.
+func (a *UnimplementedAdapter) CreateNotification(_ context.Context, notification GothNotification) (GothNotification, error) {
+	return GothNotification{}, ErrUnimplemented
+}
+
+// ListPendingNotifications returns up to limit pending notifications, oldest first.
+func (a *UnimplementedAdapter) ListPendingNotifications(_ context.Context, limit int) ([]GothNotification, error) {
+	return nil, ErrUnimplemented
+}
+
+// DeleteNotifications deletes the notifications that have been sent in a digest.
+func (a *UnimplementedAdapter) DeleteNotifications(_ context.Context, ids ...uuid.UUID) error {
+	return ErrUnimplemented
+}
+
+// PurgeDeleted hard-deletes soft-deleted records and expired verification tokens older than the duration.
+func (a *UnimplementedAdapter) PurgeDeleted(_ context.Context, olderThan time.Duration) error {
+	return ErrUnimplemented
+}
+
+// DeleteSessionsWhere deletes all sessions matching the filter and returns the number of deleted sessions.
+func (a *UnimplementedAdapter) DeleteSessionsWhere(_ context.Context, filter SessionFilter) (int64, error) {
+	return 0, ErrUnimplemented
+}
+
+// CountUsers counts the users matching the filter.
+func (a *UnimplementedAdapter) CountUsers(_ context.Context, filter UserFilter) (int64, error) {
+	return 0, ErrUnimplemented
+}
+
+// ListUsers lists a page of the users matching the filter.
+func (a *UnimplementedAdapter) ListUsers(_ context.Context, filter UserFilter, page Page) (UserPage, error) {
+	return UserPage{}, ErrUnimplemented
+}
+
+// CountActiveSessions counts the not expired sessions matching the filter.
+func (a *UnimplementedAdapter) CountActiveSessions(_ context.Context, filter SessionFilter) (int64, error) {
+	return 0, ErrUnimplemented
+}
+
+// ListSessions lists a page of the not expired sessions matching the filter.
+func (a *UnimplementedAdapter) ListSessions(_ context.Context, filter SessionFilter, page Page) (SessionPage, error) {
+	return SessionPage{}, ErrUnimplemented
+}
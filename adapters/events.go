@@ -0,0 +1,122 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+var _ Adapter = (*eventAdapter)(nil)
+
+type eventAdapter struct {
+	Adapter
+	emitter events.Emitter
+}
+
+// WithEvents returns an adapter that emits session events to the emitter, so other
+// services and cache layers can invalidate or mirror the session state.
+// The events never contain the session token, only its hash.
+func WithEvents(adapter Adapter, emitter events.Emitter) Adapter {
+	return &eventAdapter{Adapter: adapter, emitter: emitter}
+}
+
+// Unwrap returns the base adapter.
+func (a *eventAdapter) Unwrap() Adapter {
+	return a.Adapter
+}
+
+// CreateSession creates a new session and emits events.SessionCreated.
+func (a *eventAdapter) CreateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	session, err := a.Adapter.CreateSession(ctx, session)
+	if err != nil {
+		return session, err
+	}
+	a.emitter.Emit(ctx, sessionEvent(events.SessionCreated, session))
+
+	return session, nil
+}
+
+// UpdateSession updates a session and emits events.SessionUpdated.
+func (a *eventAdapter) UpdateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	session, err := a.Adapter.UpdateSession(ctx, session)
+	if err != nil {
+		return session, err
+	}
+	a.emitter.Emit(ctx, sessionEvent(events.SessionUpdated, session))
+
+	return session, nil
+}
+
+// RefreshSession refreshes a session and emits events.SessionRefreshed.
+func (a *eventAdapter) RefreshSession(ctx context.Context, session GothSession) (GothSession, error) {
+	session, err := a.Adapter.RefreshSession(ctx, session)
+	if err != nil {
+		return session, err
+	}
+	a.emitter.Emit(ctx, sessionEvent(events.SessionRefreshed, session))
+
+	return session, nil
+}
+
+// TouchSessions extends the expiry of the sessions in a batch and emits events.SessionRefreshed
+// for every touched session, as the write-behind equivalent of RefreshSession.
+func (a *eventAdapter) TouchSessions(ctx context.Context, touches []SessionTouch) error {
+	err := a.Adapter.TouchSessions(ctx, touches)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range touches {
+		e := events.New(events.SessionRefreshed, uuid.Nil)
+		e.Data = map[string]any{
+			"token_hash":   HashToken(t.SessionToken),
+			"expires_at":   t.ExpiresAt,
+			"last_seen_at": t.LastSeenAt,
+		}
+		a.emitter.Emit(ctx, e)
+	}
+
+	return nil
+}
+
+// DeleteSession deletes a session and emits events.SessionDeleted.
+func (a *eventAdapter) DeleteSession(ctx context.Context, sessionToken string) error {
+	err := a.Adapter.DeleteSession(ctx, sessionToken)
+	if err != nil {
+		return err
+	}
+
+	e := events.New(events.SessionDeleted, uuid.Nil)
+	e.Data = map[string]any{"token_hash": HashToken(sessionToken)}
+	a.emitter.Emit(ctx, e)
+
+	return nil
+}
+
+// DeleteSessionsWhere deletes the sessions matching the filter and emits events.SessionsDeleted.
+func (a *eventAdapter) DeleteSessionsWhere(ctx context.Context, filter SessionFilter) (int64, error) {
+	n, err := a.Adapter.DeleteSessionsWhere(ctx, filter)
+	if err != nil {
+		return n, err
+	}
+
+	e := events.New(events.SessionsDeleted, uuid.Nil)
+	e.Data = map[string]any{"filter": filter, "deleted": n}
+	a.emitter.Emit(ctx, e)
+
+	return n, nil
+}
+
+func sessionEvent(t events.Type, session GothSession) events.Event {
+	e := events.New(t, session.UserID)
+	e.Provider = session.Provider
+	e.Data = map[string]any{
+		"session_id": session.ID,
+		"token_hash": HashToken(session.SessionToken),
+		"expires_at": session.ExpiresAt,
+		"version":    session.Version,
+	}
+
+	return e
+}
@@ -0,0 +1,182 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidSessionExport is returned by ImportSessions if a line of the export cannot be decrypted with the key.
+var ErrInvalidSessionExport = errors.New("adapters: invalid session export")
+
+// sessionExportData is the additional data of the encrypted lines, it binds them to the format.
+var sessionExportData = []byte("goth-sessions-v1")
+
+// maxSessionExportLine is the maximum length of a line of the export.
+const maxSessionExportLine = 1 << 20
+
+// ExportSessions writes the not expired sessions of the session store of the adapter to w as NDJSON,
+// e.g. before a process restart or a blue-green switch. Every line is a session with the hash of its
+// token, encrypted with AES-GCM and the key (16, 24 or 32 bytes) and base64 encoded.
+// The nonces are read from random, e.g. the Rand of the goth config, or crypto/rand if random is nil.
+// It returns the number of exported sessions.
+func ExportSessions(ctx context.Context, adapter Adapter, w io.Writer, key []byte, random io.Reader) (int, error) {
+	m, ok := sessionMigrator(adapter)
+	if !ok {
+		return 0, ErrUnsupportedSessionMigration
+	}
+
+	if random == nil {
+		random = rand.Reader
+	}
+
+	gcm, err := sessionExportCipher(key)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	n := 0
+	page := Page{Limit: MaxPageLimit}
+	for {
+		sessions, err := m.ExportSessions(ctx, page)
+		if err != nil {
+			return n, err
+		}
+
+		for _, session := range sessions.Sessions {
+			session.User = GothUser{}
+
+			b, err := json.Marshal(session)
+			if err != nil {
+				return n, err
+			}
+
+			nonce := make([]byte, gcm.NonceSize())
+			if _, err := io.ReadFull(random, nonce); err != nil {
+				return n, err
+			}
+
+			line := base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, b, sessionExportData))
+			if _, err := bw.WriteString(line + "\n"); err != nil {
+				return n, err
+			}
+			n++
+		}
+
+		if sessions.NextCursor == "" {
+			break
+		}
+		page.Cursor = sessions.NextCursor
+	}
+
+	return n, bw.Flush()
+}
+
+// ImportSessions reads the sessions exported by ExportSessions from r and stores them in the session
+// store of the adapter. Sessions that already exist or have expired in the meantime are skipped.
+// The users of the sessions must exist in the adapter. It returns the number of read sessions.
+func ImportSessions(ctx context.Context, adapter Adapter, r io.Reader, key []byte) (int, error) {
+	m, ok := sessionMigrator(adapter)
+	if !ok {
+		return 0, ErrUnsupportedSessionMigration
+	}
+
+	gcm, err := sessionExportCipher(key)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	batch := make([]GothSession, 0, MaxPageLimit)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		sessions := make([]GothSession, 0, len(batch))
+		for _, session := range batch {
+			if session.IsValid() {
+				sessions = append(sessions, session)
+			}
+		}
+		batch = batch[:0]
+
+		if len(sessions) == 0 {
+			return nil
+		}
+
+		return m.ImportSessions(ctx, sessions...)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSessionExportLine)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		session, err := decryptSession(gcm, scanner.Bytes())
+		if err != nil {
+			return n, fmt.Errorf("%w: line %d", err, n+1)
+		}
+
+		batch = append(batch, session)
+		n++
+
+		if len(batch) == MaxPageLimit {
+			if err := flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+
+	return n, flush()
+}
+
+// decryptSession decrypts a line of the export.
+func decryptSession(gcm cipher.AEAD, line []byte) (GothSession, error) {
+	enc := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+
+	l, err := base64.StdEncoding.Decode(enc, line)
+	if err != nil || l < gcm.NonceSize() {
+		return GothSession{}, ErrInvalidSessionExport
+	}
+	enc = enc[:l]
+
+	b, err := gcm.Open(nil, enc[:gcm.NonceSize()], enc[gcm.NonceSize():], sessionExportData)
+	if err != nil {
+		return GothSession{}, ErrInvalidSessionExport
+	}
+
+	session := GothSession{}
+	if err := json.Unmarshal(b, &session); err != nil {
+		return GothSession{}, ErrInvalidSessionExport
+	}
+
+	return session, nil
+}
+
+// sessionExportCipher returns the AES-GCM cipher of the key.
+func sessionExportCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("adapters: invalid session export key: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
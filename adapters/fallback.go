@@ -0,0 +1,608 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ Adapter = (*FallbackAdapter)(nil)
+
+// FallbackAdapter is an adapter that reads from the primary adapter and falls back to the secondary adapter,
+// e.g. to migrate between adapters without downtime (from gorm to Redis sessions). Records are created in the
+// primary adapter, updates of records that only exist in the secondary adapter are applied there.
+// Deletes are applied to both adapters, so that a deleted record (e.g. a session on logout) is not found in the other.
+// Counts and lists fall back only if the primary adapter fails, they are not merged.
+type FallbackAdapter struct {
+	primary   Adapter
+	secondary Adapter
+	dualWrite bool
+	onError   func(ctx context.Context, err error)
+}
+
+// FallbackOpt is a function that configures the fallback adapter.
+type FallbackOpt func(*FallbackAdapter)
+
+// WithDualWrite writes created and updated records to the secondary adapter as well,
+// so that a migration can be rolled back to the secondary adapter.
+func WithDualWrite() FallbackOpt {
+	return func(a *FallbackAdapter) {
+		a.dualWrite = true
+	}
+}
+
+// WithSecondaryErrorHandler sets the handler of the errors of dual writes and deletes in the secondary
+// adapter, which do not fail the call once the primary adapter succeeded.
+func WithSecondaryErrorHandler(fn func(ctx context.Context, err error)) FallbackOpt {
+	return func(a *FallbackAdapter) {
+		a.onError = fn
+	}
+}
+
+// NewFallbackAdapter returns an adapter that reads from the primary adapter and falls back to the secondary adapter.
+func NewFallbackAdapter(primary, secondary Adapter, opts ...FallbackOpt) *FallbackAdapter {
+	a := &FallbackAdapter{
+		primary:   primary,
+		secondary: secondary,
+		onError:   func(context.Context, error) {},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Primary returns the primary adapter.
+func (a *FallbackAdapter) Primary() Adapter {
+	return a.primary
+}
+
+// Secondary returns the secondary adapter.
+func (a *FallbackAdapter) Secondary() Adapter {
+	return a.secondary
+}
+
+// fallback calls fn with the primary adapter and with the secondary adapter if the primary fails.
+// The error of the primary adapter is returned if both fail.
+func fallback[T any](_ context.Context, a *FallbackAdapter, fn func(s Adapter) (T, error)) (T, error) {
+	v, err := fn(a.primary)
+	if err == nil {
+		return v, nil
+	}
+
+	if v, serr := fn(a.secondary); serr == nil {
+		return v, nil
+	}
+
+	return v, err
+}
+
+// write calls fn with the primary adapter and, with dual writes, with the secondary adapter and the result.
+func write[T any](ctx context.Context, a *FallbackAdapter, value T, fn func(s Adapter, value T) (T, error)) (T, error) {
+	v, err := fn(a.primary, value)
+	if err != nil {
+		return v, err
+	}
+
+	if a.dualWrite {
+		if _, err := fn(a.secondary, v); err != nil {
+			a.onError(ctx, err)
+		}
+	}
+
+	return v, nil
+}
+
+// update calls fn with the primary adapter and falls back to the secondary adapter.
+// With dual writes fn is called with the secondary adapter also after the primary succeeded.
+func update[T any](ctx context.Context, a *FallbackAdapter, fn func(s Adapter) (T, error)) (T, error) {
+	v, err := fn(a.primary)
+	if err != nil {
+		if v, serr := fn(a.secondary); serr == nil {
+			return v, nil
+		}
+
+		return v, err
+	}
+
+	if a.dualWrite {
+		if _, err := fn(a.secondary); err != nil {
+			a.onError(ctx, err)
+		}
+	}
+
+	return v, nil
+}
+
+// exec calls fn like update for calls without a result.
+func (a *FallbackAdapter) exec(ctx context.Context, fn func(s Adapter) error) error {
+	_, err := update(ctx, a, func(s Adapter) (struct{}, error) {
+		return struct{}{}, fn(s)
+	})
+
+	return err
+}
+
+// delete calls fn with both adapters. It fails only if both fail.
+func (a *FallbackAdapter) delete(ctx context.Context, fn func(s Adapter) error) error {
+	_, err := a.deleteCount(ctx, func(s Adapter) (int64, error) {
+		return 0, fn(s)
+	})
+
+	return err
+}
+
+// deleteCount calls fn with both adapters and returns the sum of the deleted records. It fails only if both fail.
+func (a *FallbackAdapter) deleteCount(ctx context.Context, fn func(s Adapter) (int64, error)) (int64, error) {
+	n, err := fn(a.primary)
+	m, serr := fn(a.secondary)
+
+	if err != nil && serr != nil {
+		return 0, err
+	}
+
+	if err == nil && serr != nil {
+		a.onError(ctx, serr)
+	}
+
+	return n + m, nil
+}
+
+// CreateUser calls CreateUser of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateUser(ctx context.Context, user GothUser) (GothUser, error) {
+	return write(ctx, a, user, func(s Adapter, user GothUser) (GothUser, error) {
+		return s.CreateUser(ctx, user)
+	})
+}
+
+// GetUser calls GetUser of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetUser(ctx context.Context, id uuid.UUID) (GothUser, error) {
+	return fallback(ctx, a, func(s Adapter) (GothUser, error) {
+		return s.GetUser(ctx, id)
+	})
+}
+
+// GetUserByEmail calls GetUserByEmail of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetUserByEmail(ctx context.Context, email string) (GothUser, error) {
+	return fallback(ctx, a, func(s Adapter) (GothUser, error) {
+		return s.GetUserByEmail(ctx, email)
+	})
+}
+
+// GetUserByAccount calls GetUserByAccount of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetUserByAccount(ctx context.Context, provider string, providerAccountID string) (GothUser, error) {
+	return fallback(ctx, a, func(s Adapter) (GothUser, error) {
+		return s.GetUserByAccount(ctx, provider, providerAccountID)
+	})
+}
+
+// UpdateUser calls UpdateUser of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UpdateUser(ctx context.Context, user GothUser) (GothUser, error) {
+	return update(ctx, a, func(s Adapter) (GothUser, error) {
+		return s.UpdateUser(ctx, user)
+	})
+}
+
+// DeleteUser calls DeleteUser of both adapters.
+func (a *FallbackAdapter) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteUser(ctx, id)
+	})
+}
+
+// LinkAccount calls LinkAccount of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) LinkAccount(ctx context.Context, accountID, userID uuid.UUID) error {
+	return a.exec(ctx, func(s Adapter) error {
+		return s.LinkAccount(ctx, accountID, userID)
+	})
+}
+
+// UnlinkAccount calls UnlinkAccount of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UnlinkAccount(ctx context.Context, accountID, userID uuid.UUID) error {
+	return a.exec(ctx, func(s Adapter) error {
+		return s.UnlinkAccount(ctx, accountID, userID)
+	})
+}
+
+// CountUsers calls CountUsers of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) CountUsers(ctx context.Context, filter UserFilter) (int64, error) {
+	return fallback(ctx, a, func(s Adapter) (int64, error) {
+		return s.CountUsers(ctx, filter)
+	})
+}
+
+// ListUsers calls ListUsers of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListUsers(ctx context.Context, filter UserFilter, page Page) (UserPage, error) {
+	return fallback(ctx, a, func(s Adapter) (UserPage, error) {
+		return s.ListUsers(ctx, filter, page)
+	})
+}
+
+// CreateSession calls CreateSession of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	return write(ctx, a, session, func(s Adapter, session GothSession) (GothSession, error) {
+		return s.CreateSession(ctx, session)
+	})
+}
+
+// GetSession calls GetSession of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetSession(ctx context.Context, sessionToken string) (GothSession, error) {
+	return fallback(ctx, a, func(s Adapter) (GothSession, error) {
+		return s.GetSession(ctx, sessionToken)
+	})
+}
+
+// UpdateSession calls UpdateSession of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UpdateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	return update(ctx, a, func(s Adapter) (GothSession, error) {
+		return s.UpdateSession(ctx, session)
+	})
+}
+
+// RefreshSession calls RefreshSession of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) RefreshSession(ctx context.Context, session GothSession) (GothSession, error) {
+	return update(ctx, a, func(s Adapter) (GothSession, error) {
+		return s.RefreshSession(ctx, session)
+	})
+}
+
+// TouchSessions calls TouchSessions of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) TouchSessions(ctx context.Context, touches []SessionTouch) error {
+	return a.exec(ctx, func(s Adapter) error {
+		return s.TouchSessions(ctx, touches)
+	})
+}
+
+// DeleteSession calls DeleteSession of both adapters.
+func (a *FallbackAdapter) DeleteSession(ctx context.Context, sessionToken string) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteSession(ctx, sessionToken)
+	})
+}
+
+// DeleteSessionsWhere calls DeleteSessionsWhere of both adapters and returns the sum of the deleted records.
+func (a *FallbackAdapter) DeleteSessionsWhere(ctx context.Context, filter SessionFilter) (int64, error) {
+	return a.deleteCount(ctx, func(s Adapter) (int64, error) {
+		return s.DeleteSessionsWhere(ctx, filter)
+	})
+}
+
+// CountActiveSessions calls CountActiveSessions of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) CountActiveSessions(ctx context.Context, filter SessionFilter) (int64, error) {
+	return fallback(ctx, a, func(s Adapter) (int64, error) {
+		return s.CountActiveSessions(ctx, filter)
+	})
+}
+
+// ListSessions calls ListSessions of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListSessions(ctx context.Context, filter SessionFilter, page Page) (SessionPage, error) {
+	return fallback(ctx, a, func(s Adapter) (SessionPage, error) {
+		return s.ListSessions(ctx, filter, page)
+	})
+}
+
+// CreateVerificationToken calls CreateVerificationToken of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateVerificationToken(ctx context.Context, verficationToken GothVerificationToken) (GothVerificationToken, error) {
+	return write(ctx, a, verficationToken, func(s Adapter, verficationToken GothVerificationToken) (GothVerificationToken, error) {
+		return s.CreateVerificationToken(ctx, verficationToken)
+	})
+}
+
+// UseVerficationToken calls UseVerficationToken of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UseVerficationToken(ctx context.Context, identifier string, token string) (GothVerificationToken, error) {
+	return update(ctx, a, func(s Adapter) (GothVerificationToken, error) {
+		return s.UseVerficationToken(ctx, identifier, token)
+	})
+}
+
+// MergeUsers calls MergeUsers of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) MergeUsers(ctx context.Context, winnerID, loserID uuid.UUID) error {
+	return a.exec(ctx, func(s Adapter) error {
+		return s.MergeUsers(ctx, winnerID, loserID)
+	})
+}
+
+// CreateTeam calls CreateTeam of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateTeam(ctx context.Context, team GothTeam) (GothTeam, error) {
+	return write(ctx, a, team, func(s Adapter, team GothTeam) (GothTeam, error) {
+		return s.CreateTeam(ctx, team)
+	})
+}
+
+// GetTeam calls GetTeam of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetTeam(ctx context.Context, id uuid.UUID) (GothTeam, error) {
+	return fallback(ctx, a, func(s Adapter) (GothTeam, error) {
+		return s.GetTeam(ctx, id)
+	})
+}
+
+// GetTeamBySlug calls GetTeamBySlug of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetTeamBySlug(ctx context.Context, slug string) (GothTeam, error) {
+	return fallback(ctx, a, func(s Adapter) (GothTeam, error) {
+		return s.GetTeamBySlug(ctx, slug)
+	})
+}
+
+// UpdateTeam calls UpdateTeam of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UpdateTeam(ctx context.Context, team GothTeam) (GothTeam, error) {
+	return update(ctx, a, func(s Adapter) (GothTeam, error) {
+		return s.UpdateTeam(ctx, team)
+	})
+}
+
+// DeleteTeam calls DeleteTeam of both adapters.
+func (a *FallbackAdapter) DeleteTeam(ctx context.Context, id uuid.UUID) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteTeam(ctx, id)
+	})
+}
+
+// AddTeamMember calls AddTeamMember of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) AddTeamMember(ctx context.Context, teamID, userID uuid.UUID, role string) error {
+	return a.exec(ctx, func(s Adapter) error {
+		return s.AddTeamMember(ctx, teamID, userID, role)
+	})
+}
+
+// RemoveTeamMember calls RemoveTeamMember of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) RemoveTeamMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	return a.exec(ctx, func(s Adapter) error {
+		return s.RemoveTeamMember(ctx, teamID, userID)
+	})
+}
+
+// ListUserTeams calls ListUserTeams of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListUserTeams(ctx context.Context, userID uuid.UUID) ([]GothTeam, error) {
+	return fallback(ctx, a, func(s Adapter) ([]GothTeam, error) {
+		return s.ListUserTeams(ctx, userID)
+	})
+}
+
+// GetThrottle calls GetThrottle of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetThrottle(ctx context.Context, identifier string) (GothThrottle, error) {
+	return fallback(ctx, a, func(s Adapter) (GothThrottle, error) {
+		return s.GetThrottle(ctx, identifier)
+	})
+}
+
+// IncrementThrottle calls IncrementThrottle of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) IncrementThrottle(ctx context.Context, identifier string) (GothThrottle, error) {
+	return update(ctx, a, func(s Adapter) (GothThrottle, error) {
+		return s.IncrementThrottle(ctx, identifier)
+	})
+}
+
+// AttemptThrottle calls AttemptThrottle of the primary adapter and falls back to the secondary adapter on error,
+// but not if the identity is throttled. With dual writes the attempt is also recorded on the secondary adapter.
+func (a *FallbackAdapter) AttemptThrottle(ctx context.Context, identifier string, delay func(failures int) time.Duration) (GothThrottle, error) {
+	t, err := a.primary.AttemptThrottle(ctx, identifier, delay)
+	if errors.Is(err, ErrThrottled) {
+		return t, err
+	}
+
+	if err != nil {
+		return a.secondary.AttemptThrottle(ctx, identifier, delay)
+	}
+
+	if a.dualWrite {
+		if _, err := a.secondary.IncrementThrottle(ctx, identifier); err != nil {
+			a.onError(ctx, err)
+		}
+	}
+
+	return t, nil
+}
+
+// ResetThrottle calls ResetThrottle of both adapters.
+func (a *FallbackAdapter) ResetThrottle(ctx context.Context, identifier string) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.ResetThrottle(ctx, identifier)
+	})
+}
+
+// CreateProviderDomain calls CreateProviderDomain of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateProviderDomain(ctx context.Context, domain GothProviderDomain) (GothProviderDomain, error) {
+	return write(ctx, a, domain, func(s Adapter, domain GothProviderDomain) (GothProviderDomain, error) {
+		return s.CreateProviderDomain(ctx, domain)
+	})
+}
+
+// GetProviderDomain calls GetProviderDomain of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetProviderDomain(ctx context.Context, domain string) (GothProviderDomain, error) {
+	return fallback(ctx, a, func(s Adapter) (GothProviderDomain, error) {
+		return s.GetProviderDomain(ctx, domain)
+	})
+}
+
+// DeleteProviderDomain calls DeleteProviderDomain of both adapters.
+func (a *FallbackAdapter) DeleteProviderDomain(ctx context.Context, domain string) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteProviderDomain(ctx, domain)
+	})
+}
+
+// CreateCredential calls CreateCredential of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateCredential(ctx context.Context, credential GothCredential) (GothCredential, error) {
+	return write(ctx, a, credential, func(s Adapter, credential GothCredential) (GothCredential, error) {
+		return s.CreateCredential(ctx, credential)
+	})
+}
+
+// ListCredentials calls ListCredentials of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListCredentials(ctx context.Context, userID uuid.UUID) ([]GothCredential, error) {
+	return fallback(ctx, a, func(s Adapter) ([]GothCredential, error) {
+		return s.ListCredentials(ctx, userID)
+	})
+}
+
+// UpdateCredential calls UpdateCredential of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UpdateCredential(ctx context.Context, credential GothCredential) (GothCredential, error) {
+	return update(ctx, a, func(s Adapter) (GothCredential, error) {
+		return s.UpdateCredential(ctx, credential)
+	})
+}
+
+// DeleteCredential calls DeleteCredential of both adapters.
+func (a *FallbackAdapter) DeleteCredential(ctx context.Context, userID, id uuid.UUID) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteCredential(ctx, userID, id)
+	})
+}
+
+// CreateTrustedDevice calls CreateTrustedDevice of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateTrustedDevice(ctx context.Context, device GothTrustedDevice) (GothTrustedDevice, error) {
+	return write(ctx, a, device, func(s Adapter, device GothTrustedDevice) (GothTrustedDevice, error) {
+		return s.CreateTrustedDevice(ctx, device)
+	})
+}
+
+// GetTrustedDevice calls GetTrustedDevice of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetTrustedDevice(ctx context.Context, id uuid.UUID) (GothTrustedDevice, error) {
+	return fallback(ctx, a, func(s Adapter) (GothTrustedDevice, error) {
+		return s.GetTrustedDevice(ctx, id)
+	})
+}
+
+// ListTrustedDevices calls ListTrustedDevices of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListTrustedDevices(ctx context.Context, userID uuid.UUID) ([]GothTrustedDevice, error) {
+	return fallback(ctx, a, func(s Adapter) ([]GothTrustedDevice, error) {
+		return s.ListTrustedDevices(ctx, userID)
+	})
+}
+
+// DeleteTrustedDevice calls DeleteTrustedDevice of both adapters.
+func (a *FallbackAdapter) DeleteTrustedDevice(ctx context.Context, userID, id uuid.UUID) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteTrustedDevice(ctx, userID, id)
+	})
+}
+
+// DeleteTrustedDevices calls DeleteTrustedDevices of both adapters and returns the sum of the deleted records.
+func (a *FallbackAdapter) DeleteTrustedDevices(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return a.deleteCount(ctx, func(s Adapter) (int64, error) {
+		return s.DeleteTrustedDevices(ctx, userID)
+	})
+}
+
+// GetAccount calls GetAccount of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetAccount(ctx context.Context, userID uuid.UUID, provider string) (GothAccount, error) {
+	return fallback(ctx, a, func(s Adapter) (GothAccount, error) {
+		return s.GetAccount(ctx, userID, provider)
+	})
+}
+
+// UpdateAccount calls UpdateAccount of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) UpdateAccount(ctx context.Context, account GothAccount) (GothAccount, error) {
+	return update(ctx, a, func(s Adapter) (GothAccount, error) {
+		return s.UpdateAccount(ctx, account)
+	})
+}
+
+// UpsertAccount calls UpsertAccount of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) UpsertAccount(ctx context.Context, account GothAccount) (GothAccount, error) {
+	return write(ctx, a, account, func(s Adapter, account GothAccount) (GothAccount, error) {
+		return s.UpsertAccount(ctx, account)
+	})
+}
+
+// ListExpiringAccounts calls ListExpiringAccounts of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListExpiringAccounts(ctx context.Context, before time.Time, limit int) ([]GothAccount, error) {
+	return fallback(ctx, a, func(s Adapter) ([]GothAccount, error) {
+		return s.ListExpiringAccounts(ctx, before, limit)
+	})
+}
+
+// CreateInstallation calls CreateInstallation of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateInstallation(ctx context.Context, installation GothInstallation) (GothInstallation, error) {
+	return write(ctx, a, installation, func(s Adapter, installation GothInstallation) (GothInstallation, error) {
+		return s.CreateInstallation(ctx, installation)
+	})
+}
+
+// GetInstallation calls GetInstallation of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetInstallation(ctx context.Context, teamID uuid.UUID, provider string) (GothInstallation, error) {
+	return fallback(ctx, a, func(s Adapter) (GothInstallation, error) {
+		return s.GetInstallation(ctx, teamID, provider)
+	})
+}
+
+// DeleteInstallation calls DeleteInstallation of both adapters.
+func (a *FallbackAdapter) DeleteInstallation(ctx context.Context, teamID uuid.UUID, provider string) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteInstallation(ctx, teamID, provider)
+	})
+}
+
+// CreateInvitation calls CreateInvitation of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateInvitation(ctx context.Context, invitation GothInvitation) (GothInvitation, error) {
+	return write(ctx, a, invitation, func(s Adapter, invitation GothInvitation) (GothInvitation, error) {
+		return s.CreateInvitation(ctx, invitation)
+	})
+}
+
+// GetInvitationByToken calls GetInvitationByToken of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) GetInvitationByToken(ctx context.Context, token string) (GothInvitation, error) {
+	return fallback(ctx, a, func(s Adapter) (GothInvitation, error) {
+		return s.GetInvitationByToken(ctx, token)
+	})
+}
+
+// AcceptInvitation calls AcceptInvitation of the primary adapter and falls back to the secondary adapter on error. With dual writes it is called on both.
+func (a *FallbackAdapter) AcceptInvitation(ctx context.Context, id, userID uuid.UUID) (GothInvitation, error) {
+	return update(ctx, a, func(s Adapter) (GothInvitation, error) {
+		return s.AcceptInvitation(ctx, id, userID)
+	})
+}
+
+// DeleteInvitation calls DeleteInvitation of both adapters.
+func (a *FallbackAdapter) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteInvitation(ctx, id)
+	})
+}
+
+// ListNotificationPreferences calls ListNotificationPreferences of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]GothNotificationPreference, error) {
+	return fallback(ctx, a, func(s Adapter) ([]GothNotificationPreference, error) {
+		return s.ListNotificationPreferences(ctx, userID)
+	})
+}
+
+// SetNotificationPreference calls SetNotificationPreference of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) SetNotificationPreference(ctx context.Context, preference GothNotificationPreference) (GothNotificationPreference, error) {
+	return write(ctx, a, preference, func(s Adapter, preference GothNotificationPreference) (GothNotificationPreference, error) {
+		return s.SetNotificationPreference(ctx, preference)
+	})
+}
+
+// CreateNotification calls CreateNotification of the primary adapter and, with dual writes, of the secondary adapter with the result.
+func (a *FallbackAdapter) CreateNotification(ctx context.Context, notification GothNotification) (GothNotification, error) {
+	return write(ctx, a, notification, func(s Adapter, notification GothNotification) (GothNotification, error) {
+		return s.CreateNotification(ctx, notification)
+	})
+}
+
+// ListPendingNotifications calls ListPendingNotifications of the primary adapter and falls back to the secondary adapter on error.
+func (a *FallbackAdapter) ListPendingNotifications(ctx context.Context, limit int) ([]GothNotification, error) {
+	return fallback(ctx, a, func(s Adapter) ([]GothNotification, error) {
+		return s.ListPendingNotifications(ctx, limit)
+	})
+}
+
+// DeleteNotifications calls DeleteNotifications of both adapters.
+func (a *FallbackAdapter) DeleteNotifications(ctx context.Context, ids ...uuid.UUID) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.DeleteNotifications(ctx, ids...)
+	})
+}
+
+// PurgeDeleted calls PurgeDeleted of both adapters.
+func (a *FallbackAdapter) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	return a.delete(ctx, func(s Adapter) error {
+		return s.PurgeDeleted(ctx, olderThan)
+	})
+}
@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrFlowNotFound is returned when a flow entry does not exist, has expired or has already been consumed.
+var ErrFlowNotFound = errors.New("flow entry not found")
+
+// FlowStore stores short-lived artifacts of authentication flows, such as OAuth states,
+// PKCE verifiers, device flow codes or QR login challenges. Entries are single-use.
+type FlowStore interface {
+	// Put stores the value for the key until the TTL has passed. An existing entry is replaced.
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// GetAndDelete atomically returns and deletes the value of the key, so that it can be consumed only once.
+	// It returns ErrFlowNotFound if there is no unexpired entry for the key.
+	GetAndDelete(ctx context.Context, key string) ([]byte, error)
+}
+
+// GothFlow is an entry of a FlowStore that is persisted by an adapter.
+type GothFlow struct {
+	// Key is the key of the entry.
+	Key string `json:"key" gorm:"primaryKey"`
+	// Value is the value of the entry.
+	Value []byte `json:"value"`
+	// ExpiresAt is the expiry time of the entry.
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+}
+
+var _ FlowStore = (*MemoryFlowStore)(nil)
+
+type flowEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryFlowStore is a FlowStore in the memory of the process. It is only suited for
+// single instance deployments, as the callback of a flow must reach the same instance.
+type MemoryFlowStore struct {
+	mu      sync.Mutex
+	entries map[string]flowEntry
+	puts    int
+}
+
+// NewMemoryFlowStore creates a new in-memory flow store.
+func NewMemoryFlowStore() *MemoryFlowStore {
+	return &MemoryFlowStore{entries: map[string]flowEntry{}}
+}
+
+// memoryFlowSweep is the number of puts after which expired entries are removed.
+const memoryFlowSweep = 1024
+
+// Put stores the value for the key until the TTL has passed.
+func (s *MemoryFlowStore) Put(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	s.puts++
+	if s.puts >= memoryFlowSweep {
+		s.puts = 0
+
+		for k, e := range s.entries {
+			if !e.expiresAt.After(now) {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	s.entries[key] = flowEntry{value: append([]byte(nil), value...), expiresAt: now.Add(ttl)}
+
+	return nil
+}
+
+// GetAndDelete returns and deletes the value of the key.
+func (s *MemoryFlowStore) GetAndDelete(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, ErrFlowNotFound
+	}
+	delete(s.entries, key)
+
+	if !e.expiresAt.After(time.Now()) {
+		return nil, ErrFlowNotFound
+	}
+
+	return e.value, nil
+}
@@ -2,6 +2,8 @@ package gorm_adapter
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	goth "github.com/zeiss/fiber-goth"
@@ -12,31 +14,148 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// models are the models managed by the migrations.
+var models = []any{
+	&adapters.GothAccount{},
+	&adapters.GothUser{},
+	&adapters.GothSession{},
+	&adapters.GothVerificationToken{},
+	&adapters.GothThrottle{},
+	&adapters.GothTeam{},
+	&adapters.GothTeamMember{},
+	&adapters.GothProviderDomain{},
+	&adapters.GothCredential{},
+	&adapters.GothTrustedDevice{},
+	&adapters.GothInstallation{},
+	&adapters.GothInvitation{},
+	&adapters.GothNotificationPreference{},
+	&adapters.GothNotification{},
+	&adapters.GothFlow{},
+}
+
 // RunMigrations is a helper function to run the migrations for the database.
 func RunMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&adapters.GothAccount{},
-		&adapters.GothUser{},
-		&adapters.GothSession{},
-		&adapters.GothVerificationToken{},
-	)
+	return db.AutoMigrate(models...)
 }
 
-var _ adapters.Adapter = (*gormAdapter)(nil)
+var (
+	_ adapters.Adapter   = (*gormAdapter)(nil)
+	_ adapters.FlowStore = (*gormAdapter)(nil)
+
+	_ adapters.SessionMigrator = (*gormAdapter)(nil)
+)
 
 type gormAdapter struct {
-	db *gorm.DB
+	db     *gorm.DB
+	reader *gorm.DB
+	ids    adapters.IDGenerator
 	adapters.UnimplementedAdapter
 }
 
+// Opt is a function that configures the adapter.
+type Opt func(*gormAdapter)
+
+// WithReadReplica sets a read replica that serves the hot read paths (e.g. GetSession).
+// Writes and the reads of contexts marked with adapters.WithPrimary use the primary.
+func WithReadReplica(db *gorm.DB) Opt {
+	return func(a *gormAdapter) {
+		a.reader = db
+	}
+}
+
+// WithIDGenerator sets the generator of the primary keys of new users, accounts and sessions,
+// e.g. adapters.UUIDv7 or adapters.ULID. By default the keys are generated by the database.
+func WithIDGenerator(ids adapters.IDGenerator) Opt {
+	return func(a *gormAdapter) {
+		a.ids = ids
+	}
+}
+
 // New is a helper function to create a new adapter.
-func New(db *gorm.DB) *gormAdapter {
-	return &gormAdapter{db: db}
+func New(db *gorm.DB, opts ...Opt) *gormAdapter {
+	a := &gormAdapter{db: db, reader: db}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// read returns the read replica, or the primary if the context asks for it.
+func (a *gormAdapter) read(ctx context.Context) *gorm.DB {
+	if adapters.IsPrimary(ctx) {
+		return a.db.WithContext(ctx)
+	}
+
+	return a.reader.WithContext(ctx)
 }
 
-// CreateUser is a helper function to create a new user.
+// Ping checks the connectivity to the database and the read replica.
+func (a *gormAdapter) Ping(ctx context.Context) error {
+	for _, db := range []*gorm.DB{a.db, a.reader} {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PendingMigrations returns the missing tables and columns of the database.
+func (a *gormAdapter) PendingMigrations(ctx context.Context) ([]string, error) {
+	db := a.db.WithContext(ctx)
+	pending := []string{}
+
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+
+		if !db.Migrator().HasTable(model) {
+			pending = append(pending, "create table "+stmt.Schema.Table)
+			continue
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !field.IgnoreMigration && !db.Migrator().HasColumn(model, field.DBName) {
+				pending = append(pending, "add column "+stmt.Schema.Table+"."+field.DBName)
+			}
+		}
+	}
+
+	return pending, nil
+}
+
+// CreateUser is a helper function to create a new user. Users without an email are always created.
+// Users with an email are only created if there is no user with the email yet.
 func (a *gormAdapter) CreateUser(ctx context.Context, user adapters.GothUser) (adapters.GothUser, error) {
-	err := a.db.WithContext(ctx).Where(adapters.GothUser{Email: user.Email}).FirstOrCreate(&user).Error
+	id := user.ID
+	if err := a.assignID(&id); err != nil {
+		return adapters.GothUser{}, goth.ErrMissingUser
+	}
+
+	for i := range user.Accounts {
+		if err := a.assignID(&user.Accounts[i].ID); err != nil {
+			return adapters.GothUser{}, goth.ErrMissingUser
+		}
+	}
+
+	db := a.db.WithContext(ctx)
+
+	var err error
+	if user.Email == "" {
+		user.ID = id
+		err = db.Create(&user).Error
+	} else {
+		err = db.Where(adapters.GothUser{Email: user.Email}).Attrs(adapters.GothUser{ID: id}).FirstOrCreate(&user).Error
+	}
 	if err != nil {
 		return adapters.GothUser{}, goth.ErrMissingUser
 	}
@@ -47,10 +166,11 @@ func (a *gormAdapter) CreateUser(ctx context.Context, user adapters.GothUser) (a
 // GetSession is a helper function to retrieve a session by session token.
 func (a *gormAdapter) GetSession(ctx context.Context, sessionToken string) (adapters.GothSession, error) {
 	var session adapters.GothSession
-	err := a.db.WithContext(ctx).Preload(clause.Associations).Where("session_token = ?", sessionToken).First(&session).Error
+	err := a.read(ctx).Preload(clause.Associations).Where("session_token = ?", adapters.HashToken(sessionToken)).First(&session).Error
 	if err != nil {
 		return adapters.GothSession{}, goth.ErrMissingSession
 	}
+	session.SessionToken = sessionToken
 
 	return session, nil
 }
@@ -66,29 +186,66 @@ func (a *gormAdapter) GetUser(ctx context.Context, id uuid.UUID) (adapters.GothU
 	return user, nil
 }
 
+// GetUserByEmail is a helper function to retrieve a user by email.
+func (a *gormAdapter) GetUserByEmail(ctx context.Context, email string) (adapters.GothUser, error) {
+	var user adapters.GothUser
+	err := a.db.WithContext(ctx).Preload(clause.Associations).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		return adapters.GothUser{}, goth.ErrMissingUser
+	}
+
+	return user, nil
+}
+
+// GetUserByAccount is a helper function to retrieve a user by the account ID in a provider.
+func (a *gormAdapter) GetUserByAccount(ctx context.Context, provider string, providerAccountID string) (adapters.GothUser, error) {
+	var account adapters.GothAccount
+	err := a.db.WithContext(ctx).Where("provider = ? AND provider_account_id = ?", provider, providerAccountID).First(&account).Error
+	if err != nil || account.UserID == nil {
+		return adapters.GothUser{}, goth.ErrMissingUser
+	}
+
+	return a.GetUser(ctx, *account.UserID)
+}
+
+// UpdateUser is a helper function to update a user.
+func (a *gormAdapter) UpdateUser(ctx context.Context, user adapters.GothUser) (adapters.GothUser, error) {
+	err := a.db.WithContext(ctx).Model(&adapters.GothUser{}).Where("id = ?", user.ID).Select("name", "email", "email_verified", "image", "locale").Updates(&user).Error
+	if err != nil {
+		return adapters.GothUser{}, goth.ErrBadRequest
+	}
+
+	return a.GetUser(ctx, user.ID)
+}
+
 // CreateSession is a helper function to create a new session.
-func (a *gormAdapter) CreateSession(ctx context.Context, userID uuid.UUID, expires time.Time) (adapters.GothSession, error) {
-	session := adapters.GothSession{
-		UserID:       userID,
-		SessionToken: uuid.NewString(),
-		ExpiresAt:    expires,
-		CsrfToken: adapters.GothCsrfToken{
+func (a *gormAdapter) CreateSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	sessionToken := session.SessionToken
+	session.SessionToken = adapters.HashToken(sessionToken)
+
+	if session.CsrfToken.Token == "" {
+		session.CsrfToken = adapters.GothCsrfToken{
 			Token:     uuid.NewString(),               // creates a token that is used to prevent CSRF attacks
 			ExpiresAt: time.Now().Add(24 * time.Hour), // expires in 24 hours
-		},
+		}
+	}
+
+	if err := a.assignID(&session.ID); err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
 	}
 
 	err := a.db.Session(&gorm.Session{FullSaveAssociations: true}).WithContext(ctx).Create(&session).Error
 	if err != nil {
 		return adapters.GothSession{}, goth.ErrBadSession
 	}
+	session.SessionToken = sessionToken
 
 	return session, nil
 }
 
 // DeleteSession is a helper function to delete a session by session token.
 func (a *gormAdapter) DeleteSession(ctx context.Context, sessionToken string) error {
-	err := a.db.WithContext(ctx).Where("session_token = ?", sessionToken).Delete(&adapters.GothSession{}).Error
+	err := a.db.WithContext(ctx).Where("session_token = ?", adapters.HashToken(sessionToken)).Delete(&adapters.GothSession{}).Error
 	if err != nil {
 		return goth.ErrBadRequest
 	}
@@ -97,11 +254,91 @@ func (a *gormAdapter) DeleteSession(ctx context.Context, sessionToken string) er
 }
 
 // RefreshSession is a helper function to refresh a session.
+// It returns goth.ErrSessionConflict if the session has been modified concurrently.
 func (a *gormAdapter) RefreshSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
-	err := a.db.WithContext(ctx).Model(&adapters.GothSession{}).Where("session_token = ?", session.SessionToken).Updates(&session).Error
+	res := a.db.WithContext(ctx).Model(&adapters.GothSession{}).
+		Where("session_token = ? AND version = ?", adapters.HashToken(session.SessionToken), session.Version).
+		Updates(map[string]any{
+			"expires_at":   session.ExpiresAt,
+			"last_seen_at": session.LastSeenAt,
+			"version":      session.Version + 1,
+			"updated_at":   time.Now(),
+		})
+	if res.Error != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	if res.RowsAffected == 0 {
+		return adapters.GothSession{}, goth.ErrSessionConflict
+	}
+	session.Version++
+
+	return session, nil
+}
+
+// TouchSessions is a helper function to extend the expiry and set the last activity of sessions in one transaction.
+// The version of the sessions is not incremented, so that touches do not conflict with updates.
+func (a *gormAdapter) TouchSessions(ctx context.Context, touches []adapters.SessionTouch) error {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, t := range touches {
+			err := tx.Model(&adapters.GothSession{}).
+				Where("session_token = ?", adapters.HashToken(t.SessionToken)).
+				Updates(map[string]any{
+					"expires_at":   gorm.Expr("CASE WHEN expires_at < ? THEN ? ELSE expires_at END", t.ExpiresAt, t.ExpiresAt),
+					"last_seen_at": t.LastSeenAt,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return goth.ErrBadSession
+	}
+
+	return nil
+}
+
+// UpdateSession is a helper function to update a session and its CSRF token.
+// It returns goth.ErrSessionConflict if the session has been modified concurrently.
+func (a *gormAdapter) UpdateSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Save(&session.CsrfToken).Error
+		if err != nil {
+			return err
+		}
+		session.CsrfTokenID = session.CsrfToken.ID
+
+		res := tx.Model(&adapters.GothSession{}).
+			Where("id = ? AND version = ?", session.ID, session.Version).
+			Updates(map[string]any{
+				"expires_at":      session.ExpiresAt,
+				"csrf_token_id":   session.CsrfTokenID,
+				"version":         session.Version + 1,
+				"mfa_verified_at": session.MFAVerifiedAt,
+				"active_team_id":  session.ActiveTeamID,
+				"updated_at":      time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return goth.ErrSessionConflict
+		}
+
+		return nil
+	})
+	if errors.Is(err, goth.ErrSessionConflict) {
+		return adapters.GothSession{}, err
+	}
+
 	if err != nil {
 		return adapters.GothSession{}, goth.ErrBadSession
 	}
+	session.Version++
 
 	return session, nil
 }
@@ -116,6 +353,65 @@ func (a *gormAdapter) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// MergeUsers is a helper function to merge the loser into the winner in a single transaction.
+// Team memberships the winner already has are kept with the role of the winner.
+func (a *gormAdapter) MergeUsers(ctx context.Context, winnerID, loserID uuid.UUID) error {
+	if winnerID == loserID {
+		return goth.ErrBadRequest
+	}
+
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var winner adapters.GothUser
+		if err := tx.Where("id = ?", winnerID).First(&winner).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []any{
+			&adapters.GothAccount{},
+			&adapters.GothSession{},
+			&adapters.GothCredential{},
+			&adapters.GothTrustedDevice{},
+		} {
+			err := tx.Unscoped().Model(model).Where("user_id = ?", loserID).Update("user_id", winnerID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&adapters.GothTeamMember{}).
+			Where("user_id = ? AND team_id NOT IN (?)", loserID, tx.Model(&adapters.GothTeamMember{}).Select("team_id").Where("user_id = ?", winnerID)).
+			Update("user_id", winnerID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", loserID).Delete(&adapters.GothTeamMember{}).Error
+		if err != nil {
+			return err
+		}
+
+		res := tx.Where("id = ?", loserID).Delete(&adapters.GothUser{})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return goth.ErrMissingUser
+	}
+
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
 // LinkAccount is a helper function to link an account to a user.
 func (a *gormAdapter) LinkAccount(ctx context.Context, accountID, userID uuid.UUID) error {
 	err := a.db.WithContext(ctx).Model(&adapters.GothAccount{}).Where("id = ?", accountID).Update("user_id", userID).Error
@@ -125,3 +421,852 @@ func (a *gormAdapter) LinkAccount(ctx context.Context, accountID, userID uuid.UU
 
 	return nil
 }
+
+// GetAccount is a helper function to retrieve the account of a user for a provider.
+func (a *gormAdapter) GetAccount(ctx context.Context, userID uuid.UUID, provider string) (adapters.GothAccount, error) {
+	var account adapters.GothAccount
+	err := a.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).First(&account).Error
+	if err != nil {
+		return adapters.GothAccount{}, goth.ErrMissingAccount
+	}
+
+	return account, nil
+}
+
+// UpdateAccount is a helper function to update the tokens and scope of an account.
+func (a *gormAdapter) UpdateAccount(ctx context.Context, account adapters.GothAccount) (adapters.GothAccount, error) {
+	err := a.db.WithContext(ctx).Model(&account).Select("access_token", "refresh_token", "expires_at", "token_type", "scope", "id_token", "last_refreshed_at", "last_refresh_error").Updates(&account).Error
+	if err != nil {
+		return adapters.GothAccount{}, goth.ErrBadRequest
+	}
+
+	return account, nil
+}
+
+// UpsertAccount is a helper function to create an account or update the tokens and user of the account with the same provider and provider account ID.
+func (a *gormAdapter) UpsertAccount(ctx context.Context, account adapters.GothAccount) (adapters.GothAccount, error) {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing adapters.GothAccount
+
+		err := tx.Where("provider = ? AND provider_account_id = ?", account.Provider, account.ProviderAccountID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := a.assignID(&account.ID); err != nil {
+				return err
+			}
+
+			return tx.Omit(clause.Associations).Create(&account).Error
+		}
+
+		if err != nil {
+			return err
+		}
+
+		account.ID = existing.ID
+
+		return tx.Model(&account).Select("user_id", "access_token", "refresh_token", "expires_at", "token_type", "scope", "id_token").Updates(&account).Error
+	})
+	if err != nil {
+		return adapters.GothAccount{}, goth.ErrBadRequest
+	}
+
+	return account, nil
+}
+
+// ListExpiringAccounts is a helper function to list the accounts with a refresh token whose access token expires before the time.
+// Accounts without an expiry are never returned.
+func (a *gormAdapter) ListExpiringAccounts(ctx context.Context, before time.Time, limit int) ([]adapters.GothAccount, error) {
+	accounts := []adapters.GothAccount{}
+
+	err := a.db.WithContext(ctx).
+		Where("refresh_token IS NOT NULL AND refresh_token <> ''").
+		Where("expires_at > ? AND expires_at < ?", time.Unix(0, 0), before).
+		Order("expires_at").
+		Limit(limit).
+		Find(&accounts).Error
+	if err != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	return accounts, nil
+}
+
+// GetThrottle is a helper function to retrieve the failed attempts of an identity.
+func (a *gormAdapter) GetThrottle(ctx context.Context, identifier string) (adapters.GothThrottle, error) {
+	var throttle adapters.GothThrottle
+	err := a.db.WithContext(ctx).Where("identifier = ?", identifier).First(&throttle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return adapters.GothThrottle{Identifier: identifier}, nil
+	}
+
+	if err != nil {
+		return adapters.GothThrottle{}, goth.ErrBadRequest
+	}
+
+	return throttle, nil
+}
+
+// IncrementThrottle is a helper function to record a failed attempt of an identity.
+func (a *gormAdapter) IncrementThrottle(ctx context.Context, identifier string) (adapters.GothThrottle, error) {
+	now := time.Now()
+
+	throttle := adapters.GothThrottle{
+		Identifier:    identifier,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+
+	err := a.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "identifier"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"failures":        gorm.Expr("goth_throttles.failures + 1"),
+			"last_failure_at": now,
+			"updated_at":      now,
+		}),
+	}).Create(&throttle).Error
+	if err != nil {
+		return adapters.GothThrottle{}, goth.ErrBadRequest
+	}
+
+	return a.GetThrottle(ctx, identifier)
+}
+
+// AttemptThrottle is a helper function to check and record an attempt of an identity. The throttle
+// is locked for the check, so that concurrent attempts are counted one after another.
+func (a *gormAdapter) AttemptThrottle(ctx context.Context, identifier string, delay func(failures int) time.Duration) (adapters.GothThrottle, error) {
+	var throttle adapters.GothThrottle
+
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("identifier = ?", identifier).First(&throttle).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			throttle = adapters.GothThrottle{Identifier: identifier}
+		} else if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if now.Before(throttle.LastFailureAt.Add(delay(throttle.Failures))) {
+			return adapters.ErrThrottled
+		}
+
+		throttle.Failures++
+		throttle.LastFailureAt = now
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "identifier"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"failures":        gorm.Expr("goth_throttles.failures + 1"),
+				"last_failure_at": now,
+				"updated_at":      now,
+			}),
+		}).Create(&throttle).Error
+	})
+	if errors.Is(err, adapters.ErrThrottled) {
+		return throttle, err
+	}
+
+	if err != nil {
+		return adapters.GothThrottle{}, goth.ErrBadRequest
+	}
+
+	return throttle, nil
+}
+
+// ResetThrottle is a helper function to reset the failed attempts of an identity.
+func (a *gormAdapter) ResetThrottle(ctx context.Context, identifier string) error {
+	err := a.db.WithContext(ctx).Where("identifier = ?", identifier).Delete(&adapters.GothThrottle{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// CreateTeam is a helper function to create a new team.
+func (a *gormAdapter) CreateTeam(ctx context.Context, team adapters.GothTeam) (adapters.GothTeam, error) {
+	err := a.db.WithContext(ctx).Create(&team).Error
+	if err != nil {
+		return adapters.GothTeam{}, goth.ErrBadRequest
+	}
+
+	return team, nil
+}
+
+// GetTeam is a helper function to retrieve a team by ID.
+func (a *gormAdapter) GetTeam(ctx context.Context, id uuid.UUID) (adapters.GothTeam, error) {
+	var team adapters.GothTeam
+	err := a.db.WithContext(ctx).Preload("Members").Where("id = ?", id).First(&team).Error
+	if err != nil {
+		return adapters.GothTeam{}, goth.ErrMissingTeam
+	}
+
+	return team, nil
+}
+
+// GetTeamBySlug is a helper function to retrieve a team by slug.
+func (a *gormAdapter) GetTeamBySlug(ctx context.Context, slug string) (adapters.GothTeam, error) {
+	var team adapters.GothTeam
+	err := a.db.WithContext(ctx).Preload("Members").Where("slug = ?", slug).First(&team).Error
+	if err != nil {
+		return adapters.GothTeam{}, goth.ErrMissingTeam
+	}
+
+	return team, nil
+}
+
+// UpdateTeam is a helper function to update a team.
+func (a *gormAdapter) UpdateTeam(ctx context.Context, team adapters.GothTeam) (adapters.GothTeam, error) {
+	err := a.db.WithContext(ctx).Model(&adapters.GothTeam{}).Where("id = ?", team.ID).Updates(map[string]any{
+		"name":         team.Name,
+		"slug":         team.Slug,
+		"description":  team.Description,
+		"sso_required": team.SSORequired,
+		"sso_provider": team.SSOProvider,
+	}).Error
+	if err != nil {
+		return adapters.GothTeam{}, goth.ErrBadRequest
+	}
+
+	return a.GetTeam(ctx, team.ID)
+}
+
+// DeleteTeam is a helper function to delete a team by ID.
+func (a *gormAdapter) DeleteTeam(ctx context.Context, id uuid.UUID) error {
+	err := a.db.WithContext(ctx).Where("id = ?", id).Delete(&adapters.GothTeam{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// AddTeamMember is a helper function to add a user with a role to a team.
+func (a *gormAdapter) AddTeamMember(ctx context.Context, teamID, userID uuid.UUID, role string) error {
+	member := adapters.GothTeamMember{TeamID: teamID, UserID: userID, Role: role}
+
+	err := a.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Omit("User").Create(&member).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// RemoveTeamMember is a helper function to remove a user from a team.
+func (a *gormAdapter) RemoveTeamMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	err := a.db.WithContext(ctx).Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&adapters.GothTeamMember{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// ListUserTeams is a helper function to list the teams of a user.
+func (a *gormAdapter) ListUserTeams(ctx context.Context, userID uuid.UUID) ([]adapters.GothTeam, error) {
+	var teams []adapters.GothTeam
+	err := a.db.WithContext(ctx).
+		Joins("JOIN goth_team_members ON goth_team_members.team_id = goth_teams.id").
+		Where("goth_team_members.user_id = ?", userID).
+		Find(&teams).Error
+	if err != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	return teams, nil
+}
+
+// CreateProviderDomain is a helper function to create or update a domain to provider mapping.
+func (a *gormAdapter) CreateProviderDomain(ctx context.Context, domain adapters.GothProviderDomain) (adapters.GothProviderDomain, error) {
+	err := a.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "domain"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "updated_at", "deleted_at"}),
+	}).Create(&domain).Error
+	if err != nil {
+		return adapters.GothProviderDomain{}, goth.ErrBadRequest
+	}
+
+	return domain, nil
+}
+
+// GetProviderDomain is a helper function to retrieve the provider mapping of a domain.
+func (a *gormAdapter) GetProviderDomain(ctx context.Context, domain string) (adapters.GothProviderDomain, error) {
+	var d adapters.GothProviderDomain
+	err := a.read(ctx).Where("domain = ?", domain).First(&d).Error
+	if err != nil {
+		return adapters.GothProviderDomain{}, goth.ErrUnknownDomain
+	}
+
+	return d, nil
+}
+
+// DeleteProviderDomain is a helper function to delete the provider mapping of a domain.
+func (a *gormAdapter) DeleteProviderDomain(ctx context.Context, domain string) error {
+	err := a.db.WithContext(ctx).Where("domain = ?", domain).Delete(&adapters.GothProviderDomain{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// CreateCredential is a helper function to create a new credential.
+func (a *gormAdapter) CreateCredential(ctx context.Context, credential adapters.GothCredential) (adapters.GothCredential, error) {
+	err := a.db.WithContext(ctx).Create(&credential).Error
+	if err != nil {
+		return adapters.GothCredential{}, goth.ErrBadRequest
+	}
+
+	return credential, nil
+}
+
+// ListCredentials is a helper function to list the credentials of a user.
+func (a *gormAdapter) ListCredentials(ctx context.Context, userID uuid.UUID) ([]adapters.GothCredential, error) {
+	var credentials []adapters.GothCredential
+	err := a.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	if err != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	return credentials, nil
+}
+
+// UpdateCredential is a helper function to update the data and last use of a credential.
+func (a *gormAdapter) UpdateCredential(ctx context.Context, credential adapters.GothCredential) (adapters.GothCredential, error) {
+	err := a.db.WithContext(ctx).Model(&credential).Select("name", "data", "last_used_at").Updates(&credential).Error
+	if err != nil {
+		return adapters.GothCredential{}, goth.ErrBadRequest
+	}
+
+	return credential, nil
+}
+
+// DeleteCredential is a helper function to delete a credential of a user.
+func (a *gormAdapter) DeleteCredential(ctx context.Context, userID, id uuid.UUID) error {
+	err := a.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&adapters.GothCredential{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// CreateInstallation is a helper function to link an installation to a team.
+// An existing installation of the provider for the team is replaced.
+func (a *gormAdapter) CreateInstallation(ctx context.Context, installation adapters.GothInstallation) (adapters.GothInstallation, error) {
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("team_id = ? AND provider = ?", installation.TeamID, installation.Provider).Delete(&adapters.GothInstallation{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&installation).Error
+	})
+	if err != nil {
+		return adapters.GothInstallation{}, goth.ErrBadRequest
+	}
+
+	return installation, nil
+}
+
+// GetInstallation is a helper function to retrieve the installation of a provider linked to a team.
+func (a *gormAdapter) GetInstallation(ctx context.Context, teamID uuid.UUID, provider string) (adapters.GothInstallation, error) {
+	var installation adapters.GothInstallation
+	err := a.db.WithContext(ctx).Where("team_id = ? AND provider = ?", teamID, provider).First(&installation).Error
+	if err != nil {
+		return adapters.GothInstallation{}, goth.ErrBadRequest
+	}
+
+	return installation, nil
+}
+
+// DeleteInstallation is a helper function to unlink the installation of a provider from a team.
+func (a *gormAdapter) DeleteInstallation(ctx context.Context, teamID uuid.UUID, provider string) error {
+	err := a.db.WithContext(ctx).Where("team_id = ? AND provider = ?", teamID, provider).Delete(&adapters.GothInstallation{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// CreateInvitation is a helper function to create a new invitation.
+func (a *gormAdapter) CreateInvitation(ctx context.Context, invitation adapters.GothInvitation) (adapters.GothInvitation, error) {
+	if err := a.assignID(&invitation.ID); err != nil {
+		return adapters.GothInvitation{}, goth.ErrBadRequest
+	}
+
+	err := a.db.WithContext(ctx).Omit(clause.Associations).Create(&invitation).Error
+	if err != nil {
+		return adapters.GothInvitation{}, goth.ErrBadRequest
+	}
+
+	return invitation, nil
+}
+
+// GetInvitationByToken is a helper function to retrieve the invitation with the hash of the token.
+func (a *gormAdapter) GetInvitationByToken(ctx context.Context, token string) (adapters.GothInvitation, error) {
+	var invitation adapters.GothInvitation
+	err := a.db.WithContext(ctx).Where("token = ?", token).First(&invitation).Error
+	if err != nil {
+		return adapters.GothInvitation{}, goth.ErrBadRequest
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation is a helper function to accept a pending invitation.
+// The invitation is only updated if it has not been accepted yet, so that concurrent accepts cannot both succeed.
+func (a *gormAdapter) AcceptInvitation(ctx context.Context, id, userID uuid.UUID) (adapters.GothInvitation, error) {
+	now := time.Now()
+
+	res := a.db.WithContext(ctx).
+		Model(&adapters.GothInvitation{}).
+		Where("id = ? AND accepted_at IS NULL AND expires_at > ?", id, now).
+		Updates(map[string]any{"accepted_at": now, "accepted_by_id": userID})
+	if res.Error != nil || res.RowsAffected != 1 {
+		return adapters.GothInvitation{}, goth.ErrBadRequest
+	}
+
+	var invitation adapters.GothInvitation
+	err := a.db.WithContext(ctx).Where("id = ?", id).First(&invitation).Error
+	if err != nil {
+		return adapters.GothInvitation{}, goth.ErrBadRequest
+	}
+
+	return invitation, nil
+}
+
+// DeleteInvitation is a helper function to delete an invitation.
+func (a *gormAdapter) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	err := a.db.WithContext(ctx).Where("id = ?", id).Delete(&adapters.GothInvitation{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// ListNotificationPreferences is a helper function to list the notification preferences of a user.
+func (a *gormAdapter) ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]adapters.GothNotificationPreference, error) {
+	var preferences []adapters.GothNotificationPreference
+	err := a.db.WithContext(ctx).Where("user_id = ?", userID).Find(&preferences).Error
+	if err != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	return preferences, nil
+}
+
+// SetNotificationPreference is a helper function to create or update the notification preference of a user.
+func (a *gormAdapter) SetNotificationPreference(ctx context.Context, preference adapters.GothNotificationPreference) (adapters.GothNotificationPreference, error) {
+	err := a.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"delivery", "updated_at"}),
+	}).Create(&preference).Error
+	if err != nil {
+		return adapters.GothNotificationPreference{}, goth.ErrBadRequest
+	}
+
+	return preference, nil
+}
+
+// CreateNotification is a helper function to add a notification to the next digest of a user.
+func (a *gormAdapter) CreateNotification(ctx context.Context, notification adapters.GothNotification) (adapters.GothNotification, error) {
+	if err := a.assignID(&notification.ID); err != nil {
+		return adapters.GothNotification{}, goth.ErrBadRequest
+	}
+
+	err := a.db.WithContext(ctx).Create(&notification).Error
+	if err != nil {
+		return adapters.GothNotification{}, goth.ErrBadRequest
+	}
+
+	return notification, nil
+}
+
+// ListPendingNotifications is a helper function to list the oldest pending notifications.
+func (a *gormAdapter) ListPendingNotifications(ctx context.Context, limit int) ([]adapters.GothNotification, error) {
+	var notifications []adapters.GothNotification
+	err := a.db.WithContext(ctx).Order("occurred_at").Limit(limit).Find(&notifications).Error
+	if err != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	return notifications, nil
+}
+
+// DeleteNotifications is a helper function to delete the notifications that have been sent.
+func (a *gormAdapter) DeleteNotifications(ctx context.Context, ids ...uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := a.db.WithContext(ctx).Where("id IN ?", ids).Delete(&adapters.GothNotification{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// Put is a helper function to store an entry of a flow until the TTL has passed.
+func (a *gormAdapter) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	flow := adapters.GothFlow{Key: key, Value: value, ExpiresAt: time.Now().Add(ttl)}
+
+	err := a.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at"}),
+	}).Create(&flow).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// GetAndDelete is a helper function to consume an entry of a flow. The entry is deleted
+// and returned in a single statement, so that it can be consumed only once.
+func (a *gormAdapter) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	var flow adapters.GothFlow
+
+	res := a.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("key = ? AND expires_at > ?", key, time.Now()).
+		Delete(&flow)
+	if res.Error != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	if res.RowsAffected != 1 {
+		return nil, adapters.ErrFlowNotFound
+	}
+
+	return flow.Value, nil
+}
+
+// CreateTrustedDevice is a helper function to create a new trusted device.
+func (a *gormAdapter) CreateTrustedDevice(ctx context.Context, device adapters.GothTrustedDevice) (adapters.GothTrustedDevice, error) {
+	err := a.db.WithContext(ctx).Create(&device).Error
+	if err != nil {
+		return adapters.GothTrustedDevice{}, goth.ErrBadRequest
+	}
+
+	return device, nil
+}
+
+// GetTrustedDevice is a helper function to retrieve a trusted device by ID.
+func (a *gormAdapter) GetTrustedDevice(ctx context.Context, id uuid.UUID) (adapters.GothTrustedDevice, error) {
+	var device adapters.GothTrustedDevice
+	err := a.db.WithContext(ctx).Where("id = ?", id).First(&device).Error
+	if err != nil {
+		return adapters.GothTrustedDevice{}, goth.ErrBadRequest
+	}
+
+	return device, nil
+}
+
+// ListTrustedDevices is a helper function to list the trusted devices of a user.
+func (a *gormAdapter) ListTrustedDevices(ctx context.Context, userID uuid.UUID) ([]adapters.GothTrustedDevice, error) {
+	var devices []adapters.GothTrustedDevice
+	err := a.db.WithContext(ctx).Where("user_id = ? AND expires_at > ?", userID, time.Now()).Order("created_at").Find(&devices).Error
+	if err != nil {
+		return nil, goth.ErrBadRequest
+	}
+
+	return devices, nil
+}
+
+// DeleteTrustedDevice is a helper function to delete a trusted device of a user.
+func (a *gormAdapter) DeleteTrustedDevice(ctx context.Context, userID, id uuid.UUID) error {
+	err := a.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&adapters.GothTrustedDevice{}).Error
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// DeleteTrustedDevices is a helper function to delete all trusted devices of a user.
+func (a *gormAdapter) DeleteTrustedDevices(ctx context.Context, userID uuid.UUID) (int64, error) {
+	res := a.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&adapters.GothTrustedDevice{})
+	if res.Error != nil {
+		return 0, goth.ErrBadRequest
+	}
+
+	return res.RowsAffected, nil
+}
+
+// CreateVerificationToken is a helper function to create a new verification token.
+func (a *gormAdapter) CreateVerificationToken(ctx context.Context, verficationToken adapters.GothVerificationToken) (adapters.GothVerificationToken, error) {
+	err := a.db.WithContext(ctx).Create(&verficationToken).Error
+	if err != nil {
+		return adapters.GothVerificationToken{}, goth.ErrBadRequest
+	}
+
+	return verficationToken, nil
+}
+
+// UseVerficationToken is a helper function to use a verification token.
+// The token is compared and deleted in a single statement, so that concurrent uses cannot both succeed.
+func (a *gormAdapter) UseVerficationToken(ctx context.Context, identifier string, token string) (adapters.GothVerificationToken, error) {
+	var verficationToken adapters.GothVerificationToken
+
+	res := a.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("identifier = ? AND token = ? AND expires_at > ?", identifier, token, time.Now()).
+		Delete(&verficationToken)
+	if res.Error != nil || res.RowsAffected != 1 {
+		return adapters.GothVerificationToken{}, goth.ErrBadRequest
+	}
+
+	return verficationToken, nil
+}
+
+// PurgeDeleted is a helper function to hard-delete soft-deleted records and expired verification tokens.
+func (a *gormAdapter) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan)
+
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{
+			&adapters.GothSession{},
+			&adapters.GothAccount{},
+			&adapters.GothTeam{},
+			&adapters.GothUser{},
+			&adapters.GothProviderDomain{},
+			&adapters.GothCredential{},
+			&adapters.GothTrustedDevice{},
+			&adapters.GothInstallation{},
+			&adapters.GothInvitation{},
+			&adapters.GothNotification{},
+		} {
+			err := tx.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("expires_at < ?", time.Now()).Delete(&adapters.GothFlow{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("expires_at < ? OR (deleted_at IS NOT NULL AND deleted_at < ?)", cutoff, cutoff).Delete(&adapters.GothVerificationToken{}).Error
+	})
+	if err != nil {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// DeleteSessionsWhere is a helper function to delete all sessions matching the filter.
+func (a *gormAdapter) DeleteSessionsWhere(ctx context.Context, filter adapters.SessionFilter) (int64, error) {
+	if filter.IsEmpty() {
+		return 0, adapters.ErrEmptyFilter
+	}
+
+	res := sessionFilter(a.db.WithContext(ctx), filter).Delete(&adapters.GothSession{})
+	if res.Error != nil {
+		return 0, goth.ErrBadRequest
+	}
+
+	return res.RowsAffected, nil
+}
+
+func sessionFilter(db *gorm.DB, filter adapters.SessionFilter) *gorm.DB {
+	if filter.ID != nil {
+		db = db.Where("id = ?", *filter.ID)
+	}
+
+	if filter.UserID != nil {
+		db = db.Where("user_id = ?", *filter.UserID)
+	}
+
+	if filter.Provider != "" {
+		db = db.Where("provider = ?", filter.Provider)
+	}
+
+	if filter.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *filter.CreatedBefore)
+	}
+
+	if filter.IPPrefix != "" {
+		db = db.Where("ip_address LIKE ? ESCAPE '\\'", escapeLike(filter.IPPrefix)+"%")
+	}
+
+	return db
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// CountUsers is a helper function to count the users matching the filter.
+func (a *gormAdapter) CountUsers(ctx context.Context, filter adapters.UserFilter) (int64, error) {
+	var n int64
+
+	err := userFilter(a.read(ctx).Model(&adapters.GothUser{}), filter).Count(&n).Error
+	if err != nil {
+		return 0, goth.ErrBadRequest
+	}
+
+	return n, nil
+}
+
+// ListUsers is a helper function to list a page of the users matching the filter.
+// The users are ordered by ID and the cursor is the ID of the last user of the page.
+func (a *gormAdapter) ListUsers(ctx context.Context, filter adapters.UserFilter, page adapters.Page) (adapters.UserPage, error) {
+	limit := page.GetLimit()
+
+	db := userFilter(a.read(ctx).Model(&adapters.GothUser{}), filter)
+
+	if page.Cursor != "" {
+		cursor, err := uuid.Parse(page.Cursor)
+		if err != nil {
+			return adapters.UserPage{}, goth.ErrBadRequest
+		}
+		db = db.Where("goth_users.id > ?", cursor)
+	}
+
+	var users []adapters.GothUser
+	err := db.Preload("Accounts").Order("goth_users.id").Limit(limit + 1).Find(&users).Error
+	if err != nil {
+		return adapters.UserPage{}, goth.ErrBadRequest
+	}
+
+	p := adapters.UserPage{Users: users}
+	if len(users) > limit {
+		p.Users = users[:limit]
+		p.NextCursor = users[limit-1].ID.String()
+	}
+
+	return p, nil
+}
+
+// CountActiveSessions is a helper function to count the not expired sessions matching the filter.
+func (a *gormAdapter) CountActiveSessions(ctx context.Context, filter adapters.SessionFilter) (int64, error) {
+	var n int64
+
+	err := sessionFilter(a.read(ctx).Model(&adapters.GothSession{}), filter).Where("expires_at > ?", time.Now()).Count(&n).Error
+	if err != nil {
+		return 0, goth.ErrBadRequest
+	}
+
+	return n, nil
+}
+
+// ListSessions is a helper function to list a page of the not expired sessions matching the filter.
+func (a *gormAdapter) ListSessions(ctx context.Context, filter adapters.SessionFilter, page adapters.Page) (adapters.SessionPage, error) {
+	limit := page.GetLimit()
+
+	db := sessionFilter(a.read(ctx).Model(&adapters.GothSession{}), filter).Where("expires_at > ?", time.Now())
+
+	if page.Cursor != "" {
+		cursor, err := uuid.Parse(page.Cursor)
+		if err != nil {
+			return adapters.SessionPage{}, goth.ErrBadRequest
+		}
+		db = db.Where("id > ?", cursor)
+	}
+
+	var sessions []adapters.GothSession
+	err := db.Order("id").Limit(limit + 1).Find(&sessions).Error
+	if err != nil {
+		return adapters.SessionPage{}, goth.ErrBadRequest
+	}
+
+	for i := range sessions {
+		sessions[i].SessionToken = ""
+	}
+
+	p := adapters.SessionPage{Sessions: sessions}
+	if len(sessions) > limit {
+		p.Sessions = sessions[:limit]
+		p.NextCursor = sessions[limit-1].ID.String()
+	}
+
+	return p, nil
+}
+
+// ExportSessions is a helper function to export a page of the not expired sessions with the hash of their token.
+func (a *gormAdapter) ExportSessions(ctx context.Context, page adapters.Page) (adapters.SessionPage, error) {
+	limit := page.GetLimit()
+
+	db := a.read(ctx).Preload("CsrfToken").Where("expires_at > ?", time.Now())
+
+	if page.Cursor != "" {
+		cursor, err := uuid.Parse(page.Cursor)
+		if err != nil {
+			return adapters.SessionPage{}, goth.ErrBadRequest
+		}
+		db = db.Where("id > ?", cursor)
+	}
+
+	var sessions []adapters.GothSession
+	err := db.Order("id").Limit(limit + 1).Find(&sessions).Error
+	if err != nil {
+		return adapters.SessionPage{}, goth.ErrBadRequest
+	}
+
+	p := adapters.SessionPage{Sessions: sessions}
+	if len(sessions) > limit {
+		p.Sessions = sessions[:limit]
+		p.NextCursor = sessions[limit-1].ID.String()
+	}
+
+	return p, nil
+}
+
+// ImportSessions is a helper function to import sessions with the hash of their token.
+// Sessions that already exist are skipped.
+func (a *gormAdapter) ImportSessions(ctx context.Context, sessions ...adapters.GothSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	for i := range sessions {
+		sessions[i].User = adapters.GothUser{}
+	}
+
+	err := a.db.Session(&gorm.Session{FullSaveAssociations: true}).WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&sessions).Error
+	if err != nil {
+		return goth.ErrBadSession
+	}
+
+	return nil
+}
+
+func userFilter(db *gorm.DB, filter adapters.UserFilter) *gorm.DB {
+	if filter.Email != "" {
+		db = db.Where("LOWER(goth_users.email) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(filter.Email))+"%")
+	}
+
+	if filter.Provider != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM goth_accounts WHERE goth_accounts.user_id = goth_users.id AND goth_accounts.provider = ? AND goth_accounts.deleted_at IS NULL)", filter.Provider)
+	}
+
+	if filter.CreatedAfter != nil {
+		db = db.Where("goth_users.created_at > ?", *filter.CreatedAfter)
+	}
+
+	if filter.CreatedBefore != nil {
+		db = db.Where("goth_users.created_at < ?", *filter.CreatedBefore)
+	}
+
+	return db
+}
+
+// assignID sets a new primary key if the key is not set and a generator is configured.
+// Otherwise the key is left to the database default.
+func (a *gormAdapter) assignID(id *uuid.UUID) error {
+	if a.ids == nil || *id != uuid.Nil {
+		return nil
+	}
+
+	var err error
+	*id, err = a.ids()
+
+	return err
+}
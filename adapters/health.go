@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+)
+
+// Pinger is implemented by stores that can check the connectivity to their backend.
+type Pinger interface {
+	// Ping checks the connectivity to the backend.
+	Ping(ctx context.Context) error
+}
+
+// MigrationChecker is implemented by stores that can detect pending schema migrations.
+type MigrationChecker interface {
+	// PendingMigrations returns a description of each pending migration.
+	PendingMigrations(ctx context.Context) ([]string, error)
+}
+
+// Ping checks the connectivity of every store of the adapter that implements Pinger.
+func Ping(ctx context.Context, adapter any) error {
+	errs := []error{}
+
+	for _, s := range stores(adapter) {
+		if p, ok := s.(Pinger); ok {
+			errs = append(errs, p.Ping(ctx))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// PendingMigrations returns the pending migrations of every store of the adapter that implements MigrationChecker.
+func PendingMigrations(ctx context.Context, adapter any) ([]string, error) {
+	pending := []string{}
+
+	for _, s := range stores(adapter) {
+		m, ok := s.(MigrationChecker)
+		if !ok {
+			continue
+		}
+
+		p, err := m.PendingMigrations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		pending = append(pending, p...)
+	}
+
+	return pending, nil
+}
+
+// Close closes every store of the adapter that implements io.Closer, e.g. on shutdown.
+func Close(adapter any) error {
+	errs := []error{}
+
+	for _, s := range stores(adapter) {
+		if c, ok := s.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stores returns the distinct stores backing the adapter.
+func stores(adapter any) []any {
+	for {
+		u, ok := adapter.(interface{ Unwrap() Adapter })
+		if !ok {
+			break
+		}
+
+		adapter = u.Unwrap()
+	}
+
+	if f, ok := adapter.(*FallbackAdapter); ok {
+		all := stores(f.primary)
+		for _, n := range stores(f.secondary) {
+			if !slices.Contains(all, n) {
+				all = append(all, n)
+			}
+		}
+
+		return all
+	}
+
+	c, ok := adapter.(*Composite)
+	if !ok {
+		return []any{adapter}
+	}
+
+	all := []any{}
+	for _, s := range []any{c.UserStore, c.SessionStore, c.TokenStore, c.TeamStore, c.ThrottleStore, c.DomainStore, c.CredentialStore, c.DeviceStore, c.AccountStore, c.InstallationStore, c.InvitationStore, c.NotificationStore, c.Purger} {
+		for _, n := range stores(s) {
+			if n != nil && !slices.Contains(all, n) {
+				all = append(all, n)
+			}
+		}
+	}
+
+	return all
+}
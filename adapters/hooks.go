@@ -0,0 +1,174 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+var _ Adapter = (*HooksAdapter)(nil)
+
+// Hooks are called by the HooksAdapter around the writes of the base adapter. Before hooks can modify
+// the record or abort the write with an error. After hooks are called with the written record, an error
+// is returned to the caller, but the write is not rolled back.
+type Hooks struct {
+	// BeforeCreateUser is called before a user is created.
+	BeforeCreateUser func(ctx context.Context, user *GothUser) error
+	// AfterCreateUser is called after a user has been created, e.g. to assign a default team.
+	AfterCreateUser func(ctx context.Context, user GothUser) error
+	// BeforeDeleteUser is called before a user is deleted.
+	BeforeDeleteUser func(ctx context.Context, id uuid.UUID) error
+	// AfterDeleteUser is called after a user has been deleted.
+	AfterDeleteUser func(ctx context.Context, id uuid.UUID) error
+	// BeforeCreateSession is called before a session is created.
+	BeforeCreateSession func(ctx context.Context, session *GothSession) error
+	// AfterCreateSession is called after a session has been created.
+	AfterCreateSession func(ctx context.Context, session GothSession) error
+	// BeforeUpsertAccount is called before an account is created or updated.
+	BeforeUpsertAccount func(ctx context.Context, account *GothAccount) error
+	// AfterUpsertAccount is called after an account has been created or updated.
+	AfterUpsertAccount func(ctx context.Context, account GothAccount) error
+	// BeforeCreateTeam is called before a team is created.
+	BeforeCreateTeam func(ctx context.Context, team *GothTeam) error
+	// AfterCreateTeam is called after a team has been created.
+	AfterCreateTeam func(ctx context.Context, team GothTeam) error
+	// BeforeAddTeamMember is called before a user is added to a team, e.g. to check the seats of the team.
+	BeforeAddTeamMember func(ctx context.Context, teamID, userID uuid.UUID, role string) error
+	// AfterAddTeamMember is called after a user has been added to a team.
+	AfterAddTeamMember func(ctx context.Context, teamID, userID uuid.UUID, role string) error
+}
+
+// HooksAdapter is an adapter that calls the hooks around the writes of the base adapter,
+// so that invariants are enforced regardless of the handler that triggered the write.
+type HooksAdapter struct {
+	Adapter
+	hooks Hooks
+}
+
+// WithHooks returns an adapter that calls the hooks around the writes of the base adapter.
+func WithHooks(base Adapter, hooks Hooks) *HooksAdapter {
+	return &HooksAdapter{Adapter: base, hooks: hooks}
+}
+
+// Unwrap returns the base adapter.
+func (a *HooksAdapter) Unwrap() Adapter {
+	return a.Adapter
+}
+
+// CreateUser calls the user hooks around CreateUser of the base adapter.
+func (a *HooksAdapter) CreateUser(ctx context.Context, user GothUser) (GothUser, error) {
+	if a.hooks.BeforeCreateUser != nil {
+		if err := a.hooks.BeforeCreateUser(ctx, &user); err != nil {
+			return GothUser{}, err
+		}
+	}
+
+	user, err := a.Adapter.CreateUser(ctx, user)
+	if err != nil {
+		return GothUser{}, err
+	}
+
+	if a.hooks.AfterCreateUser != nil {
+		return user, a.hooks.AfterCreateUser(ctx, user)
+	}
+
+	return user, nil
+}
+
+// DeleteUser calls the user hooks around DeleteUser of the base adapter.
+func (a *HooksAdapter) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if a.hooks.BeforeDeleteUser != nil {
+		if err := a.hooks.BeforeDeleteUser(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := a.Adapter.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+
+	if a.hooks.AfterDeleteUser != nil {
+		return a.hooks.AfterDeleteUser(ctx, id)
+	}
+
+	return nil
+}
+
+// CreateSession calls the session hooks around CreateSession of the base adapter.
+func (a *HooksAdapter) CreateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	if a.hooks.BeforeCreateSession != nil {
+		if err := a.hooks.BeforeCreateSession(ctx, &session); err != nil {
+			return GothSession{}, err
+		}
+	}
+
+	session, err := a.Adapter.CreateSession(ctx, session)
+	if err != nil {
+		return GothSession{}, err
+	}
+
+	if a.hooks.AfterCreateSession != nil {
+		return session, a.hooks.AfterCreateSession(ctx, session)
+	}
+
+	return session, nil
+}
+
+// UpsertAccount calls the account hooks around UpsertAccount of the base adapter.
+func (a *HooksAdapter) UpsertAccount(ctx context.Context, account GothAccount) (GothAccount, error) {
+	if a.hooks.BeforeUpsertAccount != nil {
+		if err := a.hooks.BeforeUpsertAccount(ctx, &account); err != nil {
+			return GothAccount{}, err
+		}
+	}
+
+	account, err := a.Adapter.UpsertAccount(ctx, account)
+	if err != nil {
+		return GothAccount{}, err
+	}
+
+	if a.hooks.AfterUpsertAccount != nil {
+		return account, a.hooks.AfterUpsertAccount(ctx, account)
+	}
+
+	return account, nil
+}
+
+// CreateTeam calls the team hooks around CreateTeam of the base adapter.
+func (a *HooksAdapter) CreateTeam(ctx context.Context, team GothTeam) (GothTeam, error) {
+	if a.hooks.BeforeCreateTeam != nil {
+		if err := a.hooks.BeforeCreateTeam(ctx, &team); err != nil {
+			return GothTeam{}, err
+		}
+	}
+
+	team, err := a.Adapter.CreateTeam(ctx, team)
+	if err != nil {
+		return GothTeam{}, err
+	}
+
+	if a.hooks.AfterCreateTeam != nil {
+		return team, a.hooks.AfterCreateTeam(ctx, team)
+	}
+
+	return team, nil
+}
+
+// AddTeamMember calls the team member hooks around AddTeamMember of the base adapter.
+func (a *HooksAdapter) AddTeamMember(ctx context.Context, teamID, userID uuid.UUID, role string) error {
+	if a.hooks.BeforeAddTeamMember != nil {
+		if err := a.hooks.BeforeAddTeamMember(ctx, teamID, userID, role); err != nil {
+			return err
+		}
+	}
+
+	if err := a.Adapter.AddTeamMember(ctx, teamID, userID, role); err != nil {
+		return err
+	}
+
+	if a.hooks.AfterAddTeamMember != nil {
+		return a.hooks.AfterAddTeamMember(ctx, teamID, userID, role)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package adapters
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator generates the primary keys of new records.
+// Adapters leave the keys to the database default if no generator is configured.
+type IDGenerator func() (uuid.UUID, error)
+
+// UUIDv4 generates random UUIDs (version 4).
+func UUIDv4() (uuid.UUID, error) {
+	return uuid.NewRandom()
+}
+
+// UUIDv7 generates time-ordered UUIDs (version 7), which keep B-tree indexes local under heavy inserts.
+func UUIDv7() (uuid.UUID, error) {
+	return uuid.NewV7()
+}
+
+// ULID generates ULIDs (48 bit millisecond timestamp and 80 random bits) in the binary layout of a UUID,
+// so they fit the UUID columns and sort by creation time.
+func ULID() (uuid.UUID, error) {
+	var id uuid.UUID
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], ts[2:])
+
+	if _, err := rand.Read(id[6:]); err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,260 @@
+package memcached_adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/google/uuid"
+)
+
+var (
+	_ adapters.SessionStore    = (*memcachedAdapter)(nil)
+	_ adapters.SessionMigrator = (*memcachedAdapter)(nil)
+)
+
+// DefaultPrefix is the default prefix of the session keys.
+const DefaultPrefix = "goth:session:"
+
+type memcachedAdapter struct {
+	client *memcache.Client
+	prefix string
+}
+
+// Opt is a function that configures the adapter.
+type Opt func(*memcachedAdapter)
+
+// WithPrefix sets the prefix of the session keys.
+func WithPrefix(prefix string) Opt {
+	return func(a *memcachedAdapter) {
+		a.prefix = prefix
+	}
+}
+
+// New is a helper function to create a new session store. It only implements
+// adapters.SessionStore and is intended to be composed with another adapter
+// via adapters.WithSessionStore.
+func New(client *memcache.Client, opts ...Opt) *memcachedAdapter {
+	a := &memcachedAdapter{client: client, prefix: DefaultPrefix}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Ping checks the connectivity to the memcached servers.
+func (a *memcachedAdapter) Ping(_ context.Context) error {
+	return a.client.Ping()
+}
+
+// CreateSession is a helper function to create a new session.
+func (a *memcachedAdapter) CreateSession(_ context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	now := time.Now()
+
+	session.ID = uuid.New()
+	session.Version = 1
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	if session.CsrfToken.Token == "" {
+		session.CsrfToken = adapters.GothCsrfToken{
+			ID:        uuid.New(),
+			Token:     uuid.NewString(),
+			ExpiresAt: now.Add(24 * time.Hour),
+		}
+	}
+	session.CsrfTokenID = session.CsrfToken.ID
+
+	item, err := a.item(session)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	err = a.client.Add(item)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	return session, nil
+}
+
+// GetSession is a helper function to retrieve a session by session token.
+func (a *memcachedAdapter) GetSession(_ context.Context, sessionToken string) (adapters.GothSession, error) {
+	session, _, err := a.get(sessionToken)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrMissingSession
+	}
+
+	return session, nil
+}
+
+// UpdateSession is a helper function to update a session.
+// It returns goth.ErrSessionConflict if the session has been modified concurrently.
+func (a *memcachedAdapter) UpdateSession(_ context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	return a.swap(session)
+}
+
+// RefreshSession is a helper function to refresh a session.
+// It returns goth.ErrSessionConflict if the session has been modified concurrently.
+func (a *memcachedAdapter) RefreshSession(_ context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	return a.swap(session)
+}
+
+// TouchSessions is a helper function to extend the expiry and set the last activity of sessions.
+// Sessions modified concurrently are skipped.
+func (a *memcachedAdapter) TouchSessions(_ context.Context, touches []adapters.SessionTouch) error {
+	for _, t := range touches {
+		session, _, err := a.get(t.SessionToken)
+		if err != nil {
+			continue
+		}
+
+		if t.ExpiresAt.After(session.ExpiresAt) {
+			session.ExpiresAt = t.ExpiresAt
+		}
+		session.LastSeenAt = &t.LastSeenAt
+
+		_, err = a.swap(session)
+		if err != nil && !errors.Is(err, goth.ErrSessionConflict) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteSession is a helper function to delete a session by session token.
+func (a *memcachedAdapter) DeleteSession(_ context.Context, sessionToken string) error {
+	err := a.client.Delete(a.key(sessionToken))
+	if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// DeleteSessionsWhere is not supported, because memcached cannot enumerate keys.
+func (a *memcachedAdapter) DeleteSessionsWhere(_ context.Context, _ adapters.SessionFilter) (int64, error) {
+	return 0, adapters.ErrUnimplemented
+}
+
+// CountActiveSessions is not supported, because memcached cannot enumerate keys.
+func (a *memcachedAdapter) CountActiveSessions(_ context.Context, _ adapters.SessionFilter) (int64, error) {
+	return 0, adapters.ErrUnimplemented
+}
+
+// ListSessions is not supported, because memcached cannot enumerate keys.
+func (a *memcachedAdapter) ListSessions(_ context.Context, _ adapters.SessionFilter, _ adapters.Page) (adapters.SessionPage, error) {
+	return adapters.SessionPage{}, adapters.ErrUnimplemented
+}
+
+// ExportSessions is not supported, because memcached cannot enumerate keys.
+func (a *memcachedAdapter) ExportSessions(_ context.Context, _ adapters.Page) (adapters.SessionPage, error) {
+	return adapters.SessionPage{}, adapters.ErrUnimplemented
+}
+
+// ImportSessions is a helper function to import sessions with the hash of their token.
+// Sessions that already exist or are expired are skipped.
+func (a *memcachedAdapter) ImportSessions(_ context.Context, sessions ...adapters.GothSession) error {
+	for _, session := range sessions {
+		if !session.IsValid() {
+			continue
+		}
+
+		session.User = adapters.GothUser{}
+
+		b, err := json.Marshal(session)
+		if err != nil {
+			return goth.ErrBadSession
+		}
+
+		err = a.client.Add(&memcache.Item{
+			Key:        a.prefix + session.SessionToken,
+			Value:      b,
+			Expiration: int32(session.ExpiresAt.Unix()), // absolute unix time
+		})
+		if err != nil && !errors.Is(err, memcache.ErrNotStored) {
+			return goth.ErrBadSession
+		}
+	}
+
+	return nil
+}
+
+func (a *memcachedAdapter) swap(session adapters.GothSession) (adapters.GothSession, error) {
+	current, item, err := a.get(session.SessionToken)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	if current.Version != session.Version {
+		return adapters.GothSession{}, goth.ErrSessionConflict
+	}
+
+	session.Version++
+	session.UpdatedAt = time.Now()
+
+	next, err := a.item(session)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+	item.Value = next.Value
+	item.Expiration = next.Expiration
+
+	err = a.client.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+		return adapters.GothSession{}, goth.ErrSessionConflict
+	}
+
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	return session, nil
+}
+
+func (a *memcachedAdapter) get(sessionToken string) (adapters.GothSession, *memcache.Item, error) {
+	item, err := a.client.Get(a.key(sessionToken))
+	if err != nil {
+		return adapters.GothSession{}, nil, err
+	}
+
+	var session adapters.GothSession
+	err = json.Unmarshal(item.Value, &session)
+	if err != nil {
+		return adapters.GothSession{}, nil, err
+	}
+	session.SessionToken = sessionToken
+
+	return session, item, nil
+}
+
+func (a *memcachedAdapter) item(session adapters.GothSession) (*memcache.Item, error) {
+	key := a.key(session.SessionToken)
+
+	// only the hash of the token is persisted
+	session.SessionToken = adapters.HashToken(session.SessionToken)
+	session.User = adapters.GothUser{}
+
+	b, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memcache.Item{
+		Key:        key,
+		Value:      b,
+		Expiration: int32(session.ExpiresAt.Unix()), // absolute unix time
+	}, nil
+}
+
+func (a *memcachedAdapter) key(sessionToken string) string {
+	return a.prefix + adapters.HashToken(sessionToken)
+}
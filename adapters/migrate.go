@@ -0,0 +1,196 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupportedSessionMigration is returned by Migrate if the sessions cannot be exported from the source or imported into the destination.
+var ErrUnsupportedSessionMigration = errors.New("adapters: session store does not support migrations")
+
+// SessionMigrator is implemented by session stores that can export and import sessions with the hash of their
+// token, so that sessions can be moved to another store without signing users out. The token itself is never known.
+type SessionMigrator interface {
+	// ExportSessions returns a page of the not expired sessions. The SessionToken of the sessions is the hash of the token.
+	ExportSessions(ctx context.Context, page Page) (SessionPage, error)
+	// ImportSessions stores the sessions with the hash of the token as SessionToken.
+	// Sessions that already exist are skipped.
+	ImportSessions(ctx context.Context, sessions ...GothSession) error
+}
+
+// MigrateOptions are the options of Migrate.
+type MigrateOptions struct {
+	// BatchSize is the number of users and sessions read at once.
+	//
+	// Optional. Default: MaxPageLimit
+	BatchSize int
+
+	// DryRun reads and counts the records without writing to the destination.
+	DryRun bool
+
+	// SkipSessions does not copy the sessions, e.g. if the source cannot export them.
+	SkipSessions bool
+
+	// Progress is called after every batch with the number of records copied so far.
+	Progress func(MigrateProgress)
+}
+
+// MigrateProgress is the progress of Migrate.
+type MigrateProgress struct {
+	// Users is the number of copied users.
+	Users int `json:"users"`
+	// Accounts is the number of copied accounts with their tokens.
+	Accounts int `json:"accounts"`
+	// Sessions is the number of copied sessions.
+	Sessions int `json:"sessions"`
+	// DryRun is true if nothing has been written.
+	DryRun bool `json:"dry_run"`
+}
+
+// Migrate copies the users with their accounts and tokens, and the not expired sessions from the source
+// to the destination adapter in batches. Users are matched by email in the destination, so that the
+// migration can be resumed. Verification tokens are short-lived and not copied.
+//
+// nolint:gocyclo
+func Migrate(ctx context.Context, src, dst Adapter, opts MigrateOptions) (MigrateProgress, error) {
+	progress := MigrateProgress{DryRun: opts.DryRun}
+
+	report := func() {
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	limit := opts.BatchSize
+	if limit <= 0 {
+		limit = MaxPageLimit
+	}
+
+	var exporter, importer SessionMigrator
+	if !opts.SkipSessions {
+		var ok bool
+		if exporter, ok = sessionMigrator(src); !ok {
+			return progress, ErrUnsupportedSessionMigration
+		}
+
+		if importer, ok = sessionMigrator(dst); !ok && !opts.DryRun {
+			return progress, ErrUnsupportedSessionMigration
+		}
+	}
+
+	// the users are matched by email, their ID in the destination may differ
+	ids := map[uuid.UUID]uuid.UUID{}
+
+	page := Page{Limit: limit}
+	for {
+		users, err := src.ListUsers(ctx, UserFilter{}, page)
+		if err != nil {
+			return progress, err
+		}
+
+		for _, user := range users.Users {
+			id, err := migrateUser(ctx, dst, user, opts.DryRun)
+			if err != nil {
+				return progress, err
+			}
+			ids[user.ID] = id
+
+			progress.Users++
+			progress.Accounts += len(user.Accounts)
+		}
+		report()
+
+		if users.NextCursor == "" {
+			break
+		}
+		page.Cursor = users.NextCursor
+	}
+
+	if opts.SkipSessions {
+		return progress, nil
+	}
+
+	page = Page{Limit: limit}
+	for {
+		sessions, err := exporter.ExportSessions(ctx, page)
+		if err != nil {
+			return progress, err
+		}
+
+		batch := make([]GothSession, 0, len(sessions.Sessions))
+		for _, session := range sessions.Sessions {
+			id, ok := ids[session.UserID]
+			if !ok {
+				continue
+			}
+
+			session.UserID = id
+			session.User = GothUser{}
+			batch = append(batch, session)
+		}
+
+		if !opts.DryRun && len(batch) > 0 {
+			if err := importer.ImportSessions(ctx, batch...); err != nil {
+				return progress, err
+			}
+		}
+
+		progress.Sessions += len(batch)
+		report()
+
+		if sessions.NextCursor == "" {
+			break
+		}
+		page.Cursor = sessions.NextCursor
+	}
+
+	return progress, nil
+}
+
+// migrateUser creates the user in the destination, unless a user with the email exists,
+// and upserts the accounts of the user. It returns the ID of the user in the destination.
+func migrateUser(ctx context.Context, dst Adapter, user GothUser, dryRun bool) (uuid.UUID, error) {
+	if dryRun {
+		return user.ID, nil
+	}
+
+	accounts := user.Accounts
+	user.Accounts = nil
+
+	created, err := dst.CreateUser(ctx, user)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	for _, account := range accounts {
+		account.UserID = &created.ID
+
+		if _, err := dst.UpsertAccount(ctx, account); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	return created.ID, nil
+}
+
+// sessionMigrator returns the session store of the adapter, if it implements SessionMigrator.
+func sessionMigrator(adapter any) (SessionMigrator, bool) {
+	for {
+		u, ok := adapter.(interface{ Unwrap() Adapter })
+		if !ok {
+			break
+		}
+
+		adapter = u.Unwrap()
+	}
+
+	if c, ok := adapter.(*Composite); ok {
+		adapter = c.SessionStore
+	}
+
+	m, ok := adapter.(SessionMigrator)
+
+	return m, ok
+}
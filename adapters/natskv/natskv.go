@@ -0,0 +1,386 @@
+package natskv_adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var (
+	_ adapters.SessionStore    = (*natsAdapter)(nil)
+	_ adapters.SessionMigrator = (*natsAdapter)(nil)
+)
+
+// DefaultPrefix is the default prefix of the session keys.
+const DefaultPrefix = "session."
+
+type natsAdapter struct {
+	kv     jetstream.KeyValue
+	prefix string
+}
+
+// Opt is a function that configures the adapter.
+type Opt func(*natsAdapter)
+
+// WithPrefix sets the prefix of the session keys.
+func WithPrefix(prefix string) Opt {
+	return func(a *natsAdapter) {
+		a.prefix = prefix
+	}
+}
+
+// New is a helper function to create a new session store in a NATS key-value bucket.
+// It only implements adapters.SessionStore and is intended to be composed with another
+// adapter via adapters.WithSessionStore.
+//
+// Sessions are created with a per-key TTL, which requires a bucket with `LimitMarkerTTL`
+// set. Refreshed sessions are bounded by the TTL of the bucket, which should be at least
+// the session expiry. Expired sessions are never returned.
+func New(kv jetstream.KeyValue, opts ...Opt) *natsAdapter {
+	a := &natsAdapter{kv: kv, prefix: DefaultPrefix}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Ping checks the connectivity to the key-value bucket.
+func (a *natsAdapter) Ping(ctx context.Context) error {
+	_, err := a.kv.Status(ctx)
+
+	return err
+}
+
+// CreateSession is a helper function to create a new session.
+func (a *natsAdapter) CreateSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	now := time.Now()
+
+	session.ID = uuid.New()
+	session.Version = 1
+	session.CreatedAt = now
+	session.UpdatedAt = now
+
+	if session.CsrfToken.Token == "" {
+		session.CsrfToken = adapters.GothCsrfToken{
+			ID:        uuid.New(),
+			Token:     uuid.NewString(),
+			ExpiresAt: now.Add(24 * time.Hour),
+		}
+	}
+	session.CsrfTokenID = session.CsrfToken.ID
+
+	b, err := encode(session)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	_, err = a.kv.Create(ctx, a.key(session.SessionToken), b, jetstream.KeyTTL(time.Until(session.ExpiresAt)))
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	return session, nil
+}
+
+// GetSession is a helper function to retrieve a session by session token.
+func (a *natsAdapter) GetSession(ctx context.Context, sessionToken string) (adapters.GothSession, error) {
+	session, _, err := a.get(ctx, sessionToken)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrMissingSession
+	}
+
+	return session, nil
+}
+
+// UpdateSession is a helper function to update a session.
+// It returns goth.ErrSessionConflict if the session has been modified concurrently.
+func (a *natsAdapter) UpdateSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	return a.swap(ctx, session)
+}
+
+// RefreshSession is a helper function to refresh a session.
+// It returns goth.ErrSessionConflict if the session has been modified concurrently.
+func (a *natsAdapter) RefreshSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	return a.swap(ctx, session)
+}
+
+// TouchSessions is a helper function to extend the expiry and set the last activity of sessions.
+// Sessions modified concurrently are skipped.
+func (a *natsAdapter) TouchSessions(ctx context.Context, touches []adapters.SessionTouch) error {
+	for _, t := range touches {
+		session, _, err := a.get(ctx, t.SessionToken)
+		if err != nil {
+			continue
+		}
+
+		if t.ExpiresAt.After(session.ExpiresAt) {
+			session.ExpiresAt = t.ExpiresAt
+		}
+		session.LastSeenAt = &t.LastSeenAt
+
+		_, err = a.swap(ctx, session)
+		if err != nil && !errors.Is(err, goth.ErrSessionConflict) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteSession is a helper function to delete a session by session token.
+func (a *natsAdapter) DeleteSession(ctx context.Context, sessionToken string) error {
+	err := a.kv.Purge(ctx, a.key(sessionToken))
+	if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return goth.ErrBadRequest
+	}
+
+	return nil
+}
+
+// DeleteSessionsWhere is a helper function to delete all sessions matching the filter.
+// It scans all keys of the bucket.
+func (a *natsAdapter) DeleteSessionsWhere(ctx context.Context, filter adapters.SessionFilter) (int64, error) {
+	if filter.IsEmpty() {
+		return 0, adapters.ErrEmptyFilter
+	}
+
+	var n int64
+
+	err := a.scan(ctx, func(key string, session adapters.GothSession) error {
+		if !adapters.MatchSession(filter, session) {
+			return nil
+		}
+
+		if err := a.kv.Purge(ctx, key); err != nil {
+			return err
+		}
+		n++
+
+		return nil
+	})
+	if err != nil {
+		return n, goth.ErrBadRequest
+	}
+
+	return n, nil
+}
+
+// CountActiveSessions is a helper function to count the not expired sessions matching the filter.
+// It scans all keys of the bucket.
+func (a *natsAdapter) CountActiveSessions(ctx context.Context, filter adapters.SessionFilter) (int64, error) {
+	var n int64
+
+	err := a.scan(ctx, func(_ string, session adapters.GothSession) error {
+		if session.IsValid() && adapters.MatchSession(filter, session) {
+			n++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, goth.ErrBadRequest
+	}
+
+	return n, nil
+}
+
+// ListSessions is a helper function to list a page of the not expired sessions matching the filter.
+// It scans all keys of the bucket.
+func (a *natsAdapter) ListSessions(ctx context.Context, filter adapters.SessionFilter, page adapters.Page) (adapters.SessionPage, error) {
+	var cursor uuid.UUID
+	if page.Cursor != "" {
+		var err error
+		if cursor, err = uuid.Parse(page.Cursor); err != nil {
+			return adapters.SessionPage{}, goth.ErrBadRequest
+		}
+	}
+
+	sessions := []adapters.GothSession{}
+
+	err := a.scan(ctx, func(_ string, session adapters.GothSession) error {
+		if session.IsValid() && adapters.MatchSession(filter, session) && bytes.Compare(session.ID[:], cursor[:]) > 0 {
+			session.SessionToken = ""
+			sessions = append(sessions, session)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return adapters.SessionPage{}, goth.ErrBadRequest
+	}
+
+	slices.SortFunc(sessions, func(a, b adapters.GothSession) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	limit := page.GetLimit()
+
+	p := adapters.SessionPage{Sessions: sessions}
+	if len(sessions) > limit {
+		p.Sessions = sessions[:limit]
+		p.NextCursor = sessions[limit-1].ID.String()
+	}
+
+	return p, nil
+}
+
+// ExportSessions is a helper function to export a page of the not expired sessions with the hash of their token.
+func (a *natsAdapter) ExportSessions(ctx context.Context, page adapters.Page) (adapters.SessionPage, error) {
+	var cursor uuid.UUID
+	if page.Cursor != "" {
+		var err error
+		if cursor, err = uuid.Parse(page.Cursor); err != nil {
+			return adapters.SessionPage{}, goth.ErrBadRequest
+		}
+	}
+
+	sessions := []adapters.GothSession{}
+
+	// the value holds the hash of the token
+	err := a.scan(ctx, func(_ string, session adapters.GothSession) error {
+		if session.IsValid() && bytes.Compare(session.ID[:], cursor[:]) > 0 {
+			sessions = append(sessions, session)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return adapters.SessionPage{}, goth.ErrBadRequest
+	}
+
+	slices.SortFunc(sessions, func(a, b adapters.GothSession) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	limit := page.GetLimit()
+
+	p := adapters.SessionPage{Sessions: sessions}
+	if len(sessions) > limit {
+		p.Sessions = sessions[:limit]
+		p.NextCursor = sessions[limit-1].ID.String()
+	}
+
+	return p, nil
+}
+
+// ImportSessions is a helper function to import sessions with the hash of their token.
+// Sessions that already exist or are expired are skipped.
+func (a *natsAdapter) ImportSessions(ctx context.Context, sessions ...adapters.GothSession) error {
+	for _, session := range sessions {
+		if !session.IsValid() {
+			continue
+		}
+
+		session.User = adapters.GothUser{}
+
+		b, err := json.Marshal(session)
+		if err != nil {
+			return goth.ErrBadSession
+		}
+
+		_, err = a.kv.Create(ctx, a.prefix+session.SessionToken, b, jetstream.KeyTTL(time.Until(session.ExpiresAt)))
+		if err != nil && !errors.Is(err, jetstream.ErrKeyExists) {
+			return goth.ErrBadSession
+		}
+	}
+
+	return nil
+}
+
+func (a *natsAdapter) scan(ctx context.Context, fn func(key string, session adapters.GothSession) error) error {
+	lister, err := a.kv.ListKeysFiltered(ctx, a.prefix+">")
+	if err != nil {
+		return err
+	}
+
+	for key := range lister.Keys() {
+		entry, err := a.kv.Get(ctx, key)
+		if err != nil {
+			continue
+		}
+
+		var session adapters.GothSession
+		if err := json.Unmarshal(entry.Value(), &session); err != nil {
+			continue
+		}
+
+		if err := fn(key, session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *natsAdapter) swap(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	current, revision, err := a.get(ctx, session.SessionToken)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	if current.Version != session.Version {
+		return adapters.GothSession{}, goth.ErrSessionConflict
+	}
+
+	session.Version++
+	session.UpdatedAt = time.Now()
+
+	b, err := encode(session)
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	_, err = a.kv.Update(ctx, a.key(session.SessionToken), b, revision)
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return adapters.GothSession{}, goth.ErrSessionConflict
+	}
+
+	if err != nil {
+		return adapters.GothSession{}, goth.ErrBadSession
+	}
+
+	return session, nil
+}
+
+func (a *natsAdapter) get(ctx context.Context, sessionToken string) (adapters.GothSession, uint64, error) {
+	entry, err := a.kv.Get(ctx, a.key(sessionToken))
+	if err != nil {
+		return adapters.GothSession{}, 0, err
+	}
+
+	var session adapters.GothSession
+	err = json.Unmarshal(entry.Value(), &session)
+	if err != nil {
+		return adapters.GothSession{}, 0, err
+	}
+
+	if !session.IsValid() {
+		return adapters.GothSession{}, 0, jetstream.ErrKeyNotFound
+	}
+	session.SessionToken = sessionToken
+
+	return session, entry.Revision(), nil
+}
+
+func (a *natsAdapter) key(sessionToken string) string {
+	return a.prefix + adapters.HashToken(sessionToken)
+}
+
+func encode(session adapters.GothSession) ([]byte, error) {
+	// only the hash of the token is persisted
+	session.SessionToken = adapters.HashToken(session.SessionToken)
+	session.User = adapters.GothUser{}
+
+	return json.Marshal(session)
+}
@@ -0,0 +1,24 @@
+package adapters
+
+import "context"
+
+// The contextKey type is unexported to prevent collisions with context keys defined in
+// other packages.
+type contextKey int
+
+const (
+	primaryKey contextKey = iota
+)
+
+// WithPrimary returns a context that asks adapters with read replicas to read from the primary,
+// e.g. for reads that follow a write and must not see a lagging replica.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// IsPrimary returns true if the reads of the context must be served by the primary.
+func IsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey).(bool)
+
+	return primary
+}
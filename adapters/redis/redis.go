@@ -0,0 +1,76 @@
+package redis_adapter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ adapters.FlowStore = (*redisFlowStore)(nil)
+
+// DefaultPrefix is the default prefix of the flow keys.
+const DefaultPrefix = "goth:flow:"
+
+type redisFlowStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// Opt is a function that configures the store.
+type Opt func(*redisFlowStore)
+
+// WithPrefix sets the prefix of the flow keys.
+func WithPrefix(prefix string) Opt {
+	return func(s *redisFlowStore) {
+		s.prefix = prefix
+	}
+}
+
+// New is a helper function to create a new flow store in Redis with the client (e.g. redis.NewClient).
+// Entries expire with the TTL of the key and are consumed with GETDEL, which requires Redis 6.2 or later.
+func New(client redis.UniversalClient, opts ...Opt) *redisFlowStore {
+	s := &redisFlowStore{client: client, prefix: DefaultPrefix}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Ping checks the connectivity to Redis.
+func (s *redisFlowStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Put stores the value for the key with the TTL.
+func (s *redisFlowStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
+
+// GetAndDelete atomically returns and deletes the value of the key.
+func (s *redisFlowStore) GetAndDelete(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.GetDel(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, adapters.ErrFlowNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Close closes the client.
+func (s *redisFlowStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,539 @@
+package adapters
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ Adapter = (*TimeoutAdapter)(nil)
+
+// TimeoutAdapter is an adapter that applies a deadline to every call of the base adapter.
+type TimeoutAdapter struct {
+	base    Adapter
+	timeout time.Duration
+}
+
+// WithTimeout returns an adapter that applies the timeout as a deadline to every call of the base adapter.
+// A non-positive timeout returns the base adapter.
+func WithTimeout(base Adapter, timeout time.Duration) Adapter {
+	if timeout <= 0 {
+		return base
+	}
+
+	return &TimeoutAdapter{base: base, timeout: timeout}
+}
+
+// Unwrap returns the base adapter.
+func (a *TimeoutAdapter) Unwrap() Adapter {
+	return a.base
+}
+
+func (a *TimeoutAdapter) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, a.timeout)
+}
+
+// CreateUser calls CreateUser of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateUser(ctx context.Context, user GothUser) (GothUser, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateUser(ctx, user)
+}
+
+// GetUser calls GetUser of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetUser(ctx context.Context, id uuid.UUID) (GothUser, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetUser(ctx, id)
+}
+
+// GetUserByEmail calls GetUserByEmail of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetUserByEmail(ctx context.Context, email string) (GothUser, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetUserByEmail(ctx, email)
+}
+
+// GetUserByAccount calls GetUserByAccount of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetUserByAccount(ctx context.Context, provider string, providerAccountID string) (GothUser, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetUserByAccount(ctx, provider, providerAccountID)
+}
+
+// UpdateUser calls UpdateUser of the base adapter with a deadline.
+func (a *TimeoutAdapter) UpdateUser(ctx context.Context, user GothUser) (GothUser, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UpdateUser(ctx, user)
+}
+
+// DeleteUser calls DeleteUser of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteUser(ctx, id)
+}
+
+// LinkAccount calls LinkAccount of the base adapter with a deadline.
+func (a *TimeoutAdapter) LinkAccount(ctx context.Context, accountID, userID uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.LinkAccount(ctx, accountID, userID)
+}
+
+// UnlinkAccount calls UnlinkAccount of the base adapter with a deadline.
+func (a *TimeoutAdapter) UnlinkAccount(ctx context.Context, accountID, userID uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UnlinkAccount(ctx, accountID, userID)
+}
+
+// CountUsers calls CountUsers of the base adapter with a deadline.
+func (a *TimeoutAdapter) CountUsers(ctx context.Context, filter UserFilter) (int64, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CountUsers(ctx, filter)
+}
+
+// ListUsers calls ListUsers of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListUsers(ctx context.Context, filter UserFilter, page Page) (UserPage, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListUsers(ctx, filter, page)
+}
+
+// CreateSession calls CreateSession of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateSession(ctx, session)
+}
+
+// GetSession calls GetSession of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetSession(ctx context.Context, sessionToken string) (GothSession, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetSession(ctx, sessionToken)
+}
+
+// UpdateSession calls UpdateSession of the base adapter with a deadline.
+func (a *TimeoutAdapter) UpdateSession(ctx context.Context, session GothSession) (GothSession, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UpdateSession(ctx, session)
+}
+
+// RefreshSession calls RefreshSession of the base adapter with a deadline.
+func (a *TimeoutAdapter) RefreshSession(ctx context.Context, session GothSession) (GothSession, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.RefreshSession(ctx, session)
+}
+
+// TouchSessions calls TouchSessions of the base adapter with a deadline.
+func (a *TimeoutAdapter) TouchSessions(ctx context.Context, touches []SessionTouch) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.TouchSessions(ctx, touches)
+}
+
+// DeleteSession calls DeleteSession of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteSession(ctx context.Context, sessionToken string) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteSession(ctx, sessionToken)
+}
+
+// DeleteSessionsWhere calls DeleteSessionsWhere of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteSessionsWhere(ctx context.Context, filter SessionFilter) (int64, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteSessionsWhere(ctx, filter)
+}
+
+// CountActiveSessions calls CountActiveSessions of the base adapter with a deadline.
+func (a *TimeoutAdapter) CountActiveSessions(ctx context.Context, filter SessionFilter) (int64, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CountActiveSessions(ctx, filter)
+}
+
+// ListSessions calls ListSessions of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListSessions(ctx context.Context, filter SessionFilter, page Page) (SessionPage, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListSessions(ctx, filter, page)
+}
+
+// CreateVerificationToken calls CreateVerificationToken of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateVerificationToken(ctx context.Context, verficationToken GothVerificationToken) (GothVerificationToken, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateVerificationToken(ctx, verficationToken)
+}
+
+// UseVerficationToken calls UseVerficationToken of the base adapter with a deadline.
+func (a *TimeoutAdapter) UseVerficationToken(ctx context.Context, identifier string, token string) (GothVerificationToken, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UseVerficationToken(ctx, identifier, token)
+}
+
+// MergeUsers calls MergeUsers of the base adapter with a deadline.
+func (a *TimeoutAdapter) MergeUsers(ctx context.Context, winnerID, loserID uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.MergeUsers(ctx, winnerID, loserID)
+}
+
+// CreateTeam calls CreateTeam of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateTeam(ctx context.Context, team GothTeam) (GothTeam, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateTeam(ctx, team)
+}
+
+// GetTeam calls GetTeam of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetTeam(ctx context.Context, id uuid.UUID) (GothTeam, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetTeam(ctx, id)
+}
+
+// GetTeamBySlug calls GetTeamBySlug of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetTeamBySlug(ctx context.Context, slug string) (GothTeam, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetTeamBySlug(ctx, slug)
+}
+
+// UpdateTeam calls UpdateTeam of the base adapter with a deadline.
+func (a *TimeoutAdapter) UpdateTeam(ctx context.Context, team GothTeam) (GothTeam, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UpdateTeam(ctx, team)
+}
+
+// DeleteTeam calls DeleteTeam of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteTeam(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteTeam(ctx, id)
+}
+
+// AddTeamMember calls AddTeamMember of the base adapter with a deadline.
+func (a *TimeoutAdapter) AddTeamMember(ctx context.Context, teamID, userID uuid.UUID, role string) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.AddTeamMember(ctx, teamID, userID, role)
+}
+
+// RemoveTeamMember calls RemoveTeamMember of the base adapter with a deadline.
+func (a *TimeoutAdapter) RemoveTeamMember(ctx context.Context, teamID, userID uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.RemoveTeamMember(ctx, teamID, userID)
+}
+
+// ListUserTeams calls ListUserTeams of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListUserTeams(ctx context.Context, userID uuid.UUID) ([]GothTeam, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListUserTeams(ctx, userID)
+}
+
+// GetThrottle calls GetThrottle of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetThrottle(ctx context.Context, identifier string) (GothThrottle, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetThrottle(ctx, identifier)
+}
+
+// IncrementThrottle calls IncrementThrottle of the base adapter with a deadline.
+func (a *TimeoutAdapter) IncrementThrottle(ctx context.Context, identifier string) (GothThrottle, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.IncrementThrottle(ctx, identifier)
+}
+
+// AttemptThrottle calls AttemptThrottle of the base adapter with a deadline.
+func (a *TimeoutAdapter) AttemptThrottle(ctx context.Context, identifier string, delay func(failures int) time.Duration) (GothThrottle, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.AttemptThrottle(ctx, identifier, delay)
+}
+
+// ResetThrottle calls ResetThrottle of the base adapter with a deadline.
+func (a *TimeoutAdapter) ResetThrottle(ctx context.Context, identifier string) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ResetThrottle(ctx, identifier)
+}
+
+// CreateProviderDomain calls CreateProviderDomain of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateProviderDomain(ctx context.Context, domain GothProviderDomain) (GothProviderDomain, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateProviderDomain(ctx, domain)
+}
+
+// GetProviderDomain calls GetProviderDomain of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetProviderDomain(ctx context.Context, domain string) (GothProviderDomain, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetProviderDomain(ctx, domain)
+}
+
+// DeleteProviderDomain calls DeleteProviderDomain of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteProviderDomain(ctx context.Context, domain string) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteProviderDomain(ctx, domain)
+}
+
+// CreateCredential calls CreateCredential of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateCredential(ctx context.Context, credential GothCredential) (GothCredential, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateCredential(ctx, credential)
+}
+
+// ListCredentials calls ListCredentials of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListCredentials(ctx context.Context, userID uuid.UUID) ([]GothCredential, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListCredentials(ctx, userID)
+}
+
+// UpdateCredential calls UpdateCredential of the base adapter with a deadline.
+func (a *TimeoutAdapter) UpdateCredential(ctx context.Context, credential GothCredential) (GothCredential, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UpdateCredential(ctx, credential)
+}
+
+// DeleteCredential calls DeleteCredential of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteCredential(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteCredential(ctx, userID, id)
+}
+
+// CreateTrustedDevice calls CreateTrustedDevice of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateTrustedDevice(ctx context.Context, device GothTrustedDevice) (GothTrustedDevice, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateTrustedDevice(ctx, device)
+}
+
+// GetTrustedDevice calls GetTrustedDevice of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetTrustedDevice(ctx context.Context, id uuid.UUID) (GothTrustedDevice, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetTrustedDevice(ctx, id)
+}
+
+// ListTrustedDevices calls ListTrustedDevices of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListTrustedDevices(ctx context.Context, userID uuid.UUID) ([]GothTrustedDevice, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListTrustedDevices(ctx, userID)
+}
+
+// DeleteTrustedDevice calls DeleteTrustedDevice of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteTrustedDevice(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteTrustedDevice(ctx, userID, id)
+}
+
+// DeleteTrustedDevices calls DeleteTrustedDevices of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteTrustedDevices(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteTrustedDevices(ctx, userID)
+}
+
+// GetAccount calls GetAccount of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetAccount(ctx context.Context, userID uuid.UUID, provider string) (GothAccount, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetAccount(ctx, userID, provider)
+}
+
+// UpdateAccount calls UpdateAccount of the base adapter with a deadline.
+func (a *TimeoutAdapter) UpdateAccount(ctx context.Context, account GothAccount) (GothAccount, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UpdateAccount(ctx, account)
+}
+
+// UpsertAccount calls UpsertAccount of the base adapter with a deadline.
+func (a *TimeoutAdapter) UpsertAccount(ctx context.Context, account GothAccount) (GothAccount, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.UpsertAccount(ctx, account)
+}
+
+// ListExpiringAccounts calls ListExpiringAccounts of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListExpiringAccounts(ctx context.Context, before time.Time, limit int) ([]GothAccount, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListExpiringAccounts(ctx, before, limit)
+}
+
+// CreateInstallation calls CreateInstallation of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateInstallation(ctx context.Context, installation GothInstallation) (GothInstallation, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateInstallation(ctx, installation)
+}
+
+// GetInstallation calls GetInstallation of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetInstallation(ctx context.Context, teamID uuid.UUID, provider string) (GothInstallation, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetInstallation(ctx, teamID, provider)
+}
+
+// DeleteInstallation calls DeleteInstallation of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteInstallation(ctx context.Context, teamID uuid.UUID, provider string) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteInstallation(ctx, teamID, provider)
+}
+
+// CreateInvitation calls CreateInvitation of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateInvitation(ctx context.Context, invitation GothInvitation) (GothInvitation, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateInvitation(ctx, invitation)
+}
+
+// GetInvitationByToken calls GetInvitationByToken of the base adapter with a deadline.
+func (a *TimeoutAdapter) GetInvitationByToken(ctx context.Context, token string) (GothInvitation, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.GetInvitationByToken(ctx, token)
+}
+
+// AcceptInvitation calls AcceptInvitation of the base adapter with a deadline.
+func (a *TimeoutAdapter) AcceptInvitation(ctx context.Context, id, userID uuid.UUID) (GothInvitation, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.AcceptInvitation(ctx, id, userID)
+}
+
+// DeleteInvitation calls DeleteInvitation of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteInvitation(ctx, id)
+}
+
+// ListNotificationPreferences calls ListNotificationPreferences of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]GothNotificationPreference, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListNotificationPreferences(ctx, userID)
+}
+
+// SetNotificationPreference calls SetNotificationPreference of the base adapter with a deadline.
+func (a *TimeoutAdapter) SetNotificationPreference(ctx context.Context, preference GothNotificationPreference) (GothNotificationPreference, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.SetNotificationPreference(ctx, preference)
+}
+
+// CreateNotification calls CreateNotification of the base adapter with a deadline.
+func (a *TimeoutAdapter) CreateNotification(ctx context.Context, notification GothNotification) (GothNotification, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.CreateNotification(ctx, notification)
+}
+
+// ListPendingNotifications calls ListPendingNotifications of the base adapter with a deadline.
+func (a *TimeoutAdapter) ListPendingNotifications(ctx context.Context, limit int) ([]GothNotification, error) {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.ListPendingNotifications(ctx, limit)
+}
+
+// DeleteNotifications calls DeleteNotifications of the base adapter with a deadline.
+func (a *TimeoutAdapter) DeleteNotifications(ctx context.Context, ids ...uuid.UUID) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.DeleteNotifications(ctx, ids...)
+}
+
+// PurgeDeleted calls PurgeDeleted of the base adapter with a deadline.
+func (a *TimeoutAdapter) PurgeDeleted(ctx context.Context, olderThan time.Duration) error {
+	ctx, cancel := a.context(ctx)
+	defer cancel()
+
+	return a.base.PurgeDeleted(ctx, olderThan)
+}
@@ -0,0 +1,15 @@
+package adapters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns the SHA-256 hash of a token as hex string.
+// Adapters should only persist the hash of a session token, so that
+// a leak of the storage cannot be used to hijack sessions.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
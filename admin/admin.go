@@ -0,0 +1,247 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// Config defines the config for the admin handlers.
+// The handlers do not authorize the caller, the routes have to be protected by the application.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Adapter is the adapter used to store the session.
+	Adapter adapters.Adapter
+
+	// ErrorHandler is executed when an error is returned from fiber.Handler.
+	//
+	// Optional. Default: DefaultErrorHandler
+	ErrorHandler fiber.ErrorHandler
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	ErrorHandler: defaultErrorHandler,
+}
+
+// default ErrorHandler that process return error from fiber.Handler
+func defaultErrorHandler(_ *fiber.Ctx, err error) error {
+	return err
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = ConfigDefault.ErrorHandler
+	}
+
+	return cfg
+}
+
+// RevokeSessionsResponse is the response of the revoke sessions handler.
+type RevokeSessionsResponse struct {
+	// Deleted is the number of revoked sessions.
+	Deleted int64 `json:"deleted"`
+}
+
+// NewRevokeSessionsHandler returns a handler that deletes all sessions matching
+// the adapters.SessionFilter in the request body.
+func NewRevokeSessionsHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var filter adapters.SessionFilter
+		if err := c.BodyParser(&filter); err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		if filter.IsEmpty() {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		n, err := cfg.Adapter.DeleteSessionsWhere(c.Context(), filter)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(RevokeSessionsResponse{Deleted: n})
+	}
+}
+
+// RevokeTrustedDevicesRequest is the request of the revoke trusted devices handler.
+type RevokeTrustedDevicesRequest struct {
+	// UserID is the user to revoke the trusted devices for.
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// NewRevokeTrustedDevicesHandler returns a handler that revokes all trusted devices of
+// the user in the request body, so that the second factor is required again on every browser.
+func NewRevokeTrustedDevicesHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var req RevokeTrustedDevicesRequest
+		if err := c.BodyParser(&req); err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		if req.UserID == uuid.Nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		n, err := cfg.Adapter.DeleteTrustedDevices(c.Context(), req.UserID)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(RevokeSessionsResponse{Deleted: n})
+	}
+}
+
+// MergeUsersRequest is the request of the merge users handler.
+type MergeUsersRequest struct {
+	// WinnerID is the user that is kept.
+	WinnerID uuid.UUID `json:"winner_id"`
+	// LoserID is the user that is merged into the winner and deleted.
+	LoserID uuid.UUID `json:"loser_id"`
+}
+
+// NewMergeUsersHandler returns a handler that merges the loser in the request body into the winner,
+// e.g. to clean up duplicate users. The accounts, sessions and team memberships of the loser are moved
+// to the winner and the loser is deleted. It responds with the merged winner.
+func NewMergeUsersHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var req MergeUsersRequest
+		if err := c.BodyParser(&req); err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		if req.WinnerID == uuid.Nil || req.LoserID == uuid.Nil || req.WinnerID == req.LoserID {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		if err := cfg.Adapter.MergeUsers(c.Context(), req.WinnerID, req.LoserID); err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		user, err := cfg.Adapter.GetUser(c.Context(), req.WinnerID)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(user)
+	}
+}
+
+// StatsResponse is the response of the stats handler.
+type StatsResponse struct {
+	// Users is the number of users.
+	Users int64 `json:"users"`
+	// ActiveSessions is the number of not expired sessions.
+	ActiveSessions int64 `json:"active_sessions"`
+}
+
+// NewStatsHandler returns a handler that reports the number of users and active sessions.
+func NewStatsHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		users, err := cfg.Adapter.CountUsers(c.Context(), adapters.UserFilter{})
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		sessions, err := cfg.Adapter.CountActiveSessions(c.Context(), adapters.SessionFilter{})
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(StatsResponse{Users: users, ActiveSessions: sessions})
+	}
+}
+
+// NewListUsersHandler returns a handler that lists a page of users. The adapters.UserFilter
+// and adapters.Page are read from the query (e.g. `?email=example.com&limit=20&cursor=...`).
+// The `created_after` and `created_before` parameters are RFC 3339 timestamps.
+func NewListUsersHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var filter adapters.UserFilter
+		if err := c.QueryParser(&filter); err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		var err error
+
+		filter.CreatedAfter, err = queryTime(c, "created_after")
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		filter.CreatedBefore, err = queryTime(c, "created_before")
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		var page adapters.Page
+		if err := c.QueryParser(&page); err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		users, err := cfg.Adapter.ListUsers(c.Context(), filter, page)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(users)
+	}
+}
+
+func queryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
@@ -0,0 +1,99 @@
+package goth
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+var (
+	_ GothHandler = (*MeHandler)(nil)
+	_ GothHandler = (*SessionInfoHandler)(nil)
+)
+
+// MeHandler is the default handler that returns the current user as JSON, e.g. for single-page apps.
+type MeHandler struct{}
+
+// NewMeHandler returns a new default me handler.
+// The handler must be mounted behind the protect middleware.
+func NewMeHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return MeHandler{}.New(cfg)
+}
+
+// New creates a new handler to return the current user.
+func (MeHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "MeHandler", ErrMissingSession)
+		}
+
+		return c.JSON(user)
+	}
+}
+
+// SessionInfo is the current session without its tokens.
+type SessionInfo struct {
+	// ID is the unique identifier of the session.
+	ID uuid.UUID `json:"id"`
+	// UserID is the user ID of the session.
+	UserID uuid.UUID `json:"user_id"`
+	// Provider is the ID of the provider the session has been created with.
+	Provider string `json:"provider"`
+	// ExpiresAt is the expiry time of the session.
+	ExpiresAt time.Time `json:"expires_at"`
+	// MFAVerified is true if a second factor has been verified for the session.
+	MFAVerified bool `json:"mfa_verified"`
+	// ActiveTeamID is the ID of the team the user currently works in.
+	ActiveTeamID *uuid.UUID `json:"active_team_id,omitempty"`
+	// CreatedAt is the creation time of the session.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SessionInfoHandler is the default handler that returns the current session as SessionInfo.
+type SessionInfoHandler struct{}
+
+// NewSessionInfoHandler returns a new default session info handler.
+// The handler must be mounted behind the protect middleware.
+func NewSessionInfoHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return SessionInfoHandler{}.New(cfg)
+}
+
+// New creates a new handler to return the current session.
+func (SessionInfoHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		session, err := SessionFromContext(c)
+		if err != nil {
+			return cfg.handleError(c, "SessionInfoHandler", ErrMissingSession)
+		}
+
+		return c.JSON(sessionInfo(session))
+	}
+}
+
+// sessionInfo returns the SessionInfo of the session.
+func sessionInfo(session adapters.GothSession) SessionInfo {
+	return SessionInfo{
+		ID:           session.ID,
+		UserID:       session.UserID,
+		Provider:     session.Provider,
+		ExpiresAt:    session.ExpiresAt,
+		MFAVerified:  session.IsMFAVerified(),
+		ActiveTeamID: session.ActiveTeamID,
+		CreatedAt:    session.CreatedAt,
+	}
+}
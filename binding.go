@@ -0,0 +1,184 @@
+package goth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/internal/secure"
+)
+
+// ErrSessionBindingMismatch is thrown if a session is presented by a client that does not match its binding.
+var ErrSessionBindingMismatch = NewError(http.StatusUnauthorized, "session has been presented by a different client")
+
+// BindingMode is the strictness of the session binding.
+type BindingMode int
+
+const (
+	// BindingOff does not check the binding of sessions.
+	BindingOff BindingMode = iota
+	// BindingFlag emits an events.SessionBindingMismatch event and flags the request (see SessionBindingMismatch).
+	BindingFlag
+	// BindingStrict deletes the session, so that the client has to sign in again.
+	BindingStrict
+)
+
+// SessionBinding binds sessions to characteristics of the client that created them, as defense
+// in depth against stolen session cookies. Clients legitimately change their IP address
+// (e.g. mobile networks), so the IP address is compared by prefix only.
+type SessionBinding struct {
+	// Mode is the strictness of the binding.
+	//
+	// Optional. Default: BindingOff
+	Mode BindingMode
+
+	// IP binds the session to the network prefix of the IP address.
+	IP bool
+
+	// IPv4PrefixBits is the length of the compared prefix of IPv4 addresses.
+	//
+	// Optional. Default: 16
+	IPv4PrefixBits int
+
+	// IPv6PrefixBits is the length of the compared prefix of IPv6 addresses.
+	//
+	// Optional. Default: 48
+	IPv6PrefixBits int
+
+	// UserAgent binds the session to the hash of the user agent.
+	UserAgent bool
+
+	// ClientCert binds the session to the thumbprint of the client certificate (mTLS).
+	// Unlike the other characteristics, sessions created without a client certificate never match.
+	ClientCert bool
+
+	// Thumbprint returns the thumbprint of the client certificate of the request.
+	//
+	// Optional. Default: CertThumbprintFromTLS
+	Thumbprint func(c *fiber.Ctx) string
+}
+
+// CertThumbprintFromTLS returns the hex encoded SHA-256 thumbprint of the client certificate
+// of the TLS connection, or an empty string if the client did not present a certificate.
+func CertThumbprintFromTLS(c *fiber.Ctx) string {
+	state := c.Context().TLSConnectionState()
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CertThumbprintFromHeader returns a function that reads the thumbprint of the client certificate
+// from a header set by a TLS terminating proxy (e.g. "X-Client-Cert-Thumbprint").
+// The proxy must overwrite the header of the client.
+func CertThumbprintFromHeader(header string) func(c *fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(c.Get(header)), ":", ""))
+	}
+}
+
+// bindingMismatchKey flags requests whose session does not match the binding.
+const bindingMismatchKey = "goth_binding_mismatch"
+
+// SessionBindingMismatch returns true if the session of the request has been presented
+// by a client that does not match its binding (see BindingFlag).
+func SessionBindingMismatch(c *fiber.Ctx) bool {
+	mismatch, _ := c.Locals(bindingMismatchKey).(bool)
+
+	return mismatch
+}
+
+// checkBinding checks the binding of the session. It returns ErrSessionBindingMismatch
+// if the session has been deleted due to a mismatch.
+func (cfg Config) checkBinding(c *fiber.Ctx, session adapters.GothSession) error {
+	b := cfg.SessionBinding
+
+	if b.Mode == BindingOff || cfg.matchesBinding(c, session) {
+		return nil
+	}
+
+	e := events.New(events.SessionBindingMismatch, session.UserID)
+	e.Provider = session.Provider
+	e.Data = map[string]any{"session_id": session.ID, "ip_address": cfg.Privacy.ClientIP(c), "strict": b.Mode == BindingStrict}
+	cfg.Events.Emit(c.Context(), e)
+
+	if b.Mode == BindingFlag {
+		c.Locals(bindingMismatchKey, true)
+		return nil
+	}
+
+	if err := cfg.Adapter.DeleteSession(c.Context(), session.SessionToken); err != nil {
+		log.Errorw("failed to delete session with binding mismatch", "error", err)
+	}
+
+	return ErrSessionBindingMismatch
+}
+
+// matchesBinding returns true if the client matches the characteristics the session is bound to.
+// Characteristics that have not been recorded for the session are not checked.
+func (cfg Config) matchesBinding(c *fiber.Ctx, session adapters.GothSession) bool {
+	b := cfg.SessionBinding
+
+	if b.UserAgent && session.UserAgentHash != "" && !secure.Equal(session.UserAgentHash, cfg.Privacy.userAgentHash(c)) {
+		return false
+	}
+
+	if b.IP && session.IPAddress != "" && !cfg.sameIP(c, session.IPAddress) {
+		return false
+	}
+
+	if b.ClientCert && !secure.Equal(session.CertThumbprint, b.Thumbprint(c)) {
+		return false
+	}
+
+	return true
+}
+
+// sameIP returns true if the client has the IP address stored on the session. Hashed IP addresses (see Privacy)
+// are compared exactly, other addresses by their network prefix.
+func (cfg Config) sameIP(c *fiber.Ctx, ip string) bool {
+	if len(cfg.Privacy.HashKey) > 0 {
+		return secure.Equal(ip, cfg.Privacy.ClientIP(c))
+	}
+
+	return cfg.SessionBinding.samePrefix(ip, c.IP())
+}
+
+// samePrefix returns true if both IP addresses are in the same network prefix.
+func (b SessionBinding) samePrefix(a, other string) bool {
+	x, err := netip.ParseAddr(a)
+	if err != nil {
+		return false
+	}
+
+	y, err := netip.ParseAddr(other)
+	if err != nil {
+		return false
+	}
+
+	x, y = x.Unmap(), y.Unmap()
+	if x.Is4() != y.Is4() {
+		return false
+	}
+
+	bits := b.IPv6PrefixBits
+	if x.Is4() {
+		bits = b.IPv4PrefixBits
+	}
+
+	px, err := x.Prefix(bits)
+	if err != nil {
+		return false
+	}
+
+	return px.Contains(y)
+}
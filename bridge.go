@@ -0,0 +1,74 @@
+package goth
+
+import (
+	"bytes"
+	"html/template"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bridgeParam marks a callback that has been re-entered via the bridge page.
+const bridgeParam = "goth_bridge"
+
+// bridgeParams are the parameters of the authorization responses that are bridged. The other form
+// parameters (e.g. credentials) are never copied into the URL of the bridge page.
+var bridgeParams = []string{"code", "state", "id_token", "user", "iss", "error", "error_description", "error_uri"}
+
+// bridgeTemplate re-enters the callback with a top-level navigation of the site itself.
+// It does not need JavaScript, so it also works with a strict Content-Security-Policy.
+var bridgeTemplate = template.Must(template.New("bridge").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="referrer" content="no-referrer">
+<meta http-equiv="refresh" content="0;url={{.}}">
+</head>
+<body>
+<a href="{{.}}">Continue</a>
+</body>
+</html>
+`))
+
+// needsBridge returns true if the callback is a cross-site request that has not been bridged yet.
+// Browsers do not send SameSite=Lax cookies with cross-site POSTs and no SameSite=Strict cookies
+// with cross-site navigations. POSTs are only bridged if they carry an authorization response.
+func needsBridge(c *fiber.Ctx) bool {
+	if c.Query(bridgeParam) != "" {
+		return false
+	}
+
+	if c.Method() == fiber.MethodPost {
+		return slices.ContainsFunc(bridgeParams, func(key string) bool {
+			return c.Request().PostArgs().Has(key)
+		})
+	}
+
+	return c.Get("Sec-Fetch-Site") == "cross-site"
+}
+
+// bridgeCallback responds with the bridge page, which navigates to the callback with the
+// query parameters and the authorization response parameters of the form as query parameters.
+func bridgeCallback(c *fiber.Ctx) error {
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+
+	c.Request().URI().QueryArgs().CopyTo(args)
+	for _, key := range bridgeParams {
+		if value := c.Request().PostArgs().Peek(key); value != nil {
+			args.SetBytesV(key, value)
+		}
+	}
+	args.Set(bridgeParam, "1")
+
+	var buf bytes.Buffer
+	if err := bridgeTemplate.Execute(&buf, c.Path()+"?"+args.String()); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderReferrerPolicy, "no-referrer")
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,57 @@
+package goth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+func TestBridgeCallback(t *testing.T) {
+	providers.RegisterProvider(&stateProvider{})
+
+	app := fiber.New()
+	app.Post("/auth/:provider/callback", NewCompleteAuthHandler(Config{
+		Adapter:        &adapters.UnimplementedAdapter{},
+		CallbackBridge: true,
+	}))
+
+	tests := []struct {
+		name    string
+		form    string
+		bridged bool
+	}{
+		{name: "authorization response", form: "code=code&state=state&password=secret", bridged: true},
+		{name: "credentials", form: "email=user%40example.com&password=secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/state/callback", strings.NewReader(tt.form))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bridged := strings.Contains(string(body), bridgeParam); bridged != tt.bridged {
+				t.Fatalf("expected bridged %v, got %q", tt.bridged, body)
+			}
+
+			if strings.Contains(string(body), "secret") {
+				t.Fatalf("bridge page contains the password: %q", body)
+			}
+		})
+	}
+}
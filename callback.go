@@ -0,0 +1,81 @@
+package goth
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+// ProviderError is the error the provider redirected back to the callback with
+// (e.g. `error=access_denied` if the user declined the consent).
+type ProviderError struct {
+	// Code is the error code of the provider (e.g. "access_denied").
+	Code string
+	// Description is the human-readable description of the provider, if any.
+	Description string
+	// URI is the URI of a page with information about the error, if any.
+	URI string
+}
+
+// Error makes it compatible with the `error` interface.
+func (e *ProviderError) Error() string {
+	if e.Description != "" {
+		return e.Code + ": " + e.Description
+	}
+
+	return e.Code
+}
+
+// providerError returns the error of the callback parameters, if the provider sent one.
+func providerError(params providers.AuthParams) *ProviderError {
+	code := params.Get("error")
+	if code == "" {
+		return nil
+	}
+
+	return &ProviderError{
+		Code:        code,
+		Description: params.Get("error_description"),
+		URI:         params.Get("error_uri"),
+	}
+}
+
+// callbackFailed records the provider error as a denied login and redirects to the CallbackErrorURL
+// with the `error`, `error_description` and `provider` parameters, or passes the error to the ErrorHandler.
+func (cfg Config) callbackFailed(c *fiber.Ctx, provider, attempt string, start time.Time, err *ProviderError) error {
+	cfg.emitLogin(c.Context(), events.LoginDenied, provider, attempt, uuid.Nil, map[string]any{
+		LoginReasonKey:   err.Code,
+		LoginDurationKey: time.Since(start).Milliseconds(),
+	})
+
+	ec := ErrorContext{
+		Handler:  "CompleteAuthCompleteHandler",
+		Provider: provider,
+		Category: ErrorCategoryProvider,
+		Err:      err,
+	}
+
+	if cfg.CallbackErrorURL == "" {
+		return cfg.handleErrorContext(c, ec, err)
+	}
+
+	u, perr := url.Parse(cfg.CallbackErrorURL)
+	if perr != nil {
+		return cfg.handleErrorContext(c, ec, err)
+	}
+
+	q := u.Query()
+	q.Set("error", err.Code)
+	q.Set("provider", provider)
+
+	if err.Description != "" {
+		q.Set("error_description", err.Description)
+	}
+	u.RawQuery = q.Encode()
+
+	return SafeRedirect(c, u.String(), cfg.TrustedOrigins...)
+}
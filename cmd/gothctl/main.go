@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	gorm_adapter "github.com/zeiss/fiber-goth/adapters/gorm"
+	"github.com/zeiss/fiber-goth/gothctl"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	var dsn string
+
+	open := func() (*gorm.DB, error) {
+		if dsn == "" {
+			dsn = os.Getenv("GOTH_DATABASE_URL")
+		}
+
+		return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	}
+
+	cmd := gothctl.NewCommand(gothctl.Config{
+		Adapter: func(_ context.Context) (adapters.Adapter, error) {
+			db, err := open()
+			if err != nil {
+				return nil, err
+			}
+
+			return gorm_adapter.New(db), nil
+		},
+		Migrate: func(_ context.Context) error {
+			db, err := open()
+			if err != nil {
+				return err
+			}
+
+			return gorm_adapter.RunMigrations(db)
+		},
+	})
+	cmd.PersistentFlags().StringVar(&dsn, "dsn", "", "connection string of the Postgres database (default $GOTH_DATABASE_URL)")
+	cmd.SilenceErrors = true
+
+	if err := cmd.Execute(); err != nil {
+		log.SetFlags(0)
+		log.Fatal(err)
+	}
+}
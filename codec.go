@@ -0,0 +1,162 @@
+package goth
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrUnknownCookieVersion is returned when a cookie has been encoded with an unknown format version.
+	ErrUnknownCookieVersion = errors.New("goth: unknown cookie format version")
+	// ErrEmptyCookie is returned when a cookie value has no content to decode.
+	ErrEmptyCookie = errors.New("goth: empty cookie value")
+	// ErrCookieTooLarge is returned when a compressed cookie value decompresses to more than maxDecodedCookie bytes.
+	ErrCookieTooLarge = errors.New("goth: cookie value too large")
+)
+
+// maxDecodedCookie is the maximum size of a decompressed cookie value. Cookies are limited to about 4 KB,
+// so legitimate values stay far below it.
+const maxDecodedCookie = 64 << 10
+
+// CookieCodec serializes values that are stored in cookies.
+type CookieCodec interface {
+	// Encode serializes the value to a cookie-safe string.
+	Encode(v any) (string, error)
+	// Decode deserializes the cookie value into v.
+	Decode(data string, v any) error
+}
+
+// CookieFormat is a single version of a cookie serialization format.
+type CookieFormat interface {
+	// Marshal serializes the value.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal deserializes the data into v.
+	Unmarshal(data []byte, v any) error
+}
+
+// The known cookie format versions.
+const (
+	// CookieFormatJSON is plain JSON.
+	CookieFormatJSON byte = 1
+	// CookieFormatGzipJSON is gzip compressed JSON.
+	CookieFormatGzipJSON byte = 2
+)
+
+var _ CookieCodec = (*VersionedCookieCodec)(nil)
+
+// VersionedCookieCodec prefixes every value with a version byte. Values are always
+// encoded with the current version, but all registered versions can be decoded,
+// so the format can evolve without invalidating existing cookies.
+type VersionedCookieCodec struct {
+	current byte
+	formats map[byte]CookieFormat
+}
+
+// NewVersionedCookieCodec creates a new codec encoding with the current version.
+func NewVersionedCookieCodec(current byte, formats map[byte]CookieFormat) *VersionedCookieCodec {
+	return &VersionedCookieCodec{current: current, formats: formats}
+}
+
+// DefaultCookieCodec is the default codec. It encodes gzip compressed JSON and can decode plain JSON.
+var DefaultCookieCodec = NewVersionedCookieCodec(CookieFormatGzipJSON, map[byte]CookieFormat{
+	CookieFormatJSON:     JSONCookieFormat{},
+	CookieFormatGzipJSON: GzipCookieFormat{Format: JSONCookieFormat{}},
+})
+
+// Encode serializes the value with the current version.
+func (v *VersionedCookieCodec) Encode(value any) (string, error) {
+	f, ok := v.formats[v.current]
+	if !ok {
+		return "", ErrUnknownCookieVersion
+	}
+
+	b, err := f.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(append([]byte{v.current}, b...)), nil
+}
+
+// Decode deserializes the value with the version it has been encoded with.
+func (v *VersionedCookieCodec) Decode(data string, value any) error {
+	b, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+
+	if len(b) < 1 {
+		return ErrEmptyCookie
+	}
+
+	f, ok := v.formats[b[0]]
+	if !ok {
+		return ErrUnknownCookieVersion
+	}
+
+	return f.Unmarshal(b[1:], value)
+}
+
+// JSONCookieFormat serializes values as JSON.
+type JSONCookieFormat struct{}
+
+// Marshal serializes the value.
+func (JSONCookieFormat) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal deserializes the data into v.
+func (JSONCookieFormat) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GzipCookieFormat compresses the output of another format.
+type GzipCookieFormat struct {
+	Format CookieFormat
+}
+
+// Marshal serializes and compresses the value.
+func (g GzipCookieFormat) Marshal(v any) ([]byte, error) {
+	b, err := g.Format.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decompresses and deserializes the data into v. It returns ErrCookieTooLarge
+// if the data decompresses to more than maxDecodedCookie bytes.
+func (g GzipCookieFormat) Unmarshal(data []byte, v any) error {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(io.LimitReader(r, maxDecodedCookie+1))
+	if err != nil {
+		return err
+	}
+
+	if len(b) > maxDecodedCookie {
+		return ErrCookieTooLarge
+	}
+
+	return g.Format.Unmarshal(b, v)
+}
@@ -0,0 +1,49 @@
+package goth
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestDefaultCookieCodec(t *testing.T) {
+	type hint struct {
+		Email string `json:"email"`
+	}
+
+	data, err := DefaultCookieCodec.Encode(hint{Email: "user@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v hint
+	if err := DefaultCookieCodec.Decode(data, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Email != "user@example.com" {
+		t.Fatalf("unexpected value %+v", v)
+	}
+}
+
+func TestDefaultCookieCodecTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(bytes.Repeat([]byte(" "), maxDecodedCookie+1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := base64.RawURLEncoding.EncodeToString(append([]byte{CookieFormatGzipJSON}, buf.Bytes()...))
+
+	var v any
+	if err := DefaultCookieCodec.Decode(data, &v); !errors.Is(err, ErrCookieTooLarge) {
+		t.Fatalf("expected ErrCookieTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package goth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/pkg/cast"
+)
+
+var _ GothHandler = (*ResolveAccountConflictHandler)(nil)
+
+// ErrAccountConflict is thrown if the account of a provider is already linked to another user.
+var ErrAccountConflict = NewError(http.StatusConflict, "account is already linked to another user")
+
+// accountConflictExpiry is the duration a conflict can be resolved after the sign-in.
+const accountConflictExpiry = 10 * time.Minute
+
+// AccountConflictError is the error of an account of a provider that is already linked to another user
+// than the user it should be linked to. It unwraps to ErrAccountConflict.
+type AccountConflictError struct {
+	// Provider is the provider of the account.
+	Provider string
+	// ProviderAccountID is the ID of the account in the provider.
+	ProviderAccountID string
+	// UserID is the ID of the user the account should be linked to.
+	UserID uuid.UUID
+	// OwnerID is the ID of the user the account is linked to.
+	OwnerID uuid.UUID
+}
+
+// Error returns the message of the error.
+func (e *AccountConflictError) Error() string {
+	return fmt.Sprintf("account %s of provider %s is already linked to user %s", e.ProviderAccountID, e.Provider, e.OwnerID)
+}
+
+// Unwrap returns ErrAccountConflict.
+func (e *AccountConflictError) Unwrap() error {
+	return ErrAccountConflict
+}
+
+// accountConflictIdentifier returns the identifier of the verification token of a conflict.
+func accountConflictIdentifier(userID, ownerID uuid.UUID) string {
+	return "account-conflict:" + userID.String() + ":" + ownerID.String()
+}
+
+// confirmAccountConflict redirects to the AccountConflictURL with a single-use token to resolve the conflict.
+func (cfg Config) confirmAccountConflict(c *fiber.Ctx, conflict *AccountConflictError) error {
+	token, err := cfg.VerificationTokens.Issue(c.Context(), accountConflictIdentifier(conflict.UserID, conflict.OwnerID), accountConflictExpiry)
+	if err != nil {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+	}
+
+	e := events.New(events.AccountConflict, conflict.UserID)
+	e.Provider = conflict.Provider
+	e.Data = map[string]any{"owner_id": conflict.OwnerID}
+	cfg.Events.Emit(c.Context(), e)
+
+	q := url.Values{
+		"token":    {token},
+		"user":     {conflict.UserID.String()},
+		"owner":    {conflict.OwnerID.String()},
+		"provider": {conflict.Provider},
+	}
+
+	return SafeRedirect(c, cfg.AccountConflictURL+"?"+q.Encode(), cfg.TrustedOrigins...)
+}
+
+// ResolveAccountConflictHandler is the default handler to resolve an account conflict the user
+// has been redirected to the AccountConflictURL for. With the `action` "merge" the user the account
+// is linked to (`owner`) is merged into the matched `user`, any other action aborts.
+// In both cases the user is redirected to the LoginURL to sign in again.
+type ResolveAccountConflictHandler struct{}
+
+// NewResolveAccountConflictHandler returns a new default resolve account conflict handler.
+func NewResolveAccountConflictHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return ResolveAccountConflictHandler{}.New(cfg)
+}
+
+// New creates a new handler to resolve an account conflict.
+func (ResolveAccountConflictHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		params := ParamsFromContext(c)
+
+		userID, err := uuid.Parse(params.Get("user"))
+		if err != nil {
+			return cfg.handleError(c, "ResolveAccountConflictHandler", ErrBadRequest)
+		}
+
+		ownerID, err := uuid.Parse(params.Get("owner"))
+		if err != nil {
+			return cfg.handleError(c, "ResolveAccountConflictHandler", ErrBadRequest)
+		}
+
+		err = cfg.VerificationTokens.Use(c.Context(), accountConflictIdentifier(userID, ownerID), params.Get("token"))
+		if err != nil {
+			return cfg.handleError(c, "ResolveAccountConflictHandler", err)
+		}
+
+		if params.Get("action") == "merge" {
+			if err := cfg.Adapter.MergeUsers(c.Context(), userID, ownerID); err != nil {
+				return cfg.handleError(c, "ResolveAccountConflictHandler", err)
+			}
+
+			e := events.New(events.UsersMerged, userID)
+			e.Provider = params.Get("provider")
+			e.Data = map[string]any{"loser_id": ownerID}
+			cfg.Events.Emit(c.Context(), e)
+		}
+
+		return SafeRedirect(c, cfg.LoginURL, cfg.TrustedOrigins...)
+	}
+}
+
+// checkAccountOwner returns an AccountConflictError if the account is linked to another user than the user.
+func (cfg Config) checkAccountOwner(ctx context.Context, user adapters.GothUser, account adapters.GothAccount) error {
+	id := cast.Value(account.ProviderAccountID)
+	if id == "" {
+		return nil
+	}
+
+	owner, err := cfg.Adapter.GetUserByAccount(ctx, account.Provider, id)
+	if err != nil || owner.ID == user.ID {
+		return nil
+	}
+
+	return &AccountConflictError{
+		Provider:          account.Provider,
+		ProviderAccountID: id,
+		UserID:            user.ID,
+		OwnerID:           owner.ID,
+	}
+}
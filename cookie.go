@@ -0,0 +1,64 @@
+package goth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// HostCookiePrefix is the prefix of cookies that are bound to the host. Browsers only accept them
+	// with the Secure attribute, the path "/" and without a domain.
+	HostCookiePrefix = "__Host-"
+	// SecureCookiePrefix is the prefix of cookies that browsers only accept with the Secure attribute.
+	SecureCookiePrefix = "__Secure-"
+)
+
+// ErrInvalidCookie is returned by CheckCookie when the attributes of the cookie do not meet the constraints of its name.
+var ErrInvalidCookie = errors.New("goth: invalid cookie")
+
+// EnvCookieName returns the name of the cookie in the environment (e.g. "staging"), so that environments
+// sharing a domain do not overwrite their sessions. The prefix of the name is kept, e.g. "__Host-session"
+// becomes "__Host-session_staging". An empty environment returns the name as is.
+func EnvCookieName(name, env string) string {
+	env = strings.ToLower(strings.TrimSpace(env))
+	if env == "" {
+		return name
+	}
+
+	return name + "_" + env
+}
+
+// CheckCookie validates the cookie attributes of the config against the prefix of the CookieName.
+// The derived cookies (e.g. for invitations) share the name and attributes of the session cookie.
+func CheckCookie(cfg Config) error {
+	name := cfg.CookieName
+	if name == "" {
+		name = ConfigDefault.CookieName
+	}
+
+	if strings.ContainsAny(name, " \t\r\n;,=\"") {
+		return fmt.Errorf("%w: %q is not a valid cookie name", ErrInvalidCookie, name)
+	}
+
+	switch {
+	case strings.HasPrefix(name, HostCookiePrefix):
+		if !cfg.CookieSecure {
+			return fmt.Errorf("%w: %s requires CookieSecure", ErrInvalidCookie, name)
+		}
+
+		if cfg.CookieDomain != "" {
+			return fmt.Errorf("%w: %s must not have a CookieDomain", ErrInvalidCookie, name)
+		}
+
+		if cfg.CookiePath != "" && cfg.CookiePath != "/" {
+			return fmt.Errorf("%w: %s requires the CookiePath \"/\"", ErrInvalidCookie, name)
+		}
+	case strings.HasPrefix(name, SecureCookiePrefix):
+		if !cfg.CookieSecure {
+			return fmt.Errorf("%w: %s requires CookieSecure", ErrInvalidCookie, name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package goth
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CORSConfig is the configuration of the CORS middleware for the JSON auth endpoints
+// (NewMeHandler, NewSessionInfoHandler, csrf.NewTokenHandler and the JSON mode of the begin auth handler).
+// Requests are always credentialed, so that the session cookie is sent by single-page apps on another origin.
+type CORSConfig struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// AllowOrigins are the origins allowed to call the endpoints (e.g. "https://app.example.com").
+	// Origins are matched exactly, "*" is not supported for credentialed requests.
+	AllowOrigins []string
+
+	// AllowMethods are the methods allowed in preflight requests.
+	//
+	// Optional. Default: GET, POST, DELETE
+	AllowMethods []string
+
+	// AllowHeaders are the request headers allowed in preflight requests.
+	//
+	// Optional. Default: Content-Type, X-Csrf-Token
+	AllowHeaders []string
+
+	// ExposeHeaders are the response headers the app can read, e.g. the TokenHeader of the config.
+	//
+	// Optional. Default: ""
+	ExposeHeaders []string
+
+	// MaxAge is the duration the result of a preflight request can be cached.
+	//
+	// Optional. Default: 10m
+	MaxAge time.Duration
+}
+
+// CORSConfigDefault is the default CORS config.
+var CORSConfigDefault = CORSConfig{
+	AllowMethods: []string{fiber.MethodGet, fiber.MethodPost, fiber.MethodDelete},
+	AllowHeaders: []string{fiber.HeaderContentType, "X-Csrf-Token"},
+	MaxAge:       10 * time.Minute,
+}
+
+// Helper function to set default values
+func corsConfigDefault(config ...CORSConfig) CORSConfig {
+	if len(config) < 1 {
+		return CORSConfigDefault
+	}
+
+	cfg := config[0]
+
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = CORSConfigDefault.AllowMethods
+	}
+
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = CORSConfigDefault.AllowHeaders
+	}
+
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = CORSConfigDefault.MaxAge
+	}
+
+	return cfg
+}
+
+// NewCORSMiddleware returns a middleware that allows credentialed cross-origin requests from the AllowOrigins.
+// Requests from other origins pass without CORS headers, so that browsers block the responses.
+// Preflight requests are answered with 204 No Content.
+func NewCORSMiddleware(config ...CORSConfig) fiber.Handler {
+	cfg := corsConfigDefault(config...)
+
+	methods := strings.Join(cfg.AllowMethods, ", ")
+	headers := strings.Join(cfg.AllowHeaders, ", ")
+	expose := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderOrigin)
+
+		origin := c.Get(fiber.HeaderOrigin)
+		if origin == "" || !allowOrigin(cfg.AllowOrigins, origin) {
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+		c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
+
+		if c.Method() != fiber.MethodOptions || c.Get(fiber.HeaderAccessControlRequestMethod) == "" {
+			if expose != "" {
+				c.Set(fiber.HeaderAccessControlExposeHeaders, expose)
+			}
+
+			return c.Next()
+		}
+
+		c.Vary(fiber.HeaderAccessControlRequestMethod, fiber.HeaderAccessControlRequestHeaders)
+		c.Set(fiber.HeaderAccessControlAllowMethods, methods)
+		c.Set(fiber.HeaderAccessControlAllowHeaders, headers)
+		c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// allowOrigin returns true if the origin is one of the allowed origins.
+func allowOrigin(allowed []string, origin string) bool {
+	for _, o := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package csrf
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,7 +19,7 @@ var (
 	// ErrTokenNotFound is returned when the token is not found in the session.
 	ErrTokenNotFound = fiber.NewError(fiber.StatusForbidden, "csrf token not found in session")
 	// ErrMissingSession is returned when the session is missing from the context.
-	ErrMissingSession = fiber.NewError(fiber.StatusForbidden, "missing session in context")
+	ErrMissingSession = fiber.NewError(fiber.StatusUnauthorized, "missing session in context")
 	// ErrGenerateToken is returned when the token generator returns an error.
 	ErrGenerateToken = fiber.NewError(fiber.StatusForbidden, "failed to generate csrf token")
 	// ErrMissingToken is returned when the token is missing from the request.
@@ -54,6 +55,16 @@ type Config struct {
 	// Optional. Default: DefaultErrorHandler
 	ErrorHandler fiber.ErrorHandler
 
+	// UnauthenticatedStatus is the status of the errors of requests without a session.
+	//
+	// Optional. Default: 401
+	UnauthenticatedStatus int
+
+	// ForbiddenStatus is the status of the errors of requests with a missing or invalid token.
+	//
+	// Optional. Default: 403
+	ForbiddenStatus int
+
 	// Extractor is the function used to extract the token from the request.
 	Extractor func(c *fiber.Ctx) (string, error)
 
@@ -69,11 +80,13 @@ type Config struct {
 
 // ConfigDefault is the default config.
 var ConfigDefault = Config{
-	IdleTimeout:    30 * time.Minute,
-	ErrorHandler:   defaultErrorHandler,
-	Extractor:      FromHeader(HeaderName),
-	TokenGenerator: DefaultCsrfTokenGenerator,
-	IgnoredMethods: []string{fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace},
+	IdleTimeout:           30 * time.Minute,
+	ErrorHandler:          defaultErrorHandler,
+	UnauthenticatedStatus: fiber.StatusUnauthorized,
+	ForbiddenStatus:       fiber.StatusForbidden,
+	Extractor:             FromHeader(HeaderName),
+	TokenGenerator:        DefaultCsrfTokenGenerator,
+	IgnoredMethods:        []string{fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace},
 }
 
 // CsrfTokenGenerator is a function that generates a CSRF token.
@@ -90,10 +103,31 @@ func DefaultCsrfTokenGenerator() (string, error) {
 }
 
 // default ErrorHandler that process return error from fiber.Handler
-func defaultErrorHandler(_ *fiber.Ctx, _ error) error {
+func defaultErrorHandler(_ *fiber.Ctx, err error) error {
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		return e
+	}
+
 	return fiber.ErrForbidden
 }
 
+// handleError passes the error to the ErrorHandler with the UnauthenticatedStatus
+// or ForbiddenStatus of the config.
+func (cfg Config) handleError(c *fiber.Ctx, err error) error {
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		switch {
+		case e.Code == fiber.StatusUnauthorized && cfg.UnauthenticatedStatus != e.Code:
+			err = fiber.NewError(cfg.UnauthenticatedStatus, e.Message)
+		case e.Code == fiber.StatusForbidden && cfg.ForbiddenStatus != e.Code:
+			err = fiber.NewError(cfg.ForbiddenStatus, e.Message)
+		}
+	}
+
+	return cfg.ErrorHandler(c, err)
+}
+
 // Helper function to set default values
 // nolint:gocyclo
 func configDefault(config ...Config) Config {
@@ -112,6 +146,14 @@ func configDefault(config ...Config) Config {
 		cfg.ErrorHandler = ConfigDefault.ErrorHandler
 	}
 
+	if cfg.UnauthenticatedStatus == 0 {
+		cfg.UnauthenticatedStatus = ConfigDefault.UnauthenticatedStatus
+	}
+
+	if cfg.ForbiddenStatus == 0 {
+		cfg.ForbiddenStatus = ConfigDefault.ForbiddenStatus
+	}
+
 	if cfg.Extractor == nil {
 		cfg.Extractor = ConfigDefault.Extractor
 	}
@@ -143,7 +185,7 @@ func New(config ...Config) fiber.Handler {
 		// extract the session
 		session, err := goth.SessionFromContext(c)
 		if err != nil {
-			return cfg.ErrorHandler(c, ErrMissingSession)
+			return cfg.handleError(c, ErrMissingSession)
 		}
 
 		// Skip middleware if the method is ignored
@@ -154,25 +196,25 @@ func New(config ...Config) fiber.Handler {
 		// extract the token
 		token, err := cfg.Extractor(c)
 		if err != nil {
-			return cfg.ErrorHandler(c, ErrTokenNotFound)
+			return cfg.handleError(c, ErrTokenNotFound)
 		}
 
 		// if the token is empty, abort
 		if utilx.Empty(token) {
-			return cfg.ErrorHandler(c, ErrTokenNotFound)
+			return cfg.handleError(c, ErrTokenNotFound)
 		}
 
 		if session.GetCsrfToken().HasExpired() {
-			return cfg.ErrorHandler(c, ErrTokenNotFound)
+			return cfg.handleError(c, ErrTokenNotFound)
 		}
 
 		if !session.GetCsrfToken().IsValid(token) {
-			return cfg.ErrorHandler(c, ErrTokenNotFound)
+			return cfg.handleError(c, ErrTokenNotFound)
 		}
 
 		t, err := cfg.TokenGenerator()
 		if err != nil {
-			return cfg.ErrorHandler(c, ErrGenerateToken)
+			return cfg.handleError(c, ErrGenerateToken)
 		}
 
 		session.CsrfToken = adapters.GothCsrfToken{
@@ -182,7 +224,7 @@ func New(config ...Config) fiber.Handler {
 
 		session, err = cfg.Adapter.UpdateSession(c.Context(), session)
 		if err != nil {
-			return cfg.ErrorHandler(c, err)
+			return cfg.handleError(c, err)
 		}
 
 		// Set the session in the context
@@ -254,3 +296,28 @@ func FromQuery(param string) func(c *fiber.Ctx) (string, error) {
 		return token, nil
 	}
 }
+
+// NewTokenHandler returns a handler that responds with the CSRF token of the current session
+// and the default header to send it in (`{"token": "...", "header": "X-Csrf-Token"}`), e.g. for single-page apps.
+// The handler must be mounted behind the protect middleware.
+func NewTokenHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		session, err := goth.SessionFromContext(c)
+		if err != nil {
+			return cfg.handleError(c, ErrMissingSession)
+		}
+
+		token := session.GetCsrfToken()
+		if token.HasExpired() {
+			return cfg.handleError(c, ErrTokenNotFound)
+		}
+
+		return c.JSON(fiber.Map{"token": token.Token, "header": HeaderName})
+	}
+}
@@ -0,0 +1,70 @@
+package goth
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+var _ GothHandler = (*DiscoveryHandler)(nil)
+
+var (
+	// ErrMissingEmail is thrown if the email is missing or malformed.
+	ErrMissingEmail = NewError(http.StatusBadRequest, "missing or invalid email")
+	// ErrUnknownDomain is thrown if no provider is known for the email domain.
+	ErrUnknownDomain = NewError(http.StatusNotFound, "no provider for email domain")
+)
+
+// DiscoveryHandler is the default handler for the "enter your work email" SSO discovery.
+// It accepts an `email` parameter, looks up the provider of its domain and redirects
+// to the begin authentication route of the provider.
+type DiscoveryHandler struct{}
+
+// NewDiscoveryHandler returns a new default discovery handler.
+func NewDiscoveryHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return cfg.DiscoveryHandler.New(cfg)
+}
+
+// New creates a new handler to discover the provider.
+func (DiscoveryHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		email := strings.TrimSpace(ParamsFromContext(c).Get("email"))
+
+		domain, ok := EmailDomain(email)
+		if !ok {
+			return cfg.handleError(c, "DiscoveryHandler", ErrMissingEmail)
+		}
+
+		d, err := cfg.Adapter.GetProviderDomain(c.Context(), domain)
+		if err != nil {
+			return cfg.handleError(c, "DiscoveryHandler", ErrUnknownDomain)
+		}
+
+		if _, err := providers.GetProvider(d.Provider); err != nil {
+			return cfg.handleError(c, "DiscoveryHandler", ErrUnknownDomain)
+		}
+
+		target := strings.TrimSuffix(cfg.LoginURL, "/") + "/" + url.PathEscape(d.Provider) + "?login_hint=" + url.QueryEscape(email)
+
+		return SafeRedirect(c, target, cfg.TrustedOrigins...)
+	}
+}
+
+// EmailDomain returns the lower-cased domain of an email address.
+func EmailDomain(email string) (string, bool) {
+	i := strings.LastIndex(email, "@")
+	if i < 1 || i == len(email)-1 {
+		return "", false
+	}
+
+	return strings.ToLower(email[i+1:]), true
+}
@@ -0,0 +1,104 @@
+package goth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/oauth2"
+)
+
+// ErrorCategory is the category of an error passed to the ErrorHandler.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryInternal is an unexpected error, e.g. of the adapter.
+	ErrorCategoryInternal ErrorCategory = "internal"
+	// ErrorCategoryRequest is a malformed or incomplete request.
+	ErrorCategoryRequest ErrorCategory = "request"
+	// ErrorCategorySession is a missing, invalid or concurrently modified session.
+	ErrorCategorySession ErrorCategory = "session"
+	// ErrorCategoryState is an invalid, expired or replayed state of a login.
+	ErrorCategoryState ErrorCategory = "state"
+	// ErrorCategoryProvider is an error of the provider, e.g. a declined consent or a failed token exchange.
+	ErrorCategoryProvider ErrorCategory = "provider"
+	// ErrorCategoryDenied is a login or request that has been denied, e.g. by a policy or the seat limit.
+	ErrorCategoryDenied ErrorCategory = "denied"
+	// ErrorCategoryConfig is a feature that has not been configured, e.g. a missing sender.
+	ErrorCategoryConfig ErrorCategory = "config"
+)
+
+// ErrorContext is the context of an error passed to the ErrorHandler.
+type ErrorContext struct {
+	// Handler is the name of the handler that failed (e.g. "CompleteAuthCompleteHandler").
+	Handler string
+	// Provider is the provider of the request, if any.
+	Provider string
+	// Category is the category of the error.
+	Category ErrorCategory
+	// Err is the original error, which may be more specific than the error passed to the ErrorHandler.
+	Err error
+}
+
+// ErrorContextFromContext returns the ErrorContext of the error passed to the ErrorHandler.
+func ErrorContextFromContext(c *fiber.Ctx) (ErrorContext, bool) {
+	ec, ok := c.Locals(errorContextKey).(ErrorContext)
+
+	return ec, ok
+}
+
+// ErrorCategoryOf returns the category of the error.
+func ErrorCategoryOf(err error) ErrorCategory {
+	var pe *ProviderError
+	var re *oauth2.RetrieveError
+	var e *Error
+
+	switch {
+	case errors.As(err, &pe), errors.As(err, &re):
+		return ErrorCategoryProvider
+	case errors.Is(err, ErrInvalidState):
+		return ErrorCategoryState
+	case isAny(err, ErrUnauthenticated, ErrMissingSession, ErrBadSession, ErrMissingCookie, ErrSessionBindingMismatch, ErrSessionConflict):
+		return ErrorCategorySession
+	case isAny(err, ErrMissingInvitationSender, ErrMissingVerificationSender, ErrScopeUpgradeUnsupported):
+		return ErrorCategoryConfig
+	case errors.As(err, &e) && (e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden):
+		return ErrorCategoryDenied
+	case errors.As(err, &e) && e.Code < http.StatusInternalServerError:
+		return ErrorCategoryRequest
+	default:
+		return ErrorCategoryInternal
+	}
+}
+
+// isAny reports whether the error matches any of the targets.
+func isAny(err error, targets ...error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleError passes the error of the handler to the ErrorHandler.
+func (cfg Config) handleError(c *fiber.Ctx, handler string, err error) error {
+	return cfg.handleErrorContext(c, ErrorContext{Handler: handler, Err: err}, err)
+}
+
+// handleErrorContext passes the error to the ErrorHandler with the ErrorContext in the Locals.
+// The provider and the category are derived from the request and the cause, if not set.
+func (cfg Config) handleErrorContext(c *fiber.Ctx, ec ErrorContext, err error) error {
+	if ec.Provider == "" {
+		ec.Provider = c.Params(provider)
+	}
+
+	if ec.Category == "" {
+		ec.Category = ErrorCategoryOf(ec.Err)
+	}
+
+	c.Locals(errorContextKey, ec)
+
+	return cfg.ErrorHandler(c, cfg.withStatus(err))
+}
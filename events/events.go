@@ -0,0 +1,161 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+
+	"github.com/google/uuid"
+)
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// UserCreated is emitted when a provider signed up a new user.
+	UserCreated Type = "user.created"
+	// UsersMerged is emitted when a user has been merged into another user.
+	UsersMerged Type = "users.merged"
+	// UserUpdated is emitted when a user updated the profile.
+	UserUpdated Type = "user.updated"
+	// UserEmailChangeRequested is emitted when a user requested to change the email.
+	UserEmailChangeRequested Type = "user.email_change_requested"
+	// UserEmailChanged is emitted when a user confirmed the new email.
+	UserEmailChanged Type = "user.email_changed"
+	// UserPasswordChanged is emitted when a user changed the password.
+	UserPasswordChanged Type = "user.password_changed"
+	// SessionCreated is emitted when a session has been created.
+	SessionCreated Type = "session.created"
+	// SessionUpdated is emitted when a session has been updated.
+	SessionUpdated Type = "session.updated"
+	// SessionRefreshed is emitted when a session has been refreshed.
+	SessionRefreshed Type = "session.refreshed"
+	// SessionDeleted is emitted when a session has been deleted.
+	SessionDeleted Type = "session.deleted"
+	// SessionsDeleted is emitted when sessions have been deleted by a filter.
+	SessionsDeleted Type = "sessions.deleted"
+	// SessionBindingMismatch is emitted when a session has been presented by a client that does not match its binding.
+	SessionBindingMismatch Type = "session.binding_mismatch"
+	// SessionTeamSwitched is emitted when the active team of a session has been switched.
+	SessionTeamSwitched Type = "session.team_switched"
+	// SessionMFAVerified is emitted when a second factor has been verified for a session.
+	SessionMFAVerified Type = "session.mfa_verified"
+	// CredentialRegistered is emitted when a user registered a second factor credential.
+	CredentialRegistered Type = "credential.registered"
+	// CredentialDeleted is emitted when a user deleted a second factor credential.
+	CredentialDeleted Type = "credential.deleted"
+	// TrustedDevicesRevoked is emitted when trusted devices of a user have been revoked.
+	TrustedDevicesRevoked Type = "trusted_devices.revoked"
+	// AccountLinked is emitted when the account of a provider has been linked to an existing user.
+	AccountLinked Type = "account.linked"
+	// AccountConflict is emitted when the account of a provider could not be linked because it is linked to another user.
+	AccountConflict Type = "account.conflict"
+	// AccountScopesUpgraded is emitted when additional scopes have been granted for a linked account.
+	AccountScopesUpgraded Type = "account.scopes_upgraded"
+	// AccountTokenRevoked is emitted when the provider token of an account can no longer be refreshed,
+	// e.g. because the user revoked the consent. The user has to re-authorize the provider.
+	AccountTokenRevoked Type = "account.token_revoked"
+	// LoginStarted is emitted when a user started to login with a provider.
+	LoginStarted Type = "login.started"
+	// LoginRedirected is emitted when a user has been redirected to the provider.
+	LoginRedirected Type = "login.redirected"
+	// LoginCallbackReceived is emitted when the provider redirected the user back to the callback.
+	LoginCallbackReceived Type = "login.callback_received"
+	// LoginExchangeFailed is emitted when the provider failed to complete the authentication.
+	LoginExchangeFailed Type = "login.exchange_failed"
+	// LoginDenied is emitted when the user could not be provisioned or a sign-in policy denied the login.
+	LoginDenied Type = "login.denied"
+	// LoginSessionIssued is emitted when a session has been issued at the end of a login.
+	LoginSessionIssued Type = "login.session_issued"
+	// InvitationCreated is emitted when a user invited an email.
+	InvitationCreated Type = "invitation.created"
+	// InvitationAccepted is emitted when an invited user signed in and accepted the invitation.
+	InvitationAccepted Type = "invitation.accepted"
+	// TeamMemberAdded is emitted when a user has been added to a team by the group sync.
+	TeamMemberAdded Type = "team.member_added"
+	// TeamMemberRemoved is emitted when a user has been removed from a team by the group sync.
+	TeamMemberRemoved Type = "team.member_removed"
+)
+
+// Event is an audit event.
+type Event struct {
+	// Type is the type of the event.
+	Type Type `json:"type"`
+	// Time is the time the event occurred.
+	Time time.Time `json:"time"`
+	// UserID is the ID of the user the event relates to.
+	UserID uuid.UUID `json:"user_id,omitempty"`
+	// Provider is the ID of the provider the event relates to.
+	Provider string `json:"provider,omitempty"`
+	// Data is additional data of the event.
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// New creates a new event of the type for the user.
+func New(t Type, userID uuid.UUID) Event {
+	return Event{
+		Type:   t,
+		Time:   time.Now(),
+		UserID: userID,
+	}
+}
+
+// Emitter emits events.
+type Emitter interface {
+	// Emit emits the event. Implementations must not block the caller.
+	Emit(ctx context.Context, event Event)
+}
+
+// EmitterFunc is an adapter to use ordinary functions as emitter.
+type EmitterFunc func(ctx context.Context, event Event)
+
+// Emit calls f(ctx, event).
+func (f EmitterFunc) Emit(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// Noop is an emitter that drops all events.
+var Noop Emitter = EmitterFunc(func(context.Context, Event) {})
+
+// Multi emits events to all emitters.
+func Multi(emitters ...Emitter) Emitter {
+	return EmitterFunc(func(ctx context.Context, event Event) {
+		for _, e := range emitters {
+			e.Emit(ctx, event)
+		}
+	})
+}
+
+// Channel emits events to the channel. Events are dropped if the channel is full.
+func Channel(ch chan<- Event) Emitter {
+	return EmitterFunc(func(_ context.Context, event Event) {
+		select {
+		case ch <- event:
+		default:
+		}
+	})
+}
+
+// Publisher publishes messages to a topic of a message bus (e.g. NATS or Kafka).
+type Publisher interface {
+	// Publish publishes the value with the key to the topic.
+	Publish(ctx context.Context, topic string, key, value []byte) error
+}
+
+// Publish emits events as JSON to the topic of the publisher. The key of the
+// message is the type of the event. Errors of the publisher are logged.
+func Publish(p Publisher, topic string) Emitter {
+	return EmitterFunc(func(ctx context.Context, event Event) {
+		b, err := json.Marshal(event)
+		if err != nil {
+			log.Errorw("failed to marshal event", "error", err)
+			return
+		}
+
+		if err := p.Publish(ctx, topic, []byte(event.Type), b); err != nil {
+			log.Errorw("failed to publish event", "error", err)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+var _ events.Publisher = (*publisher)(nil)
+
+type publisher struct {
+	conn *nats.Conn
+}
+
+// NewPublisher returns a publisher that publishes messages to NATS subjects.
+// The topic is used as subject and the key is set as `Goth-Event` header.
+func NewPublisher(conn *nats.Conn) *publisher {
+	return &publisher{conn: conn}
+}
+
+// Publish publishes the value with the key to the subject.
+func (p *publisher) Publish(_ context.Context, topic string, key, value []byte) error {
+	msg := nats.NewMsg(topic)
+	msg.Header.Set("Goth-Event", string(key))
+	msg.Data = value
+
+	return p.conn.PublishMsg(msg)
+}
@@ -3,14 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
-	"html/template"
 	"log"
 	"os"
-	"sort"
 
 	goth "github.com/zeiss/fiber-goth"
 	gorm_adapter "github.com/zeiss/fiber-goth/adapters/gorm"
 	"github.com/zeiss/fiber-goth/csrf"
+	"github.com/zeiss/fiber-goth/i18n"
+	"github.com/zeiss/fiber-goth/pages"
 	"github.com/zeiss/fiber-goth/providers"
 	"github.com/zeiss/fiber-goth/providers/entraid"
 	"github.com/zeiss/fiber-goth/providers/github"
@@ -94,27 +94,10 @@ func run(_ context.Context) error {
 	providers.RegisterProvider(github.New(os.Getenv("GITHUB_KEY"), os.Getenv("GITHUB_SECRET"), "http://localhost:3000/auth/github/callback"))
 	providers.RegisterProvider(entraid.New(os.Getenv("ENTRAID_CLIENT_ID"), os.Getenv("ENTRAID_CLIENT_SECRET"), "http://localhost:3000/auth/entraid/callback", entraid.TenantType(os.Getenv("ENTRAID_TENANT_ID"))))
 
-	m := map[string]string{
-		"entraid": "EntraID",
-		"github":  "Github",
-	}
-	var keys []string
-	for k := range m {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
 	app := fiber.New()
 	app.Use(requestid.New())
 	app.Use(logger.New())
-
-	providerIndex := &ProviderIndex{Providers: keys, ProvidersMap: m}
-	engine := template.New("views")
-
-	t, err := engine.Parse(indexTemplate)
-	if err != nil {
-		log.Fatal(err)
-	}
+	app.Use(i18n.New())
 
 	gothConfig := goth.Config{
 		Adapter:        ga,
@@ -142,14 +125,9 @@ func run(_ context.Context) error {
 		return c.SendString(t)
 	})
 
-	app.Get("/login", func(c *fiber.Ctx) error {
-		c.Set(fiber.HeaderContentType, fiber.MIMETextHTML)
-		return t.Execute(c.Response().BodyWriter(), providerIndex)
-	})
+	app.Get("/login", pages.NewLoginHandler())
 	app.Get("/session", goth.NewSessionHandler(gothConfig))
-	app.Use("/login/:provider", goth.NewBeginAuthHandler(gothConfig))
-	app.Get("/auth/:provider/callback", goth.NewCompleteAuthHandler(gothConfig))
-	app.Get("/logout", goth.NewLogoutHandler(gothConfig))
+	goth.RegisterRoutes(app, gothConfig)
 
 	if err := app.Listen("0.0.0.0:3000"); err != nil {
 		return err
@@ -158,45 +136,8 @@ func run(_ context.Context) error {
 	return nil
 }
 
-type ProviderIndex struct {
-	Providers    []string
-	ProvidersMap map[string]string
-}
-
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		panic(err)
 	}
 }
-
-var helloTemplate = `<div>Hello World</div>`
-
-var indexTemplate = `{{range $key,$value:=.Providers}}
-    <p><a href="/login/{{$value}}">Log in with {{index $.ProvidersMap $value}}</a></p>
-{{end}}
-<div class="container">
-  <form action="/login/credentials">
-    <label for="usrname">Username</label>
-    <input type="text" id="usrname" name="usrname" required>
-
-    <label for="psw">Password</label>
-    <input type="password" id="psw" name="psw" pattern="(?=.*\d)(?=.*[a-z])(?=.*[A-Z]).{8,}" title="Must contain at least one number and one uppercase and lowercase letter, and at least 8 or more characters" required>
-
-    <input type="submit" value="Submit">
-  </form>
-</div>
-`
-
-var userTemplate = `
-<p><a href="/logout/{{.Provider}}">logout</a></p>
-<p>Name: {{.Name}} [{{.LastName}}, {{.FirstName}}]</p>
-<p>Email: {{.Email}}</p>
-<p>NickName: {{.NickName}}</p>
-<p>Location: {{.Location}}</p>
-<p>AvatarURL: {{.AvatarURL}} <img src="{{.AvatarURL}}"></p>
-<p>Description: {{.Description}}</p>
-<p>UserID: {{.UserID}}</p>
-<p>AccessToken: {{.AccessToken}}</p>
-<p>ExpiresAt: {{.ExpiresAt}}</p>
-<p>RefreshToken: {{.RefreshToken}}</p>
-`
@@ -0,0 +1,177 @@
+package goth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/internal/secure"
+)
+
+// stateClockSkew is the duration a state may be issued in the future, e.g. by another instance.
+const stateClockSkew = time.Minute
+
+// processStateKey is the key the states are signed with if no Secret is configured. It is random
+// per process, so that the states of such a config are only valid for the instance that issued them.
+var processStateKey = sync.OnceValue(func() Key {
+	k, err := NewKey()
+	if err != nil {
+		panic(err)
+	}
+
+	return k
+})
+
+// stateSigningKey returns the key the states are signed with, derived from the Secret
+// or the processStateKey without a Secret.
+func (cfg Config) stateSigningKey() Key {
+	if cfg.Secret == "" {
+		return processStateKey()
+	}
+
+	return deriveKey([]byte(cfg.Secret), signingKeyLabel)
+}
+
+// signState appends the issued-at time and the HMAC of the state and time to the state
+// (`<state>.<issued-at>.<mac>`), so that a callback can be rejected once the StateExpiry
+// has passed without a lookup.
+func (cfg Config) signState(state string, issuedAt time.Time) string {
+	s := state + "." + strconv.FormatInt(issuedAt.Unix(), 36)
+
+	return s + "." + stateMAC(cfg.stateSigningKey(), s)
+}
+
+// verifyState returns ErrInvalidState if the state has not been signed with the key of the
+// config or has been issued longer than the StateExpiry ago.
+func (cfg Config) verifyState(state string, now time.Time) error {
+	i := strings.LastIndexByte(state, '.')
+	if i < 0 || !secure.Equal(state[i+1:], stateMAC(cfg.stateSigningKey(), state[:i])) {
+		return ErrInvalidState
+	}
+	s := state[:i]
+
+	i = strings.LastIndexByte(s, '.')
+	if i < 0 {
+		return ErrInvalidState
+	}
+
+	ts, err := strconv.ParseInt(s[i+1:], 36, 64)
+	if err != nil {
+		return ErrInvalidState
+	}
+
+	issuedAt := time.Unix(ts, 0)
+	if now.Sub(issuedAt) > cfg.StateExpiry || issuedAt.Sub(now) > stateClockSkew {
+		return ErrInvalidState
+	}
+
+	return nil
+}
+
+// stateMAC returns the HMAC-SHA256 of the state keyed with the key.
+func stateMAC(key Key, state string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("state:" + state))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stateKey returns the key of the state in the FlowStore.
+func stateKey(state string) string {
+	return "state:" + adapters.HashToken(state)
+}
+
+// storeState stores the state of a login with the provider in the FlowStore.
+func (cfg Config) storeState(ctx context.Context, state, provider string) error {
+	if cfg.FlowStore == nil {
+		return nil
+	}
+
+	return cfg.FlowStore.Put(ctx, stateKey(state), []byte(provider), cfg.StateExpiry)
+}
+
+// consumeState verifies the state of a callback and consumes it from the FlowStore. It returns
+// ErrInvalidState if the state is missing, expired or has not been issued for a login with the provider.
+func (cfg Config) consumeState(ctx context.Context, state, provider string) error {
+	if state == "" {
+		return ErrInvalidState
+	}
+
+	if err := cfg.verifyState(state, time.Now()); err != nil {
+		return err
+	}
+
+	if cfg.FlowStore == nil {
+		return nil
+	}
+
+	value, err := cfg.FlowStore.GetAndDelete(ctx, stateKey(state))
+	if err != nil || !secure.EqualBytes(value, []byte(provider)) {
+		return ErrInvalidState
+	}
+
+	return nil
+}
+
+// loginKey returns the key of the login completed with the state in the FlowStore.
+func loginKey(state string) string {
+	return "login:" + adapters.HashToken(state)
+}
+
+// storeLogin records in the FlowStore that the state has completed the login of the session,
+// so that a repeated callback with the state (e.g. a reload) can be matched to the session.
+func (cfg Config) storeLogin(ctx context.Context, state, sessionToken string) error {
+	if cfg.FlowStore == nil || state == "" {
+		return nil
+	}
+
+	return cfg.FlowStore.Put(ctx, loginKey(state), []byte(adapters.HashToken(sessionToken)), cfg.StateExpiry)
+}
+
+// isCurrentLogin reports whether the state has completed the login of the current session.
+// The record is kept, so that the callback can be repeated until the StateExpiry.
+func (cfg Config) isCurrentLogin(c *fiber.Ctx, state string) bool {
+	if cfg.FlowStore == nil || state == "" {
+		return false
+	}
+
+	session, err := cfg.currentSession(c)
+	if err != nil {
+		return false
+	}
+
+	value, err := cfg.FlowStore.GetAndDelete(c.Context(), loginKey(state))
+	if err != nil || !secure.EqualBytes(value, []byte(adapters.HashToken(session.SessionToken))) {
+		return false
+	}
+
+	_ = cfg.FlowStore.Put(c.Context(), loginKey(state), value, cfg.StateExpiry)
+
+	return true
+}
+
+// staleState handles a callback whose state is invalid. Every login is stored under its own state,
+// so logins in several tabs do not invalidate each other. If the state has already completed the login
+// of the current session (e.g. the callback has been reloaded), the login completes without an error.
+// Any other invalid, replayed or expired state is rejected.
+func (cfg Config) staleState(c *fiber.Ctx, provider, state, attempt string, start time.Time, err error) error {
+	if !cfg.isCurrentLogin(c, state) {
+		return cfg.loginFailed(c, events.LoginDenied, provider, attempt, start, err)
+	}
+
+	cfg.emitLogin(c.Context(), events.LoginDenied, provider, attempt, uuid.Nil, map[string]any{
+		LoginReasonKey:   ErrInvalidState.Message,
+		LoginDurationKey: time.Since(start).Milliseconds(),
+	})
+
+	return cfg.CompletionFilter(c)
+}
@@ -0,0 +1,68 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+// stateProvider is a provider whose callbacks carry the state, it counts the completed callbacks.
+type stateProvider struct {
+	providers.UnimplementedProvider
+	completed int
+}
+
+func (p *stateProvider) ID() string {
+	return "state"
+}
+
+func (p *stateProvider) Name() string {
+	return "State"
+}
+
+func (p *stateProvider) CompleteAuth(_ context.Context, _ adapters.Adapter, _ providers.AuthParams) (adapters.GothUser, error) {
+	p.completed++
+
+	return adapters.GothUser{}, providers.ErrUnimplemented
+}
+
+func TestCallbackWithoutState(t *testing.T) {
+	p := &stateProvider{}
+	providers.RegisterProvider(p)
+
+	tests := []struct {
+		name      string
+		flowStore adapters.FlowStore
+	}{
+		{name: "without flow store"},
+		{name: "with flow store", flowStore: adapters.NewMemoryFlowStore()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/auth/:provider/callback", NewCompleteAuthHandler(Config{
+				Adapter:   &adapters.UnimplementedAdapter{},
+				FlowStore: tt.flowStore,
+			}))
+
+			res, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/state/callback?code=code", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.StatusCode != fiber.StatusBadRequest {
+				t.Fatalf("unexpected status %d", res.StatusCode)
+			}
+
+			if p.completed != 0 {
+				t.Fatal("callback without state completed the authentication")
+			}
+		})
+	}
+}
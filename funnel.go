@@ -0,0 +1,91 @@
+package goth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+// The data keys of the login funnel events. The keys are stable, so that the funnel
+// can be measured across releases.
+const (
+	// LoginAttemptKey is the correlation ID of the steps of a login. It is derived from the OAuth state.
+	LoginAttemptKey = "attempt"
+	// LoginReasonKey is the reason of a failed or denied login.
+	LoginReasonKey = "reason"
+	// LoginDurationKey is the duration since the callback has been received in milliseconds.
+	LoginDurationKey = "duration_ms"
+)
+
+// loginAttempt returns the correlation ID of a login for the state. The state itself is never emitted.
+func loginAttempt(state string) string {
+	if state == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(state))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// emitLogin emits a login funnel event.
+func (cfg Config) emitLogin(ctx context.Context, t events.Type, provider, attempt string, userID uuid.UUID, data map[string]any) {
+	if cfg.Privacy.DisableFunnel && funnelOnly[t] {
+		return
+	}
+
+	e := events.New(t, userID)
+	e.Provider = provider
+	e.Data = map[string]any{LoginAttemptKey: attempt}
+
+	for k, v := range data {
+		e.Data[k] = v
+	}
+
+	if cfg.Privacy.DisableFunnel {
+		delete(e.Data, LoginAttemptKey)
+		delete(e.Data, LoginDurationKey)
+	}
+
+	cfg.Events.Emit(ctx, e)
+}
+
+// loginFailed emits the login funnel event of the failure and handles the error.
+func (cfg Config) loginFailed(c *fiber.Ctx, t events.Type, provider, attempt string, start time.Time, err error) error {
+	log.Error(err)
+
+	reason := err.Error()
+
+	var e *Error
+	if errors.As(err, &e) {
+		reason = e.Message
+	}
+
+	cfg.emitLogin(c.Context(), t, provider, attempt, uuid.Nil, map[string]any{
+		LoginReasonKey:   reason,
+		LoginDurationKey: time.Since(start).Milliseconds(),
+	})
+
+	ec := ErrorContext{
+		Handler:  "CompleteAuthCompleteHandler",
+		Provider: provider,
+		Err:      err,
+	}
+
+	if t == events.LoginExchangeFailed {
+		ec.Category = ErrorCategoryProvider
+	}
+
+	if e != nil {
+		return cfg.handleErrorContext(c, ec, e)
+	}
+
+	return cfg.handleErrorContext(c, ec, ErrMissingUser)
+}
@@ -5,9 +5,13 @@
 package goth
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"strings"
@@ -16,23 +20,65 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/gofiber/fiber/v2/utils"
+	"github.com/google/uuid"
 	"github.com/valyala/fasthttp"
 	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/mailer"
 	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/utilx"
 )
 
 var _ GothHandler = (*BeginAuthHandler)(nil)
 
 const charset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-"
 
+// sessionTokenLength is the number of random bytes in a session token.
+const sessionTokenLength = 32
+
+var (
+	_ providers.HeaderParams = (*Params)(nil)
+	_ providers.TLSParams    = (*Params)(nil)
+)
+
 // Params maps the parameters of the Fiber context to the gothic context.
 type Params struct {
 	ctx *fiber.Ctx
 }
 
-// Get returns the value of a query paramater.
+// ParamsFromContext returns the authentication parameters of the Fiber context.
+func ParamsFromContext(c *fiber.Ctx) *Params {
+	return &Params{ctx: c}
+}
+
+// Get returns the value of a paramater. It looks up the query parameters first,
+// then falls back to the form values (e.g. `response_mode=form_post`) and
+// finally to the request headers.
 func (p *Params) Get(key string) string {
-	return p.ctx.Query(key)
+	if v := p.ctx.Query(key); v != "" {
+		return v
+	}
+
+	if v := p.ctx.FormValue(key); v != "" {
+		return v
+	}
+
+	return p.ctx.Get(key)
+}
+
+// Header returns the value of the request header only.
+func (p *Params) Header(key string) string {
+	return p.ctx.Get(key)
+}
+
+// PeerCertificates returns the certificate chain the client presented on the TLS connection.
+func (p *Params) PeerCertificates() []*x509.Certificate {
+	state := p.ctx.Context().TLSConnectionState()
+	if state == nil {
+		return nil
+	}
+
+	return state.PeerCertificates
 }
 
 // The contextKey type is unexported to prevent collisions with context keys defined in
@@ -45,6 +91,7 @@ const (
 	sessionKey
 	tokenKey
 	userIDKey
+	errorContextKey
 )
 
 // Error is the default error type for the goth middleware.
@@ -76,15 +123,21 @@ var (
 	// ErrMissingProviderName is thrown if the provider cannot be determined.
 	ErrMissingProviderName = NewError(http.StatusBadRequest, "missing provider name in request")
 	// ErrMissingSession is thrown if there is no active session.
-	ErrMissingSession = NewError(http.StatusBadRequest, "could not find a matching session for this request")
+	ErrMissingSession = NewError(http.StatusUnauthorized, "could not find a matching session for this request")
 	// ErrBadSession is thrown if the session is invalid.
-	ErrBadSession = NewError(http.StatusBadRequest, "session is invalid")
+	ErrBadSession = NewError(http.StatusUnauthorized, "session is invalid")
 	// ErrMissingUser is thrown if the user is missing.
 	ErrMissingUser = NewError(http.StatusBadRequest, "missing user")
 	// ErrMissingCookie is thrown if the cookie is missing.
-	ErrMissingCookie = NewError(http.StatusBadRequest, "missing session cookie")
+	ErrMissingCookie = NewError(http.StatusUnauthorized, "missing session cookie")
 	// ErrBadRequest is thrown if the request is invalid.
 	ErrBadRequest = NewError(http.StatusBadRequest, "bad request")
+	// ErrSessionConflict is thrown if the session has been modified concurrently.
+	ErrSessionConflict = NewError(http.StatusConflict, "session has been modified concurrently")
+	// ErrMissingTeam is thrown if the team is missing.
+	ErrMissingTeam = NewError(http.StatusBadRequest, "missing team")
+	// ErrMissingAccount is thrown if the user has no account for the provider.
+	ErrMissingAccount = NewError(http.StatusBadRequest, "missing account")
 )
 
 const (
@@ -107,41 +160,33 @@ func (SessionHandler) New(cfg Config) fiber.Handler {
 			return c.Next()
 		}
 
-		cookie := c.Cookies(cfg.CookieName)
-		if cookie == "" {
-			return cfg.ErrorHandler(c, ErrMissingCookie)
+		token, err := cfg.Extractor(c)
+		if err != nil {
+			return cfg.handleError(c, "SessionHandler", err)
 		}
 
-		session, err := cfg.Adapter.GetSession(c.Context(), cookie)
+		session, err := cfg.Adapter.GetSession(c.Context(), token)
 		if err != nil {
-			return cfg.ErrorHandler(c, err)
+			return cfg.handleError(c, "SessionHandler", err)
 		}
 
 		if !session.IsValid() {
-			cfg.ErrorHandler(c, err)
+			return cfg.unauthenticated(c, "SessionHandler", ErrBadSession)
 		}
 
 		duration, err := time.ParseDuration(cfg.Expiry)
 		if err != nil {
-			return cfg.ErrorHandler(c, err)
+			return cfg.handleError(c, "SessionHandler", err)
 		}
 		expires := time.Now().Add(duration)
 		session.ExpiresAt = expires
 
-		session, err = cfg.Adapter.RefreshSession(c.Context(), session)
+		session, err = refreshSession(c.Context(), cfg.Adapter, session)
 		if err != nil {
-			return cfg.ErrorHandler(c, err)
+			return cfg.handleError(c, "SessionHandler", err)
 		}
 
-		cookieValue := fasthttp.Cookie{}
-		cookieValue.SetKey(cfg.CookieName)
-		cookieValue.SetValueBytes([]byte(session.SessionToken))
-		cookieValue.SetHTTPOnly(true)
-		cookieValue.SetSameSite(cfg.CookieSameSite)
-		cookieValue.SetExpire(expires)
-		cookieValue.SetPath(cfg.CookiePath)
-
-		c.Response().Header.SetCookie(&cookieValue)
+		cfg.setSessionCookie(c, session.SessionToken, expires)
 
 		return c.Next()
 	}
@@ -155,6 +200,7 @@ func NewSessionHandler(config ...Config) fiber.Handler {
 }
 
 // BeginAuthHandler is the default handler to begin the authentication process.
+// With `mode=json` it responds with the URL of the provider (`{"url": "..."}`) instead of a redirect.
 type BeginAuthHandler struct{}
 
 // New creates a new handler to begin authentication.
@@ -174,25 +220,50 @@ func (BeginAuthHandler) New(cfg Config) fiber.Handler {
 			return err
 		}
 
-		state, err := stateFromContext(c)
+		url, err := cfg.beginAuth(c, provider)
 		if err != nil {
 			return err
 		}
 
-		intent, err := provider.BeginAuth(c.Context(), cfg.Adapter, state, &Params{ctx: c})
-		if err != nil {
-			return err
-		}
-
-		url, err := intent.GetAuthURL()
-		if err != nil {
-			return err
+		// single-page apps fetch the URL and navigate to it themselves
+		if c.Query("mode") == "json" {
+			return c.JSON(fiber.Map{"url": url})
 		}
 
 		return c.Redirect(url, fiber.StatusTemporaryRedirect)
 	}
 }
 
+// beginAuth signs and stores a new state for the login with the provider and returns the URL of the provider.
+func (cfg Config) beginAuth(c *fiber.Ctx, provider providers.Provider) (string, error) {
+	state, err := stateFromContext(c, cfg.Rand)
+	if err != nil {
+		return "", err
+	}
+	state = cfg.signState(state, time.Now())
+
+	attempt := loginAttempt(state)
+	cfg.emitLogin(c.Context(), events.LoginStarted, provider.ID(), attempt, uuid.Nil, nil)
+
+	if err := cfg.storeState(c.Context(), state, provider.ID()); err != nil {
+		return "", err
+	}
+
+	intent, err := provider.BeginAuth(c.Context(), cfg.Adapter, state, ParamsFromContext(c))
+	if err != nil {
+		return "", err
+	}
+
+	url, err := intent.GetAuthURL()
+	if err != nil {
+		return "", err
+	}
+
+	cfg.emitLogin(c.Context(), events.LoginRedirected, provider.ID(), attempt, uuid.Nil, nil)
+
+	return url, nil
+}
+
 // GothHandler is the interface for defining handlers for the middleware.
 type GothHandler interface {
 	New(cfg Config) fiber.Handler
@@ -212,6 +283,10 @@ type CompleteAuthCompleteHandler struct{}
 //
 //nolint:gocyclo
 func (CompleteAuthCompleteHandler) New(cfg Config) fiber.Handler {
+	if cfg.CompletionFilter == nil {
+		cfg.CompletionFilter = RedirectTo(utilx.IfElse(cfg.CompletionURL != "", cfg.CompletionURL, ConfigDefault.CompletionURL), cfg.TrustedOrigins...)
+	}
+
 	return func(c *fiber.Ctx) error {
 		if cfg.Next != nil && cfg.Next(c) {
 			return c.Next()
@@ -219,50 +294,137 @@ func (CompleteAuthCompleteHandler) New(cfg Config) fiber.Handler {
 
 		p := c.Params(provider)
 		if p == "" {
-			return cfg.ErrorHandler(c, ErrMissingProviderName)
+			return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrMissingProviderName)
 		}
 
 		provider, err := providers.GetProvider(p)
 		if err != nil {
-			return cfg.ErrorHandler(c, ErrMissingProviderName)
+			return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrMissingProviderName)
 		}
 
 		log.Infow("", "provider", provider.Name())
 
-		user, err := provider.CompleteAuth(c.Context(), cfg.Adapter, &Params{ctx: c})
+		if cfg.CallbackBridge && !providers.IsStateless(provider) && needsBridge(c) {
+			return bridgeCallback(c)
+		}
+
+		if s := ParamsFromContext(c).Get(state); isUpgradeState(s) {
+			return cfg.completeUpgrade(c, provider, s)
+		}
+
+		if cfg.LoginQueue != nil {
+			if !cfg.LoginQueue.TryAcquire() {
+				return cfg.loginQueueFull(c)
+			}
+			defer cfg.LoginQueue.Release()
+		}
+
+		start := time.Now()
+
+		s := ParamsFromContext(c).Get(state)
+
+		attempt := loginAttempt(s)
+		cfg.emitLogin(c.Context(), events.LoginCallbackReceived, provider.ID(), attempt, uuid.Nil, nil)
+
+		if !providers.IsStateless(provider) {
+			if err := cfg.consumeState(c.Context(), s, provider.ID()); err != nil {
+				return cfg.staleState(c, provider.ID(), s, attempt, start, err)
+			}
+		}
+
+		if err := providerError(ParamsFromContext(c)); err != nil {
+			return cfg.callbackFailed(c, provider.ID(), attempt, start, err)
+		}
+
+		profile, err := provider.CompleteAuth(c.Context(), cfg.Adapter, ParamsFromContext(c))
 		if err != nil {
-			log.Error(err)
-			return cfg.ErrorHandler(c, ErrMissingUser)
+			return cfg.loginFailed(c, events.LoginExchangeFailed, provider.ID(), attempt, start, err)
+		}
+
+		user, err := cfg.provisionUser(c.Context(), provider.ID(), attempt, c.Cookies(cfg.invitationCookie()), profile)
+		if err != nil {
+			var conflict *AccountConflictError
+			if errors.As(err, &conflict) && cfg.AccountConflictURL != "" {
+				return cfg.confirmAccountConflict(c, conflict)
+			}
+
+			return cfg.loginFailed(c, events.LoginDenied, provider.ID(), attempt, start, err)
 		}
 
 		log.Infow("", "user", user.Email)
 
+		if err := cfg.syncTeams(c.Context(), provider.ID(), user); err != nil {
+			log.Error(err)
+			return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+		}
+
+		for _, policy := range cfg.SignInPolicies {
+			if err := policy(c.Context(), cfg.Adapter, provider, user); err != nil {
+				cfg.emitLogin(c.Context(), events.LoginDenied, provider.ID(), attempt, user.ID, map[string]any{LoginReasonKey: err.Error()})
+
+				log.Error(err)
+				return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+			}
+		}
+
+		var claims map[string]any
+		if cfg.SessionClaims != nil {
+			claims, err = cfg.SessionClaims(c.Context(), user)
+			if err != nil {
+				log.Error(err)
+				return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+			}
+		}
+
 		duration, err := time.ParseDuration(cfg.Expiry)
 		if err != nil {
 			log.Error(err)
-			return cfg.ErrorHandler(c, ErrMissingSession)
+			return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrMissingSession)
 		}
 		expires := time.Now().Add(duration)
 
-		session, err := cfg.Adapter.CreateSession(c.Context(), user.ID, expires)
+		token, err := cfg.SessionTokenGenerator()
+		if err != nil {
+			log.Error(err)
+			return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrMissingSession)
+		}
+
+		newDevice := cfg.isNewDevice(c, user, start)
+
+		session, err := cfg.createSession(c.Context(), adapters.GothSession{
+			UserID:         user.ID,
+			SessionToken:   token,
+			ExpiresAt:      expires,
+			Provider:       provider.ID(),
+			IPAddress:      cfg.Privacy.ClientIP(c),
+			UserAgentHash:  cfg.Privacy.userAgentHash(c),
+			CertThumbprint: cfg.SessionBinding.Thumbprint(c),
+			Claims:         claims,
+		})
+		if errors.Is(err, ErrLoginQueueFull) {
+			return cfg.loginQueueFull(c)
+		}
+
 		if err != nil {
 			log.Error(err)
-			return cfg.ErrorHandler(c, ErrMissingSession)
+			return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrMissingSession)
 		}
 
-		log.Infow("", "session", session.SessionToken)
+		if err := cfg.storeLogin(c.Context(), s, session.SessionToken); err != nil {
+			log.Error(err)
+		}
 
-		cookieValue := fasthttp.Cookie{}
-		cookieValue.SetKeyBytes([]byte(cfg.CookieName))
-		cookieValue.SetValueBytes([]byte(session.SessionToken))
-		cookieValue.SetHTTPOnly(true)
-		cookieValue.SetSameSite(fasthttp.CookieSameSiteLaxMode)
-		cookieValue.SetExpire(expires)
-		cookieValue.SetPath("/")
+		cfg.setSessionCookie(c, session.SessionToken, expires)
+
+		if c.Cookies(cfg.invitationCookie()) != "" {
+			cfg.clearFlowCookie(c, cfg.invitationCookie())
+		}
 
-		c.Vary(fiber.HeaderCookie)
+		cfg.emitLogin(c.Context(), events.LoginSessionIssued, provider.ID(), attempt, user.ID, map[string]any{LoginDurationKey: time.Since(start).Milliseconds(), "ip_address": cfg.Privacy.ClientIP(c)})
 
-		c.Response().Header.SetCookie(&cookieValue)
+		if newDevice {
+			cfg.notifyNewDevice(c, user)
+		}
 
 		return cfg.CompletionFilter(c)
 	}
@@ -294,15 +456,15 @@ func (LogoutHandler) New(cfg Config) fiber.Handler {
 
 		token, err := cfg.Extractor(c)
 		if err != nil {
-			return cfg.ErrorHandler(c, err)
+			return cfg.handleError(c, "LogoutHandler", err)
 		}
 
 		err = cfg.Adapter.DeleteSession(c.Context(), token)
 		if err != nil {
-			return cfg.ErrorHandler(c, err)
+			return cfg.handleError(c, "LogoutHandler", err)
 		}
 
-		c.ClearCookie(cfg.CookieName)
+		cfg.clearSessionCookie(c)
 
 		return cfg.CompletionFilter(c)
 	}
@@ -315,13 +477,18 @@ type ProtectMiddleware struct{}
 //
 // nolint:gocyclo
 func NewProtectMiddleware(config ...Config) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		cfg := configDefault(config...)
+	cfg := configDefault(config...)
+	duration, durationErr := time.ParseDuration(cfg.Expiry)
 
+	return func(c *fiber.Ctx) error {
 		if cfg.Next != nil && cfg.Next(c) {
 			return c.Next()
 		}
 
+		if cfg.skip(c.Path()) {
+			return c.Next()
+		}
+
 		if strings.HasPrefix(c.Path(), cfg.LoginURL) {
 			return c.Next()
 		}
@@ -330,45 +497,40 @@ func NewProtectMiddleware(config ...Config) fiber.Handler {
 			return c.Next()
 		}
 
-		if strings.HasPrefix(c.Path(), cfg.CallbackURL) {
+		if isWidgetPath(c.Path()) {
+			return c.Next()
+		}
+
+		if strings.HasPrefix(c.Path(), cfg.CallbackURL) || matchPattern(cfg.CallbackURLPattern, c.Path()) {
 			return c.Next()
 		}
 
 		token, err := cfg.Extractor(c)
 		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectMiddleware", err)
 		}
 
 		session, err := cfg.Adapter.GetSession(c.Context(), token)
 		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectMiddleware", err)
 		}
 
 		if !session.IsValid() {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectMiddleware", ErrBadSession)
 		}
 
-		duration, err := time.ParseDuration(cfg.Expiry)
-		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+		if durationErr != nil {
+			return cfg.unauthenticated(c, "ProtectMiddleware", durationErr)
 		}
-		expires := time.Now().Add(duration)
-		session.ExpiresAt = expires
 
-		session, err = cfg.Adapter.RefreshSession(c.Context(), session)
-		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+		if err := cfg.checkBinding(c, session); err != nil {
+			return cfg.unauthenticated(c, "ProtectMiddleware", err)
 		}
 
-		cookieValue := fasthttp.Cookie{}
-		cookieValue.SetKey(cfg.CookieName)
-		cookieValue.SetValueBytes([]byte(session.SessionToken))
-		cookieValue.SetHTTPOnly(true)
-		cookieValue.SetSameSite(cfg.CookieSameSite)
-		cookieValue.SetExpire(expires)
-		cookieValue.SetPath(cfg.CookiePath)
-
-		c.Response().Header.SetCookie(&cookieValue)
+		session, err = cfg.touchSession(c, session, duration)
+		if err != nil {
+			return cfg.unauthenticated(c, "ProtectMiddleware", err)
+		}
 
 		c.Locals(tokenKey, session.ID)
 		c.Locals(sessionKey, session)
@@ -383,57 +545,158 @@ type ProtectedHandler struct{}
 
 // NewProtectedHandler returns a new default protected handler.
 func NewProtectedHandler(handler fiber.Handler, config ...Config) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		cfg := configDefault(config...)
+	cfg := configDefault(config...)
+	duration, durationErr := time.ParseDuration(cfg.Expiry)
 
+	return func(c *fiber.Ctx) error {
 		if cfg.Next != nil && cfg.Next(c) {
 			return c.Next()
 		}
 
 		token, err := cfg.Extractor(c)
 		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectedHandler", err)
 		}
 
 		session, err := cfg.Adapter.GetSession(c.Context(), token)
 		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectedHandler", err)
 		}
 
 		if !session.IsValid() {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectedHandler", ErrBadSession)
 		}
 
-		duration, err := time.ParseDuration(cfg.Expiry)
-		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+		if durationErr != nil {
+			return cfg.unauthenticated(c, "ProtectedHandler", durationErr)
 		}
-		expires := time.Now().Add(duration)
-		session.ExpiresAt = expires
 
-		session, err = cfg.Adapter.RefreshSession(c.Context(), session)
+		if err := cfg.checkBinding(c, session); err != nil {
+			return cfg.unauthenticated(c, "ProtectedHandler", err)
+		}
+
+		session, err = cfg.touchSession(c, session, duration)
 		if err != nil {
-			return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+			return cfg.unauthenticated(c, "ProtectedHandler", err)
 		}
 
-		cookieValue := fasthttp.Cookie{}
-		cookieValue.SetKey(cfg.CookieName)
-		cookieValue.SetValueBytes([]byte(session.SessionToken))
-		cookieValue.SetHTTPOnly(true)
-		cookieValue.SetSameSite(cfg.CookieSameSite)
-		cookieValue.SetExpire(expires)
-		cookieValue.SetPath(cfg.CookiePath)
+		c.Locals(tokenKey, session.ID)
+		c.Locals(sessionKey, session)
+		c.Locals(userIDKey, session.UserID)
+
+		return handler(c)
+	}
+}
 
-		c.Response().Header.SetCookie(&cookieValue)
+// OptionalAuthMiddleware is the default handler for optional authentication.
+type OptionalAuthMiddleware struct{}
+
+// NewOptionalAuthMiddleware returns a new default optional auth handler. The session and
+// user are stored in the context if the request has a valid session, anonymous requests
+// pass without a redirect or error, e.g. for pages that render differently when signed in.
+func NewOptionalAuthMiddleware(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+	duration, durationErr := time.ParseDuration(cfg.Expiry)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		if cfg.skip(c.Path()) || durationErr != nil {
+			return c.Next()
+		}
+
+		session, ok := cfg.optionalSession(c)
+		if !ok {
+			return c.Next()
+		}
+
+		session, err := cfg.touchSession(c, session, duration)
+		if err != nil {
+			return c.Next()
+		}
 
 		c.Locals(tokenKey, session.ID)
 		c.Locals(sessionKey, session)
 		c.Locals(userIDKey, session.UserID)
 
-		return handler(c)
+		return c.Next()
 	}
 }
 
+// optionalSession returns the valid session of the request, if any.
+func (cfg Config) optionalSession(c *fiber.Ctx) (adapters.GothSession, bool) {
+	token, err := cfg.Extractor(c)
+	if err != nil {
+		return adapters.GothSession{}, false
+	}
+
+	session, err := cfg.Adapter.GetSession(c.Context(), token)
+	if err != nil || !session.IsValid() {
+		return adapters.GothSession{}, false
+	}
+
+	if err := cfg.checkBinding(c, session); err != nil {
+		return adapters.GothSession{}, false
+	}
+
+	return session, true
+}
+
+// sessionTouchInterval is the minimum extension of the expiry for which a session
+// and its cookie are refreshed. Requests in between only read the session.
+const sessionTouchInterval = time.Minute
+
+// touchSession extends the expiry of the session and its cookie to the duration from now,
+// unless the expiry would change by less than the sessionTouchInterval.
+// With a TouchWriter the update is queued instead and the last activity is recorded on every request.
+func (cfg Config) touchSession(c *fiber.Ctx, session adapters.GothSession, duration time.Duration) (adapters.GothSession, error) {
+	now := time.Now()
+	expires := now.Add(duration)
+	material := expires.Sub(session.ExpiresAt) >= sessionTouchInterval
+
+	if cfg.TouchWriter != nil {
+		cfg.TouchWriter.Touch(session.SessionToken, expires, now)
+	}
+
+	if !material {
+		varySession(c)
+
+		if cfg.TokenHeader != "" {
+			c.Set(cfg.TokenHeader, session.SessionToken)
+		}
+
+		return session, nil
+	}
+	session.ExpiresAt = expires
+	session.LastSeenAt = &now
+
+	if cfg.TouchWriter == nil {
+		var err error
+
+		session, err = refreshSession(c.Context(), cfg.Adapter, session)
+		if err != nil {
+			return adapters.GothSession{}, err
+		}
+	}
+
+	cfg.setSessionCookie(c, session.SessionToken, expires)
+
+	return session, nil
+}
+
+// refreshSession refreshes the session. If a concurrent request has refreshed
+// the session in the meantime, the current state of the session is read from the primary.
+func refreshSession(ctx context.Context, adapter adapters.Adapter, session adapters.GothSession) (adapters.GothSession, error) {
+	s, err := adapter.RefreshSession(ctx, session)
+	if errors.Is(err, ErrSessionConflict) {
+		return adapter.GetSession(adapters.WithPrimary(ctx), session.SessionToken)
+	}
+
+	return s, err
+}
+
 // GetStateFromContext return the state that is returned during the callback.
 func GetStateFromContext(ctx *fiber.Ctx) string {
 	return ctx.Query(state)
@@ -456,11 +719,34 @@ func SessionFromContext(c *fiber.Ctx) (adapters.GothSession, error) {
 	return session, nil
 }
 
+// ClaimsFromContext returns the claims stored on the session of the request (see Config.SessionClaims).
+// The claims are computed at sign-in and are not updated during the session.
+func ClaimsFromContext(c *fiber.Ctx) map[string]any {
+	session, err := SessionFromContext(c)
+	if err != nil {
+		return nil
+	}
+
+	return session.Claims
+}
+
 // Config caputes the configuration for running the goth middleware.
 type Config struct {
 	// Next defines a function to skip this middleware when returned true.
 	Next func(c *fiber.Ctx) bool
 
+	// SkipPaths are path prefixes the protect middleware lets pass without a session,
+	// e.g. "/static" or "/healthz". They are matched on segment boundaries.
+	//
+	// Optional. Default: nil
+	SkipPaths []string
+
+	// SkipExtensions are the file extensions the protect middleware lets pass without a session,
+	// e.g. StaticExtensions. They are matched case-insensitively.
+	//
+	// Optional. Default: nil
+	SkipExtensions []string
+
 	// BeginAuthHandler is the handler to start authentication.
 	BeginAuthHandler GothHandler
 
@@ -473,14 +759,22 @@ type Config struct {
 	// SessionHandler is the handler to manage the session.
 	SessionHandler GothHandler
 
+	// DiscoveryHandler is the handler to discover the provider of an email domain.
+	DiscoveryHandler GothHandler
+
+	// ScopeUpgradeHandler is the handler to request additional scopes for a linked account.
+	ScopeUpgradeHandler GothHandler
+
 	// IndexHandler is the handler to display the index.
 	IndexHandler fiber.Handler
 
 	// ProtectedHandler is the handler to protect the route.
 	ProtectedHandler fiber.Handler
 
-	// CompletionFilter that is executed when responses need to returned.
-	CompletionFilter func(c *fiber.Ctx) error
+	// CompletionFilter that is executed when responses need to returned (see RedirectTo, JSONResponse and NoContent).
+	//
+	// Optional. Default: RedirectTo(CompletionURL, TrustedOrigins...)
+	CompletionFilter ResponseFilter
 
 	// Secret is the secret used to sign the session.
 	Secret string
@@ -503,67 +797,306 @@ type Config struct {
 	// CookieHTTPOnly is the HTTPOnly attribute of the cookie.
 	CookieHTTPOnly bool
 
+	// CookieSecure is the Secure attribute of the cookie.
+	// It is always enabled if CookieSameSite is None.
+	CookieSecure bool
+
+	// TokenHeader is the response header the session token is returned in
+	// additionally to the cookie. The Extractor falls back to the request header.
+	// This supports apps embedded in iframes (e.g. Teams or Slack apps) where
+	// cookies may be dropped by the browser.
+	//
+	// Optional. Default: ""
+	TokenHeader string
+
 	// Encryptor is the function used to encrypt the session.
 	Encryptor func(decryptedString, key string) (string, error)
 
 	// Decryptor is the function used to decrypt the session.
 	Decryptor func(encryptedString, key string) (string, error)
 
+	// CookieCodec is the codec used to serialize values stored in cookies.
+	//
+	// Optional. Default: DefaultCookieCodec
+	CookieCodec CookieCodec
+
 	// Adapter is the adapter used to store the session.
 	// Adapter adapters.Adapter
 	Adapter adapters.Adapter
 
+	// SessionClaims computes custom attributes of the user (e.g. the plan or feature flags) that are
+	// stored on the session at sign-in and returned by ClaimsFromContext without a further lookup.
+	SessionClaims func(ctx context.Context, user adapters.GothUser) (map[string]any, error)
+
+	// SessionBinding binds sessions to the IP prefix and user agent of the client that created them.
+	//
+	// Optional. Default: BindingOff
+	SessionBinding SessionBinding
+
+	// Privacy controls the IP addresses and user agents stored on sessions and emitted with events.
+	//
+	// Optional. Default: Privacy{} (stored unchanged)
+	Privacy Privacy
+
+	// TouchWriter writes the expiry and last activity updates of sessions in the background.
+	// If set, the protect middleware does not write to the adapter on authenticated requests.
+	//
+	// Optional. Default: nil (synchronous writes)
+	TouchWriter *TouchWriter
+
+	// LoginQueue creates the sessions of completed logins with a bounded pool of workers.
+	// Beyond its threshold the callbacks are rejected with 503 and a Retry-After header.
+	//
+	// Optional. Default: nil (synchronous writes)
+	LoginQueue *LoginQueue
+
+	// AdapterTimeout is the deadline applied to each call of the adapter within the handlers,
+	// so that a slow store can't hold requests for the full server timeout.
+	//
+	// Optional. Default: 0 (no deadline)
+	AdapterTimeout time.Duration
+
 	// LoginURL is the URL to redirect to when the user is not authenticated.
 	LoginURL string
 
 	// LogoutURL is the URL to redirect to when the user logs out.
 	LogoutURL string
 
+	// WidgetURL is the prefix of the widget API (see RegisterWidgetRoutes).
+	// The protect middleware skips its routes once they are registered, the me endpoint is protected by itself.
+	//
+	// Optional. Default: "/auth/widget"
+	WidgetURL string
+
 	// CallbackURL is the URL to redirect to when the user logs out.
 	CallbackURL string
 
+	// CallbackURLPattern is the route pattern of the provider callbacks.
+	// The `:provider` segment is replaced with the provider ID.
+	//
+	// Optional. Default: "<CallbackURL>/:provider/callback"
+	CallbackURLPattern string
+
 	// CompletionURL is the default url after completion
 	CompletionURL string
 
+	// ChangePasswordURL is the URL of the password change form.
+	// It is the target of the `/.well-known/change-password` redirect.
+	ChangePasswordURL string
+
+	// SecurityTxt is served at `/.well-known/security.txt` if set.
+	SecurityTxt *SecurityTxt
+
+	// TrustedOrigins is a list of origins (e.g. "https://example.com") that are
+	// allowed as absolute redirect targets. Local paths are always allowed.
+	TrustedOrigins []string
+
 	// ErrorHandler is executed when an error is returned from fiber.Handler.
+	// The ErrorContext of the error is available with ErrorContextFromContext.
 	//
 	// Optional. Default: DefaultErrorHandler
 	ErrorHandler fiber.ErrorHandler
 
+	// UnauthenticatedStatus is the status of the errors of requests without a valid session
+	// (e.g. missing, expired or presented by a different client).
+	//
+	// Optional. Default: 401
+	UnauthenticatedStatus int
+
+	// ForbiddenStatus is the status of the errors of requests with a valid session
+	// that are denied (e.g. by a policy or a missing team membership).
+	//
+	// Optional. Default: 403
+	ForbiddenStatus int
+
 	// Extractor is the function used to extract the token from the request.
 	Extractor func(c *fiber.Ctx) (string, error)
+
+	// AllowSignUp allows providers to create new users. If disabled only
+	// pre-existing users can sign in.
+	//
+	// Optional. Default: true
+	AllowSignUp *bool
+
+	// ProviderSignUp overrides AllowSignUp per provider ID.
+	ProviderSignUp map[string]bool
+
+	// UserMatcher finds the existing user of a user authenticated by a provider. The account of the
+	// provider is linked to the matched user. If no user matches, a new user is created, unless a
+	// user with the same email exists (ErrEmailConflict).
+	//
+	// Optional. Default: DefaultUserMatcher
+	UserMatcher UserMatcher
+
+	// SignUpPolicies are evaluated before a new user is created (e.g. WithDomainSeatLimits).
+	// The first policy returning an error denies the sign-up.
+	SignUpPolicies []SignUpPolicy
+
+	// SignInPolicies are evaluated after a provider has completed the authentication
+	// and before a session is created. The first policy returning an error denies the sign-in.
+	SignInPolicies []SignInPolicy
+
+	// GroupMapper maps the groups supplied by the providers to team memberships, which are
+	// synced on every sign-in before the SignInPolicies are evaluated.
+	//
+	// Optional. Default: nil (no sync)
+	GroupMapper GroupMapper
+
+	// Events is the emitter for audit events.
+	//
+	// Optional. Default: events.Noop
+	Events events.Emitter
+
+	// VerificationSender delivers verification tokens (e.g. to confirm a new email).
+	//
+	// Optional. Default: sends the mailer.Verification template via the Mailer
+	VerificationSender func(ctx context.Context, identifier, email, token string) error
+
+	// Mailer sends the emails of the middleware (e.g. verifications and new device alerts).
+	Mailer mailer.Mailer
+
+	// MailTemplates renders the emails, e.g. mailer.Templates or a
+	// mailer.ViewsRenderer of any fiber.Views template engine.
+	//
+	// Optional. Default: mailer.DefaultTemplates
+	MailTemplates mailer.Renderer
+
+	// AppName is the name of the application used in emails.
+	AppName string
+
+	// ConfirmEmailURL is the absolute URL of the confirm email handler that is linked
+	// in verification emails (e.g. "https://example.com/account/email/confirm").
+	ConfirmEmailURL string
+
+	// NewDeviceAlert sends an email via the Mailer if a user signs in from a new device.
+	NewDeviceAlert bool
+
+	// SecurityNotifications are the security events users are notified about by the SecurityAlerts
+	// with their default delivery. Users can override the delivery with the notification preferences handler.
+	//
+	// Optional. Default: DefaultSecurityNotifications
+	SecurityNotifications map[events.Type]adapters.NotificationDelivery
+
+	// AccountConflictURL is the URL of the page the user is redirected to if the account of the provider is
+	// already linked to another user. The page receives the `token`, `user`, `owner` and `provider` parameters
+	// and lets the user merge the users or abort via the resolve account conflict handler.
+	//
+	// Optional. Default: "" (the sign-in fails with ErrAccountConflict)
+	AccountConflictURL string
+
+	// CallbackErrorURL is the URL of the page the user is redirected to if the provider redirects back
+	// with an error (e.g. `error=access_denied`). The page receives the `error`, `error_description`
+	// and `provider` parameters, e.g. pages.NewCallbackErrorHandler.
+	//
+	// Optional. Default: "" (the ProviderError is passed to the ErrorHandler)
+	CallbackErrorURL string
+
+	// CallbackBridge answers cross-site callbacks (e.g. `response_mode=form_post`) with a bridge page
+	// that re-enters the callback with a same-site top-level GET, so that the cookies set before the
+	// login (e.g. SameSite=Lax or Strict) are sent with the callback. Only the parameters of the
+	// authorization response are copied into the URL of the bridge page.
+	//
+	// Optional. Default: false
+	CallbackBridge bool
+
+	// InvitationSender delivers invitations with the token of the invitation link.
+	//
+	// Optional. Default: sends the mailer.Invitation template via the Mailer
+	InvitationSender func(ctx context.Context, inviter adapters.GothUser, invitation adapters.GothInvitation, token string) error
+
+	// AcceptInvitationURL is the absolute URL of the accept invitation handler that is linked
+	// in invitation emails (e.g. "https://example.com/invitations/accept").
+	AcceptInvitationURL string
+
+	// InvitationExpiry is the duration invitations are valid for.
+	//
+	// Optional. Default: 168h
+	InvitationExpiry time.Duration
+
+	// VerificationExpiry is the duration verification tokens are valid for.
+	//
+	// Optional. Default: 24h
+	VerificationExpiry time.Duration
+
+	// StateExpiry is the duration a login can be completed after it has been started. The issue time
+	// is encoded into the state and signed with the Secret, callbacks with an older state are rejected.
+	//
+	// Optional. Default: 10m
+	StateExpiry time.Duration
+
+	// FlowStore stores the short-lived artifacts of the login flows, such as the issued states.
+	// If set, callbacks are only accepted with a state that has been issued for the provider
+	// and has not been used yet. Multi-instance deployments need a shared store (e.g. the gorm adapter or Redis).
+	//
+	// Optional. Default: nil (states are not stored)
+	FlowStore adapters.FlowStore
+
+	// Rand is the source of the randomness of the states, nonces and session tokens, e.g. a deterministic
+	// source in tests (`rand.NewChaCha8(seed)` of math/rand/v2) or an HSM-backed DRBG.
+	//
+	// Optional. Default: crypto/rand.Reader
+	Rand io.Reader
+
+	// SessionTokenGenerator is the function used to generate new session tokens.
+	//
+	// Optional. Default: NewSessionTokenGenerator with the Rand
+	SessionTokenGenerator func() (string, error)
+
+	// VerificationTokens issues and consumes the verification tokens of the handlers.
+	//
+	// Optional. Default: NewVerificationTokens with the SessionTokenGenerator
+	VerificationTokens *VerificationTokens
 }
 
 // ConfigDefault is the default config.
 var ConfigDefault = Config{
-	ErrorHandler:        defaultErrorHandler,
-	BeginAuthHandler:    BeginAuthHandler{},
-	CompleteAuthHandler: CompleteAuthCompleteHandler{},
-	LogoutHandler:       LogoutHandler{},
-	SessionHandler:      SessionHandler{},
-	IndexHandler:        defaultIndexHandler,
-	Encryptor:           EncryptCookie,
-	Decryptor:           DecryptCookie,
-	Expiry:              "7h",
-	CookieName:          "fiber_goth.session",
-	Extractor:           TokenFromCookie("fiber_goth.session"),
-	CookieSameSite:      fasthttp.CookieSameSiteLaxMode,
-	CompletionURL:       "/",
-	LoginURL:            "/login",
-	LogoutURL:           "/logout",
-	CallbackURL:         "/auth",
+	ErrorHandler:          defaultErrorHandler,
+	UnauthenticatedStatus: http.StatusUnauthorized,
+	ForbiddenStatus:       http.StatusForbidden,
+	BeginAuthHandler:      BeginAuthHandler{},
+	CompleteAuthHandler:   CompleteAuthCompleteHandler{},
+	LogoutHandler:         LogoutHandler{},
+	SessionHandler:        SessionHandler{},
+	DiscoveryHandler:      DiscoveryHandler{},
+	ScopeUpgradeHandler:   ScopeUpgradeHandler{},
+	IndexHandler:          defaultIndexHandler,
+	Encryptor:             EncryptCookie,
+	Decryptor:             DecryptCookie,
+	CookieCodec:           DefaultCookieCodec,
+	Expiry:                "7h",
+	CookieName:            "fiber_goth.session",
+	Extractor:             TokenFromCookie("fiber_goth.session"),
+	CookieSameSite:        fasthttp.CookieSameSiteLaxMode,
+	CompletionURL:         "/",
+	LoginURL:              "/login",
+	LogoutURL:             "/logout",
+	WidgetURL:             "/auth/widget",
+	CallbackURL:           "/auth",
+	CallbackURLPattern:    "/auth/:provider/callback",
+	Rand:                  rand.Reader,
+	SessionTokenGenerator: DefaultSessionTokenGenerator,
+	Events:                events.Noop,
+	UserMatcher:           DefaultUserMatcher,
+	VerificationExpiry:    24 * time.Hour,
+	StateExpiry:           10 * time.Minute,
+	InvitationExpiry:      7 * 24 * time.Hour,
+	MailTemplates:         mailer.DefaultTemplates,
+	SecurityNotifications: DefaultSecurityNotifications,
+	SessionBinding: SessionBinding{
+		IPv4PrefixBits: 16,
+		IPv6PrefixBits: 48,
+		Thumbprint:     CertThumbprintFromTLS,
+	},
 }
 
 // default ErrorHandler that process return error from fiber.Handler
 func defaultErrorHandler(_ *fiber.Ctx, err error) error {
-	return NewError(http.StatusBadRequest, err.Error())
-}
-
-// default filter for response that process default return.
-func defaultCompletionFilter(completionURL string) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		return c.Redirect(completionURL, fiber.StatusTemporaryRedirect)
+	var e *Error
+	if errors.As(err, &e) {
+		return fiber.NewError(e.Code, e.Message)
 	}
+
+	return fiber.NewError(http.StatusBadRequest, err.Error())
 }
 
 // default index handler that process default return.
@@ -589,6 +1122,19 @@ func configDefault(config ...Config) Config {
 		cfg.Next = ConfigDefault.Next
 	}
 
+	if _, ok := cfg.Adapter.(*adapters.TimeoutAdapter); cfg.Adapter != nil && !ok {
+		cfg.Adapter = adapters.WithTimeout(cfg.Adapter, cfg.AdapterTimeout)
+	}
+
+	if cfg.Extractor == nil && cfg.TokenHeader != "" {
+		name := cfg.CookieName
+		if name == "" {
+			name = ConfigDefault.CookieName
+		}
+
+		cfg.Extractor = TokenFromCookieOrHeader(name, cfg.TokenHeader)
+	}
+
 	if cfg.Extractor == nil {
 		cfg.Extractor = ConfigDefault.Extractor
 	}
@@ -609,6 +1155,14 @@ func configDefault(config ...Config) Config {
 		cfg.SessionHandler = ConfigDefault.SessionHandler
 	}
 
+	if cfg.DiscoveryHandler == nil {
+		cfg.DiscoveryHandler = ConfigDefault.DiscoveryHandler
+	}
+
+	if cfg.ScopeUpgradeHandler == nil {
+		cfg.ScopeUpgradeHandler = ConfigDefault.ScopeUpgradeHandler
+	}
+
 	if cfg.IndexHandler == nil {
 		cfg.IndexHandler = ConfigDefault.IndexHandler
 	}
@@ -621,6 +1175,10 @@ func configDefault(config ...Config) Config {
 		cfg.Decryptor = ConfigDefault.Decryptor
 	}
 
+	if cfg.CookieCodec == nil {
+		cfg.CookieCodec = ConfigDefault.CookieCodec
+	}
+
 	if cfg.Expiry == "" {
 		cfg.Expiry = ConfigDefault.Expiry
 	}
@@ -633,6 +1191,10 @@ func configDefault(config ...Config) Config {
 		cfg.CookieSameSite = ConfigDefault.CookieSameSite
 	}
 
+	if cfg.CookieSameSite == fasthttp.CookieSameSiteNoneMode {
+		cfg.CookieSecure = true
+	}
+
 	if cfg.LoginURL == "" {
 		cfg.LoginURL = ConfigDefault.LoginURL
 	}
@@ -641,6 +1203,10 @@ func configDefault(config ...Config) Config {
 		cfg.LogoutURL = ConfigDefault.LogoutURL
 	}
 
+	if cfg.WidgetURL == "" {
+		cfg.WidgetURL = ConfigDefault.WidgetURL
+	}
+
 	if cfg.CompletionURL == "" {
 		cfg.CompletionURL = ConfigDefault.CompletionURL
 	}
@@ -649,24 +1215,96 @@ func configDefault(config ...Config) Config {
 		cfg.CallbackURL = ConfigDefault.CallbackURL
 	}
 
+	if cfg.CallbackURLPattern == "" {
+		cfg.CallbackURLPattern = strings.TrimSuffix(cfg.CallbackURL, "/") + "/:" + provider + "/callback"
+	}
+
 	if cfg.ErrorHandler == nil {
 		cfg.ErrorHandler = ConfigDefault.ErrorHandler
 	}
 
+	if cfg.UnauthenticatedStatus == 0 {
+		cfg.UnauthenticatedStatus = ConfigDefault.UnauthenticatedStatus
+	}
+
+	if cfg.ForbiddenStatus == 0 {
+		cfg.ForbiddenStatus = ConfigDefault.ForbiddenStatus
+	}
+
+	if cfg.Rand == nil {
+		cfg.Rand = ConfigDefault.Rand
+	}
+
+	if cfg.SessionTokenGenerator == nil {
+		cfg.SessionTokenGenerator = NewSessionTokenGenerator(cfg.Rand)
+	}
+
+	if cfg.Events == nil {
+		cfg.Events = ConfigDefault.Events
+	}
+
+	if cfg.UserMatcher == nil {
+		cfg.UserMatcher = ConfigDefault.UserMatcher
+	}
+
+	if cfg.SessionBinding.IPv4PrefixBits <= 0 {
+		cfg.SessionBinding.IPv4PrefixBits = ConfigDefault.SessionBinding.IPv4PrefixBits
+	}
+
+	if cfg.SessionBinding.IPv6PrefixBits <= 0 {
+		cfg.SessionBinding.IPv6PrefixBits = ConfigDefault.SessionBinding.IPv6PrefixBits
+	}
+
+	if cfg.SessionBinding.Thumbprint == nil {
+		cfg.SessionBinding.Thumbprint = ConfigDefault.SessionBinding.Thumbprint
+	}
+
+	if cfg.VerificationExpiry <= 0 {
+		cfg.VerificationExpiry = ConfigDefault.VerificationExpiry
+	}
+
+	if cfg.StateExpiry <= 0 {
+		cfg.StateExpiry = ConfigDefault.StateExpiry
+	}
+
+	if cfg.VerificationTokens == nil && cfg.Adapter != nil {
+		cfg.VerificationTokens = NewVerificationTokens(cfg.Adapter, VerificationConfig{Generator: cfg.SessionTokenGenerator})
+	}
+
+	if cfg.MailTemplates == nil {
+		cfg.MailTemplates = mailer.DefaultTemplates
+	}
+
+	if cfg.InvitationExpiry <= 0 {
+		cfg.InvitationExpiry = ConfigDefault.InvitationExpiry
+	}
+
+	if cfg.SecurityNotifications == nil {
+		cfg.SecurityNotifications = ConfigDefault.SecurityNotifications
+	}
+
+	if cfg.VerificationSender == nil && cfg.Mailer != nil {
+		cfg.VerificationSender = cfg.mailVerification()
+	}
+
+	if cfg.InvitationSender == nil && cfg.Mailer != nil {
+		cfg.InvitationSender = cfg.mailInvitation()
+	}
+
 	if cfg.CompletionFilter == nil {
-		cfg.CompletionFilter = defaultCompletionFilter(cfg.CompletionURL)
+		cfg.CompletionFilter = RedirectTo(cfg.CompletionURL, cfg.TrustedOrigins...)
 	}
 
 	return cfg
 }
 
-func stateFromContext(ctx *fiber.Ctx) (string, error) {
+func stateFromContext(ctx *fiber.Ctx, r io.Reader) (string, error) {
 	state := ctx.Query(state)
 	if len(state) > 0 {
 		return state, nil
 	}
 
-	nonce, err := generateRandomString(64)
+	nonce, err := generateRandomString(r, 64)
 	if err != nil {
 		return "", err
 	}
@@ -674,11 +1312,11 @@ func stateFromContext(ctx *fiber.Ctx) (string, error) {
 	return base64.URLEncoding.EncodeToString(nonce), nil
 }
 
-func generateRandomString(n int) ([]byte, error) {
+func generateRandomString(r io.Reader, n int) ([]byte, error) {
 	b := make([]byte, n)
 
 	for i := 0; i < n; i++ {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		num, err := rand.Int(r, big.NewInt(int64(len(charset))))
 		if err != nil {
 			return b, err
 		}
@@ -688,6 +1326,26 @@ func generateRandomString(n int) ([]byte, error) {
 	return b, nil
 }
 
+// DefaultSessionTokenGenerator generates a session token with 256 bits of entropy
+// that is encoded as base64url.
+func DefaultSessionTokenGenerator() (string, error) {
+	return NewSessionTokenGenerator(rand.Reader)()
+}
+
+// NewSessionTokenGenerator returns a generator of session tokens with 256 bits of entropy
+// of the random source that are encoded as base64url.
+func NewSessionTokenGenerator(r io.Reader) func() (string, error) {
+	return func() (string, error) {
+		b := make([]byte, sessionTokenLength)
+
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	}
+}
+
 // TokenFromContext returns the token from the request context.
 func TokenFromContext(c *fiber.Ctx) string {
 	token, ok := c.Locals(tokenKey).(string)
@@ -0,0 +1,72 @@
+package goth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// benchAdapter returns a valid session for every token.
+type benchAdapter struct {
+	adapters.UnimplementedAdapter
+	session adapters.GothSession
+}
+
+func (a *benchAdapter) GetSession(_ context.Context, _ string) (adapters.GothSession, error) {
+	s := a.session
+	s.ExpiresAt = time.Now().Add(7 * time.Hour)
+
+	return s, nil
+}
+
+func (a *benchAdapter) RefreshSession(_ context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	return session, nil
+}
+
+func benchmarkProtectMiddleware(b *testing.B, timeout time.Duration) {
+	adapter := &benchAdapter{session: adapters.GothSession{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		SessionToken: "token",
+	}}
+
+	app := fiber.New()
+	app.Use(NewProtectMiddleware(Config{Adapter: adapter, AdapterTimeout: timeout}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	handler := app.Handler()
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI("/")
+	req.Header.SetCookie(ConfigDefault.CookieName, "token")
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(req, nil, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		handler(ctx)
+
+		if ctx.Response.StatusCode() != fiber.StatusOK {
+			b.Fatalf("unexpected status %d", ctx.Response.StatusCode())
+		}
+		ctx.Response.Reset()
+	}
+}
+
+func BenchmarkProtectMiddleware(b *testing.B) {
+	benchmarkProtectMiddleware(b, 0)
+}
+
+func BenchmarkProtectMiddlewareAdapterTimeout(b *testing.B) {
+	benchmarkProtectMiddleware(b, time.Second)
+}
@@ -0,0 +1,446 @@
+// Package gothctl provides the commands of the gothctl admin tool. Apps can embed
+// the commands in their own CLI to run them against their configured adapter.
+package gothctl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// ErrNoMigrations is returned by the migrate command if no migrations are configured.
+var ErrNoMigrations = errors.New("gothctl: migrations are not configured")
+
+// Config is the configuration of the commands.
+type Config struct {
+	// Adapter opens the adapter the commands operate on.
+	Adapter func(ctx context.Context) (adapters.Adapter, error)
+
+	// Migrate runs the migrations of the adapter.
+	//
+	// Optional. Default: nil (the migrate command fails)
+	Migrate func(ctx context.Context) error
+
+	// Out is the writer of the output.
+	//
+	// Optional. Default: os.Stdout
+	Out io.Writer
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Out: os.Stdout,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	if cfg.Out == nil {
+		cfg.Out = ConfigDefault.Out
+	}
+
+	return cfg
+}
+
+// NewCommand returns the root command with all subcommands.
+func NewCommand(config ...Config) *cobra.Command {
+	cfg := configDefault(config...)
+
+	cmd := &cobra.Command{
+		Use:          "gothctl",
+		Short:        "Administer the users, sessions and providers of goth",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		cfg.migrateCommand(),
+		cfg.sessionsCommand(),
+		cfg.usersCommand(),
+		cfg.secretsCommand(),
+		cfg.providersCommand(),
+		cfg.openAPICommand(),
+	)
+
+	return cmd
+}
+
+func (cfg Config) adapter(ctx context.Context) (adapters.Adapter, error) {
+	if cfg.Adapter == nil {
+		return nil, goth.ErrMissingAdapter
+	}
+
+	return cfg.Adapter(ctx)
+}
+
+func (cfg Config) migrateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "Run the migrations of the adapter",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if cfg.Migrate == nil {
+				return ErrNoMigrations
+			}
+
+			if err := cfg.Migrate(cmd.Context()); err != nil {
+				return err
+			}
+
+			_, err := fmt.Fprintln(cfg.Out, "migrations applied")
+
+			return err
+		},
+	}
+}
+
+func (cfg Config) sessionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List and revoke sessions",
+	}
+
+	cmd.AddCommand(cfg.listSessionsCommand(), cfg.revokeSessionsCommand())
+
+	return cmd
+}
+
+// sessionFlags are the flags selecting sessions.
+type sessionFlags struct {
+	id            string
+	userID        string
+	provider      string
+	ipPrefix      string
+	createdBefore string
+}
+
+func (f *sessionFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.id, "id", "", "ID of the session")
+	cmd.Flags().StringVar(&f.userID, "user", "", "ID of the user")
+	cmd.Flags().StringVar(&f.provider, "provider", "", "ID of the provider the session has been created with")
+	cmd.Flags().StringVar(&f.ipPrefix, "ip-prefix", "", "prefix of the IP address the session has been created from")
+	cmd.Flags().StringVar(&f.createdBefore, "created-before", "", "select sessions created before the time (RFC 3339)")
+}
+
+func (f *sessionFlags) filter() (adapters.SessionFilter, error) {
+	filter := adapters.SessionFilter{Provider: f.provider, IPPrefix: f.ipPrefix}
+
+	var err error
+
+	if filter.ID, err = parseID(f.id); err != nil {
+		return adapters.SessionFilter{}, err
+	}
+
+	if filter.UserID, err = parseID(f.userID); err != nil {
+		return adapters.SessionFilter{}, err
+	}
+
+	if f.createdBefore != "" {
+		t, err := time.Parse(time.RFC3339, f.createdBefore)
+		if err != nil {
+			return adapters.SessionFilter{}, err
+		}
+		filter.CreatedBefore = &t
+	}
+
+	return filter, nil
+}
+
+// parseID parses the ID of a flag. Empty flags are nil.
+func parseID(s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+func (cfg Config) listSessionsCommand() *cobra.Command {
+	var flags sessionFlags
+	var page adapters.Page
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the active sessions",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			filter, err := flags.filter()
+			if err != nil {
+				return err
+			}
+
+			adapter, err := cfg.adapter(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			p, err := adapter.ListSessions(cmd.Context(), filter, page)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cfg.Out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tUSER\tPROVIDER\tIP\tCREATED\tEXPIRES")
+
+			for _, s := range p.Sessions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.UserID, s.Provider, s.IPAddress, s.CreatedAt.Format(time.RFC3339), s.ExpiresAt.Format(time.RFC3339))
+			}
+
+			if p.NextCursor != "" {
+				fmt.Fprintf(w, "\nnext page: --cursor %s\n", p.NextCursor)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().IntVar(&page.Limit, "limit", adapters.DefaultPageLimit, "maximum number of sessions")
+	cmd.Flags().StringVar(&page.Cursor, "cursor", "", "cursor of the page")
+
+	return cmd
+}
+
+func (cfg Config) revokeSessionsCommand() *cobra.Command {
+	var flags sessionFlags
+
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke the sessions matching the flags",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			filter, err := flags.filter()
+			if err != nil {
+				return err
+			}
+
+			if filter.IsEmpty() {
+				return adapters.ErrEmptyFilter
+			}
+
+			adapter, err := cfg.adapter(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			n, err := adapter.DeleteSessionsWhere(cmd.Context(), filter)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(cfg.Out, "%d sessions revoked\n", n)
+
+			return err
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func (cfg Config) usersCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Manage users",
+	}
+
+	var user adapters.GothUser
+	var verified bool
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			adapter, err := cfg.adapter(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if _, err := adapter.GetUserByEmail(cmd.Context(), user.Email); err == nil {
+				return goth.ErrEmailConflict
+			}
+
+			if verified {
+				user.EmailVerified = &verified
+			}
+
+			u, err := adapter.CreateUser(cmd.Context(), user)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(cfg.Out, u.ID)
+
+			return err
+		},
+	}
+
+	create.Flags().StringVar(&user.Email, "email", "", "email of the user")
+	create.Flags().StringVar(&user.Name, "name", "", "name of the user")
+	create.Flags().BoolVar(&verified, "verified", false, "mark the email as verified")
+	_ = create.MarkFlagRequired("email")
+	_ = create.MarkFlagRequired("name")
+
+	var winner, loser string
+
+	merge := &cobra.Command{
+		Use:   "merge",
+		Short: "Merge a duplicate user into another user",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			winnerID, err := uuid.Parse(winner)
+			if err != nil {
+				return err
+			}
+
+			loserID, err := uuid.Parse(loser)
+			if err != nil {
+				return err
+			}
+
+			adapter, err := cfg.adapter(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if err := adapter.MergeUsers(cmd.Context(), winnerID, loserID); err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(cfg.Out, "user %s merged into %s\n", loserID, winnerID)
+
+			return err
+		},
+	}
+
+	merge.Flags().StringVar(&winner, "winner", "", "ID of the user that is kept")
+	merge.Flags().StringVar(&loser, "loser", "", "ID of the user that is merged and deleted")
+	_ = merge.MarkFlagRequired("winner")
+	_ = merge.MarkFlagRequired("loser")
+
+	cmd.AddCommand(create, merge)
+
+	return cmd
+}
+
+func (cfg Config) secretsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Generate secrets",
+	}
+
+	var length int
+
+	generate := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a random secret (e.g. for Config.Secret)",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if length < goth.MinSecretLength {
+				return goth.ErrWeakSecret
+			}
+
+			b := make([]byte, length)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+
+			_, err := fmt.Fprintln(cfg.Out, base64.RawURLEncoding.EncodeToString(b)[:length])
+
+			return err
+		},
+	}
+
+	generate.Flags().IntVar(&length, "length", goth.MinSecretLength, "length of the secret")
+	cmd.AddCommand(generate)
+
+	return cmd
+}
+
+func (cfg Config) providersCommand() *cobra.Command {
+	var check bool
+
+	cmd := &cobra.Command{
+		Use:   "providers",
+		Short: "Inspect the registered providers",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			registered := providers.GetProviders()
+
+			ids := make([]string, 0, len(registered))
+			for id := range registered {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+
+			w := tabwriter.NewWriter(cfg.Out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tTYPE\tSTATUS")
+
+			errs := []error{}
+			for _, id := range ids {
+				p := registered[id]
+				status := "-"
+
+				if c, ok := p.(providers.Checker); ok && check {
+					status = "ok"
+
+					if err := c.Check(cmd.Context()); err != nil {
+						status = err.Error()
+						errs = append(errs, fmt.Errorf("gothctl: provider %s: %w", id, err))
+					}
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, p.Name(), p.Type(), status)
+			}
+
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			return errors.Join(errs...)
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "check the configuration of the providers")
+
+	return cmd
+}
+
+func (cfg Config) openAPICommand() *cobra.Command {
+	var prefix string
+
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Print the OpenAPI spec of the widget API",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			enc := json.NewEncoder(cfg.Out)
+			enc.SetIndent("", "  ")
+
+			return enc.Encode(goth.WidgetOpenAPI(goth.Config{WidgetURL: prefix}))
+		},
+	}
+
+	cmd.Flags().StringVar(&prefix, "prefix", goth.ConfigDefault.WidgetURL, "prefix of the widget API (Config.WidgetURL)")
+
+	return cmd
+}
@@ -0,0 +1,145 @@
+package goth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+// GroupMapper maps the group identifiers supplied by a provider (see GothUser.Groups)
+// to memberships in teams. The memberships are synced on every sign-in.
+type GroupMapper interface {
+	// MapGroups returns the roles of the user by team slug for the groups supplied by the provider.
+	MapGroups(provider string, groups []string) map[string]string
+	// ManagedTeams returns the slugs of the teams whose memberships are managed for the provider.
+	// Memberships in other teams are never removed.
+	ManagedTeams(provider string) []string
+}
+
+// TeamRole is a membership in a team.
+type TeamRole struct {
+	// Slug is the slug of the team.
+	Slug string
+	// Role is the role of the member in the team.
+	Role string
+}
+
+// GroupMapping is a static GroupMapper that maps group identifiers to team memberships.
+// If several groups map to the same team, the role of the first group in the sorted group identifiers wins.
+type GroupMapping struct {
+	// Provider is the provider supplying the groups. An empty provider matches all providers.
+	Provider string
+	// Groups maps group identifiers to team memberships.
+	Groups map[string]TeamRole
+}
+
+var _ GroupMapper = (*GroupMapping)(nil)
+
+// MapGroups returns the roles of the user by team slug.
+func (m GroupMapping) MapGroups(provider string, groups []string) map[string]string {
+	roles := map[string]string{}
+
+	if !m.matches(provider) {
+		return roles
+	}
+
+	sorted := slices.Clone(groups)
+	slices.Sort(sorted)
+
+	for _, g := range sorted {
+		r, ok := m.Groups[g]
+		if !ok {
+			continue
+		}
+
+		if _, exists := roles[r.Slug]; !exists {
+			roles[r.Slug] = r.Role
+		}
+	}
+
+	return roles
+}
+
+// ManagedTeams returns the slugs of all mapped teams.
+func (m GroupMapping) ManagedTeams(provider string) []string {
+	slugs := []string{}
+
+	if !m.matches(provider) {
+		return slugs
+	}
+
+	for _, r := range m.Groups {
+		if !slices.Contains(slugs, r.Slug) {
+			slugs = append(slugs, r.Slug)
+		}
+	}
+	slices.Sort(slugs)
+
+	return slugs
+}
+
+func (m GroupMapping) matches(provider string) bool {
+	return m.Provider == "" || m.Provider == provider
+}
+
+// syncTeams adds and removes the memberships of the user in the teams managed by the group mapper.
+// Users without groups supplied by the provider (nil) are not synced.
+//
+// nolint:gocyclo
+func (cfg Config) syncTeams(ctx context.Context, provider string, user adapters.GothUser) error {
+	if cfg.GroupMapper == nil || user.Groups == nil {
+		return nil
+	}
+
+	roles := cfg.GroupMapper.MapGroups(provider, user.Groups)
+
+	teams, err := cfg.Adapter.ListUserTeams(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	members := map[uuid.UUID]bool{}
+	for _, t := range teams {
+		members[t.ID] = true
+	}
+
+	for _, slug := range cfg.GroupMapper.ManagedTeams(provider) {
+		team, err := cfg.Adapter.GetTeamBySlug(ctx, slug)
+		if err != nil {
+			log.Warnw("failed to sync team of group mapping", "team", slug, "error", err)
+			continue
+		}
+
+		role, ok := roles[slug]
+
+		switch {
+		case ok:
+			if err := cfg.Adapter.AddTeamMember(ctx, team.ID, user.ID, role); err != nil {
+				return err
+			}
+
+			if !members[team.ID] {
+				cfg.emitTeamEvent(ctx, events.TeamMemberAdded, user.ID, provider, team, role)
+			}
+		case members[team.ID]:
+			if err := cfg.Adapter.RemoveTeamMember(ctx, team.ID, user.ID); err != nil {
+				return err
+			}
+
+			cfg.emitTeamEvent(ctx, events.TeamMemberRemoved, user.ID, provider, team, "")
+		}
+	}
+
+	return nil
+}
+
+func (cfg Config) emitTeamEvent(ctx context.Context, t events.Type, userID uuid.UUID, provider string, team adapters.GothTeam, role string) {
+	e := events.New(t, userID)
+	e.Provider = provider
+	e.Data = map[string]any{"team_id": team.ID, "team": team.Slug, "role": role}
+	cfg.Events.Emit(ctx, e)
+}
@@ -0,0 +1,110 @@
+package goth
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SecurityHeadersConfig is the configuration of the security headers of the auth pages
+// (e.g. the login and error pages of the pages package).
+type SecurityHeadersConfig struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// FormActions are the origins the forms of the pages may submit to (and be redirected to) in addition
+	// to 'self', e.g. the identity providers that receive form posts (e.g. "https://idp.example.com").
+	//
+	// Optional. Default: ""
+	FormActions []string
+
+	// FrameAncestors are the origins allowed to embed the pages in frames.
+	//
+	// Optional. Default: 'none'
+	FrameAncestors []string
+
+	// ReferrerPolicy is the Referrer-Policy of the pages, so that the codes and states
+	// in the URLs of the auth pages are not sent to other origins.
+	//
+	// Optional. Default: "no-referrer"
+	ReferrerPolicy string
+
+	// ContentSecurityPolicy replaces the policy derived from the config, e.g. for pages with scripts.
+	//
+	// Optional. Default: ""
+	ContentSecurityPolicy string
+}
+
+// SecurityHeadersConfigDefault is the default security headers config.
+var SecurityHeadersConfigDefault = SecurityHeadersConfig{
+	FrameAncestors: []string{"'none'"},
+	ReferrerPolicy: "no-referrer",
+}
+
+// Helper function to set default values
+func securityHeadersConfigDefault(config ...SecurityHeadersConfig) SecurityHeadersConfig {
+	if len(config) < 1 {
+		return SecurityHeadersConfigDefault
+	}
+
+	cfg := config[0]
+
+	if len(cfg.FrameAncestors) == 0 {
+		cfg.FrameAncestors = SecurityHeadersConfigDefault.FrameAncestors
+	}
+
+	if cfg.ReferrerPolicy == "" {
+		cfg.ReferrerPolicy = SecurityHeadersConfigDefault.ReferrerPolicy
+	}
+
+	return cfg
+}
+
+// Policy returns the Content-Security-Policy of the config. The pages may load styles and images
+// of their own origin, scripts, plugins and other resources are not allowed.
+func (cfg SecurityHeadersConfig) Policy() string {
+	if cfg.ContentSecurityPolicy != "" {
+		return cfg.ContentSecurityPolicy
+	}
+
+	cfg = securityHeadersConfigDefault(cfg)
+
+	return strings.Join([]string{
+		"default-src 'none'",
+		"style-src 'self'",
+		"img-src 'self'",
+		"base-uri 'none'",
+		strings.Join(append([]string{"form-action", "'self'"}, cfg.FormActions...), " "),
+		strings.Join(append([]string{"frame-ancestors"}, cfg.FrameAncestors...), " "),
+	}, "; ")
+}
+
+// Apply sets the security headers of the config on the response, e.g. of a custom auth page.
+func (cfg SecurityHeadersConfig) Apply(c *fiber.Ctx) {
+	cfg = securityHeadersConfigDefault(cfg)
+
+	c.Set(fiber.HeaderContentSecurityPolicy, cfg.Policy())
+	c.Set(fiber.HeaderReferrerPolicy, cfg.ReferrerPolicy)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// legacy browsers ignore frame-ancestors
+	if len(cfg.FrameAncestors) == 1 && cfg.FrameAncestors[0] == "'none'" {
+		c.Set(fiber.HeaderXFrameOptions, "DENY")
+	}
+}
+
+// NewSecurityHeadersMiddleware returns a middleware that sets the security headers
+// (Content-Security-Policy, Referrer-Policy, X-Content-Type-Options) on the responses of the auth pages.
+func NewSecurityHeadersMiddleware(config ...SecurityHeadersConfig) fiber.Handler {
+	cfg := securityHeadersConfigDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		cfg.Apply(c)
+
+		return c.Next()
+	}
+}
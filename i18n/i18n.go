@@ -0,0 +1,219 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/language"
+)
+
+// The contextKey type is unexported to prevent collisions with context keys defined in
+// other packages.
+type contextKey int
+
+const (
+	localeKey contextKey = iota
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Bundle is a catalog of translated messages. Messages are format strings for fmt.Sprintf.
+type Bundle struct {
+	fallback language.Tag
+	tags     []language.Tag
+	messages map[language.Tag]map[string]string
+	matcher  language.Matcher
+	mu       sync.RWMutex
+}
+
+// NewBundle creates a new empty bundle with the language that is used
+// if no other language matches.
+func NewBundle(fallback language.Tag) *Bundle {
+	b := &Bundle{
+		fallback: fallback,
+		messages: map[language.Tag]map[string]string{},
+	}
+	b.AddMessages(fallback, map[string]string{})
+
+	return b
+}
+
+// NewDefaultBundle creates a bundle with the built-in translations (English and German)
+// of the pages and emails shipped with the package.
+func NewDefaultBundle() *Bundle {
+	b := NewBundle(language.English)
+
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, e := range entries {
+		data, err := locales.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		if err := b.AddJSON(language.MustParse(strings.TrimSuffix(e.Name(), ".json")), data); err != nil {
+			panic(err)
+		}
+	}
+
+	return b
+}
+
+// DefaultBundle is the bundle with the built-in translations.
+var DefaultBundle = NewDefaultBundle()
+
+// AddMessages adds or replaces the messages of the language.
+func (b *Bundle) AddMessages(tag language.Tag, messages map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := b.messages[tag]
+	if !ok {
+		m = map[string]string{}
+		b.messages[tag] = m
+
+		b.tags = append(b.tags, tag)
+		b.matcher = language.NewMatcher(b.tags)
+	}
+
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// AddJSON adds the messages of the language from a flat JSON object.
+func (b *Bundle) AddJSON(tag language.Tag, data []byte) error {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("i18n: failed to parse messages for %s: %w", tag, err)
+	}
+
+	b.AddMessages(tag, messages)
+
+	return nil
+}
+
+// Match returns the best supported language for the preferences,
+// which are language tags or `Accept-Language` header values in order of priority.
+func (b *Bundle) Match(preferences ...string) language.Tag {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, p := range preferences {
+		if p == "" {
+			continue
+		}
+
+		tags, _, err := language.ParseAcceptLanguage(p)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+
+		tag, _, confidence := b.matcher.Match(tags...)
+		if confidence > language.No {
+			return b.supported(tag)
+		}
+	}
+
+	return b.fallback
+}
+
+// supported maps the matched tag (which may carry extensions) to the registered tag.
+func (b *Bundle) supported(tag language.Tag) language.Tag {
+	for _, t := range b.tags {
+		if t == tag {
+			return t
+		}
+	}
+
+	base, _ := tag.Base()
+	for _, t := range b.tags {
+		if tb, _ := t.Base(); tb == base {
+			return t
+		}
+	}
+
+	return b.fallback
+}
+
+// Translate returns the formatted message of the language. It falls back to the
+// base language, the fallback language and at last the key.
+func (b *Bundle) Translate(tag language.Tag, key string, args ...any) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	msg, ok := b.lookup(tag, key)
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
+
+func (b *Bundle) lookup(tag language.Tag, key string) (string, bool) {
+	for t := tag; ; t = t.Parent() {
+		if msg, ok := b.messages[t][key]; ok {
+			return msg, true
+		}
+
+		if t.IsRoot() {
+			break
+		}
+	}
+
+	msg, ok := b.messages[b.fallback][key]
+
+	return msg, ok
+}
+
+// WithLocale returns a context that carries the language.
+func WithLocale(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, localeKey, tag)
+}
+
+// FromContext returns the language of the context. This works with the context
+// of fiber (c.Context()) after the middleware has negotiated the language.
+func FromContext(ctx context.Context) (language.Tag, bool) {
+	tag, ok := ctx.Value(localeKey).(language.Tag)
+
+	return tag, ok
+}
+
+// LocaleFromContext returns the negotiated language of the request.
+func LocaleFromContext(c *fiber.Ctx) language.Tag {
+	tag, ok := c.Locals(localeKey).(language.Tag)
+	if !ok {
+		return DefaultBundle.fallback
+	}
+
+	return tag
+}
+
+// Locale returns the negotiated language of the request, or negotiates it from the
+// `Accept-Language` header if the middleware is not mounted.
+func (b *Bundle) Locale(c *fiber.Ctx) language.Tag {
+	if tag, ok := c.Locals(localeKey).(language.Tag); ok {
+		return tag
+	}
+
+	return b.Match(c.Get(fiber.HeaderAcceptLanguage))
+}
+
+// T translates the message into the negotiated language of the request.
+func T(c *fiber.Ctx, key string, args ...any) string {
+	return DefaultBundle.Translate(LocaleFromContext(c), key, args...)
+}
@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for the i18n middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Bundle is the catalog of translations.
+	//
+	// Optional. Default: DefaultBundle
+	Bundle *Bundle
+
+	// UserLocale returns the locale preference of the signed-in user (e.g. goth.UserLocale).
+	// If not set only the request is used to negotiate the language.
+	UserLocale func(c *fiber.Ctx) string
+
+	// QueryParam is the query parameter to explicitly select a language.
+	//
+	// Optional. Default: "lang"
+	QueryParam string
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	Bundle:     DefaultBundle,
+	QueryParam: "lang",
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	if cfg.Bundle == nil {
+		cfg.Bundle = ConfigDefault.Bundle
+	}
+
+	if cfg.QueryParam == "" {
+		cfg.QueryParam = ConfigDefault.QueryParam
+	}
+
+	return cfg
+}
+
+// New creates a middleware that negotiates the language of the request. The query parameter
+// takes precedence over the locale of the user and the `Accept-Language` header.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		var locale string
+		if cfg.UserLocale != nil {
+			locale = cfg.UserLocale(c)
+		}
+
+		tag := cfg.Bundle.Match(c.Query(cfg.QueryParam), locale, c.Get(fiber.HeaderAcceptLanguage))
+		c.Locals(localeKey, tag)
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,21 @@
+// Package secure provides constant-time comparisons of tokens, hashes and MACs,
+// so that the time of a comparison does not reveal how many bytes matched.
+package secure
+
+import "crypto/subtle"
+
+// Equal returns true if both strings are equal. The time only depends on the length of the strings,
+// which is not secret for tokens of a fixed length. Empty strings are never equal, so that a missing
+// token does not match a missing value.
+func Equal(a, b string) bool {
+	return EqualBytes([]byte(a), []byte(b))
+}
+
+// EqualBytes returns true if both byte slices are equal and not empty, like Equal.
+func EqualBytes(a, b []byte) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
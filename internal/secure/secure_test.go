@@ -0,0 +1,51 @@
+package secure
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"equal", "token", "token", true},
+		{"different", "token", "tokem", false},
+		{"prefix", "token", "tok", false},
+		{"case", "token", "Token", false},
+		{"empty", "", "", false},
+		{"empty left", "", "token", false},
+		{"empty right", "token", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []byte
+		want bool
+	}{
+		{"equal", []byte{1, 2, 3}, []byte{1, 2, 3}, true},
+		{"different", []byte{1, 2, 3}, []byte{1, 2, 4}, false},
+		{"length", []byte{1, 2, 3}, []byte{1, 2}, false},
+		{"nil", nil, nil, false},
+		{"empty", []byte{}, []byte{}, false},
+		{"nil left", nil, []byte{1}, false},
+		{"empty right", []byte{1}, []byte{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EqualBytes(tt.a, tt.b); got != tt.want {
+				t.Errorf("EqualBytes(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package secure
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// root is the root of the module relative to the package.
+const root = "../.."
+
+// secretName matches the names of values that must only be compared with Equal or EqualBytes.
+var secretName = regexp.MustCompile(`(?i)(token|mac|secret|hash|signature|thumbprint|nonce)$`)
+
+// comparers are the functions that must only be called by this package.
+var comparers = map[string]bool{
+	"bytes.Equal":                true,
+	"hmac.Equal":                 true,
+	"subtle.ConstantTimeCompare": true,
+}
+
+// allowed are the calls of comparers that do not compare secrets.
+var allowed = map[string]bool{
+	"mfa/webauthn.go:bytes.Equal": true, // public credential IDs
+}
+
+// users are the files whose token and MAC comparisons must use this package.
+var users = []string{
+	"adapters/adapter.go",    // csrf tokens
+	"flow.go",                // states
+	"binding.go",             // session bindings
+	"mfa/device.go",          // trusted device MACs
+	"providers/siwe/siwe.go", // nonces
+}
+
+func TestUsage(t *testing.T) {
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if strings.HasPrefix(rel, "internal/secure/") {
+			return nil
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.BinaryExpr:
+				if (x.Op == token.EQL || x.Op == token.NEQ) && !isZero(x.X) && !isZero(x.Y) &&
+					(secretName.MatchString(nameOf(x.X)) || secretName.MatchString(nameOf(x.Y))) {
+					t.Errorf("%s: compare %s and %s with secure.Equal", fset.Position(x.Pos()), nameOf(x.X), nameOf(x.Y))
+				}
+			case *ast.CallExpr:
+				if fn := qualifiedName(x.Fun); comparers[fn] && !allowed[rel+":"+fn] {
+					t.Errorf("%s: use secure.Equal or secure.EqualBytes instead of %s", fset.Position(x.Pos()), fn)
+				}
+			}
+
+			return true
+		})
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range users {
+		b, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(b), "secure.Equal") {
+			t.Errorf("%s: does not compare with secure.Equal or secure.EqualBytes", file)
+		}
+	}
+}
+
+// nameOf returns the name of the identifier, field or function of the expression.
+func nameOf(e ast.Expr) string {
+	switch x := e.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		return x.Sel.Name
+	case *ast.CallExpr:
+		return nameOf(x.Fun)
+	}
+
+	return ""
+}
+
+// qualifiedName returns the package qualified name of a function (e.g. "bytes.Equal").
+func qualifiedName(e ast.Expr) string {
+	s, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	pkg, ok := s.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+
+	return pkg.Name + "." + s.Sel.Name
+}
+
+// isZero reports whether the expression is an empty string or nil, which is not a secret.
+func isZero(e ast.Expr) bool {
+	switch x := e.(type) {
+	case *ast.BasicLit:
+		return x.Value == `""`
+	case *ast.Ident:
+		return x.Name == "nil"
+	}
+
+	return false
+}
@@ -0,0 +1,223 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/mailer"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+)
+
+var (
+	_ GothHandler = (*InviteHandler)(nil)
+	_ GothHandler = (*AcceptInvitationHandler)(nil)
+)
+
+var (
+	// ErrInvalidInvitation is thrown if an invitation does not exist, has expired or has already been accepted.
+	ErrInvalidInvitation = NewError(http.StatusBadRequest, "invalid or expired invitation")
+	// ErrMissingInvitationSender is thrown if no invitation sender is configured.
+	ErrMissingInvitationSender = NewError(http.StatusNotImplemented, "missing invitation sender")
+	// ErrNotTeamMember is thrown if a user invites into a team the user is not a member of.
+	ErrNotTeamMember = NewError(http.StatusForbidden, "user is not a member of the team")
+)
+
+// DefaultInvitationRole is the role of invited users in the team if the invitation has no role.
+const DefaultInvitationRole = "member"
+
+// invitationCookie returns the name of the cookie carrying the invitation token through the login.
+func (cfg Config) invitationCookie() string {
+	return cfg.CookieName + ".invitation"
+}
+
+// InviteHandler is the default handler to invite an email, optionally into a team of the current user.
+// The invitation is sent via the InvitationSender.
+type InviteHandler struct{}
+
+// NewInviteHandler returns a new default invite handler.
+// The handler must be mounted behind the protect middleware.
+func NewInviteHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return InviteHandler{}.New(cfg)
+}
+
+// New creates a new handler to invite an email.
+//
+// nolint:gocyclo
+func (InviteHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		if cfg.InvitationSender == nil {
+			return cfg.handleError(c, "InviteHandler", ErrMissingInvitationSender)
+		}
+
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "InviteHandler", ErrMissingUser)
+		}
+
+		params := ParamsFromContext(c)
+
+		email := strings.TrimSpace(params.Get("email"))
+		if _, ok := EmailDomain(email); !ok {
+			return cfg.handleError(c, "InviteHandler", ErrMissingEmail)
+		}
+
+		invitation := adapters.GothInvitation{
+			Email:       email,
+			InvitedByID: user.ID,
+			ExpiresAt:   time.Now().Add(cfg.InvitationExpiry),
+		}
+
+		if slug := strings.TrimSpace(params.Get("team")); utilx.NotEmpty(slug) {
+			team, err := cfg.memberTeam(c.Context(), user.ID, slug)
+			if err != nil {
+				return cfg.handleError(c, "InviteHandler", err)
+			}
+
+			invitation.TeamID = &team.ID
+			invitation.Role = strings.TrimSpace(params.Get("role"))
+
+			if utilx.Empty(invitation.Role) {
+				invitation.Role = DefaultInvitationRole
+			}
+		}
+
+		token, err := cfg.SessionTokenGenerator()
+		if err != nil {
+			return cfg.handleError(c, "InviteHandler", err)
+		}
+		invitation.Token = adapters.HashToken(token)
+
+		invitation, err = cfg.Adapter.CreateInvitation(c.Context(), invitation)
+		if err != nil {
+			return cfg.handleError(c, "InviteHandler", err)
+		}
+
+		err = cfg.InvitationSender(c.Context(), user, invitation, token)
+		if err != nil {
+			return cfg.handleError(c, "InviteHandler", err)
+		}
+
+		e := events.New(events.InvitationCreated, user.ID)
+		e.Data = map[string]any{"invitation_id": invitation.ID, "team_id": invitation.TeamID}
+		cfg.Events.Emit(c.Context(), e)
+
+		return c.Status(fiber.StatusCreated).JSON(invitation)
+	}
+}
+
+// AcceptInvitationHandler is the default handler of the invitation link. It remembers the invitation
+// in a cookie and redirects to the LoginURL. The next sign-in with a provider that verified
+// the invited email creates the user (even if sign-up is disabled) and accepts the invitation.
+type AcceptInvitationHandler struct{}
+
+// NewAcceptInvitationHandler returns a new default accept invitation handler.
+func NewAcceptInvitationHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return AcceptInvitationHandler{}.New(cfg)
+}
+
+// New creates a new handler to accept an invitation.
+func (AcceptInvitationHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		token := ParamsFromContext(c).Get("token")
+		if utilx.Empty(token) {
+			return cfg.handleError(c, "AcceptInvitationHandler", ErrInvalidInvitation)
+		}
+
+		invitation, err := cfg.Adapter.GetInvitationByToken(c.Context(), adapters.HashToken(token))
+		if err != nil || !invitation.IsPending() {
+			return cfg.handleError(c, "AcceptInvitationHandler", ErrInvalidInvitation)
+		}
+
+		cfg.writeFlowCookie(c, cfg.invitationCookie(), token, invitation.ExpiresAt)
+
+		return c.Redirect(cfg.LoginURL, fiber.StatusTemporaryRedirect)
+	}
+}
+
+// invitation returns the pending invitation of the token if the provider verified the invited email.
+// Invitations that do not match the profile are ignored.
+func (cfg Config) invitation(ctx context.Context, token string, profile adapters.GothUser) *adapters.GothInvitation {
+	if utilx.Empty(token) {
+		return nil
+	}
+
+	invitation, err := cfg.Adapter.GetInvitationByToken(ctx, adapters.HashToken(token))
+	if err != nil || !invitation.IsPending() {
+		return nil
+	}
+
+	if !cast.Value(profile.EmailVerified) || !strings.EqualFold(strings.TrimSpace(profile.Email), invitation.Email) {
+		return nil
+	}
+
+	return &invitation
+}
+
+// acceptInvitation accepts the invitation for the user and adds the user to the team of the invitation.
+func (cfg Config) acceptInvitation(ctx context.Context, provider string, invitation *adapters.GothInvitation, user adapters.GothUser) error {
+	accepted, err := cfg.Adapter.AcceptInvitation(ctx, invitation.ID, user.ID)
+	if err != nil {
+		return ErrInvalidInvitation
+	}
+
+	e := events.New(events.InvitationAccepted, user.ID)
+	e.Provider = provider
+	e.Data = map[string]any{"invitation_id": accepted.ID, "team_id": accepted.TeamID}
+	cfg.Events.Emit(ctx, e)
+
+	if accepted.TeamID == nil {
+		return nil
+	}
+
+	team, err := cfg.Adapter.GetTeam(ctx, *accepted.TeamID)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Adapter.AddTeamMember(ctx, team.ID, user.ID, accepted.Role); err != nil {
+		return err
+	}
+
+	cfg.emitTeamEvent(ctx, events.TeamMemberAdded, user.ID, provider, team, accepted.Role)
+
+	return nil
+}
+
+// mailInvitation returns an InvitationSender that sends the invitation template
+// with a link to the AcceptInvitationURL via the Mailer.
+func (cfg Config) mailInvitation() func(ctx context.Context, inviter adapters.GothUser, invitation adapters.GothInvitation, token string) error {
+	return func(ctx context.Context, inviter adapters.GothUser, invitation adapters.GothInvitation, token string) error {
+		link := cfg.AcceptInvitationURL
+		if link != "" {
+			link += "?" + url.Values{"token": {token}}.Encode()
+		}
+
+		return mailer.Send(ctx, cfg.Mailer, cfg.MailTemplates, mailer.Invitation, invitation.Email, mailer.Data{
+			AppName:   cfg.AppName,
+			Email:     invitation.Email,
+			InvitedBy: inviter.Name,
+			URL:       link,
+			Token:     token,
+			ExpiresAt: invitation.ExpiresAt,
+		})
+	}
+}
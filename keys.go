@@ -0,0 +1,102 @@
+package goth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// MinSecretEntropy is the minimum estimated entropy of a secret in bits.
+const MinSecretEntropy = 96
+
+// keyLength is the length of the generated and derived keys.
+const keyLength = 32
+
+// ErrLowEntropySecret is returned if a secret is too short or too predictable to derive keys from.
+var ErrLowEntropySecret = fmt.Errorf("goth: secret has less than %d bits of estimated entropy", MinSecretEntropy)
+
+// Key is the key material of a single purpose. It is never printed.
+type Key []byte
+
+// String returns a redacted placeholder, so that keys don't end up in logs.
+func (k Key) String() string {
+	return "[redacted]"
+}
+
+// GoString returns a redacted placeholder for %#v.
+func (k Key) GoString() string {
+	return k.String()
+}
+
+// Base64 returns the key as standard base64, e.g. for the key of EncryptCookie.
+func (k Key) Base64() string {
+	return base64.StdEncoding.EncodeToString(k)
+}
+
+// Keys are the keys derived from a master secret, one per purpose.
+type Keys struct {
+	// Encryption is the AES-256 key of the cookie encryption.
+	Encryption Key
+	// Signing is the HMAC key of the states and other signed values.
+	Signing Key
+	// CSRF is the HMAC key of the CSRF tokens.
+	CSRF Key
+}
+
+// labels of the derived keys, changing them rotates the keys.
+const (
+	encryptionKeyLabel = "fiber-goth/v1/cookie-encryption"
+	signingKeyLabel    = "fiber-goth/v1/signing"
+	csrfKeyLabel       = "fiber-goth/v1/csrf"
+)
+
+// NewKey generates a new random key of 256 bits, e.g. a master secret.
+func NewKey() (Key, error) {
+	k := make(Key, keyLength)
+
+	if _, err := io.ReadFull(rand.Reader, k); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// DeriveKeys derives separate keys for the cookie encryption, signing and CSRF from the master secret
+// with HKDF-SHA256, so that a single secret can be configured without reusing it across purposes.
+// It returns ErrLowEntropySecret if the secret fails CheckSecret.
+func DeriveKeys(secret []byte) (Keys, error) {
+	if err := CheckSecret(secret); err != nil {
+		return Keys{}, err
+	}
+
+	return Keys{
+		Encryption: deriveKey(secret, encryptionKeyLabel),
+		Signing:    deriveKey(secret, signingKeyLabel),
+		CSRF:       deriveKey(secret, csrfKeyLabel),
+	}, nil
+}
+
+// deriveKey derives the key of the purpose from the secret.
+func deriveKey(secret []byte, label string) Key {
+	k := make(Key, keyLength)
+
+	// reading the 32 bytes from HKDF-SHA256 cannot fail
+	_, _ = io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(label)), k)
+
+	return k
+}
+
+// CheckSecret returns ErrLowEntropySecret if the secret is shorter than MinSecretLength or its
+// estimated entropy (see TokenEntropy) is below MinSecretEntropy,
+// e.g. for repeated or dictionary-like secrets. The estimate cannot detect all weak secrets.
+func CheckSecret(secret []byte) error {
+	if len(secret) < MinSecretLength || TokenEntropy(string(secret)) < MinSecretEntropy {
+		return ErrLowEntropySecret
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package goth
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+var _ GothHandler = (*LinkedAccountsHandler)(nil)
+
+// TokenHealth is the health of the provider token of a linked account.
+type TokenHealth string
+
+const (
+	// TokenHealthy is a valid token, or an expired token that can be refreshed.
+	TokenHealthy TokenHealth = "healthy"
+	// TokenExpiring is a token that expires soon and cannot be refreshed.
+	TokenExpiring TokenHealth = "expiring"
+	// TokenExpired is an expired token that cannot be refreshed.
+	TokenExpired TokenHealth = "expired"
+	// TokenFailing is a token whose last refresh failed, e.g. because the provider was unavailable.
+	TokenFailing TokenHealth = "failing"
+	// TokenRevoked is a token that has been cleared because the provider rejected the refresh token.
+	TokenRevoked TokenHealth = "revoked"
+)
+
+// tokenExpiringWindow is the duration before the expiry a token that cannot be refreshed is expiring.
+const tokenExpiringWindow = 24 * time.Hour
+
+// LinkedAccount is the status of an account linked to a provider. It never contains the tokens.
+type LinkedAccount struct {
+	// Provider is the ID of the provider.
+	Provider string `json:"provider"`
+	// Type is the type of the account.
+	Type adapters.AccountType `json:"type"`
+	// ProviderAccountID is the ID of the account in the provider.
+	ProviderAccountID string `json:"provider_account_id"`
+	// Scopes are the granted scopes.
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is the expiry time of the access token.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Refreshable is true if the token can be refreshed with a refresh token.
+	Refreshable bool `json:"refreshable"`
+	// Health is the health of the token.
+	Health TokenHealth `json:"health"`
+	// ReconnectRequired is true if the user has to sign in with the provider again to restore the access.
+	ReconnectRequired bool `json:"reconnect_required"`
+	// LastRefreshedAt is the time the token has last been refreshed.
+	LastRefreshedAt *time.Time `json:"last_refreshed_at,omitempty"`
+	// LastRefreshError is the error of the last failed refresh.
+	LastRefreshError string `json:"last_refresh_error,omitempty"`
+	// LinkedAt is the time the account has been linked.
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+// NewLinkedAccount returns the status of the linked account.
+func NewLinkedAccount(account adapters.GothAccount) LinkedAccount {
+	health := tokenHealth(account, time.Now())
+
+	linked := LinkedAccount{
+		Provider:          account.Provider,
+		Type:              account.Type,
+		ProviderAccountID: cast.Value(account.ProviderAccountID),
+		Scopes:            providers.ParseScopes(cast.Value(account.Scope)),
+		Refreshable:       cast.Value(account.RefreshToken) != "",
+		Health:            health,
+		ReconnectRequired: health == TokenExpired || health == TokenRevoked,
+		LastRefreshedAt:   account.LastRefreshedAt,
+		LastRefreshError:  account.LastRefreshError,
+		LinkedAt:          account.CreatedAt,
+	}
+
+	if expiry := cast.Value(account.ExpiresAt); !expiry.IsZero() {
+		linked.ExpiresAt = &expiry
+	}
+
+	return linked
+}
+
+// tokenHealth returns the health of the token of the account.
+func tokenHealth(account adapters.GothAccount, now time.Time) TokenHealth {
+	refreshable := cast.Value(account.RefreshToken) != ""
+	expiry := cast.Value(account.ExpiresAt)
+
+	switch {
+	case cast.Value(account.AccessToken) == "" && !refreshable:
+		return TokenRevoked
+	case account.LastRefreshError != "":
+		return TokenFailing
+	case refreshable || expiry.IsZero() || expiry.After(now.Add(tokenExpiringWindow)):
+		return TokenHealthy
+	case expiry.After(now):
+		return TokenExpiring
+	default:
+		return TokenExpired
+	}
+}
+
+// LinkedAccountsHandler is the default handler that returns the status of the accounts of the current user
+// that are linked to OAuth2 and OpenID Connect providers, so that apps can prompt to reconnect a provider.
+type LinkedAccountsHandler struct{}
+
+// NewLinkedAccountsHandler returns a new default linked accounts handler.
+// The handler must be mounted behind the protect middleware.
+func NewLinkedAccountsHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return LinkedAccountsHandler{}.New(cfg)
+}
+
+// New creates a new handler to return the linked accounts.
+func (LinkedAccountsHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "LinkedAccountsHandler", ErrMissingUser)
+		}
+
+		accounts := []LinkedAccount{}
+
+		for _, account := range user.Accounts {
+			if account.Type != adapters.AccountTypeOAuth2 && account.Type != adapters.AccountTypeOIDC {
+				continue
+			}
+
+			accounts = append(accounts, NewLinkedAccount(account))
+		}
+
+		return c.JSON(accounts)
+	}
+}
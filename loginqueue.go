@@ -0,0 +1,286 @@
+package goth
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// ErrLoginQueueFull is returned when the login queue has reached its threshold or is not running.
+var ErrLoginQueueFull = NewError(http.StatusServiceUnavailable, "too many logins, please retry later")
+
+// LoginQueueConfig is the configuration of the login queue.
+type LoginQueueConfig struct {
+	// Workers is the number of sessions created concurrently.
+	//
+	// Optional. Default: 8
+	Workers int
+
+	// MaxPending is the number of concurrent logins beyond which callbacks are
+	// rejected with ErrLoginQueueFull.
+	//
+	// Optional. Default: 256
+	MaxPending int
+
+	// RetryAfter is the duration clients are asked to wait (Retry-After) before they retry a rejected login.
+	//
+	// Optional. Default: 5s
+	RetryAfter time.Duration
+}
+
+// LoginQueueConfigDefault is the default login queue config.
+var LoginQueueConfigDefault = LoginQueueConfig{
+	Workers:    8,
+	MaxPending: 256,
+	RetryAfter: 5 * time.Second,
+}
+
+// LoginQueue creates the sessions of completed logins with a bounded pool of workers, so that bursts of
+// logins (e.g. after a cache flush) do not overload the adapter with writes. Every callback reserves a slot
+// with TryAcquire before the state and the code are used. Beyond MaxPending reserved slots the callbacks
+// are rejected with 503 and a Retry-After header, so that the same callback can be retried. Logins are
+// also rejected until Start is called and once the queue has been stopped.
+type LoginQueue struct {
+	cfg     LoginQueueConfig
+	jobs    chan *loginJob
+	pending atomic.Int64
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	done    chan struct{}
+
+	mu      sync.Mutex
+	running bool
+}
+
+// The states of a queued session creation.
+const (
+	loginQueued int32 = iota
+	loginTaken
+	loginAbandoned
+)
+
+// loginJob is a queued session creation.
+type loginJob struct {
+	ctx     context.Context
+	adapter adapters.Adapter
+	session adapters.GothSession
+	result  chan loginResult
+	state   atomic.Int32
+}
+
+// loginResult is the result of a queued session creation.
+type loginResult struct {
+	session adapters.GothSession
+	err     error
+}
+
+// NewLoginQueue creates a new login queue.
+func NewLoginQueue(config LoginQueueConfig) *LoginQueue {
+	cfg := config
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = LoginQueueConfigDefault.Workers
+	}
+
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = LoginQueueConfigDefault.MaxPending
+	}
+
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = LoginQueueConfigDefault.RetryAfter
+	}
+
+	return &LoginQueue{
+		cfg:  cfg,
+		jobs: make(chan *loginJob, cfg.MaxPending),
+		done: make(chan struct{}),
+	}
+}
+
+// Start starts the workers until the context is done, Stop or Shutdown is called.
+// Once the workers have stopped, the queued logins are rejected.
+func (q *LoginQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.cancel != nil {
+		return
+	}
+
+	ctx, q.cancel = context.WithCancel(ctx)
+	q.running = true
+	onShutdownStop(q.Stop)
+
+	for range q.cfg.Workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-q.jobs:
+					q.run(job)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		q.mu.Lock()
+		q.running = false
+		q.mu.Unlock()
+
+		q.wg.Wait()
+		q.drain()
+		close(q.done)
+	}()
+}
+
+// take takes the job from the queue. It returns false and releases the slot held by the job
+// if the caller has abandoned the login.
+func (q *LoginQueue) take(job *loginJob) bool {
+	if !job.state.CompareAndSwap(loginQueued, loginTaken) {
+		q.Release()
+		return false
+	}
+
+	return true
+}
+
+// run creates the session of the job, unless the login has been abandoned.
+func (q *LoginQueue) run(job *loginJob) {
+	if !q.take(job) {
+		return
+	}
+
+	if err := job.ctx.Err(); err != nil {
+		job.result <- loginResult{err: err}
+		return
+	}
+
+	session, err := job.adapter.CreateSession(job.ctx, job.session)
+	job.result <- loginResult{session: session, err: err}
+}
+
+// drain rejects the queued logins.
+func (q *LoginQueue) drain() {
+	for {
+		select {
+		case job := <-q.jobs:
+			if q.take(job) {
+				job.result <- loginResult{err: ErrLoginQueueFull}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Full reports whether MaxPending slots are reserved or the queue is not running.
+func (q *LoginQueue) Full() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.full()
+}
+
+// full reports whether the queue is full, the caller must hold the lock.
+func (q *LoginQueue) full() bool {
+	return !q.running || q.pending.Load() >= int64(q.cfg.MaxPending)
+}
+
+// Pending returns the number of reserved slots.
+func (q *LoginQueue) Pending() int {
+	return int(q.pending.Load())
+}
+
+// TryAcquire reserves a slot for a login. It returns false if the queue is full. The slot has to be
+// released with Release once the login has completed.
+func (q *LoginQueue) TryAcquire() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.full() {
+		return false
+	}
+
+	q.pending.Add(1)
+
+	return true
+}
+
+// Release releases a slot reserved with TryAcquire.
+func (q *LoginQueue) Release() {
+	q.pending.Add(-1)
+}
+
+// CreateSession queues the creation of the session with the adapter and waits for the result or
+// the end of the context. The caller must hold a slot reserved with TryAcquire. If the context ends
+// before a worker has taken the job, the session is not created. It returns ErrLoginQueueFull if the
+// queue has been stopped.
+func (q *LoginQueue) CreateSession(ctx context.Context, adapter adapters.Adapter, session adapters.GothSession) (adapters.GothSession, error) {
+	job := &loginJob{ctx: ctx, adapter: adapter, session: session, result: make(chan loginResult, 1)}
+
+	q.mu.Lock()
+	if !q.running {
+		q.mu.Unlock()
+		return adapters.GothSession{}, ErrLoginQueueFull
+	}
+
+	// every queued job belongs to a reserved slot, so the queue never blocks
+	q.jobs <- job
+	q.mu.Unlock()
+
+	select {
+	case res := <-job.result:
+		return res.session, res.err
+	case <-ctx.Done():
+		// the abandoned job keeps a slot until a worker has dropped it
+		if job.state.CompareAndSwap(loginQueued, loginAbandoned) {
+			q.pending.Add(1)
+		}
+
+		return adapters.GothSession{}, ctx.Err()
+	}
+}
+
+// Stop stops the workers. The running session creations are completed, queued logins are rejected.
+func (q *LoginQueue) Stop() {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-q.done
+}
+
+// createSession creates the session with the LoginQueue, if configured, or the adapter.
+func (cfg Config) createSession(ctx context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	if cfg.LoginQueue == nil {
+		return cfg.Adapter.CreateSession(ctx, session)
+	}
+
+	return cfg.LoginQueue.CreateSession(ctx, cfg.Adapter, session)
+}
+
+// loginQueueFull rejects the callback with ErrLoginQueueFull and the Retry-After of the LoginQueue.
+func (cfg Config) loginQueueFull(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(cfg.LoginQueue.cfg.RetryAfter.Seconds()))))
+
+	return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrLoginQueueFull)
+}
@@ -0,0 +1,67 @@
+package goth
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/mailer"
+)
+
+// mailVerification returns a VerificationSender that sends the verification template
+// with a link to the ConfirmEmailURL via the Mailer.
+func (cfg Config) mailVerification() func(ctx context.Context, identifier, email, token string) error {
+	return func(ctx context.Context, _ string, email, token string) error {
+		link := cfg.ConfirmEmailURL
+		if link != "" {
+			link += "?" + url.Values{"email": {email}, "token": {token}}.Encode()
+		}
+
+		return mailer.Send(ctx, cfg.Mailer, cfg.MailTemplates, mailer.Verification, email, mailer.Data{
+			AppName:   cfg.AppName,
+			Email:     email,
+			URL:       link,
+			Token:     token,
+			ExpiresAt: time.Now().Add(cfg.VerificationExpiry),
+		})
+	}
+}
+
+// isNewDevice returns true if a new device alert should be sent for the sign-in, which
+// is the case if the user is not new and has no active session from the IP address.
+func (cfg Config) isNewDevice(c *fiber.Ctx, user adapters.GothUser, start time.Time) bool {
+	if cfg.Mailer == nil || !cfg.NewDeviceAlert || !user.CreatedAt.Before(start) {
+		return false
+	}
+
+	ip := cfg.Privacy.ClientIP(c)
+	if ip == "" {
+		return false
+	}
+
+	n, err := cfg.Adapter.CountActiveSessions(c.Context(), adapters.SessionFilter{UserID: &user.ID, IPPrefix: ip})
+	if err != nil {
+		log.Errorw("failed to count active sessions", "error", err)
+		return false
+	}
+
+	return n == 0
+}
+
+// notifyNewDevice sends the new device alert. Errors are logged and do not fail the sign-in.
+func (cfg Config) notifyNewDevice(c *fiber.Ctx, user adapters.GothUser) {
+	err := mailer.Send(c.Context(), cfg.Mailer, cfg.MailTemplates, mailer.NewDeviceAlert, user.Email, mailer.Data{
+		AppName:   cfg.AppName,
+		Name:      user.Name,
+		Email:     user.Email,
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Time:      time.Now(),
+	})
+	if err != nil {
+		log.Errorw("failed to send new device alert", "error", err)
+	}
+}
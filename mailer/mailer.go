@@ -0,0 +1,322 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/i18n"
+	"golang.org/x/text/language"
+)
+
+// ErrUnknownTemplate is returned if a template is not registered.
+var ErrUnknownTemplate = errors.New("mailer: unknown template")
+
+// Message is an email.
+type Message struct {
+	// From is the sender. Mailers use their default sender if empty.
+	From string
+	// To are the recipients.
+	To []string
+	// Subject is the subject.
+	Subject string
+	// Text is the plain text body.
+	Text string
+	// HTML is the HTML body.
+	HTML string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	// Send sends the message.
+	Send(ctx context.Context, msg Message) error
+}
+
+// MailerFunc is a function that implements Mailer.
+type MailerFunc func(ctx context.Context, msg Message) error
+
+// Send sends the message.
+func (f MailerFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Template is the name of an email template.
+type Template string
+
+const (
+	// MagicLink is the email with a link to sign in without a password.
+	MagicLink Template = "magic_link"
+	// Verification is the email with a link to verify an email address.
+	Verification Template = "verification"
+	// PasswordReset is the email with a link to reset the password.
+	PasswordReset Template = "password_reset"
+	// NewDeviceAlert is the email that notifies the user about a sign-in from a new device.
+	NewDeviceAlert Template = "new_device_alert"
+	// Invitation is the email with a link to accept an invitation.
+	Invitation Template = "invitation"
+	// SecurityAlert is the email that notifies the user about a security event as soon as it occurs.
+	SecurityAlert Template = "security_alert"
+	// SecurityDigest is the email that summarizes the security events since the last digest.
+	SecurityDigest Template = "security_digest"
+)
+
+// Event is a security event in a SecurityAlert or SecurityDigest email.
+// The type is translated with the key `security_event.<type>`.
+type Event struct {
+	// Type is the type of the event (e.g. "user.password_changed").
+	Type string
+	// Provider is the provider the event relates to.
+	Provider string
+	// IPAddress is the IP address of the client that caused the event.
+	IPAddress string
+	// Time is the time the event occurred.
+	Time time.Time
+}
+
+// Data is the data the templates are executed with.
+// Messages are translated with the T method, e.g. `{{ .T "verification.subject" .AppName }}`.
+type Data struct {
+	// AppName is the name of the application.
+	AppName string
+	// Name is the name of the recipient.
+	Name string
+	// Email is the email of the recipient.
+	Email string
+	// URL is the link of the email (e.g. the verification or magic link).
+	URL string
+	// Token is the raw token, for flows that require to enter it manually.
+	Token string
+	// InvitedBy is the name of the user that sent an invitation.
+	InvitedBy string
+	// ExpiresAt is the expiry time of the link.
+	ExpiresAt time.Time
+	// IPAddress is the IP address of the client (e.g. of a new sign-in).
+	IPAddress string
+	// UserAgent is the user agent of the client (e.g. of a new sign-in).
+	UserAgent string
+	// Time is the time of the event.
+	Time time.Time
+	// Events are the security events (security alert and digest).
+	Events []Event
+	// Locale is the language of the email (e.g. "de"). If empty the language
+	// of the context or the fallback language of the bundle is used.
+	Locale string
+
+	bundle *i18n.Bundle
+	tag    language.Tag
+}
+
+// T translates the message into the language of the email.
+func (d Data) T(key string, args ...any) string {
+	if d.bundle == nil {
+		return key
+	}
+
+	return d.bundle.Translate(d.tag, key, args...)
+}
+
+// Renderer renders the emails of the templates.
+type Renderer interface {
+	// Render renders the template for the recipient.
+	Render(name Template, to string, data Data) (Message, error)
+}
+
+//go:embed templates
+var templatesFS embed.FS
+
+type template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+var (
+	_ Renderer = (*Templates)(nil)
+	_ Renderer = (*ViewsRenderer)(nil)
+)
+
+// Templates renders the emails of the templates. The templates translate messages
+// of the bundle with the `T` function, e.g. `{{ T "verification.subject" .AppName }}`.
+type Templates struct {
+	templates map[Template]template
+	bundle    *i18n.Bundle
+}
+
+// Opt is a function that configures the templates.
+type Opt func(*Templates)
+
+// WithBundle sets the bundle of the translations.
+func WithBundle(b *i18n.Bundle) Opt {
+	return func(t *Templates) {
+		t.bundle = b
+	}
+}
+
+// NewTemplates returns the built-in templates. Each template consists of a
+// `<name>.subject.tmpl`, `<name>.txt.tmpl` and `<name>.html.tmpl` file.
+func NewTemplates(opts ...Opt) *Templates {
+	t := &Templates{templates: map[Template]template{}, bundle: i18n.DefaultBundle}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	for _, name := range []Template{MagicLink, Verification, PasswordReset, NewDeviceAlert, Invitation, SecurityAlert, SecurityDigest} {
+		subject, _ := templatesFS.ReadFile("templates/" + string(name) + ".subject.tmpl")
+		text, _ := templatesFS.ReadFile("templates/" + string(name) + ".txt.tmpl")
+		html, _ := templatesFS.ReadFile("templates/" + string(name) + ".html.tmpl")
+
+		if err := t.Register(name, string(subject), string(text), string(html)); err != nil {
+			panic(err)
+		}
+	}
+
+	return t
+}
+
+// DefaultTemplates are the built-in templates.
+var DefaultTemplates = NewTemplates()
+
+// Register adds or replaces a template. The html body is optional.
+func (t *Templates) Register(name Template, subject, text, html string) error {
+	var tmpl template
+	var err error
+
+	funcs := funcs(t.bundle, language.Und)
+
+	tmpl.subject, err = texttemplate.New(string(name) + ".subject").Funcs(funcs).Parse(strings.TrimSpace(subject))
+	if err != nil {
+		return err
+	}
+
+	tmpl.text, err = texttemplate.New(string(name) + ".txt").Funcs(funcs).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	if html != "" {
+		tmpl.html, err = htmltemplate.New(string(name) + ".html").Funcs(funcs).Parse(html)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.templates[name] = tmpl
+
+	return nil
+}
+
+// Render renders the template for the recipient.
+func (t *Templates) Render(name Template, to string, data Data) (Message, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	msg := Message{To: []string{to}}
+
+	data.bundle = t.bundle
+	data.tag = t.bundle.Match(data.Locale)
+	funcs := funcs(data.bundle, data.tag)
+
+	var b bytes.Buffer
+
+	if err := texttemplate.Must(tmpl.subject.Clone()).Funcs(funcs).Execute(&b, data); err != nil {
+		return Message{}, err
+	}
+	msg.Subject = b.String()
+	b.Reset()
+
+	if err := texttemplate.Must(tmpl.text.Clone()).Funcs(funcs).Execute(&b, data); err != nil {
+		return Message{}, err
+	}
+	msg.Text = b.String()
+	b.Reset()
+
+	if tmpl.html != nil {
+		if err := htmltemplate.Must(tmpl.html.Clone()).Funcs(funcs).Execute(&b, data); err != nil {
+			return Message{}, err
+		}
+		msg.HTML = b.String()
+	}
+
+	return msg, nil
+}
+
+func funcs(b *i18n.Bundle, tag language.Tag) map[string]any {
+	return map[string]any{
+		"T": func(key string, args ...any) string {
+			return b.Translate(tag, key, args...)
+		},
+	}
+}
+
+// Send renders the template and sends it with the mailer.
+func Send(ctx context.Context, m Mailer, t Renderer, name Template, to string, data Data) error {
+	if t == nil {
+		t = DefaultTemplates
+	}
+
+	if tag, ok := i18n.FromContext(ctx); ok && data.Locale == "" {
+		data.Locale = tag.String()
+	}
+
+	msg, err := t.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+
+	return m.Send(ctx, msg)
+}
+
+// ViewsRenderer renders the emails with a fiber.Views template engine
+// (e.g. github.com/gofiber/template/html). Each email consists of the
+// `<name>.subject`, `<name>.txt` and `<name>.html` templates, which are executed with Data.
+type ViewsRenderer struct {
+	views  fiber.Views
+	bundle *i18n.Bundle
+}
+
+// NewViewsRenderer creates a renderer with the template engine. The bundle
+// translates the messages of the T method and defaults to i18n.DefaultBundle.
+func NewViewsRenderer(views fiber.Views, bundle ...*i18n.Bundle) *ViewsRenderer {
+	r := &ViewsRenderer{views: views, bundle: i18n.DefaultBundle}
+
+	if len(bundle) > 0 && bundle[0] != nil {
+		r.bundle = bundle[0]
+	}
+
+	return r
+}
+
+// Render renders the templates for the recipient.
+func (r *ViewsRenderer) Render(name Template, to string, data Data) (Message, error) {
+	data.bundle = r.bundle
+	data.tag = r.bundle.Match(data.Locale)
+
+	parts := make([]string, 3)
+
+	for i, ext := range []string{"subject", "txt", "html"} {
+		var b bytes.Buffer
+
+		if err := r.views.Render(&b, string(name)+"."+ext, data); err != nil {
+			return Message{}, err
+		}
+
+		parts[i] = b.String()
+	}
+
+	return Message{
+		To:      []string{to},
+		Subject: strings.TrimSpace(parts[0]),
+		Text:    parts[1],
+		HTML:    parts[2],
+	}, nil
+}
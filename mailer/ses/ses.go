@@ -0,0 +1,80 @@
+package ses
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/zeiss/fiber-goth/mailer"
+)
+
+// Client is the subset of the SES client used by the mailer.
+type Client interface {
+	SendEmail(ctx context.Context, params *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+}
+
+var _ mailer.Mailer = (*Mailer)(nil)
+
+// Mailer sends emails via Amazon SES.
+type Mailer struct {
+	client Client
+	from   string
+	config string
+}
+
+// Opt is a function that configures the mailer.
+type Opt func(*Mailer)
+
+// WithConfigurationSet sets the configuration set (e.g. to track deliveries).
+func WithConfigurationSet(name string) Opt {
+	return func(m *Mailer) {
+		m.config = name
+	}
+}
+
+// New creates a new SES mailer with the default sender.
+func New(client Client, from string, opts ...Opt) *Mailer {
+	m := &Mailer{client: client, from: from}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Send sends the message.
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	from := msg.From
+	if from == "" {
+		from = m.from
+	}
+
+	body := &types.Body{
+		Text: &types.Content{Data: aws.String(msg.Text), Charset: aws.String("UTF-8")},
+	}
+
+	if msg.HTML != "" {
+		body.Html = &types.Content{Data: aws.String(msg.HTML), Charset: aws.String("UTF-8")}
+	}
+
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(from),
+		Destination:      &types.Destination{ToAddresses: msg.To},
+		Content: &types.EmailContent{
+			Simple: &types.Message{
+				Subject: &types.Content{Data: aws.String(msg.Subject), Charset: aws.String("UTF-8")},
+				Body:    body,
+			},
+		},
+	}
+
+	if m.config != "" {
+		input.ConfigurationSetName = aws.String(m.config)
+	}
+
+	_, err := m.client.SendEmail(ctx, input)
+
+	return err
+}
@@ -0,0 +1,164 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/zeiss/fiber-goth/mailer"
+)
+
+var _ mailer.Mailer = (*Mailer)(nil)
+
+// Mailer sends emails via SMTP.
+type Mailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// Opt is a function that configures the mailer.
+type Opt func(*Mailer)
+
+// WithAuth sets the authentication (e.g. smtp.PlainAuth).
+func WithAuth(auth smtp.Auth) Opt {
+	return func(m *Mailer) {
+		m.auth = auth
+	}
+}
+
+// New creates a new SMTP mailer for the server address (e.g. "smtp.example.com:587")
+// and the default sender.
+func New(addr, from string, opts ...Opt) *Mailer {
+	m := &Mailer{addr: addr, from: from}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Send sends the message. STARTTLS is used if the server supports it.
+func (m *Mailer) Send(ctx context.Context, msg mailer.Message) error {
+	from := msg.From
+	if from == "" {
+		from = m.from
+	}
+
+	body, err := encode(from, msg)
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- smtp.SendMail(m.addr, m.auth, address(from), addresses(msg.To), body)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errc:
+		return err
+	}
+}
+
+// encode builds the MIME message with a plain text and an optional HTML part.
+func encode(from string, msg mailer.Message) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		return b.Bytes(), writeQuoted(&b, msg.Text)
+	}
+
+	boundary, err := boundary()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		if err := writeQuoted(&b, part.body); err != nil {
+			return nil, err
+		}
+		b.WriteString("\r\n")
+	}
+
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return b.Bytes(), nil
+}
+
+func writeQuoted(b *bytes.Buffer, s string) error {
+	w := quotedprintable.NewWriter(b)
+
+	if _, err := w.Write([]byte(s)); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+func boundary() (string, error) {
+	r := make([]byte, 16)
+
+	if _, err := rand.Read(r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(r), nil
+}
+
+// address extracts the address from an address with display name (e.g. "Goth <goth@example.com>").
+func address(s string) string {
+	if i := strings.LastIndex(s, "<"); i >= 0 {
+		return strings.TrimSuffix(s[i+1:], ">")
+	}
+
+	return strings.TrimSpace(s)
+}
+
+func addresses(to []string) []string {
+	res := make([]string, 0, len(to))
+	for _, s := range to {
+		res = append(res, address(s))
+	}
+
+	return res
+}
+
+// Host returns the host of the server address, e.g. for smtp.PlainAuth.
+func Host(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
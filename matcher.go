@@ -0,0 +1,103 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/pkg/cast"
+)
+
+// ErrEmailConflict is thrown if a new user would take the email of an existing user that was not matched.
+var ErrEmailConflict = NewError(http.StatusConflict, "a user with this email already exists")
+
+// UserMatcher finds the existing user of a user authenticated by a provider.
+// The user passed to the matcher carries the account of the provider in Accounts.
+type UserMatcher interface {
+	// MatchUser returns the existing user or ErrMissingUser if there is none.
+	MatchUser(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error)
+}
+
+// UserMatcherFunc is a function that implements UserMatcher.
+type UserMatcherFunc func(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error)
+
+// MatchUser calls the function.
+func (f UserMatcherFunc) MatchUser(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error) {
+	return f(ctx, adapter, user)
+}
+
+// MatchByAccount matches the user that has already signed in with the same account of the provider.
+func MatchByAccount() UserMatcher {
+	return UserMatcherFunc(func(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error) {
+		for _, account := range user.Accounts {
+			if cast.Value(account.ProviderAccountID) == "" {
+				continue
+			}
+
+			if u, err := adapter.GetUserByAccount(ctx, account.Provider, cast.Value(account.ProviderAccountID)); err == nil {
+				return u, nil
+			}
+		}
+
+		return adapters.GothUser{}, ErrMissingUser
+	})
+}
+
+// MatchByEmail matches the user with the same email, regardless of whether the provider verified it.
+// Only use it with providers that do not allow users to claim arbitrary emails.
+func MatchByEmail() UserMatcher {
+	return UserMatcherFunc(func(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error) {
+		if strings.TrimSpace(user.Email) == "" {
+			return adapters.GothUser{}, ErrMissingUser
+		}
+
+		return adapter.GetUserByEmail(ctx, user.Email)
+	})
+}
+
+// MatchByVerifiedEmail matches the user with the same email if the provider verified the email.
+func MatchByVerifiedEmail() UserMatcher {
+	return UserMatcherFunc(func(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error) {
+		if !cast.Value(user.EmailVerified) {
+			return adapters.GothUser{}, ErrMissingUser
+		}
+
+		return MatchByEmail().MatchUser(ctx, adapter, user)
+	})
+}
+
+// MatchByClaim matches the user returned by the lookup for the value of a claim of the user,
+// e.g. an employee ID. Users with an empty claim are not matched.
+func MatchByClaim(claim func(user adapters.GothUser) string, lookup func(ctx context.Context, adapter adapters.Adapter, value string) (adapters.GothUser, error)) UserMatcher {
+	return UserMatcherFunc(func(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error) {
+		value := claim(user)
+		if value == "" {
+			return adapters.GothUser{}, ErrMissingUser
+		}
+
+		return lookup(ctx, adapter, value)
+	})
+}
+
+// MatchFirst returns the user of the first matcher that matches.
+func MatchFirst(matchers ...UserMatcher) UserMatcher {
+	return UserMatcherFunc(func(ctx context.Context, adapter adapters.Adapter, user adapters.GothUser) (adapters.GothUser, error) {
+		for _, m := range matchers {
+			u, err := m.MatchUser(ctx, adapter, user)
+			if err == nil {
+				return u, nil
+			}
+
+			if !errors.Is(err, ErrMissingUser) {
+				return adapters.GothUser{}, err
+			}
+		}
+
+		return adapters.GothUser{}, ErrMissingUser
+	})
+}
+
+// DefaultUserMatcher matches users by the account of the provider and then by the email, if the provider verified it.
+var DefaultUserMatcher = MatchFirst(MatchByAccount(), MatchByVerifiedEmail())
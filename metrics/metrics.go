@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/prometheus/client_golang/prometheus"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// DefaultInterval is the default interval between updates of the metrics.
+const DefaultInterval = time.Minute
+
+// Collector periodically queries the adapter and reports the number of active
+// sessions, the users created within the last day and the active sessions by provider.
+// The adapter is not queried on scrape, so scraping is cheap.
+type Collector struct {
+	adapter   adapters.Adapter
+	interval  time.Duration
+	providers []string
+
+	activeSessions   prometheus.Gauge
+	usersCreated     prometheus.Gauge
+	sessionsProvider *prometheus.GaugeVec
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+}
+
+// Opt is a function that configures the collector.
+type Opt func(*Collector)
+
+// WithInterval sets the interval between updates of the metrics.
+func WithInterval(interval time.Duration) Opt {
+	return func(c *Collector) {
+		c.interval = interval
+	}
+}
+
+// WithProviders sets the provider IDs to report sessions for.
+// Defaults to all registered providers.
+func WithProviders(ids ...string) Opt {
+	return func(c *Collector) {
+		c.providers = ids
+	}
+}
+
+// NewCollector creates a new collector. It has to be registered with
+// prometheus.Register and started with Start.
+func NewCollector(adapter adapters.Adapter, opts ...Opt) *Collector {
+	c := &Collector{
+		adapter:  adapter,
+		interval: DefaultInterval,
+		activeSessions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "goth",
+			Name:      "active_sessions",
+			Help:      "Number of sessions that have not expired.",
+		}),
+		usersCreated: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "goth",
+			Name:      "users_created_24h",
+			Help:      "Number of users created within the last 24 hours.",
+		}),
+		sessionsProvider: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "goth",
+			Name:      "active_sessions_by_provider",
+			Help:      "Number of sessions that have not expired by provider.",
+		}, []string{"provider"}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.providers == nil {
+		for id := range providers.GetProviders() {
+			c.providers = append(c.providers, id)
+		}
+	}
+
+	return c
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.activeSessions.Describe(ch)
+	c.usersCreated.Describe(ch)
+	c.sessionsProvider.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.activeSessions.Collect(ch)
+	c.usersCreated.Collect(ch)
+	c.sessionsProvider.Collect(ch)
+}
+
+// Start starts updating the metrics in the background until the context is done, Stop or goth.Shutdown is called.
+func (c *Collector) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	goth.OnShutdown(func(context.Context) error {
+		c.Stop()
+		return nil
+	})
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.Update(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops updating the metrics.
+func (c *Collector) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	c.wg.Wait()
+}
+
+// Update queries the adapter and updates the metrics once.
+func (c *Collector) Update(ctx context.Context) {
+	n, err := c.adapter.CountActiveSessions(ctx, adapters.SessionFilter{})
+	if err != nil {
+		log.Errorw("failed to count active sessions", "error", err)
+	} else {
+		c.activeSessions.Set(float64(n))
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+
+	n, err = c.adapter.CountUsers(ctx, adapters.UserFilter{CreatedAfter: &since})
+	if err != nil {
+		log.Errorw("failed to count created users", "error", err)
+	} else {
+		c.usersCreated.Set(float64(n))
+	}
+
+	for _, p := range c.providers {
+		n, err := c.adapter.CountActiveSessions(ctx, adapters.SessionFilter{Provider: p})
+		if err != nil {
+			log.Errorw("failed to count active sessions", "provider", p, "error", err)
+			continue
+		}
+
+		c.sessionsProvider.WithLabelValues(p).Set(float64(n))
+	}
+}
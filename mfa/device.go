@@ -0,0 +1,185 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/internal/secure"
+)
+
+// ErrInvalidDeviceCookie is returned if the trusted device cookie is malformed or has an invalid signature.
+var ErrInvalidDeviceCookie = errors.New("mfa: invalid trusted device cookie")
+
+// deviceCookieLength is the length of the device ID, user ID and expiry.
+const deviceCookieLength = 16 + 16 + 8
+
+// trustsDevices returns true if trusted devices are enabled.
+func (cfg Config) trustsDevices() bool {
+	return cfg.TrustedDeviceExpiry > 0 && cfg.TrustedDeviceSecret != ""
+}
+
+// trustDevice creates a trusted device for the user and sets the signed device cookie.
+func (cfg Config) trustDevice(c *fiber.Ctx, userID uuid.UUID) error {
+	device, err := cfg.Adapter.CreateTrustedDevice(c.Context(), adapters.GothTrustedDevice{
+		UserID:    userID,
+		UserAgent: cfg.Privacy.UserAgent(c),
+		IPAddress: cfg.Privacy.ClientIP(c),
+		ExpiresAt: time.Now().Add(cfg.TrustedDeviceExpiry),
+	})
+	if err != nil {
+		return err
+	}
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(cfg.TrustedDeviceCookieName)
+	cookie.SetValue(cfg.signDevice(device))
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(c.Protocol() == "https")
+	cookie.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+	cookie.SetExpire(device.ExpiresAt)
+	cookie.SetPath("/")
+
+	c.Response().Header.SetCookie(cookie)
+
+	return nil
+}
+
+// isTrustedDevice returns true if the request carries a valid device cookie of the user
+// that has not been revoked.
+func (cfg Config) isTrustedDevice(c *fiber.Ctx, userID uuid.UUID) bool {
+	if !cfg.trustsDevices() {
+		return false
+	}
+
+	value := c.Cookies(cfg.TrustedDeviceCookieName)
+	if value == "" {
+		return false
+	}
+
+	id, uid, expires, err := cfg.verifyDevice(value)
+	if err != nil || uid != userID || time.Now().After(expires) {
+		return false
+	}
+
+	device, err := cfg.Adapter.GetTrustedDevice(c.Context(), id)
+	if err != nil {
+		return false
+	}
+
+	return device.UserID == userID && device.IsValid()
+}
+
+// signDevice encodes the device as `base64url(id | user id | expiry) "." base64url(hmac)`.
+func (cfg Config) signDevice(device adapters.GothTrustedDevice) string {
+	b := make([]byte, 0, deviceCookieLength)
+	b = append(b, device.ID[:]...)
+	b = append(b, device.UserID[:]...)
+	b = binary.BigEndian.AppendUint64(b, uint64(device.ExpiresAt.Unix()))
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(cfg.deviceMAC(payload))
+}
+
+// verifyDevice verifies the signature of the device cookie and decodes it.
+func (cfg Config) verifyDevice(value string) (uuid.UUID, uuid.UUID, time.Time, error) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return uuid.Nil, uuid.Nil, time.Time{}, ErrInvalidDeviceCookie
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !secure.EqualBytes(mac, cfg.deviceMAC(payload)) {
+		return uuid.Nil, uuid.Nil, time.Time{}, ErrInvalidDeviceCookie
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || len(b) != deviceCookieLength {
+		return uuid.Nil, uuid.Nil, time.Time{}, ErrInvalidDeviceCookie
+	}
+
+	id, _ := uuid.FromBytes(b[:16])
+	userID, _ := uuid.FromBytes(b[16:32])
+	expires := time.Unix(int64(binary.BigEndian.Uint64(b[32:])), 0)
+
+	return id, userID, expires, nil
+}
+
+func (cfg Config) deviceMAC(payload string) []byte {
+	h := hmac.New(sha256.New, []byte(cfg.TrustedDeviceSecret))
+	h.Write([]byte(payload))
+
+	return h.Sum(nil)
+}
+
+// NewListTrustedDevicesHandler returns a handler that lists the trusted devices of the current user.
+func NewListTrustedDevicesHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, err := goth.SessionFromContext(c)
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrMissingSession)
+		}
+
+		devices, err := cfg.Adapter.ListTrustedDevices(c.Context(), session.UserID)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(devices)
+	}
+}
+
+// NewRevokeTrustedDevicesHandler returns a handler that revokes the trusted device with the
+// `id` route parameter of the current user, or all trusted devices if the parameter is absent.
+func NewRevokeTrustedDevicesHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, err := goth.SessionFromContext(c)
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrMissingSession)
+		}
+
+		if c.Params("id") == "" {
+			_, err := cfg.Adapter.DeleteTrustedDevices(c.Context(), session.UserID)
+			if err != nil {
+				return cfg.ErrorHandler(c, err)
+			}
+
+			cfg.Events.Emit(c.Context(), events.New(events.TrustedDevicesRevoked, session.UserID))
+
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		err = cfg.Adapter.DeleteTrustedDevice(c.Context(), session.UserID, id)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		event := events.New(events.TrustedDevicesRevoked, session.UserID)
+		event.Data = map[string]any{"device_id": id}
+		cfg.Events.Emit(c.Context(), event)
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -0,0 +1,194 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+var (
+	// ErrMFARequired is thrown if the route requires a verified second factor.
+	ErrMFARequired = goth.NewError(http.StatusForbidden, "second factor required")
+	// ErrNoCredentials is thrown if the user has no second factor registered.
+	ErrNoCredentials = goth.NewError(http.StatusBadRequest, "no second factor registered")
+	// ErrInvalidChallenge is thrown if the challenge is unknown or expired.
+	ErrInvalidChallenge = goth.NewError(http.StatusBadRequest, "invalid or expired challenge")
+	// ErrInvalidCredential is thrown if the credential could not be verified.
+	ErrInvalidCredential = goth.NewError(http.StatusUnauthorized, "invalid credential")
+)
+
+// Config defines the config for the MFA middleware and handlers.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// Adapter is the adapter used to store the session and the credentials.
+	Adapter adapters.Adapter
+
+	// WebAuthn is the relying party used to register and verify security keys and passkeys.
+	WebAuthn *webauthn.WebAuthn
+
+	// UserVerification is the user verification requirement of the authenticator.
+	//
+	// Optional. Default: protocol.VerificationPreferred
+	UserVerification protocol.UserVerificationRequirement
+
+	// ChallengeTimeout is the duration a challenge is valid for.
+	//
+	// Optional. Default: 5m
+	ChallengeTimeout time.Duration
+
+	// ChallengeURL is the URL to redirect to if a second factor is required.
+	// If empty the ErrorHandler is called with ErrMFARequired.
+	ChallengeURL string
+
+	// TrustedDeviceExpiry is the lifetime of the trusted device cookie. If set, a successful
+	// challenge with the `remember` parameter trusts the browser and skips the second factor on it.
+	//
+	// Optional. Default: 0 (disabled)
+	TrustedDeviceExpiry time.Duration
+
+	// TrustedDeviceSecret is the key used to sign the trusted device cookie.
+	// Devices are not trusted without a secret.
+	TrustedDeviceSecret string
+
+	// TrustedDeviceCookieName is the name of the trusted device cookie.
+	//
+	// Optional. Default: "fiber_goth.device"
+	TrustedDeviceCookieName string
+
+	// Privacy controls the IP address and user agent stored on trusted devices,
+	// e.g. the goth.Config.Privacy of the app.
+	//
+	// Optional. Default: goth.Privacy{} (stored unchanged)
+	Privacy goth.Privacy
+
+	// Throttler limits the failed verifications of a second factor per user, e.g. the throttler of the
+	// credentials provider. Verifications of a throttled user are rejected with goth.ErrThrottled.
+	//
+	// Optional. Default: nil (no throttling)
+	Throttler *goth.Throttler
+
+	// TrustedOrigins is a list of origins that are allowed as absolute redirect targets.
+	TrustedOrigins []string
+
+	// ErrorHandler is executed when an error is returned from fiber.Handler.
+	//
+	// Optional. Default: DefaultErrorHandler
+	ErrorHandler fiber.ErrorHandler
+
+	// Events is the emitter for audit events.
+	//
+	// Optional. Default: events.Noop
+	Events events.Emitter
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	UserVerification:        protocol.VerificationPreferred,
+	ChallengeTimeout:        5 * time.Minute,
+	TrustedDeviceCookieName: "fiber_goth.device",
+	ErrorHandler:            defaultErrorHandler,
+	Events:                  events.Noop,
+}
+
+// default ErrorHandler that process return error from fiber.Handler
+func defaultErrorHandler(_ *fiber.Ctx, err error) error {
+	return err
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	if cfg.UserVerification == "" {
+		cfg.UserVerification = ConfigDefault.UserVerification
+	}
+
+	if cfg.ChallengeTimeout <= 0 {
+		cfg.ChallengeTimeout = ConfigDefault.ChallengeTimeout
+	}
+
+	if cfg.TrustedDeviceCookieName == "" {
+		cfg.TrustedDeviceCookieName = ConfigDefault.TrustedDeviceCookieName
+	}
+
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = ConfigDefault.ErrorHandler
+	}
+
+	if cfg.Events == nil {
+		cfg.Events = ConfigDefault.Events
+	}
+
+	return cfg
+}
+
+// New creates a middleware that requires a verified second factor for the session.
+// It must be mounted behind the protect middleware, e.g. on a route group.
+func New(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		session, err := goth.SessionFromContext(c)
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrMissingSession)
+		}
+
+		if session.IsMFAVerified() {
+			return c.Next()
+		}
+
+		if cfg.isTrustedDevice(c, session.UserID) {
+			_, err := MarkVerified(c.Context(), cfg.Adapter, session)
+			if err != nil {
+				return cfg.ErrorHandler(c, err)
+			}
+
+			return c.Next()
+		}
+
+		if cfg.ChallengeURL != "" {
+			return goth.SafeRedirect(c, cfg.ChallengeURL, cfg.TrustedOrigins...)
+		}
+
+		return cfg.ErrorHandler(c, ErrMFARequired)
+	}
+}
+
+// MarkVerified records that a second factor has been verified for the session.
+func MarkVerified(ctx context.Context, adapter adapters.Adapter, session adapters.GothSession) (adapters.GothSession, error) {
+	now := time.Now()
+	session.MFAVerifiedAt = &now
+
+	s, err := adapter.UpdateSession(ctx, session)
+	if !errors.Is(err, goth.ErrSessionConflict) {
+		return s, err
+	}
+
+	// a concurrent request has updated the session, retry on the current state
+	s, err = adapter.GetSession(ctx, session.SessionToken)
+	if err != nil {
+		return adapters.GothSession{}, err
+	}
+	s.MFAVerifiedAt = &now
+
+	return adapter.UpdateSession(ctx, s)
+}
@@ -0,0 +1,371 @@
+package mfa
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+const (
+	registrationIdentifier = "webauthn-registration:"
+	challengeIdentifier    = "webauthn-challenge:"
+)
+
+var _ webauthn.User = (*User)(nil)
+
+// User is a user with its WebAuthn credentials.
+type User struct {
+	adapters.GothUser
+
+	// Credentials are the stored WebAuthn credentials of the user.
+	Credentials []adapters.GothCredential
+}
+
+// WebAuthnID returns the user handle.
+func (u *User) WebAuthnID() []byte {
+	return u.ID[:]
+}
+
+// WebAuthnName returns the name of the user account.
+func (u *User) WebAuthnName() string {
+	return u.Email
+}
+
+// WebAuthnDisplayName returns the display name of the user account.
+func (u *User) WebAuthnDisplayName() string {
+	if u.Name == "" {
+		return u.Email
+	}
+
+	return u.Name
+}
+
+// WebAuthnCredentials returns the decoded WebAuthn credentials of the user.
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+
+	for _, c := range u.Credentials {
+		if c.Type != adapters.CredentialTypeWebAuthn {
+			continue
+		}
+
+		var credential webauthn.Credential
+		if err := json.Unmarshal(c.Data, &credential); err != nil {
+			continue
+		}
+
+		credentials = append(credentials, credential)
+	}
+
+	return credentials
+}
+
+// userFromContext returns the user of the current session with its credentials.
+func userFromContext(c *fiber.Ctx, adapter adapters.Adapter) (adapters.GothSession, *User, error) {
+	session, err := goth.SessionFromContext(c)
+	if err != nil {
+		return adapters.GothSession{}, nil, goth.ErrMissingSession
+	}
+
+	user, err := adapter.GetUser(c.Context(), session.UserID)
+	if err != nil {
+		return adapters.GothSession{}, nil, goth.ErrMissingUser
+	}
+
+	credentials, err := adapter.ListCredentials(c.Context(), user.ID)
+	if err != nil {
+		return adapters.GothSession{}, nil, err
+	}
+
+	return session, &User{GothUser: user, Credentials: credentials}, nil
+}
+
+// NewBeginRegistrationHandler returns a handler that starts the registration of a
+// security key or passkey for the current user. It responds with the credential creation options.
+func NewBeginRegistrationHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, user, err := userFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		exclusions := make([]protocol.CredentialDescriptor, 0, len(user.Credentials))
+		for _, credential := range user.WebAuthnCredentials() {
+			exclusions = append(exclusions, credential.Descriptor())
+		}
+
+		creation, data, err := cfg.WebAuthn.BeginRegistration(user,
+			webauthn.WithExclusions(exclusions),
+			webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{UserVerification: cfg.UserVerification}),
+		)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		err = cfg.storeChallenge(c, registrationIdentifier, session, data.Challenge)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(creation)
+	}
+}
+
+// NewFinishRegistrationHandler returns a handler that verifies the attestation of the
+// authenticator and stores the credential. The optional `name` query parameter names the credential.
+func NewFinishRegistrationHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, user, err := userFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(c.Body()))
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		data, err := cfg.useChallenge(c, registrationIdentifier, session, user, parsed.Response.CollectedClientData.Challenge)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		credential, err := cfg.WebAuthn.CreateCredential(user, data, parsed)
+		if err != nil {
+			return cfg.ErrorHandler(c, ErrInvalidCredential)
+		}
+
+		b, err := json.Marshal(credential)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		name := strings.TrimSpace(c.Query("name"))
+		if name == "" {
+			name = "Security key"
+		}
+
+		stored, err := cfg.Adapter.CreateCredential(c.Context(), adapters.GothCredential{
+			UserID:       user.ID,
+			Type:         adapters.CredentialTypeWebAuthn,
+			Name:         name,
+			CredentialID: credential.ID,
+			Data:         b,
+		})
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		event := events.New(events.CredentialRegistered, user.ID)
+		event.Data = map[string]any{"credential_id": stored.ID, "type": stored.Type}
+		cfg.Events.Emit(c.Context(), event)
+
+		return c.Status(fiber.StatusCreated).JSON(stored)
+	}
+}
+
+// NewBeginChallengeHandler returns a handler that starts the verification of a second
+// factor for the current session. It responds with the credential assertion options.
+func NewBeginChallengeHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, user, err := userFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		if len(user.WebAuthnCredentials()) == 0 {
+			return cfg.ErrorHandler(c, ErrNoCredentials)
+		}
+
+		assertion, data, err := cfg.WebAuthn.BeginLogin(user, webauthn.WithUserVerification(cfg.UserVerification))
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		err = cfg.storeChallenge(c, challengeIdentifier, session, data.Challenge)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(assertion)
+	}
+}
+
+// NewFinishChallengeHandler returns a handler that verifies the assertion of the
+// authenticator and marks the second factor of the session as verified.
+func NewFinishChallengeHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, user, err := userFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(c.Body()))
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		key := goth.ThrottleKey("mfa", user.ID.String())
+
+		err = cfg.Throttler.Allow(c.Context(), key)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		data, err := cfg.useChallenge(c, challengeIdentifier, session, user, parsed.Response.CollectedClientData.Challenge)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		for _, credential := range user.WebAuthnCredentials() {
+			data.AllowedCredentialIDs = append(data.AllowedCredentialIDs, credential.ID)
+		}
+
+		credential, err := cfg.WebAuthn.ValidateLogin(user, data, parsed)
+		if err == nil && credential.Authenticator.CloneWarning {
+			err = ErrInvalidCredential
+		}
+
+		if err != nil {
+			return cfg.ErrorHandler(c, ErrInvalidCredential)
+		}
+
+		err = cfg.Throttler.Reset(c.Context(), key)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		err = cfg.updateCredential(c, user, credential)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		session, err = MarkVerified(c.Context(), cfg.Adapter, session)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		event := events.New(events.SessionMFAVerified, user.ID)
+		event.Data = map[string]any{"session_id": session.ID, "type": adapters.CredentialTypeWebAuthn}
+		cfg.Events.Emit(c.Context(), event)
+
+		if cfg.trustsDevices() && c.QueryBool("remember") {
+			err = cfg.trustDevice(c, user.ID)
+			if err != nil {
+				return cfg.ErrorHandler(c, err)
+			}
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// NewListCredentialsHandler returns a handler that lists the credentials of the current user.
+func NewListCredentialsHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		_, user, err := userFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		return c.JSON(user.Credentials)
+	}
+}
+
+// NewDeleteCredentialHandler returns a handler that deletes the credential with the `id`
+// route parameter of the current user.
+func NewDeleteCredentialHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		session, err := goth.SessionFromContext(c)
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrMissingSession)
+		}
+
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return cfg.ErrorHandler(c, goth.ErrBadRequest)
+		}
+
+		err = cfg.Adapter.DeleteCredential(c.Context(), session.UserID, id)
+		if err != nil {
+			return cfg.ErrorHandler(c, err)
+		}
+
+		event := events.New(events.CredentialDeleted, session.UserID)
+		event.Data = map[string]any{"credential_id": id}
+		cfg.Events.Emit(c.Context(), event)
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// storeChallenge stores the hash of the challenge scoped to the session.
+func (cfg Config) storeChallenge(c *fiber.Ctx, kind string, session adapters.GothSession, challenge string) error {
+	_, err := cfg.Adapter.CreateVerificationToken(c.Context(), adapters.GothVerificationToken{
+		Identifier: kind + session.ID.String(),
+		Token:      adapters.HashToken(challenge),
+		ExpiresAt:  time.Now().Add(cfg.ChallengeTimeout),
+	})
+
+	return err
+}
+
+// useChallenge consumes the challenge of the session and restores the ceremony data.
+func (cfg Config) useChallenge(c *fiber.Ctx, kind string, session adapters.GothSession, user *User, challenge string) (webauthn.SessionData, error) {
+	_, err := cfg.Adapter.UseVerficationToken(c.Context(), kind+session.ID.String(), adapters.HashToken(challenge))
+	if err != nil {
+		return webauthn.SessionData{}, ErrInvalidChallenge
+	}
+
+	return webauthn.SessionData{
+		Challenge:        challenge,
+		RelyingPartyID:   cfg.WebAuthn.Config.RPID,
+		UserID:           user.WebAuthnID(),
+		UserVerification: cfg.UserVerification,
+	}, nil
+}
+
+// updateCredential stores the new sign count and the last use of the credential.
+func (cfg Config) updateCredential(c *fiber.Ctx, user *User, credential *webauthn.Credential) error {
+	b, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+
+	for _, stored := range user.Credentials {
+		if !bytes.Equal(stored.CredentialID, credential.ID) {
+			continue
+		}
+
+		now := time.Now()
+		stored.Data = b
+		stored.LastUsedAt = &now
+
+		_, err := cfg.Adapter.UpdateCredential(c.Context(), stored)
+
+		return err
+	}
+
+	return ErrInvalidCredential
+}
@@ -0,0 +1,172 @@
+package goth
+
+import (
+	"encoding"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// widgetEndpoint is an endpoint of the widget API in the OpenAPI spec.
+type widgetEndpoint struct {
+	method    string
+	path      string
+	summary   string
+	response  any
+	protected bool
+}
+
+// widgetEndpoints are the endpoints of the widget API, the responses are the types the handlers respond with.
+var widgetEndpoints = []widgetEndpoint{
+	{http.MethodGet, "/providers", "List the providers", []WidgetProvider{}, false},
+	{http.MethodGet, "/providers/{provider}/begin", "Begin a login with the provider", WidgetLogin{}, false},
+	{http.MethodGet, "/session", "Poll the current session", WidgetSession{}, false},
+	{http.MethodGet, "/me", "Get the current user", WidgetUser{}, true},
+	{http.MethodPost, "/logout", "Logout the current session", nil, true},
+}
+
+// WidgetOpenAPI returns the OpenAPI 3 spec of the widget API of the config. The schemas are generated
+// from the response types, so that the spec matches the responses of the handlers.
+func WidgetOpenAPI(config ...Config) map[string]any {
+	cfg := configDefault(config...)
+
+	schemas := map[string]any{}
+	paths := map[string]any{}
+
+	errorResponse := map[string]any{
+		"description": "Error",
+		"content":     jsonContent(schemaOf(reflect.TypeOf(WidgetError{}), schemas)),
+	}
+
+	for _, e := range widgetEndpoints {
+		responses := map[string]any{"default": errorResponse}
+
+		if e.response == nil {
+			responses[strconv.Itoa(http.StatusNoContent)] = map[string]any{"description": "No Content"}
+		} else {
+			responses[strconv.Itoa(http.StatusOK)] = map[string]any{
+				"description": "OK",
+				"content":     jsonContent(schemaOf(reflect.TypeOf(e.response), schemas)),
+			}
+		}
+
+		op := map[string]any{
+			"summary":   e.summary,
+			"responses": responses,
+		}
+
+		if strings.Contains(e.path, "{provider}") {
+			op["parameters"] = []any{map[string]any{
+				"name":     "provider",
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			}}
+		}
+
+		if e.protected {
+			op["security"] = []any{map[string]any{"session": []string{}}}
+		}
+
+		path := cfg.WidgetPath(e.path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path].(map[string]any)[strings.ToLower(e.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "fiber-goth widget API",
+			"version": WidgetAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"session": map[string]any{"type": "apiKey", "in": "cookie", "name": cfg.CookieName},
+			},
+		},
+	}
+}
+
+// jsonContent returns the JSON content of the schema.
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schema of the type. Named structs are added to the schemas and referenced.
+//
+// nolint:gocyclo
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	if t.Kind() == reflect.Ptr {
+		s := schemaOf(t.Elem(), schemas)
+		if _, ok := s["$ref"]; !ok {
+			s["nullable"] = true
+		}
+
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		schemas[t.Name()] = map[string]any{} // placeholder for recursive types
+
+		properties := map[string]any{}
+		required := []string{}
+
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+
+			properties[name] = schemaOf(f.Type, schemas)
+
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+
+		schemas[t.Name()] = map[string]any{"type": "object", "properties": properties, "required": required}
+
+		return ref
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,312 @@
+package pages
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/i18n"
+	"github.com/zeiss/fiber-goth/providers"
+	"golang.org/x/text/language"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+const (
+	// LoginTemplate is the default name of the login page template.
+	LoginTemplate = "login"
+	// ErrorTemplate is the default name of the error page template.
+	ErrorTemplate = "error"
+)
+
+// DefaultViews are the built-in pages.
+var DefaultViews = TemplateViews(template.Must(template.ParseFS(templatesFS, "templates/*.html")))
+
+// Config defines the config for the built-in pages.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	Next func(c *fiber.Ctx) bool
+
+	// AppName is the name of the application shown in the title.
+	AppName string
+
+	// LoginURL is the URL of the routes to start the authentication.
+	//
+	// Optional. Default: "/login"
+	LoginURL string
+
+	// Bundle is the catalog of translations.
+	//
+	// Optional. Default: i18n.DefaultBundle
+	Bundle *i18n.Bundle
+
+	// Views is the template engine that renders the pages. Any fiber.Views
+	// (e.g. github.com/gofiber/template/html) or TemplateViews of Go templates can be used.
+	// The templates are executed with Data.
+	//
+	// Optional. Default: DefaultViews
+	Views fiber.Views
+
+	// Layout is the layout passed to the Views.
+	//
+	// Optional. Default: ""
+	Layout string
+
+	// LoginTemplate is the name of the login page template.
+	//
+	// Optional. Default: "login"
+	LoginTemplate string
+
+	// ErrorTemplate is the name of the error page template.
+	//
+	// Optional. Default: "error"
+	ErrorTemplate string
+
+	// AccountHints returns the previously used accounts that are offered on the login page,
+	// e.g. goth.Config.AccountHints.
+	//
+	// Optional. Default: nil
+	AccountHints func(c *fiber.Ctx) []goth.AccountHint
+
+	// SecurityHeaders are the security headers of the pages (e.g. the form-action origins
+	// of the identity providers). The headers are skipped if its Next returns true.
+	//
+	// Optional. Default: goth.SecurityHeadersConfigDefault
+	SecurityHeaders goth.SecurityHeadersConfig
+}
+
+// ConfigDefault is the default config.
+var ConfigDefault = Config{
+	LoginURL:      "/login",
+	Bundle:        i18n.DefaultBundle,
+	Views:         DefaultViews,
+	LoginTemplate: LoginTemplate,
+	ErrorTemplate: ErrorTemplate,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	if cfg.LoginURL == "" {
+		cfg.LoginURL = ConfigDefault.LoginURL
+	}
+
+	if cfg.Bundle == nil {
+		cfg.Bundle = ConfigDefault.Bundle
+	}
+
+	if cfg.Views == nil {
+		cfg.Views = ConfigDefault.Views
+	}
+
+	if cfg.LoginTemplate == "" {
+		cfg.LoginTemplate = ConfigDefault.LoginTemplate
+	}
+
+	if cfg.ErrorTemplate == "" {
+		cfg.ErrorTemplate = ConfigDefault.ErrorTemplate
+	}
+
+	return cfg
+}
+
+// Provider is a provider on the login page.
+type Provider struct {
+	// ID is the ID of the provider.
+	ID string
+	// Name is the name of the provider.
+	Name string
+	// URL is the URL to start the authentication with the provider.
+	URL string
+}
+
+// Account is a previously used account on the login page.
+type Account struct {
+	goth.AccountHint
+	// URL is the URL to sign in with the account.
+	URL string
+}
+
+// Data is the data the page templates are executed with.
+// Messages are translated with the T method, e.g. `{{ .T "login.title" }}`.
+type Data struct {
+	// Lang is the negotiated language (e.g. "de").
+	Lang string
+	// Title is the translated title of the page.
+	Title string
+	// AppName is the name of the application.
+	AppName string
+	// LoginURL is the URL of the login page.
+	LoginURL string
+	// Providers are the registered providers sorted by name (login page).
+	Providers []Provider
+	// Accounts are the previously used accounts, most recently used first (login page).
+	Accounts []Account
+	// Status is the HTTP status code (error page).
+	Status int
+	// Message is the message of the error (error page).
+	Message string
+	// ErrorCode is the error code the provider redirected back with, e.g. "access_denied" (error page).
+	ErrorCode string
+	// ErrorDescription is the description of the error of the provider, if any (error page).
+	ErrorDescription string
+
+	bundle *i18n.Bundle
+	tag    language.Tag
+}
+
+// T translates the message into the negotiated language.
+func (d Data) T(key string, args ...any) string {
+	if d.bundle == nil {
+		return key
+	}
+
+	return d.bundle.Translate(d.tag, key, args...)
+}
+
+// NewLoginHandler returns a handler that renders a login page with the registered providers.
+func NewLoginHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		data := cfg.data(c, "login.title")
+
+		for id, p := range providers.GetProviders() {
+			data.Providers = append(data.Providers, Provider{
+				ID:   id,
+				Name: p.Name(),
+				URL:  strings.TrimSuffix(cfg.LoginURL, "/") + "/" + id,
+			})
+		}
+		sort.Slice(data.Providers, func(i, j int) bool { return data.Providers[i].Name < data.Providers[j].Name })
+
+		if cfg.AccountHints != nil {
+			for _, hint := range cfg.AccountHints(c) {
+				data.Accounts = append(data.Accounts, Account{
+					AccountHint: hint,
+					URL:         strings.TrimSuffix(cfg.LoginURL, "/") + "/" + url.PathEscape(hint.Provider) + "?login_hint=" + url.QueryEscape(hint.Email),
+				})
+			}
+		}
+
+		return cfg.render(c, cfg.LoginTemplate, fiber.StatusOK, data)
+	}
+}
+
+// NewErrorHandler returns an error handler that renders an error page,
+// e.g. to be used as goth.Config.ErrorHandler.
+func NewErrorHandler(config ...Config) fiber.ErrorHandler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx, err error) error {
+		data := cfg.data(c, "error.title")
+		data.Status = fiber.StatusBadRequest
+
+		var fe *fiber.Error
+		var ge *goth.Error
+		var pe *goth.ProviderError
+
+		switch {
+		case errors.As(err, &pe):
+			data.ErrorCode = pe.Code
+			data.ErrorDescription = pe.Description
+		case errors.As(err, &ge):
+			data.Status = ge.Code
+			data.Message = ge.Message
+		case errors.As(err, &fe):
+			data.Status = fe.Code
+			data.Message = fe.Message
+		}
+
+		return cfg.render(c, cfg.ErrorTemplate, data.Status, data)
+	}
+}
+
+// NewCallbackErrorHandler returns a handler that renders the error page for the `error` and
+// `error_description` parameters, e.g. to be mounted at goth.Config.CallbackErrorURL.
+func NewCallbackErrorHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		data := cfg.data(c, "error.title")
+		data.Status = fiber.StatusBadRequest
+		data.ErrorCode = c.Query("error")
+		data.ErrorDescription = c.Query("error_description")
+
+		return cfg.render(c, cfg.ErrorTemplate, data.Status, data)
+	}
+}
+
+func (cfg Config) data(c *fiber.Ctx, title string) Data {
+	tag := cfg.Bundle.Locale(c)
+
+	return Data{
+		Lang:     tag.String(),
+		Title:    cfg.Bundle.Translate(tag, title),
+		AppName:  cfg.AppName,
+		LoginURL: cfg.LoginURL,
+		bundle:   cfg.Bundle,
+		tag:      tag,
+	}
+}
+
+func (cfg Config) render(c *fiber.Ctx, name string, status int, data Data) error {
+	c.Status(status)
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderContentLanguage, data.Lang)
+	c.Vary(fiber.HeaderAcceptLanguage)
+
+	if cfg.SecurityHeaders.Next == nil || !cfg.SecurityHeaders.Next(c) {
+		cfg.SecurityHeaders.Apply(c)
+	}
+
+	var layouts []string
+	if cfg.Layout != "" {
+		layouts = append(layouts, cfg.Layout)
+	}
+
+	return cfg.Views.Render(c.Response().BodyWriter(), name, data, layouts...)
+}
+
+var _ fiber.Views = (*templateViews)(nil)
+
+type templateViews struct {
+	t *template.Template
+}
+
+// TemplateViews returns fiber.Views that execute the named templates of the Go templates.
+// Layouts are not supported, templates should be composed with `{{ template }}`.
+func TemplateViews(t *template.Template) fiber.Views {
+	return &templateViews{t: t}
+}
+
+// Load loads the templates.
+func (v *templateViews) Load() error {
+	return nil
+}
+
+// Render executes the named template.
+func (v *templateViews) Render(w io.Writer, name string, data any, _ ...string) error {
+	return v.t.ExecuteTemplate(w, name, data)
+}
@@ -0,0 +1,77 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+var (
+	// ErrSSORequired is thrown if a member of a team requiring SSO signs in with another provider.
+	ErrSSORequired = NewError(http.StatusForbidden, "team requires single sign-on with its identity provider")
+	// ErrEmailDomainNotAllowed is thrown if the email domain of a user is not allowed to sign in.
+	ErrEmailDomainNotAllowed = NewError(http.StatusForbidden, "email domain is not allowed to sign in")
+)
+
+// SignInPolicy decides if a user that has been authenticated by a provider is allowed to sign in.
+type SignInPolicy func(ctx context.Context, adapter adapters.Adapter, provider providers.Provider, user adapters.GothUser) error
+
+// EnforceTeamSSO denies the sign-in of members of a team that requires SSO
+// when they authenticate with any other provider than the one bound to the team.
+func EnforceTeamSSO() SignInPolicy {
+	return func(ctx context.Context, adapter adapters.Adapter, provider providers.Provider, user adapters.GothUser) error {
+		teams, err := adapter.ListUserTeams(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, team := range teams {
+			if team.SSORequired && cast.Value(team.SSOProvider) != provider.ID() {
+				return ErrSSORequired
+			}
+		}
+
+		return nil
+	}
+}
+
+// WithAllowedEmailDomains only allows users with an email of one of the domains to sign in.
+func WithAllowedEmailDomains(domains ...string) SignInPolicy {
+	return func(_ context.Context, _ adapters.Adapter, _ providers.Provider, user adapters.GothUser) error {
+		if !matchEmailDomain(user.Email, domains...) {
+			return ErrEmailDomainNotAllowed
+		}
+
+		return nil
+	}
+}
+
+// WithBlockedEmailDomains denies users with an email of one of the domains to sign in.
+func WithBlockedEmailDomains(domains ...string) SignInPolicy {
+	return func(_ context.Context, _ adapters.Adapter, _ providers.Provider, user adapters.GothUser) error {
+		if _, ok := EmailDomain(user.Email); !ok || matchEmailDomain(user.Email, domains...) {
+			return ErrEmailDomainNotAllowed
+		}
+
+		return nil
+	}
+}
+
+func matchEmailDomain(email string, domains ...string) bool {
+	domain, ok := EmailDomain(email)
+	if !ok {
+		return false
+	}
+
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimPrefix(d, "@"), domain) {
+			return true
+		}
+	}
+
+	return false
+}
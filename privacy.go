@@ -0,0 +1,164 @@
+package goth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+// Privacy controls the personal data of the clients that is stored on sessions and
+// trusted devices and emitted with events, e.g. to comply with data minimization policies.
+// The zero value stores and emits the data unchanged.
+type Privacy struct {
+	// OmitIPAddress does not store or emit the IP addresses of the clients.
+	// The session binding to the IP and the new device alerts are not available.
+	OmitIPAddress bool
+
+	// OmitUserAgent does not store the user agents (or their hashes) of the clients.
+	// The session binding to the user agent is not available.
+	OmitUserAgent bool
+
+	// IPv4PrefixBits truncates IPv4 addresses to the prefix before they are stored or emitted (e.g. 24 for 192.0.2.0).
+	//
+	// Optional. Default: 0 (no truncation)
+	IPv4PrefixBits int
+
+	// IPv6PrefixBits truncates IPv6 addresses to the prefix before they are stored or emitted (e.g. 48).
+	//
+	// Optional. Default: 0 (no truncation)
+	IPv6PrefixBits int
+
+	// HashKey replaces the stored IP addresses and user agent hashes with keyed hashes (HMAC-SHA256),
+	// so that they can be compared but not read. Emitter pseudonymizes the identifiers of events with it.
+	//
+	// Optional. Default: nil (no hashing)
+	HashKey []byte
+
+	// DisableFunnel disables the first-party analytics of the login funnel: the started, redirected and
+	// callback received events are not emitted, and the attempt and the duration are removed from the
+	// other login events. The events of the security alerts are still emitted.
+	DisableFunnel bool
+}
+
+// ClientIP returns the IP address of the client as it is stored and emitted.
+func (p Privacy) ClientIP(c *fiber.Ctx) string {
+	if p.OmitIPAddress {
+		return ""
+	}
+
+	ip := p.truncate(c.IP())
+
+	if len(p.HashKey) > 0 {
+		return p.hash(ip)
+	}
+
+	return ip
+}
+
+// UserAgent returns the user agent of the client as it is stored, e.g. on trusted devices.
+func (p Privacy) UserAgent(c *fiber.Ctx) string {
+	if p.OmitUserAgent {
+		return ""
+	}
+
+	return c.Get(fiber.HeaderUserAgent)
+}
+
+// userAgentHash returns the hash of the user agent of the client that is stored on the session.
+func (p Privacy) userAgentHash(c *fiber.Ctx) string {
+	if p.OmitUserAgent {
+		return ""
+	}
+
+	if len(p.HashKey) > 0 {
+		return p.hash(c.Get(fiber.HeaderUserAgent))
+	}
+
+	return adapters.HashToken(c.Get(fiber.HeaderUserAgent))
+}
+
+// truncate truncates the IP address to the configured prefix.
+func (p Privacy) truncate(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+
+	bits := p.IPv6PrefixBits
+	if addr.Is4() {
+		bits = p.IPv4PrefixBits
+	}
+
+	if bits <= 0 {
+		return addr.String()
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return addr.String()
+	}
+
+	return prefix.Addr().String()
+}
+
+// hash returns the hex encoded HMAC-SHA256 of the value.
+func (p Privacy) hash(value string) string {
+	mac := hmac.New(sha256.New, p.HashKey)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Pseudonymize returns a stable pseudonym of the ID derived with the HashKey,
+// or the ID if no HashKey is configured.
+func (p Privacy) Pseudonymize(id uuid.UUID) uuid.UUID {
+	if len(p.HashKey) == 0 || id == uuid.Nil {
+		return id
+	}
+
+	return uuid.NewHash(hmac.New(sha256.New, p.HashKey), uuid.Nil, id[:], 8)
+}
+
+// Emitter returns an emitter that pseudonymizes the user ID and the IDs in the data (e.g. the session ID)
+// of the events before they are emitted to next, e.g. an audit log. The IP addresses are emitted as ClientIP.
+// It should only wrap the emitters that store events, the security alerts need the actual user IDs.
+func (p Privacy) Emitter(next events.Emitter) events.Emitter {
+	return events.EmitterFunc(func(ctx context.Context, e events.Event) {
+		e.UserID = p.Pseudonymize(e.UserID)
+
+		data := make(map[string]any, len(e.Data))
+		for k, v := range e.Data {
+			switch id := v.(type) {
+			case uuid.UUID:
+				v = p.Pseudonymize(id)
+			case *uuid.UUID:
+				if id != nil {
+					v = p.Pseudonymize(*id)
+				}
+			}
+
+			data[k] = v
+		}
+
+		if e.Data != nil {
+			e.Data = data
+		}
+
+		next.Emit(ctx, e)
+	})
+}
+
+// funnelOnly are the login events only emitted for the analytics of the login funnel.
+var funnelOnly = map[events.Type]bool{
+	events.LoginStarted:          true,
+	events.LoginRedirected:       true,
+	events.LoginCallbackReceived: true,
+}
@@ -0,0 +1,205 @@
+package goth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/text/language"
+)
+
+var (
+	_ GothHandler = (*UpdateProfileHandler)(nil)
+	_ GothHandler = (*ChangeEmailHandler)(nil)
+	_ GothHandler = (*ConfirmEmailHandler)(nil)
+)
+
+// ErrMissingVerificationSender is thrown if no verification sender is configured.
+var ErrMissingVerificationSender = NewError(http.StatusNotImplemented, "missing verification sender")
+
+// ErrInvalidVerificationToken is thrown if a verification token is invalid or expired.
+var ErrInvalidVerificationToken = NewError(http.StatusBadRequest, "invalid or expired verification token")
+
+// UserFromContext returns the user of the current session.
+func UserFromContext(c *fiber.Ctx, adapter adapters.Adapter) (adapters.GothUser, error) {
+	session, err := SessionFromContext(c)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	return adapter.GetUser(c.Context(), session.UserID)
+}
+
+// UserLocale returns a function that reads the locale preference of the
+// signed-in user, e.g. for the i18n middleware.
+func UserLocale(adapter adapters.Adapter) func(c *fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		user, err := UserFromContext(c, adapter)
+		if err != nil {
+			return ""
+		}
+
+		return user.Locale
+	}
+}
+
+// UpdateProfileHandler is the default handler to update the name, image and locale of the current user.
+type UpdateProfileHandler struct{}
+
+// NewUpdateProfileHandler returns a new default update profile handler.
+// The handler must be mounted behind the protect middleware.
+func NewUpdateProfileHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return UpdateProfileHandler{}.New(cfg)
+}
+
+// New creates a new handler to update the profile.
+func (UpdateProfileHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "UpdateProfileHandler", ErrMissingUser)
+		}
+
+		params := ParamsFromContext(c)
+
+		if name := strings.TrimSpace(params.Get("name")); utilx.NotEmpty(name) {
+			user.Name = name
+		}
+
+		if image := strings.TrimSpace(params.Get("image")); utilx.NotEmpty(image) {
+			user.Image = cast.Ptr(image)
+		}
+
+		if locale := strings.TrimSpace(params.Get("locale")); utilx.NotEmpty(locale) {
+			if _, err := language.Parse(locale); err != nil {
+				return cfg.handleError(c, "UpdateProfileHandler", ErrBadRequest)
+			}
+
+			user.Locale = locale
+		}
+
+		user, err = cfg.Adapter.UpdateUser(c.Context(), user)
+		if err != nil {
+			return cfg.handleError(c, "UpdateProfileHandler", err)
+		}
+
+		cfg.Events.Emit(c.Context(), events.New(events.UserUpdated, user.ID))
+
+		return c.JSON(user)
+	}
+}
+
+// ChangeEmailHandler is the default handler to request the change of the email of the current user.
+// It sends a verification token to the new email that has to be confirmed.
+type ChangeEmailHandler struct{}
+
+// NewChangeEmailHandler returns a new default change email handler.
+// The handler must be mounted behind the protect middleware.
+func NewChangeEmailHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return ChangeEmailHandler{}.New(cfg)
+}
+
+// New creates a new handler to change the email.
+func (ChangeEmailHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		if cfg.VerificationSender == nil {
+			return cfg.handleError(c, "ChangeEmailHandler", ErrMissingVerificationSender)
+		}
+
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "ChangeEmailHandler", ErrMissingUser)
+		}
+
+		email := strings.TrimSpace(ParamsFromContext(c).Get("email"))
+		if _, ok := EmailDomain(email); !ok {
+			return cfg.handleError(c, "ChangeEmailHandler", ErrMissingEmail)
+		}
+
+		identifier := changeEmailIdentifier(user, email)
+
+		token, err := cfg.VerificationTokens.Issue(c.Context(), identifier, cfg.VerificationExpiry)
+		if err != nil {
+			return cfg.handleError(c, "ChangeEmailHandler", err)
+		}
+
+		err = cfg.VerificationSender(c.Context(), identifier, email, token)
+		if err != nil {
+			return cfg.handleError(c, "ChangeEmailHandler", err)
+		}
+
+		cfg.Events.Emit(c.Context(), events.New(events.UserEmailChangeRequested, user.ID))
+
+		return c.SendStatus(fiber.StatusAccepted)
+	}
+}
+
+// ConfirmEmailHandler is the default handler to confirm the change of the email of the current user.
+type ConfirmEmailHandler struct{}
+
+// NewConfirmEmailHandler returns a new default confirm email handler.
+// The handler must be mounted behind the protect middleware.
+func NewConfirmEmailHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return ConfirmEmailHandler{}.New(cfg)
+}
+
+// New creates a new handler to confirm the email.
+func (ConfirmEmailHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "ConfirmEmailHandler", ErrMissingUser)
+		}
+
+		params := ParamsFromContext(c)
+		email := strings.TrimSpace(params.Get("email"))
+		token := params.Get("token")
+
+		if utilx.Empty(email) || utilx.Empty(token) {
+			return cfg.handleError(c, "ConfirmEmailHandler", ErrInvalidVerificationToken)
+		}
+
+		err = cfg.VerificationTokens.Use(c.Context(), changeEmailIdentifier(user, email), token)
+		if err != nil {
+			return cfg.handleError(c, "ConfirmEmailHandler", err)
+		}
+
+		user.Email = email
+		user.EmailVerified = cast.Ptr(true)
+
+		user, err = cfg.Adapter.UpdateUser(c.Context(), user)
+		if err != nil {
+			return cfg.handleError(c, "ConfirmEmailHandler", err)
+		}
+
+		cfg.Events.Emit(c.Context(), events.New(events.UserEmailChanged, user.ID))
+
+		return cfg.CompletionFilter(c)
+	}
+}
+
+func changeEmailIdentifier(user adapters.GothUser, email string) string {
+	return "change-email:" + user.ID.String() + ":" + strings.ToLower(email)
+}
@@ -0,0 +1,375 @@
+package apple
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrInvalidPrivateKey is returned when the .p8 key is not a PEM encoded EC private key.
+	ErrInvalidPrivateKey = errors.New("goth: invalid apple private key")
+	// ErrMissingIDToken is returned when the token response has no id_token.
+	ErrMissingIDToken = errors.New("goth: missing apple id_token")
+	// ErrInvalidIDToken is returned when the id_token is not signed by Apple or not issued for the client.
+	ErrInvalidIDToken = errors.New("goth: invalid apple id_token")
+	// ErrUnverifiedEmail is returned when the email of the user is not verified by Apple.
+	ErrUnverifiedEmail = errors.New("goth: email is not verified")
+)
+
+const (
+	// Issuer is the issuer of the id_tokens and the audience of the client secrets.
+	Issuer = "https://appleid.apple.com"
+	// AuthURL is the authorization endpoint of Apple.
+	AuthURL = Issuer + "/auth/authorize"
+	// TokenURL is the token endpoint of Apple.
+	TokenURL = Issuer + "/auth/token"
+	// KeysURL is the endpoint of the keys that sign the id_tokens.
+	KeysURL = Issuer + "/auth/keys"
+
+	// DefaultSecretExpiry is the default lifetime of the generated client secrets.
+	DefaultSecretExpiry = 5 * time.Minute
+	// keysExpiry is the duration the keys of Apple are cached.
+	keysExpiry = time.Hour
+)
+
+var _ providers.Provider = (*appleProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Apple.
+var DefaultScopes = []string{"name", "email"}
+
+type appleProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	teamID       string
+	keyID        string
+	key          *ecdsa.PrivateKey
+	callbackURL  string
+	secretExpiry time.Duration
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	keys         *providers.KeySet
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Apple provider.
+type Opt func(*appleProvider)
+
+// WithScopes sets the scopes for the Apple provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *appleProvider) {
+		p.scopes = scopes
+	}
+}
+
+// WithSecretExpiry sets the lifetime of the generated client secrets (at most 6 months).
+func WithSecretExpiry(expiry time.Duration) Opt {
+	return func(p *appleProvider) {
+		p.secretExpiry = expiry
+	}
+}
+
+// New creates a new Apple provider for the Services ID (clientKey) of the team. The client
+// secrets are signed with the PEM encoded .p8 private key of the key ID.
+func New(clientKey, teamID, keyID string, privateKey []byte, callbackURL string, opts ...Opt) (*appleProvider, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+
+	p := &appleProvider{
+		id:           "apple",
+		name:         "Apple",
+		clientKey:    clientKey,
+		teamID:       teamID,
+		keyID:        keyID,
+		key:          key,
+		callbackURL:  callbackURL,
+		secretExpiry: DefaultSecretExpiry,
+		providerType: providers.ProviderTypeOIDC,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.keys = providers.NewKeySet(KeysURL, p.client, keysExpiry)
+	p.config = &oauth2.Config{
+		ClientID:    p.clientKey,
+		RedirectURL: p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p, nil
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID    = "APPLE_CLIENT_ID"
+	EnvTeamID      = "APPLE_TEAM_ID"
+	EnvKeyID       = "APPLE_KEY_ID"
+	EnvPrivateKey  = "APPLE_PRIVATE_KEY"
+	EnvCallbackURL = "APPLE_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Apple provider loading the .p8 private key from source.
+func NewFromSource(clientKey, teamID, keyID string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*appleProvider, error) {
+	key, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, teamID, keyID, []byte(key), callbackURL, opts...)
+}
+
+// NewFromEnv creates a new Apple provider from the APPLE_CLIENT_ID, APPLE_TEAM_ID, APPLE_KEY_ID,
+// APPLE_PRIVATE_KEY and APPLE_CALLBACK_URL environment variables. The private key can also be read
+// from the file referenced by APPLE_PRIVATE_KEY_FILE.
+func NewFromEnv(opts ...Opt) (*appleProvider, error) {
+	values := map[string]string{}
+
+	for _, name := range []string{EnvClientID, EnvTeamID, EnvKeyID, EnvCallbackURL} {
+		v, err := providers.Env(name).Load()
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+
+	return NewFromSource(values[EnvClientID], values[EnvTeamID], values[EnvKeyID], providers.EnvOrFile(EnvPrivateKey), values[EnvCallbackURL], opts...)
+}
+
+// ID returns the provider's ID.
+func (a *appleProvider) ID() string {
+	return a.id
+}
+
+// Name returns the provider's name.
+func (a *appleProvider) Name() string {
+	return a.name
+}
+
+// Type returns the provider's type.
+func (a *appleProvider) Type() providers.ProviderType {
+	return a.providerType
+}
+
+// Check validates the client credentials and the reachability of the Apple endpoints.
+func (a *appleProvider) Check(ctx context.Context) error {
+	if a.clientKey == "" || a.teamID == "" || a.keyID == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, a.client, KeysURL)
+}
+
+// ClientSecret returns a new client secret, a JWT signed with the private key.
+func (a *appleProvider) ClientSecret() (string, error) {
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    a.teamID,
+		Subject:   a.clientKey,
+		Audience:  jwt.ClaimStrings{Issuer},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(a.secretExpiry)),
+	})
+	token.Header["kid"] = a.keyID
+
+	return token.SignedString(a.key)
+}
+
+// oauthConfig returns the config with a new client secret.
+func (a *appleProvider) oauthConfig() (*oauth2.Config, error) {
+	secret, err := a.ClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := *a.config
+	cfg.ClientSecret = secret
+
+	return &cfg, nil
+}
+
+// BeginAuth starts the authentication process. Apple posts the response to the callback
+// (`response_mode=form_post`) if the name or email is requested.
+func (a *appleProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	opts := []oauth2.AuthCodeOption{}
+
+	if len(a.config.Scopes) > 0 {
+		opts = append(opts, oauth2.SetAuthURLParam("response_mode", "form_post"))
+	}
+
+	return &authIntent{
+		authURL: a.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process. The profile is read from the validated id_token.
+// Apple only returns the name of the user (`user` parameter) on the first authorization of the app,
+// later sign-ins have no name.
+func (a *appleProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	cfg, err := a.oauthConfig()
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, a.client)
+
+	token, err := cfg.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return adapters.GothUser{}, ErrMissingIDToken
+	}
+
+	claims, err := a.validate(ctx, idToken)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if !claims.EmailVerified {
+		return adapters.GothUser{}, ErrUnverifiedEmail
+	}
+
+	return adapters.GothUser{
+		Name:          userName(params.Get("user")),
+		Email:         claims.Email,
+		EmailVerified: cast.Ptr(bool(claims.EmailVerified)),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOIDC,
+				Provider:          a.ID(),
+				ProviderAccountID: cast.Ptr(claims.Subject),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(a.config.Scopes)),
+				IDToken:           cast.Ptr(idToken),
+			},
+		},
+	}, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token.
+func (a *appleProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	cfg, err := a.oauthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, a.client)
+
+	return cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+// boolish is a bool that Apple encodes either as JSON bool or as string.
+type boolish bool
+
+// UnmarshalJSON decodes true, false, "true" and "false".
+func (b *boolish) UnmarshalJSON(data []byte) error {
+	*b = boolish(strings.Trim(string(data), `"`) == "true")
+
+	return nil
+}
+
+// idTokenClaims are the claims of the id_token.
+type idTokenClaims struct {
+	Email          string  `json:"email"`
+	EmailVerified  boolish `json:"email_verified"`
+	IsPrivateEmail boolish `json:"is_private_email"`
+
+	jwt.RegisteredClaims
+}
+
+// validate parses the id_token and validates the signature, issuer, audience and expiry.
+func (a *appleProvider) validate(ctx context.Context, idToken string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return a.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(a.clientKey),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if utilx.Empty(claims.Subject) {
+		return nil, ErrInvalidIDToken
+	}
+
+	return claims, nil
+}
+
+// userName returns the name of the `user` parameter Apple posts on the first authorization.
+func userName(user string) string {
+	if user == "" {
+		return ""
+	}
+
+	u := struct {
+		Name struct {
+			FirstName string `json:"firstName"`
+			LastName  string `json:"lastName"`
+		} `json:"name"`
+	}{}
+
+	if err := json.Unmarshal([]byte(user), &u); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(u.Name.FirstName + " " + u.Name.LastName)
+}
@@ -0,0 +1,349 @@
+package awssso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrMissingIDToken is returned when the token response has no id_token.
+	ErrMissingIDToken = errors.New("goth: missing identity center id_token")
+	// ErrInvalidIDToken is returned when the id_token is not signed by the instance or not issued for the client.
+	ErrInvalidIDToken = errors.New("goth: invalid identity center id_token")
+)
+
+// keysExpiry is the duration the keys of the instance are cached.
+const keysExpiry = time.Hour
+
+var _ providers.Provider = (*awsssoProvider)(nil)
+
+// DefaultScopes holds the default scopes used for AWS IAM Identity Center.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// GroupResolver resolves the groups the user is assigned to in the identity store of the instance,
+// e.g. with ListGroupMembershipsForMember of the Identity Store API. The user ID is the subject of the id_token.
+type GroupResolver interface {
+	Groups(ctx context.Context, userID string) ([]string, error)
+}
+
+// GroupResolverFunc is a function that implements GroupResolver.
+type GroupResolverFunc func(ctx context.Context, userID string) ([]string, error)
+
+// Groups calls the function.
+func (f GroupResolverFunc) Groups(ctx context.Context, userID string) ([]string, error) {
+	return f(ctx, userID)
+}
+
+type awsssoProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	baseURL      string
+	issuer       string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	keys         *providers.KeySet
+	resolver     GroupResolver
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the AWS IAM Identity Center provider.
+type Opt func(*awsssoProvider)
+
+// WithScopes sets the scopes for the AWS IAM Identity Center provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *awsssoProvider) {
+		p.scopes = scopes
+	}
+}
+
+// WithIssuer sets the issuer of the id_tokens, e.g. of a trusted token issuer. The keys are read
+// from the JWKS endpoint of the issuer.
+func WithIssuer(issuer string) Opt {
+	return func(p *awsssoProvider) {
+		p.issuer = strings.TrimSuffix(issuer, "/")
+	}
+}
+
+// WithGroupResolver resolves the assigned groups of the user during CompleteAuth, if the id_token
+// has no groups claim. The groups are set as GothUser.Groups.
+func WithGroupResolver(resolver GroupResolver) Opt {
+	return func(p *awsssoProvider) {
+		p.resolver = resolver
+	}
+}
+
+// New creates a new AWS IAM Identity Center provider for a customer managed application of the instance
+// in the region. The instance is either the ID (e.g. "ssoins-1234567890abcdef") or the ARN of the instance.
+func New(clientKey, secret, callbackURL, region, instance string, opts ...Opt) *awsssoProvider {
+	p := &awsssoProvider{
+		id:           "awssso",
+		name:         "AWS IAM Identity Center",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		baseURL:      BaseURL(region),
+		issuer:       Issuer(instance),
+		providerType: providers.ProviderTypeOIDC,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.keys = providers.NewKeySet(p.issuer+"/.well-known/jwks.json", p.client, keysExpiry)
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   p.baseURL + "/authorize",
+			TokenURL:  p.baseURL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// BaseURL returns the URL of the OIDC service of Identity Center in the region.
+func BaseURL(region string) string {
+	return fmt.Sprintf("https://oidc.%s.amazonaws.com", region)
+}
+
+// Issuer returns the issuer of the tokens of the instance. The instance is either the ID or the ARN of the instance.
+func Issuer(instance string) string {
+	if i := strings.LastIndex(instance, "/"); i >= 0 {
+		instance = instance[i+1:]
+	}
+
+	return "https://identitycenter.amazonaws.com/" + instance
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "AWSSSO_CLIENT_ID"
+	EnvClientSecret = "AWSSSO_CLIENT_SECRET"
+	EnvCallbackURL  = "AWSSSO_CALLBACK_URL"
+	EnvRegion       = "AWSSSO_REGION"
+	EnvInstance     = "AWSSSO_INSTANCE"
+)
+
+// NewFromSource creates a new AWS IAM Identity Center provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL, region, instance string, opts ...Opt) (*awsssoProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, region, instance, opts...), nil
+}
+
+// NewFromEnv creates a new AWS IAM Identity Center provider from the AWSSSO_CLIENT_ID, AWSSSO_CLIENT_SECRET,
+// AWSSSO_CALLBACK_URL, AWSSSO_REGION and AWSSSO_INSTANCE environment variables. The client secret can also be read
+// from the file referenced by AWSSSO_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*awsssoProvider, error) {
+	values := map[string]string{}
+
+	for _, key := range []string{EnvClientID, EnvCallbackURL, EnvRegion, EnvInstance} {
+		v, err := providers.Env(key).Load()
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+
+	return NewFromSource(
+		values[EnvClientID],
+		providers.EnvOrFile(EnvClientSecret),
+		values[EnvCallbackURL],
+		values[EnvRegion],
+		values[EnvInstance],
+		opts...,
+	)
+}
+
+// ID returns the provider's ID.
+func (a *awsssoProvider) ID() string {
+	return a.id
+}
+
+// Name returns the provider's name.
+func (a *awsssoProvider) Name() string {
+	return a.name
+}
+
+// Type returns the provider's type.
+func (a *awsssoProvider) Type() providers.ProviderType {
+	return a.providerType
+}
+
+// Check validates the client credentials and the reachability of the keys of the instance.
+func (a *awsssoProvider) Check(ctx context.Context) error {
+	if a.clientKey == "" || a.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, a.client, a.issuer+"/.well-known/jwks.json")
+}
+
+// BeginAuth starts the authentication process with the access portal of the instance.
+func (a *awsssoProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, params providers.AuthParams) (providers.AuthIntent, error) {
+	opts := []oauth2.AuthCodeOption{}
+
+	if hint := params.Get("login_hint"); hint != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("login_hint", hint))
+	}
+
+	return &authIntent{
+		authURL: a.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process. The profile is read from the validated id_token,
+// the assigned groups of the user (groups claim or GroupResolver) are set as GothUser.Groups.
+func (a *awsssoProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, a.client)
+
+	token, err := a.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return adapters.GothUser{}, ErrMissingIDToken
+	}
+
+	claims, err := a.validate(ctx, idToken)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	groups, err := a.groups(ctx, claims)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+
+	return adapters.GothUser{
+		Name:          name,
+		Email:         claims.Email,
+		EmailVerified: cast.Ptr(claims.EmailVerified),
+		Locale:        claims.Locale,
+		Groups:        groups,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOIDC,
+				Provider:          a.ID(),
+				ProviderAccountID: cast.Ptr(claims.Subject),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+				IDToken:           cast.Ptr(idToken),
+			},
+		},
+	}, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token.
+func (a *awsssoProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, a.client)
+
+	return a.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+// groups returns the groups of the id_token or of the GroupResolver, if the id_token has no groups claim.
+func (a *awsssoProvider) groups(ctx context.Context, claims *idTokenClaims) ([]string, error) {
+	if claims.Groups != nil || a.resolver == nil {
+		return claims.Groups, nil
+	}
+
+	groups, err := a.resolver.Groups(ctx, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("goth: failed to resolve identity center groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// idTokenClaims are the claims of the id_token.
+type idTokenClaims struct {
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Locale            string   `json:"locale"`
+	Groups            []string `json:"groups"`
+
+	jwt.RegisteredClaims
+}
+
+// validate parses the id_token and validates the signature, issuer, audience and expiry.
+func (a *awsssoProvider) validate(ctx context.Context, idToken string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return a.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithAudience(a.clientKey),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if utilx.Empty(claims.Subject) {
+		return nil, ErrInvalidIDToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,248 @@
+package box
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// AuthURL is the authorization endpoint of Box.
+	AuthURL = "https://account.box.com/api/oauth2/authorize"
+	// TokenURL is the token endpoint of Box.
+	TokenURL = "https://api.box.com/oauth2/token"
+	// UserURL is the endpoint of the authenticated user.
+	UserURL = "https://api.box.com/2.0/users/me"
+)
+
+var _ providers.Provider = (*boxProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Box. The scopes
+// are configured with the app in the Box Developer Console.
+var DefaultScopes = []string{}
+
+type boxProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	refreshes    providers.RotatingRefresh
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Box provider.
+type Opt func(*boxProvider)
+
+// WithScopes sets the additional scopes for the Box provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *boxProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// New creates a new Box provider.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *boxProvider {
+	p := &boxProvider{
+		id:           "box",
+		name:         "Box",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "BOX_CLIENT_ID"
+	EnvClientSecret = "BOX_CLIENT_SECRET"
+	EnvCallbackURL  = "BOX_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Box provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*boxProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new Box provider from the BOX_CLIENT_ID, BOX_CLIENT_SECRET
+// and BOX_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by BOX_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*boxProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (b *boxProvider) ID() string {
+	return b.id
+}
+
+// Name returns the provider's name.
+func (b *boxProvider) Name() string {
+	return b.name
+}
+
+// Type returns the provider's type.
+func (b *boxProvider) Type() providers.ProviderType {
+	return b.providerType
+}
+
+// Check validates the client credentials and the reachability of the Box endpoints.
+func (b *boxProvider) Check(ctx context.Context) error {
+	if b.clientKey == "" || b.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, b.client, AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (b *boxProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: b.config.AuthCodeURL(state),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process.
+func (b *boxProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		Login     string `json:"login"`
+		Language  string `json:"language"`
+		AvatarURL string `json:"avatar_url"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, b.client)
+
+	token, err := b.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = b.get(ctx, b.config.Client(ctx, token), UserURL, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name:   u.Name,
+		Email:  u.Login,
+		Locale: u.Language,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          b.ID(),
+				ProviderAccountID: cast.Ptr(u.ID),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(b.scopes)),
+			},
+		},
+	}
+
+	if utilx.NotEmpty(u.AvatarURL) {
+		user.Image = cast.Ptr(u.AvatarURL)
+	}
+
+	return user, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token. Box refresh tokens can only be used
+// once, the rotated refresh token of the new token replaces the stored one.
+func (b *boxProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, b.client)
+
+	return b.refreshes.Token(refreshToken, func() (*oauth2.Token, error) {
+		return b.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	})
+}
+
+func (b *boxProvider) get(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
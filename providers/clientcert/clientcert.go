@@ -0,0 +1,246 @@
+package clientcert
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+var (
+	// ErrMissingCertificate is returned when the client did not present a certificate.
+	ErrMissingCertificate = goth.NewError(fiber.StatusUnauthorized, "missing client certificate")
+	// ErrInvalidCertificate is returned when the certificate chain cannot be verified with the trusted CAs.
+	ErrInvalidCertificate = goth.NewError(fiber.StatusUnauthorized, "invalid client certificate")
+	// ErrMissingEmail is returned when the certificate has no email address.
+	ErrMissingEmail = goth.NewError(fiber.StatusForbidden, "client certificate has no email address")
+)
+
+// Identity is the user a certificate is mapped to.
+type Identity struct {
+	// Subject is the stable ID of the user, e.g. the subject DN that is kept when the certificate is renewed.
+	Subject string
+	// Email is the email of the user.
+	Email string
+	// Name is the display name of the user.
+	Name string
+}
+
+// Mapper maps the verified leaf certificate to the identity of the user.
+type Mapper func(ctx context.Context, cert *x509.Certificate) (Identity, error)
+
+// DefaultMapper maps the subject DN to the subject, the first email SAN (or a common name with "@")
+// to the email and the common name to the name of the user.
+func DefaultMapper(_ context.Context, cert *x509.Certificate) (Identity, error) {
+	id := Identity{
+		Subject: cert.Subject.String(),
+		Name:    cert.Subject.CommonName,
+	}
+
+	switch {
+	case len(cert.EmailAddresses) > 0:
+		id.Email = cert.EmailAddresses[0]
+	case strings.Contains(cert.Subject.CommonName, "@"):
+		id.Email = cert.Subject.CommonName
+	default:
+		return Identity{}, ErrMissingEmail
+	}
+
+	if id.Name == "" {
+		id.Name = id.Email
+	}
+
+	return id, nil
+}
+
+var _ providers.Provider = (*clientCertProvider)(nil)
+
+type clientCertProvider struct {
+	id           string
+	name         string
+	callbackURL  string
+	providerType providers.ProviderType
+	roots        *x509.CertPool
+	header       string
+	mapper       Mapper
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the client certificate provider.
+type Opt func(*clientCertProvider)
+
+// WithHeader reads the certificate chain from the header of a TLS terminating proxy instead of the
+// TLS connection, as URL encoded PEM (e.g. `X-Amzn-Mtls-Clientcert` or `$ssl_client_escaped_cert` of nginx).
+// The proxy must overwrite the header of the client.
+func WithHeader(header string) Opt {
+	return func(p *clientCertProvider) {
+		p.header = header
+	}
+}
+
+// WithMapper sets the function that maps the certificate to the user.
+func WithMapper(mapper Mapper) Opt {
+	return func(p *clientCertProvider) {
+		p.mapper = mapper
+	}
+}
+
+// New creates a new client certificate (mTLS) provider. The chains are verified against the roots
+// for client authentication. The server must request the client certificates, e.g. with
+// `tls.VerifyClientCertIfGiven`. Signing in redirects to the callback without an interactive flow.
+func New(roots *x509.CertPool, callbackURL string, opts ...Opt) *clientCertProvider {
+	p := &clientCertProvider{
+		id:           "clientcert",
+		name:         "Client Certificate",
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeCertificate,
+		roots:        roots,
+		mapper:       DefaultMapper,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// ID returns the provider's ID.
+func (p *clientCertProvider) ID() string {
+	return p.id
+}
+
+// Name returns the provider's name.
+func (p *clientCertProvider) Name() string {
+	return p.name
+}
+
+// Type returns the provider's type.
+func (p *clientCertProvider) Type() providers.ProviderType {
+	return p.providerType
+}
+
+// BeginAuth redirects to the callback, where the certificate of the client is verified.
+func (p *clientCertProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: p.callbackURL + "?" + url.Values{"state": {state}}.Encode(),
+	}, nil
+}
+
+// CompleteAuth verifies the certificate chain of the client and maps the leaf certificate to the user.
+func (p *clientCertProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	chain, err := p.chain(params)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if len(chain) == 0 {
+		return adapters.GothUser{}, ErrMissingCertificate
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         p.roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return adapters.GothUser{}, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+
+	id, err := p.mapper(ctx, chain[0])
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	return adapters.GothUser{
+		Name:          id.Name,
+		Email:         id.Email,
+		EmailVerified: cast.Ptr(true),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeCertificate,
+				Provider:          p.ID(),
+				ProviderAccountID: cast.Ptr(id.Subject),
+			},
+		},
+	}, nil
+}
+
+// chain returns the certificate chain of the TLS connection or of the header.
+func (p *clientCertProvider) chain(params providers.AuthParams) ([]*x509.Certificate, error) {
+	if p.header == "" {
+		tp, ok := params.(providers.TLSParams)
+		if !ok {
+			return nil, ErrMissingCertificate
+		}
+
+		return tp.PeerCertificates(), nil
+	}
+
+	hp, ok := params.(providers.HeaderParams)
+	if !ok {
+		return nil, ErrMissingCertificate
+	}
+
+	return ParseChain(hp.Header(p.header))
+}
+
+// ParseChain parses the URL encoded PEM certificates of a proxy header, leaf first.
+func ParseChain(value string) ([]*x509.Certificate, error) {
+	data, err := url.QueryUnescape(strings.TrimSpace(value))
+	if err != nil {
+		return nil, ErrInvalidCertificate
+	}
+
+	chain := []*x509.Certificate{}
+
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, ErrInvalidCertificate
+		}
+		chain = append(chain, cert)
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,349 @@
+package cognito
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrMissingIDToken is returned when the token response has no id_token.
+	ErrMissingIDToken = errors.New("goth: missing cognito id_token")
+	// ErrInvalidIDToken is returned when the id_token is not signed by the user pool or not issued for the client.
+	ErrInvalidIDToken = errors.New("goth: invalid cognito id_token")
+	// ErrUnverifiedEmail is returned when the email of the user is not verified in the user pool.
+	ErrUnverifiedEmail = errors.New("goth: email is not verified")
+)
+
+// keysExpiry is the duration the keys of the user pool are cached.
+const keysExpiry = time.Hour
+
+var _ providers.Provider = (*cognitoProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Cognito.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+type cognitoProvider struct {
+	id               string
+	name             string
+	clientKey        string
+	secret           string
+	callbackURL      string
+	baseURL          string
+	issuer           string
+	identityProvider string
+	providerType     providers.ProviderType
+	client           *http.Client
+	config           *oauth2.Config
+	scopes           []string
+	keys             *providers.KeySet
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Cognito provider.
+type Opt func(*cognitoProvider)
+
+// WithScopes sets the scopes for the Cognito provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *cognitoProvider) {
+		p.scopes = scopes
+	}
+}
+
+// WithIdentityProvider redirects the user to the federated identity provider of the user pool
+// (e.g. "Google" or the name of a SAML provider) instead of showing the hosted UI.
+func WithIdentityProvider(name string) Opt {
+	return func(p *cognitoProvider) {
+		p.identityProvider = name
+	}
+}
+
+// New creates a new Cognito provider for the app client of the user pool. The domain is either the
+// prefix of the Cognito domain (e.g. "example" for example.auth.<region>.amazoncognito.com)
+// or the custom domain of the hosted UI (e.g. "auth.example.com").
+func New(clientKey, secret, callbackURL, domain, region, userPoolID string, opts ...Opt) *cognitoProvider {
+	p := &cognitoProvider{
+		id:           "cognito",
+		name:         "Amazon Cognito",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		baseURL:      BaseURL(domain, region),
+		issuer:       Issuer(region, userPoolID),
+		providerType: providers.ProviderTypeOIDC,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.keys = providers.NewKeySet(p.issuer+"/.well-known/jwks.json", p.client, keysExpiry)
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   p.baseURL + "/oauth2/authorize",
+			TokenURL:  p.baseURL + "/oauth2/token",
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// BaseURL returns the URL of the hosted UI for the domain prefix or custom domain in the region.
+func BaseURL(domain, region string) string {
+	domain = strings.TrimSuffix(strings.TrimPrefix(domain, "https://"), "/")
+
+	if !strings.Contains(domain, ".") {
+		domain = fmt.Sprintf("%s.auth.%s.amazoncognito.com", domain, region)
+	}
+
+	return "https://" + domain
+}
+
+// Issuer returns the issuer of the tokens of the user pool in the region.
+func Issuer(region, userPoolID string) string {
+	return fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, userPoolID)
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "COGNITO_CLIENT_ID"
+	EnvClientSecret = "COGNITO_CLIENT_SECRET"
+	EnvCallbackURL  = "COGNITO_CALLBACK_URL"
+	EnvDomain       = "COGNITO_DOMAIN"
+	EnvRegion       = "COGNITO_REGION"
+	EnvUserPoolID   = "COGNITO_USER_POOL_ID"
+)
+
+// NewFromSource creates a new Cognito provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL, domain, region, userPoolID string, opts ...Opt) (*cognitoProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, domain, region, userPoolID, opts...), nil
+}
+
+// NewFromEnv creates a new Cognito provider from the COGNITO_CLIENT_ID, COGNITO_CLIENT_SECRET, COGNITO_CALLBACK_URL,
+// COGNITO_DOMAIN, COGNITO_REGION and COGNITO_USER_POOL_ID environment variables. The client secret can also be read
+// from the file referenced by COGNITO_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*cognitoProvider, error) {
+	values := map[string]string{}
+
+	for _, key := range []string{EnvClientID, EnvCallbackURL, EnvDomain, EnvRegion, EnvUserPoolID} {
+		v, err := providers.Env(key).Load()
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+
+	return NewFromSource(
+		values[EnvClientID],
+		providers.EnvOrFile(EnvClientSecret),
+		values[EnvCallbackURL],
+		values[EnvDomain],
+		values[EnvRegion],
+		values[EnvUserPoolID],
+		opts...,
+	)
+}
+
+// ID returns the provider's ID.
+func (c *cognitoProvider) ID() string {
+	return c.id
+}
+
+// Name returns the provider's name.
+func (c *cognitoProvider) Name() string {
+	return c.name
+}
+
+// Type returns the provider's type.
+func (c *cognitoProvider) Type() providers.ProviderType {
+	return c.providerType
+}
+
+// Check validates the client credentials and the reachability of the hosted UI and the keys of the user pool.
+func (c *cognitoProvider) Check(ctx context.Context) error {
+	if c.clientKey == "" || c.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	if err := providers.CheckEndpoint(ctx, c.client, c.issuer+"/.well-known/jwks.json"); err != nil {
+		return err
+	}
+
+	return providers.CheckEndpoint(ctx, c.client, c.baseURL+"/oauth2/authorize")
+}
+
+// BeginAuth starts the authentication process with the hosted UI.
+func (c *cognitoProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, params providers.AuthParams) (providers.AuthIntent, error) {
+	opts := []oauth2.AuthCodeOption{}
+
+	if c.identityProvider != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("identity_provider", c.identityProvider))
+	}
+
+	if hint := params.Get("login_hint"); hint != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("login_hint", hint))
+	}
+
+	return &authIntent{
+		authURL: c.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process. The profile is read from the validated id_token,
+// the groups of the user (cognito:groups) are set as GothUser.Groups.
+func (c *cognitoProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.client)
+
+	token, err := c.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return adapters.GothUser{}, ErrMissingIDToken
+	}
+
+	claims, err := c.validate(ctx, idToken)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if !claims.EmailVerified {
+		return adapters.GothUser{}, ErrUnverifiedEmail
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Username
+	}
+
+	return adapters.GothUser{
+		Name:          name,
+		Email:         claims.Email,
+		EmailVerified: cast.Ptr(bool(claims.EmailVerified)),
+		Image:         cast.Ptr(claims.Picture),
+		Groups:        claims.Groups,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOIDC,
+				Provider:          c.ID(),
+				ProviderAccountID: cast.Ptr(claims.Subject),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(c.config.Scopes)),
+				IDToken:           cast.Ptr(idToken),
+			},
+		},
+	}, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token.
+func (c *cognitoProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.client)
+
+	return c.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+// LogoutURL returns the URL of the logout endpoint of the hosted UI, which signs the user out of the
+// user pool and redirects to the logout URI. The logout URI must be an allowed sign-out URL of the app client.
+func (c *cognitoProvider) LogoutURL(logoutURI string) string {
+	q := url.Values{}
+	q.Set("client_id", c.clientKey)
+	q.Set("logout_uri", logoutURI)
+
+	return c.baseURL + "/logout?" + q.Encode()
+}
+
+// boolish is a bool that Cognito encodes either as JSON bool or as string.
+type boolish bool
+
+// UnmarshalJSON decodes true, false, "true" and "false".
+func (b *boolish) UnmarshalJSON(data []byte) error {
+	*b = boolish(strings.Trim(string(data), `"`) == "true")
+
+	return nil
+}
+
+// idTokenClaims are the claims of the id_token.
+type idTokenClaims struct {
+	TokenUse      string   `json:"token_use"`
+	Email         string   `json:"email"`
+	EmailVerified boolish  `json:"email_verified"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+	Username      string   `json:"cognito:username"`
+	Groups        []string `json:"cognito:groups"`
+
+	jwt.RegisteredClaims
+}
+
+// validate parses the id_token and validates the signature, issuer, audience, token use and expiry.
+func (c *cognitoProvider) validate(ctx context.Context, idToken string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return c.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(c.issuer),
+		jwt.WithAudience(c.clientKey),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if claims.TokenUse != "id" || utilx.Empty(claims.Subject) {
+		return nil, ErrInvalidIDToken
+	}
+
+	return claims, nil
+}
@@ -2,18 +2,57 @@ package credentials
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	goth "github.com/zeiss/fiber-goth"
 	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
 	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
 	"github.com/zeiss/pkg/dbx"
+	"github.com/zeiss/pkg/utilx"
 	"golang.org/x/crypto/bcrypt"
 
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrMissingCredentials is returned when the email or password is missing.
+	ErrMissingCredentials = errors.New("goth: missing email or password")
+	// ErrInvalidCredentials is returned when the email or password is wrong.
+	ErrInvalidCredentials = errors.New("goth: invalid email or password")
+	// ErrWeakPassword is returned when a new password does not meet the minimum length.
+	ErrWeakPassword = goth.NewError(fiber.StatusBadRequest, "password must have at least 8 characters")
+)
+
+// MinPasswordLength is the minimum length of a new password.
+const MinPasswordLength = 8
+
+// dummyHash is compared when no user is found, so that unknown accounts take as long as wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := dbx.HashPassword([]byte("goth: dummy password"))
+	return hash
+})
+
+// checkPassword looks up the active user of the email and compares the password with its hash.
+func (e *credentialsProvider) checkPassword(ctx context.Context, email, password string) (User, error) {
+	var u User
+	err := e.db.WithContext(ctx).Where("email = ? AND active = ?", email, true).First(&u).Error
+	if err != nil {
+		_ = dbx.CheckPassword([]byte(password), dummyHash())
+		return u, err
+	}
+
+	return u, dbx.CheckPassword([]byte(password), u.HashedPassword)
+}
+
+var _ providers.Provider = (*credentialsProvider)(nil)
+
 type User struct {
 	// ID is the unique identifier of the user.
 	ID uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
@@ -46,7 +85,11 @@ func (user *User) SetNewPassword(password string) error {
 }
 
 type credentialsProvider struct {
-	db *gorm.DB
+	id           string
+	name         string
+	providerType providers.ProviderType
+	db           *gorm.DB
+	throttler    *goth.Throttler
 
 	providers.UnimplementedProvider
 }
@@ -67,10 +110,20 @@ func (a *authIntent) GetAuthURL() (string, error) {
 // Opt is a function that configures the credentials provider.
 type Opt func(*credentialsProvider)
 
-// New creates a new GitHub provider.
+// WithThrottler sets the throttler that limits failed attempts per email.
+func WithThrottler(t *goth.Throttler) Opt {
+	return func(p *credentialsProvider) {
+		p.throttler = t
+	}
+}
+
+// New creates a new credentials provider.
 func New(db *gorm.DB, opts ...Opt) *credentialsProvider {
 	p := &credentialsProvider{
-		db: db,
+		id:           "credentials",
+		name:         "Credentials",
+		providerType: providers.ProviderTypeEmail,
+		db:           db,
 	}
 
 	for _, opt := range opts {
@@ -80,6 +133,21 @@ func New(db *gorm.DB, opts ...Opt) *credentialsProvider {
 	return p
 }
 
+// ID returns the provider's ID.
+func (e *credentialsProvider) ID() string {
+	return e.id
+}
+
+// Name returns the provider's name.
+func (e *credentialsProvider) Name() string {
+	return e.name
+}
+
+// Type returns the provider's type.
+func (e *credentialsProvider) Type() providers.ProviderType {
+	return e.providerType
+}
+
 // HashPassword returns the bcrypt hash of the password
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -90,9 +158,125 @@ func HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
+// Stateless returns true, the credentials form is posted to the callback without a state.
+func (e *credentialsProvider) Stateless() bool {
+	return true
+}
+
 // BeginAuth starts the authentication process.
 func (e *credentialsProvider) BeginAuth(ctx context.Context, adapter adapters.Adapter, state string, params providers.AuthParams) (providers.AuthIntent, error) {
 	return &authIntent{
 		authURL: "",
 	}, nil
 }
+
+// CompleteAuth completes the authentication process.
+func (e *credentialsProvider) CompleteAuth(ctx context.Context, adapter adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	email := params.Get("email")
+	password := params.Get("password")
+
+	if utilx.Empty(email) || utilx.Empty(password) {
+		return adapters.GothUser{}, ErrMissingCredentials
+	}
+
+	key := goth.ThrottleKey("email", email)
+
+	err := e.throttler.Allow(ctx, key)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	u, err := e.checkPassword(ctx, email, password)
+
+	if err != nil {
+		return adapters.GothUser{}, ErrInvalidCredentials
+	}
+
+	err = e.throttler.Reset(ctx, key)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name:  u.Name,
+		Email: u.Email,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeEmail,
+				Provider:          e.ID(),
+				ProviderAccountID: cast.Ptr(u.ID.String()),
+			},
+		},
+	}
+
+	return user, nil
+}
+
+// ChangePasswordHandler returns a handler to change the password of the current user.
+// It expects the `current_password` and `new_password` parameters and must be mounted
+// behind the protect middleware.
+func (e *credentialsProvider) ChangePasswordHandler(config ...goth.Config) fiber.Handler {
+	cfg := goth.ConfigDefault
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	errorHandler := cfg.ErrorHandler
+	if errorHandler == nil {
+		errorHandler = goth.ConfigDefault.ErrorHandler
+	}
+
+	emitter := cfg.Events
+	if emitter == nil {
+		emitter = events.Noop
+	}
+
+	return func(c *fiber.Ctx) error {
+		user, err := goth.UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return errorHandler(c, goth.ErrMissingUser)
+		}
+
+		params := goth.ParamsFromContext(c)
+		current := params.Get("current_password")
+		password := params.Get("new_password")
+
+		if len(password) < MinPasswordLength {
+			return errorHandler(c, ErrWeakPassword)
+		}
+
+		key := goth.ThrottleKey("email", user.Email)
+
+		err = e.throttler.Allow(c.Context(), key)
+		if err != nil {
+			return errorHandler(c, err)
+		}
+
+		u, err := e.checkPassword(c.Context(), user.Email, current)
+
+		if err != nil {
+			return errorHandler(c, ErrInvalidCredentials)
+		}
+
+		err = u.SetNewPassword(password)
+		if err != nil {
+			return errorHandler(c, err)
+		}
+
+		err = e.db.WithContext(c.Context()).Model(&u).Update("hashed_password", u.HashedPassword).Error
+		if err != nil {
+			return errorHandler(c, err)
+		}
+
+		err = e.throttler.Reset(c.Context(), key)
+		if err != nil {
+			return errorHandler(c, err)
+		}
+
+		event := events.New(events.UserPasswordChanged, user.ID)
+		event.Provider = e.ID()
+		emitter.Emit(c.Context(), event)
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
@@ -0,0 +1,48 @@
+package credentials
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+func TestCallbackWithFlowStore(t *testing.T) {
+	providers.RegisterProvider(New(nil))
+
+	var types []events.Type
+	cfg := goth.Config{
+		Adapter:   &adapters.UnimplementedAdapter{},
+		FlowStore: adapters.NewMemoryFlowStore(),
+		Events: events.EmitterFunc(func(_ context.Context, e events.Event) {
+			types = append(types, e.Type)
+		}),
+	}
+
+	app := fiber.New()
+	app.Post("/auth/:provider/callback", goth.NewCompleteAuthHandler(cfg))
+
+	// the form is posted without a state, the missing password fails in CompleteAuth
+	req := httptest.NewRequest(http.MethodPost, "/auth/credentials/callback", strings.NewReader("email=user%40example.com"))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("unexpected status %d", res.StatusCode)
+	}
+
+	if len(types) != 2 || types[1] != events.LoginExchangeFailed {
+		t.Fatalf("expected the callback to reach CompleteAuth, got events %v", types)
+	}
+}
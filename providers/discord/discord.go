@@ -0,0 +1,330 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/slices"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUnverifiedEmail is returned when the email of the user is not verified by Discord.
+	ErrUnverifiedEmail = errors.New("goth: email is not verified")
+	// ErrNotAllowedGuild is returned when the user is not a member of an allowed guild.
+	ErrNotAllowedGuild = errors.New("goth: user not in allowed guild")
+)
+
+const (
+	// AuthURL is the authorization endpoint of Discord.
+	AuthURL = "https://discord.com/oauth2/authorize"
+	// TokenURL is the token endpoint of Discord.
+	TokenURL = "https://discord.com/api/oauth2/token"
+	// UserURL is the endpoint of the authenticated user.
+	UserURL = "https://discord.com/api/users/@me"
+	// GuildsURL is the endpoint of the guilds of the authenticated user.
+	GuildsURL = "https://discord.com/api/users/@me/guilds"
+	// AvatarURL is the CDN URL of the avatars.
+	AvatarURL = "https://cdn.discordapp.com/avatars"
+
+	// GuildsScope is the scope to read the guilds of the user.
+	GuildsScope = "guilds"
+)
+
+var _ providers.Provider = (*discordProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Discord.
+var DefaultScopes = []string{"identify", "email"}
+
+type discordProvider struct {
+	id            string
+	name          string
+	clientKey     string
+	secret        string
+	callbackURL   string
+	providerType  providers.ProviderType
+	client        *http.Client
+	config        *oauth2.Config
+	scopes        []string
+	allowedGuilds []string
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Discord provider.
+type Opt func(*discordProvider)
+
+// WithScopes sets the additional scopes for the Discord provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *discordProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// WithAllowedGuilds restricts the sign-in to members of the guilds (server IDs).
+// The guilds scope is requested to read the guilds of the user.
+func WithAllowedGuilds(guilds ...string) Opt {
+	return func(p *discordProvider) {
+		p.allowedGuilds = guilds
+	}
+}
+
+// New creates a new Discord provider.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *discordProvider {
+	p := &discordProvider{
+		id:            "discord",
+		name:          "Discord",
+		clientKey:     clientKey,
+		secret:        secret,
+		callbackURL:   callbackURL,
+		providerType:  providers.ProviderTypeOAuth2,
+		client:        providers.DefaultClient,
+		scopes:        DefaultScopes,
+		allowedGuilds: []string{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if len(p.allowedGuilds) > 0 && !slices.Any(func(s string) bool { return s == GuildsScope }, p.scopes...) {
+		p.scopes = append(p.scopes, GuildsScope)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "DISCORD_CLIENT_ID"
+	EnvClientSecret = "DISCORD_CLIENT_SECRET"
+	EnvCallbackURL  = "DISCORD_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Discord provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*discordProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new Discord provider from the DISCORD_CLIENT_ID, DISCORD_CLIENT_SECRET
+// and DISCORD_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by DISCORD_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*discordProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (d *discordProvider) ID() string {
+	return d.id
+}
+
+// Name returns the provider's name.
+func (d *discordProvider) Name() string {
+	return d.name
+}
+
+// Type returns the provider's type.
+func (d *discordProvider) Type() providers.ProviderType {
+	return d.providerType
+}
+
+// Check validates the client credentials and the reachability of the Discord endpoints.
+func (d *discordProvider) Check(ctx context.Context) error {
+	if d.clientKey == "" || d.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, d.client, AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (d *discordProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: d.config.AuthCodeURL(state, oauth2.SetAuthURLParam("prompt", "none")),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process.
+// nolint:gocyclo
+func (d *discordProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+		Avatar     string `json:"avatar"`
+		Email      string `json:"email"`
+		Verified   bool   `json:"verified"`
+		Locale     string `json:"locale"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, d.client)
+
+	token, err := d.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	client := d.config.Client(ctx, token)
+
+	err = d.get(ctx, client, UserURL, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if !u.Verified {
+		return adapters.GothUser{}, ErrUnverifiedEmail
+	}
+
+	if len(d.allowedGuilds) > 0 {
+		guilds, err := d.guilds(ctx, client)
+		if err != nil {
+			return adapters.GothUser{}, err
+		}
+
+		if !slices.Any(checkGuild(guilds), d.allowedGuilds...) {
+			return adapters.GothUser{}, ErrNotAllowedGuild
+		}
+	}
+
+	user := adapters.GothUser{
+		Name:          u.GlobalName,
+		Email:         u.Email,
+		EmailVerified: cast.Ptr(u.Verified),
+		Locale:        u.Locale,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          d.ID(),
+				ProviderAccountID: cast.Ptr(u.ID),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+			},
+		},
+	}
+
+	if utilx.Empty(user.Name) {
+		user.Name = u.Username
+	}
+
+	if utilx.NotEmpty(u.Avatar) {
+		user.Image = cast.Ptr(fmt.Sprintf("%s/%s/%s.png", AvatarURL, u.ID, u.Avatar))
+	}
+
+	return user, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token.
+func (d *discordProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, d.client)
+
+	return d.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+// guilds returns the IDs of the guilds of the user.
+func (d *discordProvider) guilds(ctx context.Context, client *http.Client) ([]string, error) {
+	res := []struct {
+		ID string `json:"id"`
+	}{}
+
+	err := d.get(ctx, client, GuildsURL, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	guilds := make([]string, 0, len(res))
+	for _, g := range res {
+		guilds = append(guilds, g.ID)
+	}
+
+	return guilds, nil
+}
+
+// checkGuild returns a function that checks if a guild is one of the guilds of the user.
+func checkGuild(guilds []string) func(string) bool {
+	return func(guild string) bool {
+		for _, g := range guilds {
+			if g == guild {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func (d *discordProvider) get(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
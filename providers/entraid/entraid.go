@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 
 	"github.com/zeiss/fiber-goth/adapters"
 	"github.com/zeiss/fiber-goth/providers"
@@ -21,6 +22,8 @@ var DefaultScopes = []ScopeType{OpenIDScope, ProfileScope, EmailScope, UserReadS
 // also https://docs.microsoft.com/en-us/azure/active-directory/develop/active-directory-v2-protocols#endpoints
 const (
 	GraphAPIURL string = "https://graph.microsoft.com/v1.0/"
+	// DiscoveryURL is the OpenID Connect discovery document of a tenant.
+	DiscoveryURL string = "https://login.microsoftonline.com/%s/v2.0/.well-known/openid-configuration"
 )
 
 type entraIdProvider struct {
@@ -29,6 +32,7 @@ type entraIdProvider struct {
 	clientKey    string
 	secret       string
 	callbackURL  string
+	tenant       TenantType
 	providerType providers.ProviderType
 	client       *http.Client
 	config       *oauth2.Config
@@ -60,11 +64,47 @@ func New(clientKey, secret, callbackURL string, tenentType TenantType, scopes ..
 		providerType: providers.ProviderTypeOAuth2,
 		client:       providers.DefaultClient,
 	}
-	p.config = newConfig(p, utilx.IfElse(utilx.NotEmpty(tenentType), tenentType, CommonTenant), scopes...)
+	p.tenant = utilx.IfElse(utilx.NotEmpty(tenentType), tenentType, CommonTenant)
+	p.config = newConfig(p, p.tenant, scopes...)
 
 	return p
 }
 
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "ENTRAID_CLIENT_ID"
+	EnvClientSecret = "ENTRAID_CLIENT_SECRET"
+	EnvCallbackURL  = "ENTRAID_CALLBACK_URL"
+	EnvTenant       = "ENTRAID_TENANT"
+)
+
+// NewFromSource creates a new EntraID provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, tenentType TenantType, scopes ...ScopeType) (*entraIdProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, tenentType, scopes...), nil
+}
+
+// NewFromEnv creates a new EntraID provider from the ENTRAID_CLIENT_ID, ENTRAID_CLIENT_SECRET,
+// ENTRAID_CALLBACK_URL and the optional ENTRAID_TENANT environment variables. The client
+// secret can also be read from the file referenced by ENTRAID_CLIENT_SECRET_FILE.
+func NewFromEnv(scopes ...ScopeType) (*entraIdProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, TenantType(os.Getenv(EnvTenant)), scopes...)
+}
+
 // ID returns the provider's ID.
 func (g *entraIdProvider) ID() string {
 	return g.id
@@ -80,6 +120,30 @@ func (g *entraIdProvider) Type() providers.ProviderType {
 	return g.providerType
 }
 
+// Check validates the client credentials and discovers the OpenID Connect configuration of the tenant.
+func (g *entraIdProvider) Check(ctx context.Context) error {
+	if g.clientKey == "" || g.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(DiscoveryURL, g.tenant), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: discovery of tenant %s failed with %s", g.tenant, res.Status)
+	}
+
+	return nil
+}
+
 func newConfig(p *entraIdProvider, tenant TenantType, scopes ...ScopeType) *oauth2.Config {
 	c := &oauth2.Config{
 		ClientID:     p.clientKey,
@@ -127,7 +191,7 @@ func (e *entraIdProvider) BeginAuth(ctx context.Context, adapter adapters.Adapte
 
 // CompleteAuth completes the authentication process.
 // nolint:gocyclo
-func (e *entraIdProvider) CompleteAuth(ctx context.Context, adapter adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+func (e *entraIdProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
 	u := struct {
 		ID                string   `json:"id"`                // The unique identifier for the user.
 		BusinessPhones    []string `json:"businessPhones"`    // The user's phone numbers.
@@ -152,20 +216,7 @@ func (e *entraIdProvider) CompleteAuth(ctx context.Context, adapter adapters.Ada
 		return adapters.GothUser{}, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(GraphAPIURL+"me"), nil)
-	if err != nil {
-		return adapters.GothUser{}, err
-	}
-	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
-
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return adapters.GothUser{}, err
-	}
-	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
-	defer resp.Body.Close()
-
-	err = json.NewDecoder(resp.Body).Decode(&u)
+	err = e.me(ctx, token, &u)
 	if err != nil {
 		return adapters.GothUser{}, err
 	}
@@ -182,35 +233,78 @@ func (e *entraIdProvider) CompleteAuth(ctx context.Context, adapter adapters.Ada
 				AccessToken:       cast.Ptr(token.AccessToken),
 				RefreshToken:      cast.Ptr(token.RefreshToken),
 				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
 			},
 		},
 	}
 
-	user, err = adapter.CreateUser(ctx, user)
+	return user, nil
+}
+
+// BeginUpgrade starts the incremental consent of additional scopes for the linked account.
+func (e *entraIdProvider) BeginUpgrade(_ context.Context, _ adapters.GothAccount, state string, scopes []string) (providers.AuthIntent, error) {
+	url := e.config.AuthCodeURL(state, oauth2.SetAuthURLParam("scope", providers.MergeScopes(e.config.Scopes, scopes)))
+
+	return &authIntent{
+		authURL: url,
+	}, nil
+}
+
+// CompleteUpgrade exchanges the code for a token with the additional scopes.
+func (e *entraIdProvider) CompleteUpgrade(ctx context.Context, params providers.AuthParams) (adapters.GothAccount, error) {
+	u := struct {
+		ID string `json:"id"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothAccount{}, providers.ErrMissingCode
+	}
+
+	token, err := e.config.Exchange(ctx, code)
 	if err != nil {
-		return adapters.GothUser{}, err
+		return adapters.GothAccount{}, err
 	}
 
-	user, err = adapter.GetUser(ctx, user.ID)
+	err = e.me(ctx, token, &u)
 	if err != nil {
-		return adapters.GothUser{}, err
+		return adapters.GothAccount{}, err
 	}
 
-	return user, nil
+	return adapters.GothAccount{
+		Type:              adapters.AccountTypeOAuth2,
+		Provider:          e.ID(),
+		ProviderAccountID: cast.Ptr(u.ID),
+		AccessToken:       cast.Ptr(token.AccessToken),
+		RefreshToken:      cast.Ptr(token.RefreshToken),
+		ExpiresAt:         cast.Ptr(token.Expiry),
+		TokenType:         cast.Ptr(token.TokenType),
+		Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+	}, nil
+}
+
+// me fetches the signed-in user from the Graph API.
+func (e *entraIdProvider) me(ctx context.Context, token *oauth2.Token, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, GraphAPIURL+"me", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
 }
 
-// // RefreshTokenAvailable refresh token is provided by auth provider or not
-// func (p *Provider) RefreshTokenAvailable() bool {
-// 	return true
-// }
-
-// // RefreshToken get new access token based on the refresh token
-// func (p *Provider) RefreshToken(refreshToken string) (*oauth2.Token, error) {
-// 	token := &oauth2.Token{RefreshToken: refreshToken}
-// 	ts := p.config.TokenSource(goth.ContextForClient(p.Client()), token)
-// 	newToken, err := ts.Token()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return newToken, err
-// }
+// RefreshToken exchanges the refresh token for a new token.
+func (e *entraIdProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, e.client)
+
+	return e.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
@@ -0,0 +1,158 @@
+package entraid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/pkg/cast"
+	"golang.org/x/oauth2"
+)
+
+// TokenURL is the token endpoint of a tenant.
+const TokenURL string = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+
+// grantTypeJWTBearer is the grant type of the on-behalf-of flow.
+const grantTypeJWTBearer = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// OnBehalfOf exchanges the access tokens of users for tokens of a downstream API
+// with the on-behalf-of (OBO) flow. The tokens are cached per session.
+type OnBehalfOf struct {
+	provider *entraIdProvider
+	scopes   []string
+	tokenURL string
+
+	mu    sync.Mutex
+	cache map[string]*oauth2.Token
+}
+
+// OnBehalfOf returns an on-behalf-of helper of the provider requesting the scopes
+// of the downstream API (e.g. "api://downstream/.default").
+func (e *entraIdProvider) OnBehalfOf(scopes ...string) *OnBehalfOf {
+	return &OnBehalfOf{
+		provider: e,
+		scopes:   scopes,
+		tokenURL: fmt.Sprintf(TokenURL, e.tenant),
+		cache:    map[string]*oauth2.Token{},
+	}
+}
+
+// Token returns a token of the downstream API for the session, exchanging the
+// assertion (the access token of the user for this app) if there is no valid token cached.
+func (o *OnBehalfOf) Token(ctx context.Context, sessionID string, assertion string) (*oauth2.Token, error) {
+	if t, ok := o.cached(sessionID); ok {
+		return t, nil
+	}
+
+	t, err := o.exchange(ctx, assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.prune()
+	o.cache[sessionID] = t
+
+	return t, nil
+}
+
+// SessionToken returns a token of the downstream API for the session, using the
+// access token of the account of the user stored by the adapter as assertion.
+func (o *OnBehalfOf) SessionToken(ctx context.Context, adapter adapters.Adapter, session adapters.GothSession) (*oauth2.Token, error) {
+	if t, ok := o.cached(session.ID.String()); ok {
+		return t, nil
+	}
+
+	account, err := adapter.GetAccount(ctx, session.UserID, o.provider.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Token(ctx, session.ID.String(), cast.Value(account.AccessToken))
+}
+
+// Forget removes the cached token of the session, e.g. on logout.
+func (o *OnBehalfOf) Forget(sessionID string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.cache, sessionID)
+}
+
+// cached returns the valid cached token of the session.
+func (o *OnBehalfOf) cached(sessionID string) (*oauth2.Token, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	t, ok := o.cache[sessionID]
+
+	return t, ok && t.Valid()
+}
+
+// prune removes the expired tokens from the cache.
+func (o *OnBehalfOf) prune() {
+	for id, t := range o.cache {
+		if !t.Valid() {
+			delete(o.cache, id)
+		}
+	}
+}
+
+func (o *OnBehalfOf) exchange(ctx context.Context, assertion string) (*oauth2.Token, error) {
+	form := url.Values{
+		"grant_type":          {grantTypeJWTBearer},
+		"client_id":           {o.provider.clientKey},
+		"client_secret":       {o.provider.secret},
+		"assertion":           {assertion},
+		"scope":               {strings.Join(o.scopes, " ")},
+		"requested_token_use": {"on_behalf_of"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := o.provider.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body := struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		RefreshToken     string `json:"refresh_token"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, &oauth2.RetrieveError{Response: res, ErrorCode: body.Error, ErrorDescription: body.ErrorDescription}
+	}
+
+	t := &oauth2.Token{
+		AccessToken:  body.AccessToken,
+		TokenType:    body.TokenType,
+		RefreshToken: body.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}
+
+	return t.WithExtra(map[string]any{"scope": body.Scope}), nil
+}
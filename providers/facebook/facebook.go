@@ -0,0 +1,306 @@
+package facebook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// ErrMissingEmail is returned when Facebook does not return an email of the user,
+// e.g. because the user declined the email permission or has no confirmed email.
+var ErrMissingEmail = errors.New("goth: facebook user has no email")
+
+const (
+	// GraphURL is the base URL of the Facebook Graph API.
+	GraphURL = "https://graph.facebook.com"
+	// DefaultGraphVersion is the default version of the Graph API.
+	DefaultGraphVersion = "v19.0"
+)
+
+var _ providers.Provider = (*facebookProvider)(nil)
+
+var (
+	// DefaultScopes holds the default scopes used for Facebook.
+	DefaultScopes = []string{"email", "public_profile"}
+	// DefaultFields holds the default fields requested from the Graph API `/me` endpoint.
+	DefaultFields = []string{"id", "name", "email", "picture"}
+)
+
+type facebookProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	graphURL     string
+	graphVersion string
+	fields       []string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Facebook provider.
+type Opt func(*facebookProvider)
+
+// WithScopes sets the additional scopes for the Facebook provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *facebookProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// WithFields sets the fields requested from the Graph API `/me` endpoint.
+// The `id` field is always requested.
+func WithFields(fields ...string) Opt {
+	return func(p *facebookProvider) {
+		p.fields = fields
+	}
+}
+
+// WithGraphVersion sets the version of the Graph API (e.g. "v19.0").
+func WithGraphVersion(version string) Opt {
+	return func(p *facebookProvider) {
+		p.graphVersion = version
+	}
+}
+
+// WithGraphURL sets the base URL of the Graph API, e.g. for a proxy.
+func WithGraphURL(url string) Opt {
+	return func(p *facebookProvider) {
+		p.graphURL = url
+	}
+}
+
+// New creates a new Facebook provider.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *facebookProvider {
+	p := &facebookProvider{
+		id:           "facebook",
+		name:         "Facebook",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		graphURL:     GraphURL,
+		graphVersion: DefaultGraphVersion,
+		fields:       DefaultFields,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint:     endpoints.Facebook,
+		Scopes:       p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "FACEBOOK_CLIENT_ID"
+	EnvClientSecret = "FACEBOOK_CLIENT_SECRET"
+	EnvCallbackURL  = "FACEBOOK_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Facebook provider loading the app secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*facebookProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new Facebook provider from the FACEBOOK_CLIENT_ID, FACEBOOK_CLIENT_SECRET
+// and FACEBOOK_CALLBACK_URL environment variables. The app secret can also be read
+// from the file referenced by FACEBOOK_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*facebookProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (f *facebookProvider) ID() string {
+	return f.id
+}
+
+// Name returns the provider's name.
+func (f *facebookProvider) Name() string {
+	return f.name
+}
+
+// Type returns the provider's type.
+func (f *facebookProvider) Type() providers.ProviderType {
+	return f.providerType
+}
+
+// Check validates the client credentials and the reachability of the Facebook endpoints.
+func (f *facebookProvider) Check(ctx context.Context) error {
+	if f.clientKey == "" || f.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, f.client, f.config.Endpoint.AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (f *facebookProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: f.config.AuthCodeURL(state),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process.
+func (f *facebookProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Email   string `json:"email"`
+		Picture struct {
+			Data struct {
+				URL string `json:"url"`
+			} `json:"data"`
+		} `json:"picture"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, f.client)
+
+	token, err := f.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = f.get(ctx, token, "me", url.Values{"fields": {f.fieldList()}}, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if utilx.Empty(u.Email) {
+		return adapters.GothUser{}, ErrMissingEmail
+	}
+
+	user := adapters.GothUser{
+		Name:  u.Name,
+		Email: u.Email,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          f.ID(),
+				ProviderAccountID: cast.Ptr(u.ID),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(f.config.Scopes)),
+			},
+		},
+	}
+
+	if utilx.NotEmpty(u.Picture.Data.URL) {
+		user.Image = cast.Ptr(u.Picture.Data.URL)
+	}
+
+	return user, nil
+}
+
+// fieldList returns the comma separated fields requested from the `/me` endpoint.
+func (f *facebookProvider) fieldList() string {
+	fields := []string{"id"}
+
+	for _, field := range f.fields {
+		if field = strings.TrimSpace(field); field != "" && field != "id" {
+			fields = append(fields, field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// AppSecretProof returns the `appsecret_proof` of the access token, the hex encoded
+// HMAC-SHA256 of the token keyed with the app secret. Facebook rejects Graph calls
+// without a valid proof if "Require App Secret" is enabled for the app.
+func AppSecretProof(accessToken, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(accessToken))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// get calls the Graph API with the token and the appsecret_proof of the token.
+func (f *facebookProvider) get(ctx context.Context, token *oauth2.Token, path string, q url.Values, v any) error {
+	q.Set("appsecret_proof", AppSecretProof(token.AccessToken, f.secret))
+
+	endpoint := fmt.Sprintf("%s/%s/%s?%s", strings.TrimSuffix(f.graphURL, "/"), f.graphVersion, path, q.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := f.config.Client(ctx, token).Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -97,6 +97,40 @@ func New(clientKey, secret, callbackURL string, opts ...Opt) *githubProvider {
 	return p
 }
 
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "GITHUB_CLIENT_ID"
+	EnvClientSecret = "GITHUB_CLIENT_SECRET"
+	EnvCallbackURL  = "GITHUB_CALLBACK_URL"
+)
+
+// NewFromSource creates a new GitHub provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*githubProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new GitHub provider from the GITHUB_CLIENT_ID, GITHUB_CLIENT_SECRET
+// and GITHUB_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by GITHUB_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*githubProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
 // ID returns the provider's ID.
 func (g *githubProvider) ID() string {
 	return g.id
@@ -125,6 +159,15 @@ func (a *authIntent) GetAuthURL() (string, error) {
 	return a.authURL, nil
 }
 
+// Check validates the client credentials and the reachability of the GitHub endpoints.
+func (g *githubProvider) Check(ctx context.Context) error {
+	if g.clientKey == "" || g.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, g.client, g.config.Endpoint.AuthURL)
+}
+
 // BeginAuth starts the authentication process.
 func (g *githubProvider) BeginAuth(ctx context.Context, adapter adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
 	verifier := oauth2.GenerateVerifier()
@@ -137,17 +180,7 @@ func (g *githubProvider) BeginAuth(ctx context.Context, adapter adapters.Adapter
 
 // CompleteAuth completes the authentication process.
 // nolint:gocyclo
-func (g *githubProvider) CompleteAuth(ctx context.Context, adapter adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
-	u := struct {
-		ID       int    `json:"id"`
-		Email    string `json:"email"`
-		Bio      string `json:"bio"`
-		Name     string `json:"name"`
-		Login    string `json:"login"`
-		Picture  string `json:"avatar_url"`
-		Location string `json:"location"`
-	}{}
-
+func (g *githubProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
 	code := params.Get("code")
 	if code == "" {
 		return adapters.GothUser{}, adapters.ErrUnimplemented
@@ -158,13 +191,9 @@ func (g *githubProvider) CompleteAuth(ctx context.Context, adapter adapters.Adap
 		return adapters.GothUser{}, err
 	}
 
-	gc := github.NewClient(g.config.Client(ctx, token))
-
-	if utilx.NotEmpty(g.enterpriseURL) {
-		gc, err = gc.WithEnterpriseURLs(g.enterpriseURL, g.enterpriseURL)
-		if err != nil {
-			return adapters.GothUser{}, err
-		}
+	gc, err := g.newClient(ctx, token)
+	if err != nil {
+		return adapters.GothUser{}, err
 	}
 
 	gu, _, err := gc.Users.Get(ctx, "")
@@ -180,10 +209,12 @@ func (g *githubProvider) CompleteAuth(ctx context.Context, adapter adapters.Adap
 			{
 				Type:              adapters.AccountTypeOAuth2,
 				Provider:          g.ID(),
-				ProviderAccountID: cast.Ptr(strconv.Itoa(u.ID)),
+				ProviderAccountID: cast.Ptr(strconv.FormatInt(gu.GetID(), 10)),
 				AccessToken:       cast.Ptr(token.AccessToken),
 				RefreshToken:      cast.Ptr(token.RefreshToken),
 				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
 				SessionState:      token.Extra("state").(string),
 			},
 		},
@@ -219,17 +250,69 @@ func (g *githubProvider) CompleteAuth(ctx context.Context, adapter adapters.Adap
 		return adapters.GothUser{}, ErrNotAllowedOrg
 	}
 
-	user, err = adapter.CreateUser(ctx, user)
+	return user, nil
+}
+
+// BeginUpgrade starts the authorization of additional scopes for the linked account.
+// The scopes are requested together with the configured scopes.
+func (g *githubProvider) BeginUpgrade(_ context.Context, _ adapters.GothAccount, state string, scopes []string) (providers.AuthIntent, error) {
+	url := g.config.AuthCodeURL(state, oauth2.SetAuthURLParam("scope", providers.MergeScopes(g.config.Scopes, scopes)))
+
+	return &authIntent{
+		authURL: url,
+	}, nil
+}
+
+// CompleteUpgrade exchanges the code for a token with the additional scopes.
+func (g *githubProvider) CompleteUpgrade(ctx context.Context, params providers.AuthParams) (adapters.GothAccount, error) {
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothAccount{}, providers.ErrMissingCode
+	}
+
+	token, err := g.config.Exchange(ctx, code)
 	if err != nil {
-		return adapters.GothUser{}, err
+		return adapters.GothAccount{}, err
 	}
 
-	user, err = adapter.GetUser(ctx, user.ID)
+	gc, err := g.newClient(ctx, token)
 	if err != nil {
-		return adapters.GothUser{}, err
+		return adapters.GothAccount{}, err
 	}
 
-	return user, nil
+	gu, _, err := gc.Users.Get(ctx, "")
+	if err != nil {
+		return adapters.GothAccount{}, err
+	}
+
+	return adapters.GothAccount{
+		Type:              adapters.AccountTypeOAuth2,
+		Provider:          g.ID(),
+		ProviderAccountID: cast.Ptr(strconv.FormatInt(gu.GetID(), 10)),
+		AccessToken:       cast.Ptr(token.AccessToken),
+		RefreshToken:      cast.Ptr(token.RefreshToken),
+		ExpiresAt:         cast.Ptr(token.Expiry),
+		TokenType:         cast.Ptr(token.TokenType),
+		Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+	}, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token. GitHub only issues
+// refresh tokens for GitHub Apps with expiring user access tokens.
+func (g *githubProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, g.client)
+
+	return g.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+func (g *githubProvider) newClient(ctx context.Context, token *oauth2.Token) (*github.Client, error) {
+	gc := github.NewClient(g.config.Client(ctx, token))
+
+	if utilx.NotEmpty(g.enterpriseURL) {
+		return gc.WithEnterpriseURLs(g.enterpriseURL, g.enterpriseURL)
+	}
+
+	return gc, nil
 }
 
 func newConfig(p *githubProvider, scopes ...string) *oauth2.Config {
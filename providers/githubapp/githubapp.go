@@ -0,0 +1,274 @@
+package githubapp
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+// Provider is the provider ID an installation is stored with.
+const Provider = "githubapp"
+
+// DefaultBaseURL is the base URL of the GitHub API.
+const DefaultBaseURL = "https://api.github.com"
+
+var (
+	// ErrInvalidPrivateKey is returned when the private key of the app is not a PEM encoded RSA key.
+	ErrInvalidPrivateKey = errors.New("goth: invalid GitHub App private key")
+	// ErrInstallationNotFound is returned when an installation does not exist or is not accessible by the app.
+	ErrInstallationNotFound = errors.New("goth: GitHub App installation not found")
+)
+
+// expiryDelta is the time before the expiry at which cached installation tokens are renewed.
+const expiryDelta = time.Minute
+
+// jwtExpiry is the lifetime of the app JWT, which GitHub limits to 10 minutes.
+const jwtExpiry = 9 * time.Minute
+
+// Token is an installation access token.
+type Token struct {
+	// Token is the installation access token.
+	Token string `json:"token"`
+	// ExpiresAt is the expiry time of the token.
+	ExpiresAt time.Time `json:"expires_at"`
+	// Permissions are the permissions granted to the token.
+	Permissions map[string]string `json:"permissions,omitempty"`
+	// RepositorySelection is either "all" or "selected".
+	RepositorySelection string `json:"repository_selection,omitempty"`
+}
+
+// Valid returns true if the token is not expiring within the expiry delta.
+func (t Token) Valid() bool {
+	return t.Token != "" && time.Now().Add(expiryDelta).Before(t.ExpiresAt)
+}
+
+// Installation is an installation of the app.
+type Installation struct {
+	// ID is the ID of the installation.
+	ID int64 `json:"id"`
+	// Account is the account the app is installed on.
+	Account struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"account"`
+}
+
+// App authenticates as a GitHub App and manages the installation tokens of
+// the installations linked to teams.
+type App struct {
+	appID   int64
+	key     *rsa.PrivateKey
+	baseURL string
+	client  *http.Client
+	adapter adapters.Adapter
+
+	mu     sync.Mutex
+	tokens map[int64]Token
+}
+
+// Opt is a function that configures the app.
+type Opt func(*App)
+
+// WithBaseURL sets the base URL of the API, e.g. for GitHub Enterprise Server ("https://github.example.com/api/v3").
+func WithBaseURL(url string) Opt {
+	return func(a *App) {
+		a.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithClient sets the HTTP client.
+func WithClient(client *http.Client) Opt {
+	return func(a *App) {
+		a.client = client
+	}
+}
+
+// New creates a new GitHub App with the PEM encoded private key. The adapter stores the installations of teams.
+func New(appID int64, privateKey []byte, adapter adapters.Adapter, opts ...Opt) (*App, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPrivateKey, err)
+	}
+
+	a := &App{
+		appID:   appID,
+		key:     key,
+		baseURL: DefaultBaseURL,
+		client:  providers.DefaultClient,
+		adapter: adapter,
+		tokens:  map[int64]Token{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a, nil
+}
+
+// NewFromSource creates a new GitHub App loading the private key from the source.
+func NewFromSource(appID int64, source providers.CredentialSource, adapter adapters.Adapter, opts ...Opt) (*App, error) {
+	key, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(appID, []byte(key), adapter, opts...)
+}
+
+// JWT returns a JWT to authenticate as the app.
+func (a *App) JWT() (string, error) {
+	now := time.Now()
+
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    strconv.FormatInt(a.appID, 10),
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(jwtExpiry)),
+	}).SignedString(a.key)
+}
+
+// Installation returns an installation of the app.
+func (a *App) Installation(ctx context.Context, installationID int64) (Installation, error) {
+	var installation Installation
+
+	err := a.do(ctx, http.MethodGet, fmt.Sprintf("/app/installations/%d", installationID), &installation)
+	if err != nil {
+		return Installation{}, err
+	}
+
+	return installation, nil
+}
+
+// InstallationToken returns an installation access token. Tokens are cached until shortly before their expiry.
+func (a *App) InstallationToken(ctx context.Context, installationID int64) (Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if t, ok := a.tokens[installationID]; ok && t.Valid() {
+		return t, nil
+	}
+
+	var t Token
+
+	err := a.do(ctx, http.MethodPost, fmt.Sprintf("/app/installations/%d/access_tokens", installationID), &t)
+	if err != nil {
+		return Token{}, err
+	}
+
+	a.tokens[installationID] = t
+
+	return t, nil
+}
+
+// Link links an installation of the app to a team. The installation is verified with GitHub.
+func (a *App) Link(ctx context.Context, teamID uuid.UUID, installationID int64) (adapters.GothInstallation, error) {
+	installation, err := a.Installation(ctx, installationID)
+	if err != nil {
+		return adapters.GothInstallation{}, err
+	}
+
+	return a.adapter.CreateInstallation(ctx, adapters.GothInstallation{
+		TeamID:         teamID,
+		Provider:       Provider,
+		InstallationID: strconv.FormatInt(installation.ID, 10),
+		AccountLogin:   installation.Account.Login,
+		AccountType:    installation.Account.Type,
+	})
+}
+
+// Unlink unlinks the installation of the app from a team.
+func (a *App) Unlink(ctx context.Context, teamID uuid.UUID) error {
+	installationID, err := a.installationID(ctx, teamID)
+	if err == nil {
+		a.mu.Lock()
+		delete(a.tokens, installationID)
+		a.mu.Unlock()
+	}
+
+	return a.adapter.DeleteInstallation(ctx, teamID, Provider)
+}
+
+// TeamToken returns an installation access token of the installation linked to the team.
+func (a *App) TeamToken(ctx context.Context, teamID uuid.UUID) (Token, error) {
+	installationID, err := a.installationID(ctx, teamID)
+	if err != nil {
+		return Token{}, err
+	}
+
+	return a.InstallationToken(ctx, installationID)
+}
+
+// TokenSource returns a token source of the installation linked to the team, e.g. for github.NewClient(oauth2.NewClient(ctx, ts)).
+func (a *App) TokenSource(ctx context.Context, teamID uuid.UUID) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &tokenSource{ctx: ctx, app: a, teamID: teamID})
+}
+
+type tokenSource struct {
+	ctx    context.Context
+	app    *App
+	teamID uuid.UUID
+}
+
+// Token returns the installation access token.
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	t, err := s.app.TeamToken(s.ctx, s.teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{AccessToken: t.Token, TokenType: "Bearer", Expiry: t.ExpiresAt.Add(-expiryDelta)}, nil
+}
+
+func (a *App) installationID(ctx context.Context, teamID uuid.UUID) (int64, error) {
+	installation, err := a.adapter.GetInstallation(ctx, teamID, Provider)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(installation.InstallationID, 10, 64)
+}
+
+func (a *App) do(ctx context.Context, method, path string, v any) error {
+	token, err := a.JWT()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrInstallationNotFound
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("goth: GitHub App request %s %s failed with %s", method, path, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,269 @@
+package headertrust
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+)
+
+var (
+	// ErrMissingIdentity is returned when the request has no identity headers of the proxy.
+	ErrMissingIdentity = goth.NewError(fiber.StatusUnauthorized, "missing identity of the proxy")
+	// ErrInvalidAssertion is returned when the signed assertion of the proxy is invalid or does not match the headers.
+	ErrInvalidAssertion = goth.NewError(fiber.StatusUnauthorized, "invalid assertion of the proxy")
+)
+
+// CloudflareAssertionHeader is the header of the JWT Cloudflare Access signs for every request.
+const CloudflareAssertionHeader = "Cf-Access-Jwt-Assertion"
+
+// keysExpiry is the duration the keys of Cloudflare Access are cached.
+const keysExpiry = time.Hour
+
+// Headers are the names of the identity headers the proxy injects.
+type Headers struct {
+	// Subject is the header of the unique ID of the user. Defaults to the Email header.
+	Subject string
+	// Email is the header of the email of the user.
+	Email string
+	// Name is the optional header of the display name of the user.
+	Name string
+	// Image is the optional header of the image URL of the user.
+	Image string
+}
+
+var (
+	// TailscaleHeaders are the identity headers of `tailscale serve`.
+	TailscaleHeaders = Headers{
+		Email: "Tailscale-User-Login",
+		Name:  "Tailscale-User-Name",
+		Image: "Tailscale-User-Profile-Pic",
+	}
+	// CloudflareHeaders are the identity headers of Cloudflare Access.
+	CloudflareHeaders = Headers{
+		Email: "Cf-Access-Authenticated-User-Email",
+	}
+)
+
+var _ providers.Provider = (*headerTrustProvider)(nil)
+
+type headerTrustProvider struct {
+	id           string
+	name         string
+	callbackURL  string
+	headers      Headers
+	providerType providers.ProviderType
+	assertion    *assertion
+
+	providers.UnimplementedProvider
+}
+
+// assertion verifies the JWT a proxy signs for every request.
+type assertion struct {
+	header   string
+	issuer   string
+	audience string
+	keys     *providers.KeySet
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the header trust provider.
+type Opt func(*headerTrustProvider)
+
+// WithID sets the ID and name of the provider, e.g. to trust several proxies.
+func WithID(id, name string) Opt {
+	return func(p *headerTrustProvider) {
+		p.id = id
+		p.name = name
+	}
+}
+
+// WithHeaders sets the names of the identity headers.
+func WithHeaders(headers Headers) Opt {
+	return func(p *headerTrustProvider) {
+		p.headers = headers
+	}
+}
+
+// WithCloudflareAccess verifies the JWT Cloudflare Access signs for every request against the keys
+// of the team domain (e.g. "example.cloudflareaccess.com") and the audience (AUD) tag of the application.
+// The identity is read from the claims of the JWT.
+func WithCloudflareAccess(teamDomain, audience string) Opt {
+	return func(p *headerTrustProvider) {
+		issuer := "https://" + strings.TrimSuffix(strings.TrimPrefix(teamDomain, "https://"), "/")
+
+		p.assertion = &assertion{
+			header:   CloudflareAssertionHeader,
+			issuer:   issuer,
+			audience: audience,
+			keys:     providers.NewKeySet(issuer+"/cdn-cgi/access/certs", providers.DefaultClient, keysExpiry),
+		}
+	}
+}
+
+// New creates a new provider that trusts the identity headers an upstream zero-trust proxy injects
+// (e.g. Tailscale or Cloudflare Access). The app must only be reachable via the proxy, otherwise the
+// headers can be set by anyone. With Cloudflare Access the signed assertion should be verified
+// (see WithCloudflareAccess). Signing in redirects to the callback URL without an interactive flow.
+func New(callbackURL string, opts ...Opt) *headerTrustProvider {
+	p := &headerTrustProvider{
+		id:           "headertrust",
+		name:         "Proxy",
+		callbackURL:  callbackURL,
+		headers:      TailscaleHeaders,
+		providerType: providers.ProviderTypeProxy,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// ID returns the provider's ID.
+func (p *headerTrustProvider) ID() string {
+	return p.id
+}
+
+// Name returns the provider's name.
+func (p *headerTrustProvider) Name() string {
+	return p.name
+}
+
+// Type returns the provider's type.
+func (p *headerTrustProvider) Type() providers.ProviderType {
+	return p.providerType
+}
+
+// Check validates the reachability of the keys of the proxy.
+func (p *headerTrustProvider) Check(ctx context.Context) error {
+	if p.assertion == nil {
+		return nil
+	}
+
+	return providers.CheckEndpoint(ctx, providers.DefaultClient, p.assertion.issuer+"/cdn-cgi/access/certs")
+}
+
+// BeginAuth redirects to the callback, the user has already been authenticated by the proxy.
+func (p *headerTrustProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: p.callbackURL + "?" + url.Values{"state": {state}}.Encode(),
+	}, nil
+}
+
+// CompleteAuth reads the identity of the user from the request headers of the proxy.
+// The headers are never read from query or form parameters.
+//
+// nolint:gocyclo
+func (p *headerTrustProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	hp, ok := params.(providers.HeaderParams)
+	if !ok {
+		return adapters.GothUser{}, ErrMissingIdentity
+	}
+
+	email := strings.TrimSpace(hp.Header(p.headers.Email))
+	subject := email
+
+	if p.headers.Subject != "" {
+		subject = strings.TrimSpace(hp.Header(p.headers.Subject))
+	}
+
+	if p.assertion != nil {
+		claims, err := p.assertion.verify(ctx, hp.Header(p.assertion.header))
+		if err != nil {
+			return adapters.GothUser{}, err
+		}
+
+		if email != "" && !strings.EqualFold(email, claims.Email) {
+			return adapters.GothUser{}, ErrInvalidAssertion
+		}
+
+		email, subject = claims.Email, claims.Subject
+	}
+
+	if utilx.Empty(email) || utilx.Empty(subject) {
+		return adapters.GothUser{}, ErrMissingIdentity
+	}
+
+	name := hp.Header(p.headers.Name)
+	if name == "" {
+		name = email
+	}
+
+	user := adapters.GothUser{
+		Name:          name,
+		Email:         email,
+		EmailVerified: cast.Ptr(true),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeProxy,
+				Provider:          p.ID(),
+				ProviderAccountID: cast.Ptr(subject),
+			},
+		},
+	}
+
+	if image := hp.Header(p.headers.Image); image != "" {
+		user.Image = cast.Ptr(image)
+	}
+
+	return user, nil
+}
+
+// assertionClaims are the claims of the assertion.
+type assertionClaims struct {
+	Email string `json:"email"`
+
+	jwt.RegisteredClaims
+}
+
+// verify parses the assertion and validates the signature, issuer, audience and expiry.
+func (a *assertion) verify(ctx context.Context, token string) (*assertionClaims, error) {
+	if token == "" {
+		return nil, ErrMissingIdentity
+	}
+
+	claims := &assertionClaims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return a.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(a.issuer),
+		jwt.WithAudience(a.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAssertion, err)
+	}
+
+	if utilx.Empty(claims.Subject) || utilx.Empty(claims.Email) {
+		return nil, ErrInvalidAssertion
+	}
+
+	return claims, nil
+}
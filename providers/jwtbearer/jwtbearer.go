@@ -0,0 +1,253 @@
+package jwtbearer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+)
+
+var (
+	// ErrMissingAssertion is returned when the request has no assertion.
+	ErrMissingAssertion = goth.NewError(fiber.StatusUnauthorized, "missing assertion")
+	// ErrInvalidAssertion is returned when the assertion is not signed by the issuer, not issued for the audience or expired.
+	ErrInvalidAssertion = goth.NewError(fiber.StatusUnauthorized, "invalid assertion")
+	// ErrReplayedAssertion is returned when an assertion with the same ID has already been used.
+	ErrReplayedAssertion = goth.NewError(fiber.StatusUnauthorized, "assertion has already been used")
+	// ErrUnverifiedEmail is returned when the email of the assertion is not verified by the issuer.
+	ErrUnverifiedEmail = goth.NewError(fiber.StatusForbidden, "email is not verified")
+	// ErrInteractiveUnsupported is returned when a sign-in is started, the assertions are presented by the client.
+	ErrInteractiveUnsupported = goth.NewError(fiber.StatusBadRequest, "the provider accepts assertions only")
+)
+
+const (
+	// keysExpiry is the duration the keys of the issuer are cached.
+	keysExpiry = time.Hour
+	// DefaultMaxLifetime is the default maximum lifetime of an assertion.
+	DefaultMaxLifetime = time.Hour
+)
+
+var _ providers.Provider = (*jwtBearerProvider)(nil)
+
+type jwtBearerProvider struct {
+	id              string
+	name            string
+	issuer          string
+	audience        string
+	providerType    providers.ProviderType
+	keys            *providers.KeySet
+	jwksURL         string
+	maxLifetime     time.Duration
+	flows           adapters.FlowStore
+	requireVerified bool
+
+	providers.UnimplementedProvider
+}
+
+// Opt is a function that configures the JWT bearer provider.
+type Opt func(*jwtBearerProvider)
+
+// WithID sets the ID and name of the provider, e.g. to trust several issuers.
+func WithID(id, name string) Opt {
+	return func(p *jwtBearerProvider) {
+		p.id = id
+		p.name = name
+	}
+}
+
+// WithMaxLifetime sets the maximum lifetime (exp - iat) of the accepted assertions.
+func WithMaxLifetime(lifetime time.Duration) Opt {
+	return func(p *jwtBearerProvider) {
+		p.maxLifetime = lifetime
+	}
+}
+
+// WithFlowStore rejects assertions whose ID (jti) has already been used until they expire.
+// Multi-instance deployments need a shared store.
+func WithFlowStore(store adapters.FlowStore) Opt {
+	return func(p *jwtBearerProvider) {
+		p.flows = store
+	}
+}
+
+// WithVerifiedEmail only accepts assertions whose email is verified (`email_verified`).
+func WithVerifiedEmail() Opt {
+	return func(p *jwtBearerProvider) {
+		p.requireVerified = true
+	}
+}
+
+// New creates a new provider that mints sessions for RS256 signed JWTs of the trusted issuer, whose keys
+// are published at the JWKS URL, and the audience. The JWT is posted to the callback of the provider as
+// `assertion` parameter or `Authorization: Bearer` header. The callbacks carry no state.
+func New(issuer, jwksURL, audience string, opts ...Opt) *jwtBearerProvider {
+	p := &jwtBearerProvider{
+		id:           "jwtbearer",
+		name:         "JWT",
+		issuer:       issuer,
+		audience:     audience,
+		jwksURL:      jwksURL,
+		providerType: providers.ProviderTypeJWT,
+		maxLifetime:  DefaultMaxLifetime,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.keys = providers.NewKeySet(jwksURL, providers.DefaultClient, keysExpiry)
+
+	return p
+}
+
+// ID returns the provider's ID.
+func (p *jwtBearerProvider) ID() string {
+	return p.id
+}
+
+// Name returns the provider's name.
+func (p *jwtBearerProvider) Name() string {
+	return p.name
+}
+
+// Type returns the provider's type.
+func (p *jwtBearerProvider) Type() providers.ProviderType {
+	return p.providerType
+}
+
+// Stateless returns true, the assertions are presented without a state.
+func (p *jwtBearerProvider) Stateless() bool {
+	return true
+}
+
+// Check validates the reachability of the keys of the issuer.
+func (p *jwtBearerProvider) Check(ctx context.Context) error {
+	return providers.CheckEndpoint(ctx, providers.DefaultClient, p.jwksURL)
+}
+
+// BeginAuth returns ErrInteractiveUnsupported, the assertions are presented by the client.
+func (p *jwtBearerProvider) BeginAuth(_ context.Context, _ adapters.Adapter, _ string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return nil, ErrInteractiveUnsupported
+}
+
+// claims are the claims of an assertion.
+type claims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+
+	jwt.RegisteredClaims
+}
+
+// CompleteAuth verifies the assertion and maps its claims to the user.
+//
+// nolint:gocyclo
+func (p *jwtBearerProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	token := assertion(params)
+	if token == "" {
+		return adapters.GothUser{}, ErrMissingAssertion
+	}
+
+	c := &claims{}
+
+	_, err := jwt.ParseWithClaims(token, c, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+
+		return p.keys.Key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(p.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return adapters.GothUser{}, fmt.Errorf("%w: %w", ErrInvalidAssertion, err)
+	}
+
+	if utilx.Empty(c.Subject) || utilx.Empty(c.Email) {
+		return adapters.GothUser{}, ErrInvalidAssertion
+	}
+
+	if c.IssuedAt != nil && c.ExpiresAt.Sub(c.IssuedAt.Time) > p.maxLifetime {
+		return adapters.GothUser{}, fmt.Errorf("%w: lifetime exceeds %s", ErrInvalidAssertion, p.maxLifetime)
+	}
+
+	if p.requireVerified && !c.EmailVerified {
+		return adapters.GothUser{}, ErrUnverifiedEmail
+	}
+
+	if p.flows != nil {
+		if err := p.consume(ctx, c); err != nil {
+			return adapters.GothUser{}, err
+		}
+	}
+
+	name := c.Name
+	if name == "" {
+		name = c.Email
+	}
+
+	user := adapters.GothUser{
+		Name:          name,
+		Email:         c.Email,
+		EmailVerified: cast.Ptr(c.EmailVerified),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeJWT,
+				Provider:          p.ID(),
+				ProviderAccountID: cast.Ptr(c.Subject),
+			},
+		},
+	}
+
+	if c.Picture != "" {
+		user.Image = cast.Ptr(c.Picture)
+	}
+
+	return user, nil
+}
+
+// consume marks the ID of the assertion as used until it expires. Assertions without an ID are rejected.
+func (p *jwtBearerProvider) consume(ctx context.Context, c *claims) error {
+	if c.ID == "" {
+		return fmt.Errorf("%w: missing jti", ErrInvalidAssertion)
+	}
+
+	key := "jwtbearer:" + adapters.HashToken(p.issuer+" "+c.ID)
+
+	// the entry has to be consumed and stored again, the flow store has no insert-if-absent
+	if _, err := p.flows.GetAndDelete(ctx, key); err == nil {
+		return ErrReplayedAssertion
+	}
+
+	return p.flows.Put(ctx, key, []byte{1}, time.Until(c.ExpiresAt.Time))
+}
+
+// assertion returns the assertion of the `assertion` parameter or the bearer token of the Authorization header.
+func assertion(params providers.AuthParams) string {
+	if v := params.Get("assertion"); v != "" {
+		return v
+	}
+
+	hp, ok := params.(providers.HeaderParams)
+	if !ok {
+		return ""
+	}
+
+	scheme, token, _ := strings.Cut(strings.TrimSpace(hp.Header(fiber.HeaderAuthorization)), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
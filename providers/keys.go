@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned when a token is signed with a key that is not in the key set.
+var ErrUnknownKey = errors.New("goth: unknown signing key")
+
+// KeySet caches the RSA public keys of a JWKS endpoint, e.g. the keys a provider signs its id_tokens with.
+// The keys are fetched again if they are expired or a token is signed with an unknown key.
+type KeySet struct {
+	url    string
+	client *http.Client
+	expiry time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet returns a key set for the JWKS endpoint. The keys are cached for the expiry.
+func NewKeySet(url string, client *http.Client, expiry time.Duration) *KeySet {
+	return &KeySet{url: url, client: client, expiry: expiry}
+}
+
+// Key returns the key with the ID.
+func (s *KeySet) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[kid]; ok && time.Since(s.fetchedAt) < s.expiry {
+		return key, nil
+	}
+
+	// Keys are fetched at most once a minute for unknown key IDs.
+	if time.Since(s.fetchedAt) >= time.Minute {
+		if err := s.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	return key, nil
+}
+
+// fetch loads the keys from the endpoint.
+func (s *KeySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	res := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, k := range res.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	return nil
+}
@@ -2,12 +2,15 @@ package providers
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/zeiss/fiber-goth/adapters"
+
+	"golang.org/x/oauth2"
 )
 
 // DefaultClient is the default HTTP client used.
@@ -36,15 +39,96 @@ type Provider interface {
 	Type() ProviderType
 	// BeginAuth starts the authentication process.
 	BeginAuth(ctx context.Context, adapter adapters.Adapter, state string, params AuthParams) (AuthIntent, error)
-	// CompleteAuth completes the authentication process.
+	// CompleteAuth completes the authentication process. It returns the profile of the user
+	// with the account (and tokens) of the provider. Providers do not persist the user,
+	// the profile is matched to an existing user or created by goth.
 	CompleteAuth(ctx context.Context, adapter adapters.Adapter, params AuthParams) (adapters.GothUser, error)
 }
 
+// Checker is implemented by providers that can validate their configuration,
+// e.g. by discovering their endpoints and checking their credentials.
+type Checker interface {
+	// Check validates the configuration of the provider.
+	Check(ctx context.Context) error
+}
+
+// ScopeUpgrader is implemented by providers that can request additional scopes
+// for an already-linked account (incremental authorization).
+type ScopeUpgrader interface {
+	// BeginUpgrade starts the authorization of the scopes for the linked account.
+	BeginUpgrade(ctx context.Context, account adapters.GothAccount, state string, scopes []string) (AuthIntent, error)
+	// CompleteUpgrade exchanges the authorization for the account with the granted token and scope.
+	CompleteUpgrade(ctx context.Context, params AuthParams) (adapters.GothAccount, error)
+}
+
+// TokenRefresher is implemented by providers that can refresh the access token
+// of a linked account with its refresh token.
+type TokenRefresher interface {
+	// RefreshToken exchanges the refresh token for a new token.
+	RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error)
+}
+
+// StatelessProvider is implemented by providers whose callbacks are not started by BeginAuth
+// and carry no state, e.g. signed assertions presented by a client.
+type StatelessProvider interface {
+	// Stateless returns true if the callbacks of the provider carry no state.
+	Stateless() bool
+}
+
+// IsStateless returns true if the callbacks of the provider carry no state.
+func IsStateless(p Provider) bool {
+	s, ok := p.(StatelessProvider)
+
+	return ok && s.Stateless()
+}
+
+// ErrMissingCode is returned when the authorization code is missing.
+var ErrMissingCode = errors.New("goth: missing authorization code")
+
+// ErrMissingClientCredentials is returned when a provider has no client ID or secret.
+var ErrMissingClientCredentials = errors.New("goth: missing client ID or secret")
+
+// CheckEndpoint checks that the endpoint is reachable and does not respond with a server error.
+func CheckEndpoint(ctx context.Context, client *http.Client, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("goth: endpoint %s responded with %s", endpoint, res.Status)
+	}
+
+	return nil
+}
+
 // AuthParams is the type of authentication parameters.
 type AuthParams interface {
 	Get(string) string
 }
 
+// HeaderParams is implemented by authentication parameters that can read the request headers only.
+// Unlike Get, the values cannot be set with query or form parameters, e.g. to trust the identity
+// headers of an upstream proxy.
+type HeaderParams interface {
+	AuthParams
+	// Header returns the value of the request header.
+	Header(string) string
+}
+
+// TLSParams is implemented by authentication parameters that can read the TLS connection of the request.
+type TLSParams interface {
+	AuthParams
+	// PeerCertificates returns the certificate chain the client presented, leaf first.
+	PeerCertificates() []*x509.Certificate
+}
+
 // AuthIntent is the type of authentication intent.
 type AuthIntent interface {
 	// GetAuthURL returns the URL for the authentication end-point.
@@ -65,6 +149,16 @@ const (
 	ProviderTypeEmail ProviderType = "email"
 	// ProviderTypeWebAuthn represents a WebAuthn account type.
 	ProviderTypeWebAuthn ProviderType = "webauthn"
+	// ProviderTypeEthereum represents an Ethereum wallet account type.
+	ProviderTypeEthereum ProviderType = "ethereum"
+	// ProviderTypeProxy represents an account authenticated by an upstream proxy.
+	ProviderTypeProxy ProviderType = "proxy"
+	// ProviderTypeKerberos represents a Kerberos account type.
+	ProviderTypeKerberos ProviderType = "kerberos"
+	// ProviderTypeCertificate represents a client certificate account type.
+	ProviderTypeCertificate ProviderType = "certificate"
+	// ProviderTypeJWT represents a JWT bearer assertion account type.
+	ProviderTypeJWT ProviderType = "jwt"
 	// ProviderTypeUnknown represents an unknown account type.
 	ProviderTypeUnknown ProviderType = "unknow"
 )
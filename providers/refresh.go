@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+// rotatedGrace is the duration the result of a refresh is reused for the same refresh token.
+const rotatedGrace = time.Minute
+
+// RotatingRefresh deduplicates the refreshes of a provider that rotates the refresh token with every
+// refresh and rejects the previous one (e.g. Zoom or Box). Concurrent refreshes of the same token
+// (e.g. by a request and the refresh worker) share the result of the first refresh instead of failing,
+// refreshes of different tokens run in parallel. The zero value is ready to use.
+type RotatingRefresh struct {
+	group singleflight.Group
+
+	mu        sync.Mutex
+	refreshed map[string]rotatedToken
+}
+
+// rotatedToken is the token a refresh token has been exchanged for.
+type rotatedToken struct {
+	token *oauth2.Token
+	at    time.Time
+}
+
+// Token returns the token the refresh token has been exchanged for within the last minute,
+// or exchanges it with refresh.
+func (r *RotatingRefresh) Token(refreshToken string, refresh func() (*oauth2.Token, error)) (*oauth2.Token, error) {
+	key := adapters.HashToken(refreshToken)
+
+	if token, ok := r.cached(key); ok {
+		return token, nil
+	}
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		// the token may have been refreshed since the lookup
+		if token, ok := r.cached(key); ok {
+			return token, nil
+		}
+
+		token, err := refresh()
+		if err != nil {
+			return nil, err
+		}
+		r.store(key, token)
+
+		return token, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(*oauth2.Token), nil
+}
+
+// cached returns the token the refresh token has been exchanged for within the rotatedGrace.
+func (r *RotatingRefresh) cached(key string) (*oauth2.Token, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.refreshed[key]
+	if !ok || time.Since(t.at) > rotatedGrace {
+		return nil, false
+	}
+
+	return t.token, true
+}
+
+// store caches the token of the refresh token and removes the expired tokens.
+func (r *RotatingRefresh) store(key string, token *oauth2.Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.refreshed == nil {
+		r.refreshed = map[string]rotatedToken{}
+	}
+
+	now := time.Now()
+	for k, t := range r.refreshed {
+		if now.Sub(t.at) > rotatedGrace {
+			delete(r.refreshed, k)
+		}
+	}
+
+	r.refreshed[key] = rotatedToken{token: token, at: now}
+}
@@ -0,0 +1,37 @@
+package providers
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ParseScopes splits a scope string that is separated by spaces or commas (e.g. GitHub).
+func ParseScopes(scope string) []string {
+	return strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// MergeScopes merges the scopes into a sorted, space-separated scope string without duplicates.
+func MergeScopes(scopes ...[]string) string {
+	merged := []string{}
+	for _, s := range scopes {
+		merged = append(merged, s...)
+	}
+
+	slices.Sort(merged)
+
+	return strings.Join(slices.Compact(merged), " ")
+}
+
+// ScopeOf returns the scope granted with the token response, if any.
+func ScopeOf(token *oauth2.Token) []string {
+	s, ok := token.Extra("scope").(string)
+	if !ok {
+		return nil
+	}
+
+	return ParseScopes(s)
+}
@@ -0,0 +1,134 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoCredential is returned when a credential source has no value.
+var ErrNoCredential = errors.New("goth: no credential found")
+
+// DefaultSecretMountPath is the default path at which Docker and Kubernetes mount secrets.
+const DefaultSecretMountPath = "/run/secrets"
+
+// CredentialSource loads a credential (e.g. a client secret) from outside of the application code.
+type CredentialSource interface {
+	// Load returns the credential.
+	Load() (string, error)
+}
+
+// CredentialSourceFunc is a function that implements the CredentialSource interface.
+type CredentialSourceFunc func() (string, error)
+
+// Load returns the credential.
+func (f CredentialSourceFunc) Load() (string, error) {
+	return f()
+}
+
+// Static returns a credential source that always returns the given value.
+func Static(value string) CredentialSource {
+	return CredentialSourceFunc(func() (string, error) {
+		if value == "" {
+			return "", ErrNoCredential
+		}
+
+		return value, nil
+	})
+}
+
+// Env returns a credential source that reads the credential from the named environment variable.
+func Env(name string) CredentialSource {
+	return CredentialSourceFunc(func() (string, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return "", fmt.Errorf("%w: environment variable %s is not set", ErrNoCredential, name)
+		}
+
+		return v, nil
+	})
+}
+
+// File returns a credential source that reads the credential from the file at path.
+// Surrounding whitespace, such as a trailing newline, is removed.
+func File(path string) CredentialSource {
+	return CredentialSourceFunc(func() (string, error) {
+		b, err := os.ReadFile(filepath.Clean(path))
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: file %s does not exist", ErrNoCredential, path)
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		v := strings.TrimSpace(string(b))
+		if v == "" {
+			return "", fmt.Errorf("%w: file %s is empty", ErrNoCredential, path)
+		}
+
+		return v, nil
+	})
+}
+
+// SecretMount returns a credential source that reads the named secret from a
+// Docker or Kubernetes secret mount. The directory defaults to DefaultSecretMountPath.
+func SecretMount(name string, dir ...string) CredentialSource {
+	d := DefaultSecretMountPath
+	if len(dir) > 0 && dir[0] != "" {
+		d = dir[0]
+	}
+
+	return File(filepath.Join(d, name))
+}
+
+// EnvOrFile returns a credential source that reads the named environment variable,
+// or the file referenced by the environment variable suffixed with "_FILE".
+func EnvOrFile(name string) CredentialSource {
+	return FirstOf(Env(name), CredentialSourceFunc(func() (string, error) {
+		path, ok := os.LookupEnv(name + "_FILE")
+		if !ok || path == "" {
+			return "", fmt.Errorf("%w: environment variable %s_FILE is not set", ErrNoCredential, name)
+		}
+
+		return File(path).Load()
+	}))
+}
+
+// FirstOf returns a credential source that returns the first credential found in sources.
+func FirstOf(sources ...CredentialSource) CredentialSource {
+	return CredentialSourceFunc(func() (string, error) {
+		errs := make([]error, 0, len(sources))
+
+		for _, s := range sources {
+			v, err := s.Load()
+			if err == nil {
+				return v, nil
+			}
+
+			if !errors.Is(err, ErrNoCredential) {
+				return "", err
+			}
+
+			errs = append(errs, err)
+		}
+
+		if len(errs) == 0 {
+			return "", ErrNoCredential
+		}
+
+		return "", errors.Join(errs...)
+	})
+}
+
+// MustLoad returns the credential of the source and panics if it cannot be loaded.
+func MustLoad(source CredentialSource) string {
+	v, err := source.Load()
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
@@ -0,0 +1,217 @@
+package siwe
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// secp256k1 is the curve y² = x³ + 7 of the Ethereum signatures.
+var secp256k1 = struct {
+	P, N, Gx, Gy *big.Int
+}{
+	P:  hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f"),
+	N:  hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"),
+	Gx: hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+	Gy: hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+}
+
+func hexInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+// ParseAddress parses the hex encoded address of a wallet (e.g. "0xAb58...") in any case.
+func ParseAddress(s string) ([20]byte, error) {
+	var addr [20]byte
+
+	h, ok := strings.CutPrefix(s, "0x")
+	if !ok || len(h) != 40 {
+		return addr, ErrInvalidAddress
+	}
+
+	if _, err := hex.Decode(addr[:], []byte(h)); err != nil {
+		return addr, ErrInvalidAddress
+	}
+
+	return addr, nil
+}
+
+// ChecksumAddress returns the EIP-55 checksum encoding of the address.
+func ChecksumAddress(addr [20]byte) string {
+	h := hex.EncodeToString(addr[:])
+	sum := keccak256([]byte(h))
+
+	b := []byte(h)
+	for i, c := range b {
+		nibble := sum[i/2] >> 4
+		if i%2 == 1 {
+			nibble = sum[i/2] & 0x0f
+		}
+
+		if c >= 'a' && nibble >= 8 {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(b)
+}
+
+// checksum returns the EIP-55 encoding of the address string or "" if it is invalid.
+func checksum(s string) string {
+	addr, err := ParseAddress(s)
+	if err != nil {
+		return ""
+	}
+
+	return ChecksumAddress(addr)
+}
+
+// keccak256 returns the Keccak-256 hash used by Ethereum.
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+
+	return h.Sum(nil)
+}
+
+// personalHash returns the EIP-191 hash of the message, as signed by `personal_sign` of the wallets.
+func personalHash(message string) []byte {
+	return keccak256([]byte("\x19Ethereum Signed Message:\n"+strconv.Itoa(len(message))), []byte(message))
+}
+
+// RecoverAddress returns the address of the wallet that signed the message with `personal_sign`.
+// The signature is the hex encoded 65 bytes r, s and v.
+func RecoverAddress(message, signature string) ([20]byte, error) {
+	var addr [20]byte
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return addr, ErrInvalidSignature
+	}
+
+	pub, err := recoverPublicKey(personalHash(message), sig)
+	if err != nil {
+		return addr, err
+	}
+
+	copy(addr[:], keccak256(pub)[12:])
+
+	return addr, nil
+}
+
+// point is an affine point of secp256k1, nil is the point at infinity.
+type point struct {
+	x, y *big.Int
+}
+
+// recoverPublicKey returns the uncompressed public key (x and y without prefix) of the signature of the hash.
+func recoverPublicKey(hash, sig []byte) ([]byte, error) {
+	c := secp256k1
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+
+	if v > 1 || r.Sign() == 0 || s.Sign() == 0 || r.Cmp(c.N) >= 0 || s.Cmp(c.N) >= 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	// y² = x³ + 7, the root is y = (x³ + 7)^((p + 1) / 4) as p ≡ 3 (mod 4)
+	y2 := new(big.Int).Exp(r, big.NewInt(3), c.P)
+	y2.Add(y2, big.NewInt(7)).Mod(y2, c.P)
+
+	y := new(big.Int).Exp(y2, new(big.Int).Rsh(new(big.Int).Add(c.P, big.NewInt(1)), 2), c.P)
+	if new(big.Int).Exp(y, big.NewInt(2), c.P).Cmp(y2) != 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	if y.Bit(0) != uint(v) {
+		y.Sub(c.P, y)
+	}
+
+	// Q = r⁻¹ (sR - eG)
+	rInv := new(big.Int).ModInverse(r, c.N)
+	e := new(big.Int).Mod(new(big.Int).SetBytes(hash), c.N)
+
+	u1 := new(big.Int).Mul(new(big.Int).Neg(e), rInv)
+	u1.Mod(u1, c.N)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, c.N)
+
+	q := add(mul(&point{c.Gx, c.Gy}, u1), mul(&point{r, y}, u2))
+	if q == nil {
+		return nil, ErrInvalidSignature
+	}
+
+	pub := make([]byte, 64)
+	q.x.FillBytes(pub[:32])
+	q.y.FillBytes(pub[32:])
+
+	return pub, nil
+}
+
+// add returns the sum of the points.
+func add(a, b *point) *point {
+	p := secp256k1.P
+
+	if a == nil {
+		return b
+	}
+
+	if b == nil {
+		return a
+	}
+
+	var l *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return nil
+		}
+
+		// λ = 3x² / 2y
+		l = new(big.Int).Mul(a.x, a.x)
+		l.Mul(l, big.NewInt(3))
+		l.Mul(l, new(big.Int).ModInverse(new(big.Int).Lsh(a.y, 1), p))
+	} else {
+		// λ = (y₂ - y₁) / (x₂ - x₁)
+		dx := new(big.Int).Sub(b.x, a.x)
+		dx.Mod(dx, p)
+
+		l = new(big.Int).Sub(b.y, a.y)
+		l.Mul(l, new(big.Int).ModInverse(dx, p))
+	}
+	l.Mod(l, p)
+
+	x := new(big.Int).Mul(l, l)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, p)
+
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, l).Sub(y, a.y).Mod(y, p)
+
+	return &point{x, y}
+}
+
+// mul returns the product of the point and the scalar.
+func mul(a *point, k *big.Int) *point {
+	var r *point
+
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = add(r, r)
+
+		if k.Bit(i) == 1 {
+			r = add(r, a)
+		}
+	}
+
+	return r
+}
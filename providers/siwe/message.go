@@ -0,0 +1,192 @@
+package siwe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// messageHeader is the suffix of the first line of a message.
+const messageHeader = " wants you to sign in with your Ethereum account:"
+
+// Message is a Sign-In with Ethereum message (EIP-4361).
+type Message struct {
+	// Domain is the host (and port) requesting the signing.
+	Domain string
+	// Address is the EIP-55 checksum address of the wallet.
+	Address string
+	// Statement is the optional human-readable assertion the user signs.
+	Statement string
+	// URI is the URI of the resource the user signs in to.
+	URI string
+	// Version is the version of the message, always "1".
+	Version string
+	// ChainID is the EIP-155 chain ID the session is bound to.
+	ChainID int64
+	// Nonce is the random token that prevents replay attacks.
+	Nonce string
+	// IssuedAt is the time the message has been issued.
+	IssuedAt time.Time
+	// ExpirationTime is the optional time the message expires.
+	ExpirationTime time.Time
+	// NotBefore is the optional time the message becomes valid.
+	NotBefore time.Time
+	// RequestID is the optional identifier of the request, e.g. the state of the login.
+	RequestID string
+	// Resources are the optional URIs the user wishes to have resolved.
+	Resources []string
+}
+
+// String returns the message in the format the wallet signs.
+func (m Message) String() string {
+	var b strings.Builder
+
+	b.WriteString(m.Domain + messageHeader + "\n")
+	b.WriteString(m.Address + "\n\n")
+
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + strconv.FormatInt(m.ChainID, 10) + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt.UTC().Format(time.RFC3339))
+
+	if !m.ExpirationTime.IsZero() {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+
+	if !m.NotBefore.IsZero() {
+		b.WriteString("\nNot Before: " + m.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+
+		for _, r := range m.Resources {
+			b.WriteString("\n- " + r)
+		}
+	}
+
+	return b.String()
+}
+
+// ParseMessage parses a Sign-In with Ethereum message.
+//
+// nolint:gocyclo
+func ParseMessage(s string) (Message, error) {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if len(lines) < 9 {
+		return Message{}, fmt.Errorf("%w: too short", ErrInvalidMessage)
+	}
+
+	m := Message{}
+
+	domain, ok := strings.CutSuffix(lines[0], messageHeader)
+	if !ok || domain == "" {
+		return Message{}, fmt.Errorf("%w: invalid header", ErrInvalidMessage)
+	}
+	m.Domain = domain
+
+	if _, err := ParseAddress(lines[1]); err != nil || lines[1] != checksum(lines[1]) {
+		return Message{}, fmt.Errorf("%w: address is not in EIP-55 format", ErrInvalidMessage)
+	}
+	m.Address = lines[1]
+
+	if lines[2] != "" {
+		return Message{}, fmt.Errorf("%w: missing empty line", ErrInvalidMessage)
+	}
+
+	i := 3
+	if lines[i] != "" {
+		m.Statement = lines[i]
+		i++
+	}
+
+	if lines[i] != "" {
+		return Message{}, fmt.Errorf("%w: missing empty line", ErrInvalidMessage)
+	}
+	i++
+
+	field := func(tag string, required bool) (string, error) {
+		if i < len(lines) {
+			if v, ok := strings.CutPrefix(lines[i], tag+": "); ok {
+				i++
+				return v, nil
+			}
+		}
+
+		if required {
+			return "", fmt.Errorf("%w: missing %s", ErrInvalidMessage, tag)
+		}
+
+		return "", nil
+	}
+
+	var err error
+	var chainID, issuedAt, expirationTime, notBefore string
+
+	for _, f := range []struct {
+		tag      string
+		value    *string
+		required bool
+	}{
+		{"URI", &m.URI, true},
+		{"Version", &m.Version, true},
+		{"Chain ID", &chainID, true},
+		{"Nonce", &m.Nonce, true},
+		{"Issued At", &issuedAt, true},
+		{"Expiration Time", &expirationTime, false},
+		{"Not Before", &notBefore, false},
+		{"Request ID", &m.RequestID, false},
+	} {
+		if *f.value, err = field(f.tag, f.required); err != nil {
+			return Message{}, err
+		}
+	}
+
+	if m.ChainID, err = strconv.ParseInt(chainID, 10, 64); err != nil {
+		return Message{}, fmt.Errorf("%w: invalid chain ID", ErrInvalidMessage)
+	}
+
+	for _, t := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{issuedAt, &m.IssuedAt},
+		{expirationTime, &m.ExpirationTime},
+		{notBefore, &m.NotBefore},
+	} {
+		if t.value == "" {
+			continue
+		}
+
+		if *t.target, err = time.Parse(time.RFC3339Nano, t.value); err != nil {
+			return Message{}, fmt.Errorf("%w: invalid time %q", ErrInvalidMessage, t.value)
+		}
+	}
+
+	if i < len(lines) && lines[i] == "Resources:" {
+		for i++; i < len(lines); i++ {
+			r, ok := strings.CutPrefix(lines[i], "- ")
+			if !ok {
+				break
+			}
+			m.Resources = append(m.Resources, r)
+		}
+	}
+
+	if i != len(lines) {
+		return Message{}, fmt.Errorf("%w: unexpected line %q", ErrInvalidMessage, lines[i])
+	}
+
+	return m, nil
+}
@@ -0,0 +1,335 @@
+package siwe
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"math/big"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/internal/secure"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+)
+
+var (
+	// ErrInvalidAddress is returned when the address of the wallet is not a hex encoded Ethereum address.
+	ErrInvalidAddress = goth.NewError(fiber.StatusBadRequest, "invalid wallet address")
+	// ErrInvalidChain is returned when the chain ID is not allowed.
+	ErrInvalidChain = goth.NewError(fiber.StatusBadRequest, "chain is not allowed")
+	// ErrMissingSignature is returned when the message or the signature is missing.
+	ErrMissingSignature = goth.NewError(fiber.StatusBadRequest, "missing message or signature")
+	// ErrInvalidMessage is returned when the message is malformed, expired or not issued for the domain.
+	ErrInvalidMessage = goth.NewError(fiber.StatusBadRequest, "invalid sign-in message")
+	// ErrInvalidSignature is returned when the message is not signed by the wallet of the message.
+	ErrInvalidSignature = goth.NewError(fiber.StatusUnauthorized, "invalid signature")
+	// ErrInvalidNonce is returned when the nonce of the message has not been issued, has expired or has already been used.
+	ErrInvalidNonce = goth.NewError(fiber.StatusUnauthorized, "invalid or expired nonce")
+)
+
+const (
+	// DefaultExpiry is the default duration a message can be signed in.
+	DefaultExpiry = 10 * time.Minute
+	// DefaultSignURL is the default URL of the page that asks the wallet to sign the message.
+	DefaultSignURL = "/login/siwe/sign"
+	// nonceLength is the number of alphanumeric characters of a nonce.
+	nonceLength = 17
+	// clockSkew is the duration a message may be issued in the future.
+	clockSkew = time.Minute
+)
+
+// EmailResolver maps the EIP-55 address of a wallet to the email of the user.
+type EmailResolver func(ctx context.Context, address string) (string, error)
+
+// DefaultEmailResolver maps the address to a non-routable address
+// (e.g. "0xab58...@wallet.invalid") because users are identified by email.
+func DefaultEmailResolver(_ context.Context, address string) (string, error) {
+	return strings.ToLower(address) + "@wallet.invalid", nil
+}
+
+var _ providers.Provider = (*siweProvider)(nil)
+
+type siweProvider struct {
+	id           string
+	name         string
+	providerType providers.ProviderType
+	domain       string
+	uri          string
+	statement    string
+	chainIDs     []int64
+	expiry       time.Duration
+	signURL      string
+	flows        adapters.FlowStore
+	resolver     EmailResolver
+	random       io.Reader
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the SIWE provider.
+type Opt func(*siweProvider)
+
+// WithStatement sets the statement the user signs (e.g. "Sign in to Example").
+func WithStatement(statement string) Opt {
+	return func(p *siweProvider) {
+		p.statement = statement
+	}
+}
+
+// WithChainIDs sets the EIP-155 chain IDs users can sign in with. The first chain is the default.
+func WithChainIDs(ids ...int64) Opt {
+	return func(p *siweProvider) {
+		p.chainIDs = ids
+	}
+}
+
+// WithExpiry sets the duration a message can be signed in.
+func WithExpiry(expiry time.Duration) Opt {
+	return func(p *siweProvider) {
+		p.expiry = expiry
+	}
+}
+
+// WithSignURL sets the URL of the page that asks the wallet to sign the message. The message
+// and the state are appended as `message` and `state` query parameters.
+func WithSignURL(u string) Opt {
+	return func(p *siweProvider) {
+		p.signURL = u
+	}
+}
+
+// WithFlowStore sets the store of the issued nonces. Multi-instance deployments need a shared store.
+func WithFlowStore(store adapters.FlowStore) Opt {
+	return func(p *siweProvider) {
+		p.flows = store
+	}
+}
+
+// WithEmailResolver sets the function that maps the address of a wallet to the email of the user.
+func WithEmailResolver(resolver EmailResolver) Opt {
+	return func(p *siweProvider) {
+		p.resolver = resolver
+	}
+}
+
+// WithRand sets the source of the randomness of the nonces, e.g. the Rand of the goth config.
+func WithRand(r io.Reader) Opt {
+	return func(p *siweProvider) {
+		p.random = r
+	}
+}
+
+// New creates a new Sign-In with Ethereum (EIP-4361) provider for the domain (e.g. "example.com")
+// and the URI users sign in to (e.g. "https://example.com"). The nonces are kept in memory
+// unless a flow store is set.
+func New(domain, uri string, opts ...Opt) *siweProvider {
+	p := &siweProvider{
+		id:           "siwe",
+		name:         "Ethereum",
+		providerType: providers.ProviderTypeEthereum,
+		domain:       domain,
+		uri:          uri,
+		chainIDs:     []int64{1},
+		expiry:       DefaultExpiry,
+		signURL:      DefaultSignURL,
+		resolver:     DefaultEmailResolver,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.flows == nil {
+		p.flows = adapters.NewMemoryFlowStore()
+	}
+
+	if p.random == nil {
+		p.random = rand.Reader
+	}
+
+	return p
+}
+
+// ID returns the provider's ID.
+func (p *siweProvider) ID() string {
+	return p.id
+}
+
+// Name returns the provider's name.
+func (p *siweProvider) Name() string {
+	return p.name
+}
+
+// Type returns the provider's type.
+func (p *siweProvider) Type() providers.ProviderType {
+	return p.providerType
+}
+
+// nonceKey returns the key of the nonce in the flow store.
+func nonceKey(nonce string) string {
+	return "siwe:" + adapters.HashToken(nonce)
+}
+
+// IssueMessage issues a message for the wallet on the chain with a new nonce.
+// The request ID is included in the message, e.g. the state of the login.
+func (p *siweProvider) IssueMessage(ctx context.Context, address string, chainID int64, requestID string) (Message, error) {
+	addr, err := ParseAddress(address)
+	if err != nil {
+		return Message{}, err
+	}
+
+	if !slices.Contains(p.chainIDs, chainID) {
+		return Message{}, ErrInvalidChain
+	}
+
+	nonce, err := generateNonce(p.random)
+	if err != nil {
+		return Message{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	m := Message{
+		Domain:         p.domain,
+		Address:        ChecksumAddress(addr),
+		Statement:      p.statement,
+		URI:            p.uri,
+		Version:        "1",
+		ChainID:        chainID,
+		Nonce:          nonce,
+		IssuedAt:       now,
+		ExpirationTime: now.Add(p.expiry),
+		RequestID:      requestID,
+	}
+
+	if err := p.flows.Put(ctx, nonceKey(nonce), []byte(m.Address), p.expiry); err != nil {
+		return Message{}, err
+	}
+
+	return m, nil
+}
+
+// BeginAuth issues a message for the `address` and `chain_id` parameters and redirects to the sign URL.
+func (p *siweProvider) BeginAuth(ctx context.Context, _ adapters.Adapter, state string, params providers.AuthParams) (providers.AuthIntent, error) {
+	chainID := p.chainIDs[0]
+
+	if v := params.Get("chain_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidChain
+		}
+		chainID = id
+	}
+
+	m, err := p.IssueMessage(ctx, params.Get("address"), chainID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authIntent{
+		authURL: p.signURL + "?" + url.Values{"message": {m.String()}, "state": {state}}.Encode(),
+	}, nil
+}
+
+// CompleteAuth verifies the `signature` of the `message` and creates the user of the wallet.
+// Signatures of smart contract wallets (EIP-1271) are not supported.
+//
+// nolint:gocyclo
+func (p *siweProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	message, signature := params.Get("message"), params.Get("signature")
+
+	if utilx.Empty(message) || utilx.Empty(signature) {
+		return adapters.GothUser{}, ErrMissingSignature
+	}
+
+	m, err := ParseMessage(message)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	now := time.Now()
+
+	switch {
+	case m.Domain != p.domain, m.URI != p.uri, m.Version != "1":
+		return adapters.GothUser{}, ErrInvalidMessage
+	case !slices.Contains(p.chainIDs, m.ChainID):
+		return adapters.GothUser{}, ErrInvalidChain
+	case m.RequestID != params.Get("state"):
+		return adapters.GothUser{}, ErrInvalidMessage
+	case m.IssuedAt.After(now.Add(clockSkew)):
+		return adapters.GothUser{}, ErrInvalidMessage
+	case !m.ExpirationTime.IsZero() && !now.Before(m.ExpirationTime):
+		return adapters.GothUser{}, ErrInvalidMessage
+	case !m.NotBefore.IsZero() && now.Before(m.NotBefore):
+		return adapters.GothUser{}, ErrInvalidMessage
+	}
+
+	addr, err := RecoverAddress(message, signature)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	address := ChecksumAddress(addr)
+	if address != m.Address {
+		return adapters.GothUser{}, ErrInvalidSignature
+	}
+
+	issued, err := p.flows.GetAndDelete(ctx, nonceKey(m.Nonce))
+	if err != nil || !secure.EqualBytes(issued, []byte(address)) {
+		return adapters.GothUser{}, ErrInvalidNonce
+	}
+
+	email, err := p.resolver(ctx, address)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	return adapters.GothUser{
+		Name:  address,
+		Email: email,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeEthereum,
+				Provider:          p.ID(),
+				ProviderAccountID: cast.Ptr(address),
+			},
+		},
+	}, nil
+}
+
+// generateNonce returns a random alphanumeric nonce read from r.
+func generateNonce(r io.Reader) (string, error) {
+	const charset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	b := make([]byte, nonceLength)
+	for i := range b {
+		n, err := rand.Int(r, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+
+	return string(b), nil
+}
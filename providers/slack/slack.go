@@ -0,0 +1,287 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/slices"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUnverifiedEmail is returned when the email of the user is not verified by Slack.
+	ErrUnverifiedEmail = errors.New("goth: email is not verified")
+	// ErrNotAllowedTeam is returned when the user is not in an allowed workspace.
+	ErrNotAllowedTeam = errors.New("goth: user not in allowed workspace")
+)
+
+const (
+	// AuthURL is the OpenID Connect authorization endpoint of Slack.
+	AuthURL = "https://slack.com/openid/connect/authorize"
+	// TokenURL is the OpenID Connect token endpoint of Slack (openid.connect.token).
+	TokenURL = "https://slack.com/api/openid.connect.token"
+	// UserInfoURL is the OpenID Connect user info endpoint of Slack (openid.connect.userInfo).
+	UserInfoURL = "https://slack.com/api/openid.connect.userInfo"
+)
+
+var _ providers.Provider = (*slackProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Slack.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+type slackProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	allowedTeams []string
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Slack provider.
+type Opt func(*slackProvider)
+
+// WithScopes sets the additional scopes for the Slack provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *slackProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// WithAllowedTeams restricts the sign-in to users of the Slack workspaces (team IDs).
+// With a single workspace the user signs in to it directly.
+func WithAllowedTeams(teams ...string) Opt {
+	return func(p *slackProvider) {
+		p.allowedTeams = teams
+	}
+}
+
+// New creates a new Slack provider.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *slackProvider {
+	p := &slackProvider{
+		id:           "slack",
+		name:         "Slack",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOIDC,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+		allowedTeams: []string{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "SLACK_CLIENT_ID"
+	EnvClientSecret = "SLACK_CLIENT_SECRET"
+	EnvCallbackURL  = "SLACK_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Slack provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*slackProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new Slack provider from the SLACK_CLIENT_ID, SLACK_CLIENT_SECRET
+// and SLACK_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by SLACK_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*slackProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (s *slackProvider) ID() string {
+	return s.id
+}
+
+// Name returns the provider's name.
+func (s *slackProvider) Name() string {
+	return s.name
+}
+
+// Type returns the provider's type.
+func (s *slackProvider) Type() providers.ProviderType {
+	return s.providerType
+}
+
+// Check validates the client credentials and the reachability of the Slack endpoints.
+func (s *slackProvider) Check(ctx context.Context) error {
+	if s.clientKey == "" || s.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, s.client, AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (s *slackProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, params providers.AuthParams) (providers.AuthIntent, error) {
+	opts := []oauth2.AuthCodeOption{}
+
+	if len(s.allowedTeams) == 1 {
+		opts = append(opts, oauth2.SetAuthURLParam("team", s.allowedTeams[0]))
+	}
+
+	if hint := params.Get("login_hint"); hint != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("login_hint", hint))
+	}
+
+	return &authIntent{
+		authURL: s.config.AuthCodeURL(state, opts...),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process.
+// nolint:gocyclo
+func (s *slackProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		OK            bool   `json:"ok"`
+		Error         string `json:"error"`
+		Sub           string `json:"sub"`
+		Name          string `json:"name"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Picture       string `json:"picture"`
+		Locale        string `json:"locale"`
+		TeamID        string `json:"https://slack.com/team_id"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, s.client)
+
+	token, err := s.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = s.get(ctx, s.config.Client(ctx, token), UserInfoURL, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if !u.OK {
+		return adapters.GothUser{}, fmt.Errorf("goth: slack user info failed with %s", u.Error)
+	}
+
+	if !u.EmailVerified {
+		return adapters.GothUser{}, ErrUnverifiedEmail
+	}
+
+	if len(s.allowedTeams) > 0 && !slices.Any(func(team string) bool { return team == u.TeamID }, s.allowedTeams...) {
+		return adapters.GothUser{}, ErrNotAllowedTeam
+	}
+
+	user := adapters.GothUser{
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: cast.Ptr(u.EmailVerified),
+		Locale:        u.Locale,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOIDC,
+				Provider:          s.ID(),
+				ProviderAccountID: cast.Ptr(u.Sub),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+			},
+		},
+	}
+
+	if utilx.NotEmpty(u.Picture) {
+		user.Image = cast.Ptr(u.Picture)
+	}
+
+	if idToken, ok := token.Extra("id_token").(string); ok {
+		user.Accounts[0].IDToken = cast.Ptr(idToken)
+	}
+
+	return user, nil
+}
+
+func (s *slackProvider) get(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,349 @@
+package smsotp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+)
+
+var (
+	// ErrInvalidPhoneNumber is returned when the phone number is not in E.164 format.
+	ErrInvalidPhoneNumber = goth.NewError(fiber.StatusBadRequest, "invalid phone number")
+	// ErrMissingCode is returned when the phone number or the code is missing.
+	ErrMissingCode = goth.NewError(fiber.StatusBadRequest, "missing phone number or code")
+	// ErrInvalidCode is returned when the code is wrong or expired.
+	ErrInvalidCode = goth.NewError(fiber.StatusUnauthorized, "invalid or expired code")
+)
+
+const (
+	// DefaultCodeLength is the default number of digits of a code.
+	DefaultCodeLength = 6
+	// DefaultCodeExpiry is the default duration a code is valid for.
+	DefaultCodeExpiry = 5 * time.Minute
+	// DefaultVerifyURL is the default URL of the form to enter the code.
+	DefaultVerifyURL = "/login/smsotp/verify"
+)
+
+// Gateway sends SMS messages.
+type Gateway interface {
+	// Send sends the message to the phone number in E.164 format.
+	Send(ctx context.Context, phone, message string) error
+}
+
+// GatewayFunc is a function that implements Gateway.
+type GatewayFunc func(ctx context.Context, phone, message string) error
+
+// Send sends the message to the phone number.
+func (f GatewayFunc) Send(ctx context.Context, phone, message string) error {
+	return f(ctx, phone, message)
+}
+
+// EmailResolver maps a verified phone number to the email of the user.
+type EmailResolver func(ctx context.Context, phone string) (string, error)
+
+// DefaultEmailResolver maps the phone number to a non-routable address
+// (e.g. "4915112345678@phone.invalid") because users are identified by email.
+func DefaultEmailResolver(_ context.Context, phone string) (string, error) {
+	return strings.TrimPrefix(phone, "+") + "@phone.invalid", nil
+}
+
+var _ providers.Provider = (*smsProvider)(nil)
+
+type smsProvider struct {
+	id           string
+	name         string
+	providerType providers.ProviderType
+	gateway      Gateway
+	throttler    *goth.Throttler
+	resolver     EmailResolver
+	codeLength   int
+	expiry       time.Duration
+	verifyURL    string
+	message      string
+	random       io.Reader
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the SMS OTP provider.
+type Opt func(*smsProvider)
+
+// WithThrottler sets the throttler that limits the issued codes and failed attempts per phone number.
+// By default a throttler with the ThrottleConfigDefault keeps the counters in the adapter of the flow.
+func WithThrottler(t *goth.Throttler) Opt {
+	return func(p *smsProvider) {
+		p.throttler = t
+	}
+}
+
+// WithEmailResolver sets the function that maps a phone number to the email of the user.
+func WithEmailResolver(resolver EmailResolver) Opt {
+	return func(p *smsProvider) {
+		p.resolver = resolver
+	}
+}
+
+// WithCodeLength sets the number of digits of a code.
+func WithCodeLength(n int) Opt {
+	return func(p *smsProvider) {
+		p.codeLength = n
+	}
+}
+
+// WithExpiry sets the duration a code is valid for.
+func WithExpiry(expiry time.Duration) Opt {
+	return func(p *smsProvider) {
+		p.expiry = expiry
+	}
+}
+
+// WithVerifyURL sets the URL of the form to enter the code. The phone number and the state are
+// appended as `phone` and `state` query parameters, the form has to submit both with the code.
+func WithVerifyURL(u string) Opt {
+	return func(p *smsProvider) {
+		p.verifyURL = u
+	}
+}
+
+// WithMessage sets the format of the message. It has to contain a single `%s` verb for the code.
+func WithMessage(format string) Opt {
+	return func(p *smsProvider) {
+		p.message = format
+	}
+}
+
+// WithRand sets the source of the randomness of the codes, e.g. the Rand of the goth config.
+func WithRand(r io.Reader) Opt {
+	return func(p *smsProvider) {
+		p.random = r
+	}
+}
+
+// New creates a new SMS OTP provider.
+func New(gateway Gateway, opts ...Opt) *smsProvider {
+	p := &smsProvider{
+		id:           "smsotp",
+		name:         "SMS",
+		providerType: providers.ProviderTypeEmail,
+		gateway:      gateway,
+		resolver:     DefaultEmailResolver,
+		codeLength:   DefaultCodeLength,
+		expiry:       DefaultCodeExpiry,
+		verifyURL:    DefaultVerifyURL,
+		message:      "Your verification code is %s",
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.random == nil {
+		p.random = rand.Reader
+	}
+
+	return p
+}
+
+// ID returns the provider's ID.
+func (p *smsProvider) ID() string {
+	return p.id
+}
+
+// Name returns the provider's name.
+func (p *smsProvider) Name() string {
+	return p.name
+}
+
+// Type returns the provider's type.
+func (p *smsProvider) Type() providers.ProviderType {
+	return p.providerType
+}
+
+// BeginAuth issues a code to the `phone` parameter and redirects to the verify URL.
+func (p *smsProvider) BeginAuth(ctx context.Context, adapter adapters.Adapter, state string, params providers.AuthParams) (providers.AuthIntent, error) {
+	phone, err := NormalizePhone(params.Get("phone"))
+	if err != nil {
+		return nil, err
+	}
+
+	key := goth.ThrottleKey("smsotp-issue", phone)
+
+	// every issued code counts as failed attempt to enforce progressive delays on re-sends
+	err = p.throttle(adapter).Allow(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := generateCode(p.random, p.codeLength)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = adapter.CreateVerificationToken(ctx, adapters.GothVerificationToken{
+		Identifier: identifier(phone),
+		Token:      tokenHash(phone, code),
+		ExpiresAt:  time.Now().Add(p.expiry),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.gateway.Send(ctx, phone, fmt.Sprintf(p.message, code))
+	if err != nil {
+		return nil, err
+	}
+
+	return &authIntent{
+		authURL: p.verifyURL + "?" + url.Values{"phone": {phone}, "state": {state}}.Encode(),
+	}, nil
+}
+
+// CompleteAuth verifies the `code` for the `phone` parameter and creates the user.
+func (p *smsProvider) CompleteAuth(ctx context.Context, adapter adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	code := strings.TrimSpace(params.Get("code"))
+
+	if utilx.Empty(params.Get("phone")) || utilx.Empty(code) {
+		return adapters.GothUser{}, ErrMissingCode
+	}
+
+	phone, err := NormalizePhone(params.Get("phone"))
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	key := goth.ThrottleKey("smsotp", phone)
+	throttler := p.throttle(adapter)
+
+	err = throttler.Allow(ctx, key)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	_, err = adapter.UseVerficationToken(ctx, identifier(phone), tokenHash(phone, code))
+	if err != nil {
+		return adapters.GothUser{}, ErrInvalidCode
+	}
+
+	for _, k := range []string{key, goth.ThrottleKey("smsotp-issue", phone)} {
+		if err := throttler.Reset(ctx, k); err != nil {
+			return adapters.GothUser{}, err
+		}
+	}
+
+	email, err := p.resolver(ctx, phone)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name:  phone,
+		Email: email,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeEmail,
+				Provider:          p.ID(),
+				ProviderAccountID: cast.Ptr(phone),
+			},
+		},
+	}
+
+	return user, nil
+}
+
+// throttle returns the configured throttler or a default throttler of the adapter,
+// so that codes cannot be brute-forced and sends are not unlimited without WithThrottler.
+func (p *smsProvider) throttle(adapter adapters.Adapter) *goth.Throttler {
+	if p.throttler != nil {
+		return p.throttler
+	}
+
+	return goth.NewThrottler(adapter)
+}
+
+// NormalizePhone removes separators from the phone number and validates it is in E.164 format.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')', '.':
+			return -1
+		}
+
+		return r
+	}, strings.TrimSpace(phone))
+
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+
+	if len(phone) < 8 || len(phone) > 16 || phone[0] != '+' || phone[1] == '0' {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	for _, r := range phone[1:] {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	return phone, nil
+}
+
+// GenerateCode generates a random numeric code with n digits.
+func GenerateCode(n int) (string, error) {
+	return generateCode(rand.Reader, n)
+}
+
+// generateCode generates a random numeric code with n digits read from r.
+func generateCode(r io.Reader, n int) (string, error) {
+	if n <= 0 {
+		return "", errors.New("smsotp: invalid code length")
+	}
+
+	b := make([]byte, n)
+
+	for i := range b {
+		num, err := rand.Int(r, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+
+		b[i] = byte('0' + num.Int64())
+	}
+
+	return string(b), nil
+}
+
+func identifier(phone string) string {
+	return "smsotp:" + phone
+}
+
+// tokenHash scopes the code to the phone number, since codes are not unique across phone numbers.
+func tokenHash(phone, code string) string {
+	return adapters.HashToken(identifier(phone) + ":" + code)
+}
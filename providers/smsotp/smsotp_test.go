@@ -0,0 +1,242 @@
+package smsotp
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+// testAdapter keeps the verification tokens, users, sessions and throttles of a login in memory.
+type testAdapter struct {
+	adapters.UnimplementedAdapter
+
+	mu        sync.Mutex
+	tokens    map[string]adapters.GothVerificationToken
+	users     map[uuid.UUID]adapters.GothUser
+	sessions  map[string]adapters.GothSession
+	throttles map[string]adapters.GothThrottle
+}
+
+func newTestAdapter() *testAdapter {
+	return &testAdapter{
+		tokens:    map[string]adapters.GothVerificationToken{},
+		users:     map[uuid.UUID]adapters.GothUser{},
+		sessions:  map[string]adapters.GothSession{},
+		throttles: map[string]adapters.GothThrottle{},
+	}
+}
+
+func (a *testAdapter) CreateVerificationToken(_ context.Context, token adapters.GothVerificationToken) (adapters.GothVerificationToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.tokens[token.Identifier+":"+token.Token] = token
+
+	return token, nil
+}
+
+func (a *testAdapter) UseVerficationToken(_ context.Context, identifier, token string) (adapters.GothVerificationToken, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	t, ok := a.tokens[identifier+":"+token]
+	if !ok {
+		return adapters.GothVerificationToken{}, adapters.ErrUnimplemented
+	}
+	delete(a.tokens, identifier+":"+token)
+
+	return t, nil
+}
+
+func (a *testAdapter) CreateUser(_ context.Context, user adapters.GothUser) (adapters.GothUser, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user.ID = uuid.New()
+	a.users[user.ID] = user
+
+	return user, nil
+}
+
+func (a *testAdapter) GetUser(_ context.Context, id uuid.UUID) (adapters.GothUser, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	user, ok := a.users[id]
+	if !ok {
+		return adapters.GothUser{}, goth.ErrMissingUser
+	}
+
+	return user, nil
+}
+
+func (a *testAdapter) CreateSession(_ context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	session.ID = uuid.New()
+	a.sessions[session.SessionToken] = session
+
+	return session, nil
+}
+
+func (a *testAdapter) AttemptThrottle(_ context.Context, identifier string, delay func(failures int) time.Duration) (adapters.GothThrottle, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	throttle := a.throttles[identifier]
+
+	now := time.Now()
+	if now.Before(throttle.LastFailureAt.Add(delay(throttle.Failures))) {
+		return throttle, adapters.ErrThrottled
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	a.throttles[identifier] = throttle
+
+	return throttle, nil
+}
+
+func (a *testAdapter) ResetThrottle(_ context.Context, identifier string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.throttles, identifier)
+
+	return nil
+}
+
+func TestDefaultThrottler(t *testing.T) {
+	sent := 0
+	p := New(GatewayFunc(func(_ context.Context, _, _ string) error {
+		sent++
+		return nil
+	}))
+
+	adapter := newTestAdapter()
+	params := url.Values{"phone": {"+4915112345678"}, "code": {"000000"}}
+
+	for i := 0; i < goth.ThrottleConfigDefault.FreeAttempts; i++ {
+		if _, err := p.BeginAuth(context.Background(), adapter, "state", params); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := p.BeginAuth(context.Background(), adapter, "state", params); !errors.Is(err, goth.ErrThrottled) {
+		t.Fatalf("send: expected ErrThrottled, got %v", err)
+	}
+
+	if sent != goth.ThrottleConfigDefault.FreeAttempts {
+		t.Fatalf("send: expected %d codes, got %d", goth.ThrottleConfigDefault.FreeAttempts, sent)
+	}
+
+	for i := 0; i < goth.ThrottleConfigDefault.FreeAttempts; i++ {
+		if _, err := p.CompleteAuth(context.Background(), adapter, params); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("verify: expected ErrInvalidCode, got %v", err)
+		}
+	}
+
+	if _, err := p.CompleteAuth(context.Background(), adapter, params); !errors.Is(err, goth.ErrThrottled) {
+		t.Fatalf("verify: expected ErrThrottled, got %v", err)
+	}
+}
+
+func TestCallbackWithFlowStore(t *testing.T) {
+	var code string
+	providers.RegisterProvider(New(GatewayFunc(func(_ context.Context, _, message string) error {
+		code = regexp.MustCompile(`\d+`).FindString(message)
+		return nil
+	})))
+
+	adapter := newTestAdapter()
+	cfg := goth.Config{
+		Adapter:   adapter,
+		FlowStore: adapters.NewMemoryFlowStore(),
+		Secret:    "test-secret-test-secret-test-secret",
+	}
+
+	app := fiber.New()
+	app.Get("/login/:provider", goth.NewBeginAuthHandler(cfg))
+	app.Get("/auth/:provider/callback", goth.NewCompleteAuthHandler(cfg))
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/login/smsotp?phone=%2B4915112345678", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode != fiber.StatusTemporaryRedirect {
+		t.Fatalf("begin: unexpected status %d", res.StatusCode)
+	}
+
+	verify, err := url.Parse(res.Header.Get(fiber.HeaderLocation))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verify.Path != DefaultVerifyURL || verify.Query().Get("state") == "" {
+		t.Fatalf("begin: unexpected verify URL %q", verify)
+	}
+
+	// the verify form submits the phone number and the state of the verify URL with the code
+	callback := func(q url.Values) *http.Response {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/auth/smsotp/callback?"+q.Encode(), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	withoutState := url.Values{"phone": {verify.Query().Get("phone")}, "code": {code}}
+	if res := callback(withoutState); res.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("callback without state: unexpected status %d", res.StatusCode)
+	}
+
+	q := verify.Query()
+	q.Set("code", code)
+
+	res = callback(q)
+	if res.StatusCode != fiber.StatusSeeOther {
+		t.Fatalf("callback: unexpected status %d", res.StatusCode)
+	}
+
+	if len(adapter.sessions) != 1 {
+		t.Fatalf("callback: expected a session, got %d", len(adapter.sessions))
+	}
+
+	if res := callback(q); res.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("replayed callback: unexpected status %d", res.StatusCode)
+	}
+}
+
+func TestWithRand(t *testing.T) {
+	codes := []string{}
+	for range 2 {
+		p := New(GatewayFunc(func(_ context.Context, _, message string) error {
+			codes = append(codes, message)
+			return nil
+		}), WithRand(rand.NewChaCha8([32]byte{})))
+
+		if _, err := p.BeginAuth(context.Background(), newTestAdapter(), "state", url.Values{"phone": {"+4915112345678"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if codes[0] != codes[1] {
+		t.Fatalf("expected the codes of the same source, got %q and %q", codes[0], codes[1])
+	}
+}
@@ -0,0 +1,69 @@
+package sns
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/zeiss/fiber-goth/providers/smsotp"
+)
+
+// Publisher is the subset of the SNS client used by the gateway.
+type Publisher interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+var _ smsotp.Gateway = (*Gateway)(nil)
+
+// Gateway sends SMS messages via Amazon SNS.
+type Gateway struct {
+	client   Publisher
+	senderID string
+}
+
+// Opt is a function that configures the gateway.
+type Opt func(*Gateway)
+
+// WithSenderID sets the alphanumeric sender ID, if supported in the destination country.
+func WithSenderID(id string) Opt {
+	return func(g *Gateway) {
+		g.senderID = id
+	}
+}
+
+// New creates a new SNS gateway.
+func New(client Publisher, opts ...Opt) *Gateway {
+	g := &Gateway{client: client}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Send sends the message to the phone number as transactional SMS.
+func (g *Gateway) Send(ctx context.Context, phone, message string) error {
+	attrs := map[string]types.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String("Transactional"),
+		},
+	}
+
+	if g.senderID != "" {
+		attrs["AWS.SNS.SMS.SenderID"] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(g.senderID),
+		}
+	}
+
+	_, err := g.client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(phone),
+		Message:           aws.String(message),
+		MessageAttributes: attrs,
+	})
+
+	return err
+}
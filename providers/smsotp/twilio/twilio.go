@@ -0,0 +1,98 @@
+package twilio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/fiber-goth/providers/smsotp"
+)
+
+// DefaultBaseURL is the base URL of the Twilio API.
+const DefaultBaseURL = "https://api.twilio.com/2010-04-01"
+
+var _ smsotp.Gateway = (*Gateway)(nil)
+
+// Gateway sends SMS messages via the Twilio Messaging API.
+type Gateway struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	client     *http.Client
+}
+
+// Opt is a function that configures the gateway.
+type Opt func(*Gateway)
+
+// WithBaseURL sets the base URL of the API.
+func WithBaseURL(u string) Opt {
+	return func(g *Gateway) {
+		g.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// WithClient sets the HTTP client.
+func WithClient(client *http.Client) Opt {
+	return func(g *Gateway) {
+		g.client = client
+	}
+}
+
+// New creates a new Twilio gateway. The from is the sender phone number
+// or a messaging service SID (starting with "MG").
+func New(accountSID, authToken, from string, opts ...Opt) *Gateway {
+	g := &Gateway{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		baseURL:    DefaultBaseURL,
+		client:     providers.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Send sends the message to the phone number.
+func (g *Gateway) Send(ctx context.Context, phone, message string) error {
+	form := url.Values{
+		"To":   {phone},
+		"Body": {message},
+	}
+
+	if strings.HasPrefix(g.from, "MG") {
+		form.Set("MessagingServiceSid", g.from)
+	} else {
+		form.Set("From", g.from)
+	}
+
+	u := fmt.Sprintf("%s/Accounts/%s/Messages.json", g.baseURL, url.PathEscape(g.accountSID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(g.accountSID, g.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("twilio: failed to send message: %s: %s", res.Status, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package spnego
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	goth "github.com/zeiss/fiber-goth"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+var (
+	// ErrMissingToken is returned when the request has no Negotiate authorization.
+	ErrMissingToken = goth.NewError(fiber.StatusUnauthorized, "missing negotiate token")
+	// ErrInvalidToken is returned when the Negotiate token cannot be verified with the keytab.
+	ErrInvalidToken = goth.NewError(fiber.StatusUnauthorized, "invalid negotiate token")
+	// ErrNTLMUnsupported is returned when the browser falls back to NTLM, e.g. if the host is not in the intranet zone.
+	ErrNTLMUnsupported = goth.NewError(fiber.StatusUnauthorized, "NTLM is not supported, use Kerberos")
+	// ErrNotAllowedRealm is returned when the principal is not in an allowed realm.
+	ErrNotAllowedRealm = goth.NewError(fiber.StatusForbidden, "principal not in allowed realm")
+)
+
+// ntlmSignature is the prefix of NTLM messages sent with the Negotiate scheme.
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// Principal is the Kerberos principal of the authenticated user.
+type Principal struct {
+	// Name is the name of the principal (e.g. "jdoe").
+	Name string
+	// Realm is the Kerberos realm of the principal (e.g. "EXAMPLE.COM").
+	Realm string
+}
+
+// String returns the principal in the `name@REALM` form.
+func (p Principal) String() string {
+	return p.Name + "@" + p.Realm
+}
+
+// Acceptor accepts the SPNEGO (or raw Kerberos) token of the browser against the keytab of the service
+// principal (e.g. HTTP/intranet.example.com@EXAMPLE.COM), e.g. with the service of a Kerberos library.
+type Acceptor interface {
+	// Accept verifies the token and returns the principal of the client.
+	Accept(ctx context.Context, token []byte) (Principal, error)
+}
+
+// AcceptorFunc is a function that implements Acceptor.
+type AcceptorFunc func(ctx context.Context, token []byte) (Principal, error)
+
+// Accept verifies the token.
+func (f AcceptorFunc) Accept(ctx context.Context, token []byte) (Principal, error) {
+	return f(ctx, token)
+}
+
+// EmailResolver maps the principal to the email of the user.
+type EmailResolver func(ctx context.Context, principal Principal) (string, error)
+
+// DefaultEmailResolver maps the principal to the email in the lower-cased realm (e.g. "jdoe@example.com").
+func DefaultEmailResolver(_ context.Context, principal Principal) (string, error) {
+	return strings.ToLower(principal.Name + "@" + principal.Realm), nil
+}
+
+var _ providers.Provider = (*spnegoProvider)(nil)
+
+type spnegoProvider struct {
+	id           string
+	name         string
+	callbackURL  string
+	providerType providers.ProviderType
+	acceptor     Acceptor
+	resolver     EmailResolver
+	realms       []string
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the SPNEGO provider.
+type Opt func(*spnegoProvider)
+
+// WithEmailResolver sets the function that maps a principal to the email of the user.
+func WithEmailResolver(resolver EmailResolver) Opt {
+	return func(p *spnegoProvider) {
+		p.resolver = resolver
+	}
+}
+
+// WithAllowedRealms restricts the sign-in to principals of the Kerberos realms.
+func WithAllowedRealms(realms ...string) Opt {
+	return func(p *spnegoProvider) {
+		p.realms = realms
+	}
+}
+
+// New creates a new Kerberos/SPNEGO provider. The tokens are verified by the acceptor.
+// The callback route must be mounted behind the Challenge middleware, so that browsers
+// send the Negotiate token silently.
+func New(acceptor Acceptor, callbackURL string, opts ...Opt) *spnegoProvider {
+	p := &spnegoProvider{
+		id:           "spnego",
+		name:         "Kerberos",
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeKerberos,
+		acceptor:     acceptor,
+		resolver:     DefaultEmailResolver,
+		realms:       []string{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// ID returns the provider's ID.
+func (p *spnegoProvider) ID() string {
+	return p.id
+}
+
+// Name returns the provider's name.
+func (p *spnegoProvider) Name() string {
+	return p.name
+}
+
+// Type returns the provider's type.
+func (p *spnegoProvider) Type() providers.ProviderType {
+	return p.providerType
+}
+
+// BeginAuth redirects to the callback, where the browser authenticates with Negotiate.
+func (p *spnegoProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: p.callbackURL + "?" + url.Values{"state": {state}}.Encode(),
+	}, nil
+}
+
+// CompleteAuth verifies the Negotiate token of the Authorization header and maps the principal to the user.
+func (p *spnegoProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	hp, ok := params.(providers.HeaderParams)
+	if !ok {
+		return adapters.GothUser{}, ErrMissingToken
+	}
+
+	token, err := negotiateToken(hp.Header(fiber.HeaderAuthorization))
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	principal, err := p.acceptor.Accept(ctx, token)
+	if err != nil {
+		return adapters.GothUser{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if len(p.realms) > 0 && !slices.ContainsFunc(p.realms, func(r string) bool { return strings.EqualFold(r, principal.Realm) }) {
+		return adapters.GothUser{}, ErrNotAllowedRealm
+	}
+
+	email, err := p.resolver(ctx, principal)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	return adapters.GothUser{
+		Name:          principal.Name,
+		Email:         email,
+		EmailVerified: cast.Ptr(true),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeKerberos,
+				Provider:          p.ID(),
+				ProviderAccountID: cast.Ptr(principal.String()),
+			},
+		},
+	}, nil
+}
+
+// negotiateToken returns the decoded token of the Negotiate authorization.
+func negotiateToken(authorization string) ([]byte, error) {
+	scheme, value, _ := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !strings.EqualFold(scheme, "Negotiate") || value == "" {
+		return nil, ErrMissingToken
+	}
+
+	token, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if bytes.HasPrefix(token, ntlmSignature) {
+		return nil, ErrNTLMUnsupported
+	}
+
+	return token, nil
+}
+
+// Challenge returns a middleware for the callback route that answers requests without a Negotiate
+// authorization with 401 and `WWW-Authenticate: Negotiate`, so that the browser retries with the token.
+// Browsers only send the token silently for hosts in the intranet zone (or the configured allowlist).
+func Challenge() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, err := negotiateToken(c.Get(fiber.HeaderAuthorization)); err == nil || errors.Is(err, ErrNTLMUnsupported) {
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderWWWAuthenticate, "Negotiate")
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		return c.Status(fiber.StatusUnauthorized).SendString("Kerberos authentication required")
+	}
+}
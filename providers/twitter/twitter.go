@@ -0,0 +1,282 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+// ErrMissingVerifier is returned when the PKCE verifier of the state is missing or has expired.
+var ErrMissingVerifier = errors.New("goth: missing or expired pkce verifier")
+
+const (
+	// AuthURL is the authorization endpoint of X (Twitter).
+	AuthURL = "https://twitter.com/i/oauth2/authorize"
+	// TokenURL is the token endpoint of X (Twitter).
+	TokenURL = "https://api.twitter.com/2/oauth2/token"
+	// UserURL is the endpoint of the authenticated user.
+	UserURL = "https://api.twitter.com/2/users/me?user.fields=name,username,profile_image_url"
+
+	// verifierExpiry is the duration the PKCE verifier of a login is kept.
+	verifierExpiry = 10 * time.Minute
+)
+
+var _ providers.Provider = (*twitterProvider)(nil)
+
+// DefaultScopes holds the default scopes used for X (Twitter).
+var DefaultScopes = []string{"users.read", "tweet.read", "offline.access"}
+
+type twitterProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	flows        adapters.FlowStore
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the X (Twitter) provider.
+type Opt func(*twitterProvider)
+
+// WithScopes sets the scopes for the X (Twitter) provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *twitterProvider) {
+		p.scopes = scopes
+	}
+}
+
+// WithFlowStore sets the store of the PKCE verifiers. Multi-instance deployments need a shared store.
+func WithFlowStore(store adapters.FlowStore) Opt {
+	return func(p *twitterProvider) {
+		p.flows = store
+	}
+}
+
+// New creates a new X (Twitter) provider. The PKCE verifiers are kept in memory unless a flow store is set.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *twitterProvider {
+	p := &twitterProvider{
+		id:           "twitter",
+		name:         "X",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.flows == nil {
+		p.flows = adapters.NewMemoryFlowStore()
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "TWITTER_CLIENT_ID"
+	EnvClientSecret = "TWITTER_CLIENT_SECRET"
+	EnvCallbackURL  = "TWITTER_CALLBACK_URL"
+)
+
+// NewFromSource creates a new X (Twitter) provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*twitterProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new X (Twitter) provider from the TWITTER_CLIENT_ID, TWITTER_CLIENT_SECRET
+// and TWITTER_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by TWITTER_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*twitterProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (t *twitterProvider) ID() string {
+	return t.id
+}
+
+// Name returns the provider's name.
+func (t *twitterProvider) Name() string {
+	return t.name
+}
+
+// Type returns the provider's type.
+func (t *twitterProvider) Type() providers.ProviderType {
+	return t.providerType
+}
+
+// Check validates the client credentials and the reachability of the X (Twitter) endpoints.
+func (t *twitterProvider) Check(ctx context.Context) error {
+	if t.clientKey == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, t.client, AuthURL)
+}
+
+// verifierKey returns the key of the PKCE verifier of the state.
+func verifierKey(state string) string {
+	return "pkce:twitter:" + adapters.HashToken(state)
+}
+
+// BeginAuth starts the authentication process. The PKCE verifier is stored for the state.
+func (t *twitterProvider) BeginAuth(ctx context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	verifier := oauth2.GenerateVerifier()
+
+	if err := t.flows.Put(ctx, verifierKey(state), []byte(verifier), verifierExpiry); err != nil {
+		return nil, err
+	}
+
+	return &authIntent{
+		authURL: t.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process. X (Twitter) does not return the email of the user.
+func (t *twitterProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	res := struct {
+		Data struct {
+			ID              string `json:"id"`
+			Name            string `json:"name"`
+			Username        string `json:"username"`
+			ProfileImageURL string `json:"profile_image_url"`
+		} `json:"data"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	verifier, err := t.flows.GetAndDelete(ctx, verifierKey(params.Get("state")))
+	if err != nil {
+		return adapters.GothUser{}, ErrMissingVerifier
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, t.client)
+
+	token, err := t.config.Exchange(ctx, code, oauth2.VerifierOption(string(verifier)))
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = t.get(ctx, token, UserURL, &res)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name: res.Data.Name,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          t.ID(),
+				ProviderAccountID: cast.Ptr(res.Data.ID),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+			},
+		},
+	}
+
+	if utilx.Empty(user.Name) {
+		user.Name = res.Data.Username
+	}
+
+	if utilx.NotEmpty(res.Data.ProfileImageURL) {
+		user.Image = cast.Ptr(res.Data.ProfileImageURL)
+	}
+
+	return user, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token. Refresh tokens are only issued with the offline.access scope.
+func (t *twitterProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, t.client)
+
+	return t.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+func (t *twitterProvider) get(ctx context.Context, token *oauth2.Token, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.config.Client(ctx, token).Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
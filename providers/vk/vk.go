@@ -0,0 +1,303 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrMissingEmail is returned when the token response has no email, e.g. if the email scope has not been granted.
+	ErrMissingEmail = errors.New("goth: missing email")
+	// ErrMissingUser is returned when users.get returns no user.
+	ErrMissingUser = errors.New("goth: missing user")
+)
+
+const (
+	// AuthURL is the authorization endpoint of VK ID.
+	AuthURL = "https://oauth.vk.com/authorize"
+	// TokenURL is the token endpoint of VK ID.
+	TokenURL = "https://oauth.vk.com/access_token"
+	// UsersGetURL is the users.get method of the VK API.
+	UsersGetURL = "https://api.vk.com/method/users.get"
+	// APIVersion is the version of the VK API.
+	APIVersion = "5.199"
+
+	// EmailScope is the scope to return the email of the user with the token.
+	EmailScope = "email"
+)
+
+var _ providers.Provider = (*vkProvider)(nil)
+
+// DefaultScopes holds the default scopes used for VK.
+var DefaultScopes = []string{EmailScope}
+
+// DefaultFields are the fields of the user requested from users.get.
+var DefaultFields = []string{"photo_200", "screen_name"}
+
+type vkProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	lang         string
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the VK provider.
+type Opt func(*vkProvider)
+
+// WithScopes sets the additional scopes for the VK provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *vkProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// WithLang sets the language of the names returned by users.get (e.g. "ru" or "en").
+func WithLang(lang string) Opt {
+	return func(p *vkProvider) {
+		p.lang = lang
+	}
+}
+
+// New creates a new VK provider with the ID and the protected key of the app.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *vkProvider {
+	p := &vkProvider{
+		id:           "vk",
+		name:         "VK",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+		lang:         "en",
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "VK_CLIENT_ID"
+	EnvClientSecret = "VK_CLIENT_SECRET"
+	EnvCallbackURL  = "VK_CALLBACK_URL"
+)
+
+// NewFromSource creates a new VK provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*vkProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new VK provider from the VK_CLIENT_ID, VK_CLIENT_SECRET
+// and VK_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by VK_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*vkProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (v *vkProvider) ID() string {
+	return v.id
+}
+
+// Name returns the provider's name.
+func (v *vkProvider) Name() string {
+	return v.name
+}
+
+// Type returns the provider's type.
+func (v *vkProvider) Type() providers.ProviderType {
+	return v.providerType
+}
+
+// Check validates the client credentials and the reachability of the VK endpoints.
+func (v *vkProvider) Check(ctx context.Context) error {
+	if v.clientKey == "" || v.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, v.client, AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (v *vkProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: v.config.AuthCodeURL(state, oauth2.SetAuthURLParam("v", APIVersion)),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process. VK returns the email and the ID
+// of the user with the token, the profile is read with users.get.
+func (v *vkProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, v.client)
+
+	token, err := v.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	email, _ := token.Extra("email").(string)
+	if utilx.Empty(email) {
+		return adapters.GothUser{}, ErrMissingEmail
+	}
+
+	u, err := v.user(ctx, token)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name:          strings.TrimSpace(u.FirstName + " " + u.LastName),
+		Email:         email,
+		EmailVerified: cast.Ptr(true),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          v.ID(),
+				ProviderAccountID: cast.Ptr(strconv.FormatInt(u.ID, 10)),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(v.scopes)),
+			},
+		},
+	}
+
+	if utilx.Empty(user.Name) {
+		user.Name = u.ScreenName
+	}
+
+	if utilx.NotEmpty(u.Photo200) {
+		user.Image = cast.Ptr(u.Photo200)
+	}
+
+	return user, nil
+}
+
+// vkUser is a user of the users.get response.
+type vkUser struct {
+	ID         int64  `json:"id"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	ScreenName string `json:"screen_name"`
+	Photo200   string `json:"photo_200"`
+}
+
+// user returns the authenticated user with users.get.
+func (v *vkProvider) user(ctx context.Context, token *oauth2.Token) (vkUser, error) {
+	res := struct {
+		Response []vkUser `json:"response"`
+		Error    *struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"error_msg"`
+		} `json:"error"`
+	}{}
+
+	q := url.Values{
+		"access_token": {token.AccessToken},
+		"fields":       {strings.Join(DefaultFields, ",")},
+		"lang":         {v.lang},
+		"v":            {APIVersion},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, UsersGetURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return vkUser{}, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return vkUser{}, err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return vkUser{}, fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return vkUser{}, err
+	}
+
+	// VK returns the errors of the API with status 200
+	if res.Error != nil {
+		return vkUser{}, fmt.Errorf("goth: request to %s failed with %d: %s", req.URL.Host+req.URL.Path, res.Error.Code, res.Error.Message)
+	}
+
+	if len(res.Response) == 0 {
+		return vkUser{}, ErrMissingUser
+	}
+
+	return res.Response[0], nil
+}
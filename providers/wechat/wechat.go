@@ -0,0 +1,371 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+// ErrMissingOpenID is returned when the token response of WeChat has no openid.
+var ErrMissingOpenID = errors.New("goth: missing openid")
+
+const (
+	// QRConnectURL is the authorization endpoint of the QR-code web login (Open Platform website apps).
+	QRConnectURL = "https://open.weixin.qq.com/connect/qrconnect"
+	// AuthURL is the authorization endpoint of the login within the WeChat app (Official Accounts).
+	AuthURL = "https://open.weixin.qq.com/connect/oauth2/authorize"
+	// TokenURL is the token endpoint of WeChat.
+	TokenURL = "https://api.weixin.qq.com/sns/oauth2/access_token"
+	// RefreshURL is the endpoint to refresh the access token.
+	RefreshURL = "https://api.weixin.qq.com/sns/oauth2/refresh_token"
+	// UserInfoURL is the endpoint of the profile of the user.
+	UserInfoURL = "https://api.weixin.qq.com/sns/userinfo"
+
+	// ScopeLogin is the scope of the QR-code web login.
+	ScopeLogin = "snsapi_login"
+	// ScopeUserInfo is the scope of the login within the WeChat app that includes the profile.
+	ScopeUserInfo = "snsapi_userinfo"
+
+	// redirectFragment is the fragment WeChat requires on the authorization URLs.
+	redirectFragment = "#wechat_redirect"
+)
+
+var _ providers.Provider = (*wechatProvider)(nil)
+
+// EmailResolver maps the ID of the user (the unionid or the openid) to the email of the user.
+// WeChat does not share email addresses.
+type EmailResolver func(ctx context.Context, id string) (string, error)
+
+// DefaultEmailResolver maps the ID to a non-routable address (e.g. "o6_bmjrpt...@wechat.invalid")
+// because users are identified by email.
+func DefaultEmailResolver(_ context.Context, id string) (string, error) {
+	return strings.ToLower(id) + "@wechat.invalid", nil
+}
+
+type wechatProvider struct {
+	id           string
+	name         string
+	appID        string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	authURL      string
+	scope        string
+	lang         string
+	resolver     EmailResolver
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the WeChat provider.
+type Opt func(*wechatProvider)
+
+// WithInApp signs users in within the WeChat app (Official Accounts) instead of the QR-code web login.
+func WithInApp() Opt {
+	return func(p *wechatProvider) {
+		p.authURL = AuthURL
+		p.scope = ScopeUserInfo
+	}
+}
+
+// WithLang sets the language of the profile (e.g. "zh_CN", "zh_TW" or "en").
+func WithLang(lang string) Opt {
+	return func(p *wechatProvider) {
+		p.lang = lang
+	}
+}
+
+// WithEmailResolver sets the function that maps the ID of the user to the email of the user.
+func WithEmailResolver(resolver EmailResolver) Opt {
+	return func(p *wechatProvider) {
+		p.resolver = resolver
+	}
+}
+
+// New creates a new WeChat provider with the AppID and AppSecret of the app. By default
+// users sign in by scanning a QR code (website apps of the Open Platform).
+func New(appID, secret, callbackURL string, opts ...Opt) *wechatProvider {
+	p := &wechatProvider{
+		id:           "wechat",
+		name:         "WeChat",
+		appID:        appID,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		authURL:      QRConnectURL,
+		scope:        ScopeLogin,
+		lang:         "en",
+		resolver:     DefaultEmailResolver,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvAppID       = "WECHAT_APP_ID"
+	EnvAppSecret   = "WECHAT_APP_SECRET"
+	EnvCallbackURL = "WECHAT_CALLBACK_URL"
+)
+
+// NewFromSource creates a new WeChat provider loading the app secret from source.
+func NewFromSource(appID string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*wechatProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(appID, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new WeChat provider from the WECHAT_APP_ID, WECHAT_APP_SECRET
+// and WECHAT_CALLBACK_URL environment variables. The app secret can also be read
+// from the file referenced by WECHAT_APP_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*wechatProvider, error) {
+	appID, err := providers.Env(EnvAppID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(appID, providers.EnvOrFile(EnvAppSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (w *wechatProvider) ID() string {
+	return w.id
+}
+
+// Name returns the provider's name.
+func (w *wechatProvider) Name() string {
+	return w.name
+}
+
+// Type returns the provider's type.
+func (w *wechatProvider) Type() providers.ProviderType {
+	return w.providerType
+}
+
+// Check validates the app credentials and the reachability of the WeChat endpoints.
+func (w *wechatProvider) Check(ctx context.Context) error {
+	if w.appID == "" || w.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, w.client, w.authURL)
+}
+
+// BeginAuth starts the authentication process. WeChat expects the `appid` instead of the
+// `client_id` and the `#wechat_redirect` fragment.
+func (w *wechatProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	v := url.Values{
+		"appid":         {w.appID},
+		"redirect_uri":  {w.callbackURL},
+		"response_type": {"code"},
+		"scope":         {w.scope},
+		"state":         {state},
+	}
+
+	return &authIntent{
+		authURL: w.authURL + "?" + v.Encode() + redirectFragment,
+	}, nil
+}
+
+// tokenResponse is the token response of WeChat, the openid is returned with the token.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	OpenID       string `json:"openid"`
+	Scope        string `json:"scope"`
+	UnionID      string `json:"unionid"`
+}
+
+// CompleteAuth completes the authentication process.
+func (w *wechatProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		OpenID     string `json:"openid"`
+		Nickname   string `json:"nickname"`
+		HeadImgURL string `json:"headimgurl"`
+		UnionID    string `json:"unionid"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	token, err := w.token(ctx, TokenURL, url.Values{
+		"appid":      {w.appID},
+		"secret":     {w.secret},
+		"code":       {code},
+		"grant_type": {"authorization_code"},
+	})
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = w.get(ctx, UserInfoURL, url.Values{
+		"access_token": {token.AccessToken},
+		"openid":       {token.OpenID},
+		"lang":         {w.lang},
+	}, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	// the unionid is the same for all apps of the Open Platform account, the openid is per app
+	id := token.OpenID
+	switch {
+	case utilx.NotEmpty(u.UnionID):
+		id = u.UnionID
+	case utilx.NotEmpty(token.UnionID):
+		id = token.UnionID
+	}
+
+	email, err := w.resolver(ctx, id)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name:  u.Nickname,
+		Email: email,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          w.ID(),
+				ProviderAccountID: cast.Ptr(id),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)),
+				TokenType:         cast.Ptr("Bearer"),
+				Scope:             cast.Ptr(providers.MergeScopes(strings.Split(token.Scope, ","))),
+			},
+		},
+	}
+
+	if utilx.Empty(user.Name) {
+		user.Name = id
+	}
+
+	if utilx.NotEmpty(u.HeadImgURL) {
+		user.Image = cast.Ptr(u.HeadImgURL)
+	}
+
+	return user, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token. The refresh tokens of WeChat
+// are valid for 30 days and are not rotated.
+func (w *wechatProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	res, err := w.token(ctx, RefreshURL, url.Values{
+		"appid":         {w.appID},
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token := &oauth2.Token{
+		AccessToken:  res.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: res.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(res.ExpiresIn) * time.Second),
+	}
+
+	return token.WithExtra(map[string]any{"openid": res.OpenID, "scope": res.Scope}), nil
+}
+
+// token requests a token with the parameters in the query, as WeChat expects them.
+func (w *wechatProvider) token(ctx context.Context, endpoint string, params url.Values) (*tokenResponse, error) {
+	res := &tokenResponse{}
+
+	err := w.get(ctx, endpoint, params, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if utilx.Empty(res.OpenID) {
+		return nil, ErrMissingOpenID
+	}
+
+	return res, nil
+}
+
+// apiError is the error WeChat returns with status 200.
+type apiError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (w *wechatProvider) get(ctx context.Context, endpoint string, params url.Values, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	e := apiError{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return err
+	}
+
+	if e.ErrCode != 0 {
+		return fmt.Errorf("goth: request to %s failed with %d: %s", req.URL.Host+req.URL.Path, e.ErrCode, e.ErrMsg)
+	}
+
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,261 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+// ErrMissingEmail is returned when the user has no email, e.g. if the login:email scope has not been granted.
+var ErrMissingEmail = errors.New("goth: missing email")
+
+const (
+	// AuthURL is the authorization endpoint of Yandex ID.
+	AuthURL = "https://oauth.yandex.ru/authorize"
+	// TokenURL is the token endpoint of Yandex ID.
+	TokenURL = "https://oauth.yandex.ru/token"
+	// InfoURL is the endpoint of the profile of the user.
+	InfoURL = "https://login.yandex.ru/info?format=json"
+	// AvatarURL is the URL of the avatars.
+	AvatarURL = "https://avatars.yandex.net/get-yapic"
+)
+
+var _ providers.Provider = (*yandexProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Yandex.
+var DefaultScopes = []string{"login:info", "login:email", "login:avatar"}
+
+type yandexProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Yandex provider.
+type Opt func(*yandexProvider)
+
+// WithScopes sets the additional scopes for the Yandex provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *yandexProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// New creates a new Yandex provider.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *yandexProvider {
+	p := &yandexProvider{
+		id:           "yandex",
+		name:         "Yandex",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "YANDEX_CLIENT_ID"
+	EnvClientSecret = "YANDEX_CLIENT_SECRET"
+	EnvCallbackURL  = "YANDEX_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Yandex provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*yandexProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new Yandex provider from the YANDEX_CLIENT_ID, YANDEX_CLIENT_SECRET
+// and YANDEX_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by YANDEX_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*yandexProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (y *yandexProvider) ID() string {
+	return y.id
+}
+
+// Name returns the provider's name.
+func (y *yandexProvider) Name() string {
+	return y.name
+}
+
+// Type returns the provider's type.
+func (y *yandexProvider) Type() providers.ProviderType {
+	return y.providerType
+}
+
+// Check validates the client credentials and the reachability of the Yandex endpoints.
+func (y *yandexProvider) Check(ctx context.Context) error {
+	if y.clientKey == "" || y.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, y.client, AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (y *yandexProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: y.config.AuthCodeURL(state),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process.
+func (y *yandexProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		ID              string `json:"id"`
+		Login           string `json:"login"`
+		DisplayName     string `json:"display_name"`
+		RealName        string `json:"real_name"`
+		DefaultEmail    string `json:"default_email"`
+		DefaultAvatarID string `json:"default_avatar_id"`
+		IsAvatarEmpty   bool   `json:"is_avatar_empty"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, y.client)
+
+	token, err := y.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = y.get(ctx, token, InfoURL, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	if utilx.Empty(u.DefaultEmail) {
+		return adapters.GothUser{}, ErrMissingEmail
+	}
+
+	user := adapters.GothUser{
+		Name:          u.RealName,
+		Email:         u.DefaultEmail,
+		EmailVerified: cast.Ptr(true),
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          y.ID(),
+				ProviderAccountID: cast.Ptr(u.ID),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+			},
+		},
+	}
+
+	if utilx.Empty(user.Name) {
+		user.Name = utilx.IfElse(utilx.NotEmpty(u.DisplayName), u.DisplayName, u.Login)
+	}
+
+	if utilx.NotEmpty(u.DefaultAvatarID) && !u.IsAvatarEmpty {
+		user.Image = cast.Ptr(fmt.Sprintf("%s/%s/islands-200", AvatarURL, u.DefaultAvatarID))
+	}
+
+	return user, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token.
+func (y *yandexProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, y.client)
+
+	return y.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+// get requests the endpoint with the `OAuth` authorization scheme of Yandex.
+func (y *yandexProvider) get(ctx context.Context, token *oauth2.Token, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "OAuth "+token.AccessToken)
+
+	resp, err := y.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
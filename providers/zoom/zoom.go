@@ -0,0 +1,258 @@
+package zoom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+
+	"github.com/zeiss/pkg/cast"
+	"github.com/zeiss/pkg/utilx"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// AuthURL is the authorization endpoint of Zoom.
+	AuthURL = "https://zoom.us/oauth/authorize"
+	// TokenURL is the token endpoint of Zoom.
+	TokenURL = "https://zoom.us/oauth/token"
+	// UserURL is the endpoint of the authenticated user.
+	UserURL = "https://api.zoom.us/v2/users/me"
+)
+
+var _ providers.Provider = (*zoomProvider)(nil)
+
+// DefaultScopes holds the default scopes used for Zoom. The scopes
+// are configured with the app in the Zoom App Marketplace.
+var DefaultScopes = []string{}
+
+type zoomProvider struct {
+	id           string
+	name         string
+	clientKey    string
+	secret       string
+	callbackURL  string
+	providerType providers.ProviderType
+	client       *http.Client
+	config       *oauth2.Config
+	scopes       []string
+	refreshes    providers.RotatingRefresh
+
+	providers.UnimplementedProvider
+}
+
+type authIntent struct {
+	authURL string
+}
+
+// GetAuthURL returns the URL for the authentication end-point.
+func (a *authIntent) GetAuthURL() (string, error) {
+	if a.authURL == "" {
+		return "", providers.ErrNoAuthURL
+	}
+
+	return a.authURL, nil
+}
+
+// Opt is a function that configures the Zoom provider.
+type Opt func(*zoomProvider)
+
+// WithScopes sets the additional scopes for the Zoom provider.
+func WithScopes(scopes ...string) Opt {
+	return func(p *zoomProvider) {
+		p.scopes = append(p.scopes, scopes...)
+	}
+}
+
+// New creates a new Zoom provider.
+func New(clientKey, secret, callbackURL string, opts ...Opt) *zoomProvider {
+	p := &zoomProvider{
+		id:           "zoom",
+		name:         "Zoom",
+		clientKey:    clientKey,
+		secret:       secret,
+		callbackURL:  callbackURL,
+		providerType: providers.ProviderTypeOAuth2,
+		client:       providers.DefaultClient,
+		scopes:       DefaultScopes,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.config = &oauth2.Config{
+		ClientID:     p.clientKey,
+		ClientSecret: p.secret,
+		RedirectURL:  p.callbackURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   AuthURL,
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+		Scopes: p.scopes,
+	}
+
+	return p
+}
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvClientID     = "ZOOM_CLIENT_ID"
+	EnvClientSecret = "ZOOM_CLIENT_SECRET"
+	EnvCallbackURL  = "ZOOM_CALLBACK_URL"
+)
+
+// NewFromSource creates a new Zoom provider loading the client secret from source.
+func NewFromSource(clientKey string, source providers.CredentialSource, callbackURL string, opts ...Opt) (*zoomProvider, error) {
+	secret, err := source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(clientKey, secret, callbackURL, opts...), nil
+}
+
+// NewFromEnv creates a new Zoom provider from the ZOOM_CLIENT_ID, ZOOM_CLIENT_SECRET
+// and ZOOM_CALLBACK_URL environment variables. The client secret can also be read
+// from the file referenced by ZOOM_CLIENT_SECRET_FILE.
+func NewFromEnv(opts ...Opt) (*zoomProvider, error) {
+	clientKey, err := providers.Env(EnvClientID).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	callbackURL, err := providers.Env(EnvCallbackURL).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFromSource(clientKey, providers.EnvOrFile(EnvClientSecret), callbackURL, opts...)
+}
+
+// ID returns the provider's ID.
+func (z *zoomProvider) ID() string {
+	return z.id
+}
+
+// Name returns the provider's name.
+func (z *zoomProvider) Name() string {
+	return z.name
+}
+
+// Type returns the provider's type.
+func (z *zoomProvider) Type() providers.ProviderType {
+	return z.providerType
+}
+
+// Check validates the client credentials and the reachability of the Zoom endpoints.
+func (z *zoomProvider) Check(ctx context.Context) error {
+	if z.clientKey == "" || z.secret == "" {
+		return providers.ErrMissingClientCredentials
+	}
+
+	return providers.CheckEndpoint(ctx, z.client, AuthURL)
+}
+
+// BeginAuth starts the authentication process.
+func (z *zoomProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &authIntent{
+		authURL: z.config.AuthCodeURL(state),
+	}, nil
+}
+
+// CompleteAuth completes the authentication process.
+func (z *zoomProvider) CompleteAuth(ctx context.Context, _ adapters.Adapter, params providers.AuthParams) (adapters.GothUser, error) {
+	u := struct {
+		ID          string `json:"id"`
+		FirstName   string `json:"first_name"`
+		LastName    string `json:"last_name"`
+		DisplayName string `json:"display_name"`
+		Email       string `json:"email"`
+		PicURL      string `json:"pic_url"`
+		Language    string `json:"language"`
+		Verified    int    `json:"verified"`
+	}{}
+
+	code := params.Get("code")
+	if code == "" {
+		return adapters.GothUser{}, providers.ErrMissingCode
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, z.client)
+
+	token, err := z.config.Exchange(ctx, code)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	err = z.get(ctx, z.config.Client(ctx, token), UserURL, &u)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	user := adapters.GothUser{
+		Name:          u.DisplayName,
+		Email:         u.Email,
+		EmailVerified: cast.Ptr(u.Verified == 1),
+		Locale:        u.Language,
+		Accounts: []adapters.GothAccount{
+			{
+				Type:              adapters.AccountTypeOAuth2,
+				Provider:          z.ID(),
+				ProviderAccountID: cast.Ptr(u.ID),
+				AccessToken:       cast.Ptr(token.AccessToken),
+				RefreshToken:      cast.Ptr(token.RefreshToken),
+				ExpiresAt:         cast.Ptr(token.Expiry),
+				TokenType:         cast.Ptr(token.TokenType),
+				Scope:             cast.Ptr(providers.MergeScopes(providers.ScopeOf(token))),
+			},
+		},
+	}
+
+	if utilx.Empty(user.Name) {
+		user.Name = strings.TrimSpace(u.FirstName + " " + u.LastName)
+	}
+
+	if utilx.NotEmpty(u.PicURL) {
+		user.Image = cast.Ptr(u.PicURL)
+	}
+
+	return user, nil
+}
+
+// RefreshToken exchanges the refresh token for a new token. Zoom rotates the refresh token with
+// every refresh and rejects the previous one, so concurrent refreshes of the same token (e.g. by a
+// request and the refresh worker) share the result of the first refresh instead of failing.
+func (z *zoomProvider) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, z.client)
+
+	return z.refreshes.Token(refreshToken, func() (*oauth2.Token, error) {
+		return z.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	})
+}
+
+func (z *zoomProvider) get(ctx context.Context, client *http.Client, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer io.Copy(io.Discard, resp.Body) // equivalent to `cp body /dev/null`
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("goth: request to %s failed with %s", req.URL.Host+req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,138 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/pkg/cast"
+)
+
+// ErrAccountNotProvisioned is thrown if sign-up is disabled and the user does not exist yet.
+var ErrAccountNotProvisioned = NewError(http.StatusForbidden, "account not provisioned")
+
+// canSignUp returns true if new users can be created by the provider.
+func (cfg Config) canSignUp(provider string) bool {
+	if allow, ok := cfg.ProviderSignUp[provider]; ok {
+		return allow
+	}
+
+	return cfg.AllowSignUp == nil || *cfg.AllowSignUp
+}
+
+// provisionUser persists the profile returned by a provider. The accounts of the profile are linked
+// to the user found by the user matcher, or a new user is created. Profiles that already have an ID
+// (providers persisting the user themselves) are only reloaded. A pending invitation of the token
+// for the verified email of the profile is accepted.
+func (cfg Config) provisionUser(ctx context.Context, provider, attempt, invitation string, profile adapters.GothUser) (adapters.GothUser, error) {
+	id := profile.ID
+
+	if id == uuid.Nil {
+		user, err := cfg.resolveUser(ctx, provider, attempt, cfg.invitation(ctx, invitation, profile), profile)
+		if err != nil {
+			return adapters.GothUser{}, err
+		}
+		id = user.ID
+	}
+
+	user, err := cfg.Adapter.GetUser(ctx, id)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+	user.Groups = profile.Groups
+
+	return user, nil
+}
+
+// resolveUser links the accounts of the profile to the matched user or creates a new user.
+// It returns ErrAccountNotProvisioned if no user matches, sign-up is disabled and the user is not invited.
+//
+// nolint:gocyclo
+func (cfg Config) resolveUser(ctx context.Context, provider, attempt string, invitation *adapters.GothInvitation, profile adapters.GothUser) (adapters.GothUser, error) {
+	existing, err := cfg.UserMatcher.MatchUser(ctx, cfg.Adapter, profile)
+	if err == nil {
+		if err := cfg.linkAccounts(ctx, existing, profile.Accounts); err != nil {
+			return adapters.GothUser{}, err
+		}
+
+		if invitation != nil {
+			return existing, cfg.acceptInvitation(ctx, provider, invitation, existing)
+		}
+
+		return existing, nil
+	}
+
+	if !errors.Is(err, ErrMissingUser) {
+		return adapters.GothUser{}, err
+	}
+
+	if invitation == nil && !cfg.canSignUp(provider) {
+		return adapters.GothUser{}, ErrAccountNotProvisioned
+	}
+
+	if _, err := cfg.Adapter.GetUserByEmail(ctx, profile.Email); profile.Email != "" && err == nil {
+		return adapters.GothUser{}, ErrEmailConflict
+	}
+
+	for _, policy := range cfg.SignUpPolicies {
+		if err := policy(ctx, cfg.Adapter, provider, profile); err != nil {
+			return adapters.GothUser{}, err
+		}
+	}
+
+	user, err := cfg.Adapter.CreateUser(ctx, profile)
+	if err != nil {
+		return adapters.GothUser{}, err
+	}
+
+	cfg.emitLogin(ctx, events.UserCreated, provider, attempt, user.ID, nil)
+
+	if invitation != nil {
+		return user, cfg.acceptInvitation(ctx, provider, invitation, user)
+	}
+
+	return user, nil
+}
+
+// linkAccounts creates or updates the accounts for the user. It returns an AccountConflictError
+// if an account is already linked to another user.
+func (cfg Config) linkAccounts(ctx context.Context, user adapters.GothUser, accounts []adapters.GothAccount) error {
+	for _, account := range accounts {
+		linked := hasAccount(user, account)
+
+		if !linked {
+			if err := cfg.checkAccountOwner(ctx, user, account); err != nil {
+				return err
+			}
+		}
+
+		account.UserID = &user.ID
+		account.User = adapters.GothUser{}
+
+		if _, err := cfg.Adapter.UpsertAccount(ctx, account); err != nil {
+			return err
+		}
+
+		if !linked {
+			e := events.New(events.AccountLinked, user.ID)
+			e.Provider = account.Provider
+			cfg.Events.Emit(ctx, e)
+		}
+	}
+
+	return nil
+}
+
+// hasAccount returns true if the account of the provider is already linked to the user.
+func hasAccount(user adapters.GothUser, account adapters.GothAccount) bool {
+	for _, a := range user.Accounts {
+		if a.Provider == account.Provider && cast.Value(a.ProviderAccountID) == cast.Value(account.ProviderAccountID) {
+			return true
+		}
+	}
+
+	return false
+}
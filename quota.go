@@ -0,0 +1,90 @@
+package goth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// ErrSeatsExhausted is thrown if a user cannot be created because all seats of the tenant are taken.
+var ErrSeatsExhausted = NewError(http.StatusForbidden, "all seats are taken, ask your administrator for another seat")
+
+// SeatsExhaustedError is the error of a sign-up that exceeds the seat limit of a tenant. It unwraps to ErrSeatsExhausted.
+type SeatsExhaustedError struct {
+	// Tenant is the tenant whose seats are taken (e.g. the email domain).
+	Tenant string
+	// Limit is the number of seats of the tenant.
+	Limit int64
+	// Used is the number of users of the tenant.
+	Used int64
+}
+
+// Error returns the message of the error.
+func (e *SeatsExhaustedError) Error() string {
+	return fmt.Sprintf("all %d seats of %s are taken", e.Limit, e.Tenant)
+}
+
+// Unwrap returns ErrSeatsExhausted.
+func (e *SeatsExhaustedError) Unwrap() error {
+	return ErrSeatsExhausted
+}
+
+// SignUpPolicy decides if a new user can be created for the profile a provider authenticated.
+// It is evaluated before the user is created, also for invited users.
+type SignUpPolicy func(ctx context.Context, adapter adapters.Adapter, provider string, profile adapters.GothUser) error
+
+// Seats is the seat limit of a tenant.
+type Seats struct {
+	// Tenant is the name of the tenant reported in the SeatsExhaustedError.
+	Tenant string
+	// Filter selects the users occupying the seats of the tenant.
+	Filter adapters.UserFilter
+	// Limit is the number of seats of the tenant.
+	Limit int64
+}
+
+// WithSeatLimit denies the sign-up of new users if the tenant of the profile has no seats left.
+// The tenant returns the seats of the profile or false if the profile has no seat limit.
+// The users of the tenant are counted with CountUsers of the adapter.
+func WithSeatLimit(tenant func(ctx context.Context, profile adapters.GothUser) (Seats, bool)) SignUpPolicy {
+	return func(ctx context.Context, adapter adapters.Adapter, _ string, profile adapters.GothUser) error {
+		seats, ok := tenant(ctx, profile)
+		if !ok {
+			return nil
+		}
+
+		used, err := adapter.CountUsers(ctx, seats.Filter)
+		if err != nil {
+			return err
+		}
+
+		if used >= seats.Limit {
+			return &SeatsExhaustedError{Tenant: seats.Tenant, Limit: seats.Limit, Used: used}
+		}
+
+		return nil
+	}
+}
+
+// WithDomainSeatLimits limits the number of users per email domain (e.g. {"example.com": 25}).
+// Users of other domains are not limited. The users are counted with the email filter
+// of the adapter, which also matches longer domains with the same prefix (e.g. "example.com.au").
+func WithDomainSeatLimits(limits map[string]int64) SignUpPolicy {
+	return WithSeatLimit(func(_ context.Context, profile adapters.GothUser) (Seats, bool) {
+		domain, ok := EmailDomain(profile.Email)
+		if !ok {
+			return Seats{}, false
+		}
+
+		for d, limit := range limits {
+			if strings.EqualFold(d, domain) {
+				return Seats{Tenant: domain, Filter: adapters.UserFilter{Email: "@" + domain}, Limit: limit}, true
+			}
+		}
+
+		return Seats{}, false
+	})
+}
@@ -0,0 +1,78 @@
+package goth
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultRedirectURL is the fallback target for unsafe redirects.
+const DefaultRedirectURL = "/"
+
+// IsSafeRedirect returns true if the target is a local path or an absolute
+// http(s) URL whose origin is in the allowlist (e.g. "https://example.com").
+func IsSafeRedirect(target string, allowlist ...string) bool {
+	if target == "" || strings.ContainsAny(target, "\\\r\n\t") {
+		return false
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(target, "//")
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	origin := u.Scheme + "://" + strings.ToLower(u.Host)
+	for _, allowed := range allowlist {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SafeRedirect redirects to the target if it is safe (see IsSafeRedirect),
+// otherwise it redirects to DefaultRedirectURL.
+func SafeRedirect(c *fiber.Ctx, target string, allowlist ...string) error {
+	if !IsSafeRedirect(target, allowlist...) {
+		target = DefaultRedirectURL
+	}
+
+	return c.Redirect(target, fiber.StatusTemporaryRedirect)
+}
+
+// ResponseFilter is a CompletionFilter that writes the response after a login, logout or profile update.
+type ResponseFilter = func(c *fiber.Ctx) error
+
+// RedirectTo returns a ResponseFilter that redirects to the target if it is safe (see IsSafeRedirect).
+// It responds with 303 See Other, so browsers follow with a GET also after a POST callback (e.g. form_post).
+func RedirectTo(target string, allowlist ...string) ResponseFilter {
+	return func(c *fiber.Ctx) error {
+		if !IsSafeRedirect(target, allowlist...) {
+			target = DefaultRedirectURL
+		}
+
+		return c.Redirect(target, fiber.StatusSeeOther)
+	}
+}
+
+// JSONResponse returns a ResponseFilter that responds with the payload as JSON, e.g. for single-page apps.
+func JSONResponse(payload any) ResponseFilter {
+	return func(c *fiber.Ctx) error {
+		return c.JSON(payload)
+	}
+}
+
+// NoContent is a ResponseFilter that responds with 204 No Content, e.g. for API clients.
+func NoContent(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusNoContent)
+}
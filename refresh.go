@@ -0,0 +1,172 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/pkg/cast"
+	"golang.org/x/oauth2"
+)
+
+// RefreshConfig is the configuration of the token refresh worker.
+type RefreshConfig struct {
+	// Adapter is the adapter storing the accounts.
+	Adapter adapters.Adapter
+
+	// Window is the duration before the expiry of a token in which it is refreshed.
+	//
+	// Optional. Default: 10m
+	Window time.Duration
+
+	// Interval is the interval between scans.
+	//
+	// Optional. Default: 5m
+	Interval time.Duration
+
+	// BatchSize is the maximum number of accounts refreshed per scan.
+	//
+	// Optional. Default: 100
+	BatchSize int
+
+	// Events is the emitter of the token revoked events.
+	//
+	// Optional. Default: events.Noop
+	Events events.Emitter
+}
+
+// RefreshConfigDefault is the default refresh config.
+var RefreshConfigDefault = RefreshConfig{
+	Window:    10 * time.Minute,
+	Interval:  5 * time.Minute,
+	BatchSize: 100,
+	Events:    events.Noop,
+}
+
+// RefreshWorker periodically refreshes the stored provider tokens that are
+// nearing expiry. Accounts whose refresh token has been rejected permanently
+// (e.g. revoked consent) are cleared and an events.AccountTokenRevoked event
+// is emitted, so apps can prompt for re-authorization.
+type RefreshWorker struct {
+	cfg    RefreshConfig
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRefreshWorker creates a new token refresh worker.
+func NewRefreshWorker(config RefreshConfig) *RefreshWorker {
+	cfg := config
+
+	if cfg.Window <= 0 {
+		cfg.Window = RefreshConfigDefault.Window
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = RefreshConfigDefault.Interval
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = RefreshConfigDefault.BatchSize
+	}
+
+	if cfg.Events == nil {
+		cfg.Events = RefreshConfigDefault.Events
+	}
+
+	return &RefreshWorker{cfg: cfg}
+}
+
+// Start starts refreshing in the background until the context is done, Stop or Shutdown is called.
+func (w *RefreshWorker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	onShutdownStop(w.Stop)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			w.Refresh(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh runs a single scan and refreshes the tokens nearing expiry.
+func (w *RefreshWorker) Refresh(ctx context.Context) {
+	accounts, err := w.cfg.Adapter.ListExpiringAccounts(ctx, time.Now().Add(w.cfg.Window), w.cfg.BatchSize)
+	if err != nil {
+		log.Errorw("failed to list expiring accounts", "error", err)
+		return
+	}
+
+	for _, account := range accounts {
+		if ctx.Err() != nil {
+			return
+		}
+
+		_, err := RefreshAccount(ctx, w.cfg.Adapter, account)
+		if err == nil || errors.Is(err, ErrTokenExpired) {
+			continue
+		}
+
+		if !IsTokenRevoked(err) {
+			log.Errorw("failed to refresh account", "account", account.ID, "provider", account.Provider, "error", err)
+			continue
+		}
+
+		w.revoke(ctx, account, err)
+	}
+}
+
+// revoke clears the tokens of an account that can no longer be refreshed.
+func (w *RefreshWorker) revoke(ctx context.Context, account adapters.GothAccount, err error) {
+	account.AccessToken = cast.Ptr("")
+	account.RefreshToken = cast.Ptr("")
+	account.LastRefreshError = err.Error()
+
+	if _, err := w.cfg.Adapter.UpdateAccount(ctx, account); err != nil {
+		log.Errorw("failed to clear revoked account", "account", account.ID, "error", err)
+	}
+
+	e := events.New(events.AccountTokenRevoked, cast.Value(account.UserID))
+	e.Provider = account.Provider
+	w.cfg.Events.Emit(ctx, e)
+}
+
+// Stop stops the worker and waits for a running scan to finish.
+func (w *RefreshWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.wg.Wait()
+}
+
+// IsTokenRevoked returns true if the error is a permanent rejection of the refresh token
+// by the provider, e.g. because the user revoked the consent.
+func IsTokenRevoked(err error) bool {
+	var re *oauth2.RetrieveError
+	if !errors.As(err, &re) {
+		return false
+	}
+
+	if re.ErrorCode != "" {
+		return re.ErrorCode == "invalid_grant" || re.ErrorCode == "unauthorized_client"
+	}
+
+	return re.Response != nil && (re.Response.StatusCode == http.StatusBadRequest || re.Response.StatusCode == http.StatusUnauthorized)
+}
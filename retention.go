@@ -0,0 +1,95 @@
+package goth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// RetentionConfig is the configuration of the retention worker.
+type RetentionConfig struct {
+	// Adapter is the adapter to purge.
+	Adapter adapters.Adapter
+
+	// Retention is the duration soft-deleted records are kept.
+	//
+	// Optional. Default: 720h
+	Retention time.Duration
+
+	// Interval is the interval between purges.
+	//
+	// Optional. Default: 1h
+	Interval time.Duration
+}
+
+// RetentionConfigDefault is the default retention config.
+var RetentionConfigDefault = RetentionConfig{
+	Retention: 30 * 24 * time.Hour,
+	Interval:  time.Hour,
+}
+
+// RetentionWorker periodically hard-deletes soft-deleted data and expired
+// verification tokens that are older than the retention window.
+type RetentionWorker struct {
+	cfg    RetentionConfig
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetentionWorker creates a new retention worker.
+func NewRetentionWorker(config RetentionConfig) *RetentionWorker {
+	cfg := config
+
+	if cfg.Retention <= 0 {
+		cfg.Retention = RetentionConfigDefault.Retention
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = RetentionConfigDefault.Interval
+	}
+
+	return &RetentionWorker{cfg: cfg}
+}
+
+// Start starts purging in the background until the context is done, Stop or Shutdown is called.
+func (w *RetentionWorker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	onShutdownStop(w.Stop)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			w.Purge(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Purge runs a single purge.
+func (w *RetentionWorker) Purge(ctx context.Context) {
+	if err := w.cfg.Adapter.PurgeDeleted(ctx, w.cfg.Retention); err != nil {
+		log.Errorw("failed to purge deleted data", "error", err)
+	}
+}
+
+// Stop stops the worker and waits for a running purge to finish.
+func (w *RetentionWorker) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.wg.Wait()
+}
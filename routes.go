@@ -0,0 +1,66 @@
+package goth
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RegisterRoutes registers the begin, complete, scope upgrade and logout routes
+// of the middleware on the router, derived from the config:
+//
+//	GET       <LoginURL>/:provider
+//	GET, POST <CallbackURLPattern>
+//	GET       <CallbackURL>/:provider/upgrade
+//	GET       <LogoutURL>
+func RegisterRoutes(router fiber.Router, config ...Config) {
+	cfg := configDefault(config...)
+
+	router.Get(strings.TrimSuffix(cfg.LoginURL, "/")+"/:"+provider, cfg.BeginAuthHandler.New(cfg))
+
+	complete := cfg.CompleteAuthHandler.New(cfg)
+	router.Get(cfg.CallbackURLPattern, complete)
+	router.Post(cfg.CallbackURLPattern, complete)
+
+	router.Get(strings.TrimSuffix(cfg.CallbackURL, "/")+"/:"+provider+"/upgrade", cfg.ScopeUpgradeHandler.New(cfg))
+
+	router.Get(cfg.LogoutURL, cfg.LogoutHandler.New(cfg))
+}
+
+// CallbackPath returns the callback path of the provider derived from the CallbackURLPattern.
+func (cfg Config) CallbackPath(providerID string) string {
+	return strings.ReplaceAll(cfg.CallbackURLPattern, ":"+provider, providerID)
+}
+
+// matchPattern returns true if the path matches the route pattern. Segments
+// starting with ":" match any single non-empty segment. It does not allocate.
+func matchPattern(pattern, path string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	pattern, path = strings.Trim(pattern, "/"), strings.Trim(path, "/")
+
+	for {
+		p, patternRest, patternMore := strings.Cut(pattern, "/")
+		s, pathRest, pathMore := strings.Cut(path, "/")
+
+		if strings.HasPrefix(p, ":") {
+			if s == "" {
+				return false
+			}
+		} else if p != s {
+			return false
+		}
+
+		if patternMore != pathMore {
+			return false
+		}
+
+		if !patternMore {
+			return true
+		}
+
+		pattern, path = patternRest, pathRest
+	}
+}
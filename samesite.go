@@ -0,0 +1,180 @@
+package goth
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	ios12Agent         = regexp.MustCompile(`\(iP.+; CPU .*OS 12[_\d]*.*\) AppleWebKit/`)
+	macos1014Agent     = regexp.MustCompile(`\(Macintosh;.*Mac OS X 10_14[_\d]*.*\) AppleWebKit/`)
+	macosSafariAgent   = regexp.MustCompile(`Version/.* Safari/`)
+	macosEmbeddedAgent = regexp.MustCompile(`^Mozilla/[.\d]+ \(Macintosh;.*Mac OS X [_\d]+\) AppleWebKit/[.\d]+ \(KHTML, like Gecko\)$`)
+	chromiumAgent      = regexp.MustCompile(`Chrom(e|ium)`)
+	chromium5166Agent  = regexp.MustCompile(`Chrom(e|ium)/(5[1-9]|6[0-6])\.`)
+	ucBrowserAgent     = regexp.MustCompile(`UCBrowser/(\d+)\.(\d+)\.(\d+)`)
+)
+
+// IsSameSiteNoneIncompatible reports whether the user agent is known to mishandle
+// cookies with `SameSite=None`. These clients either reject the cookie or treat it
+// as `SameSite=Strict` (e.g. iOS 12, Safari on macOS 10.14, Chrome 51 to 66 and UC Browser before 12.13.2).
+//
+// See https://www.chromium.org/updates/same-site/incompatible-clients
+func IsSameSiteNoneIncompatible(userAgent string) bool {
+	if ios12Agent.MatchString(userAgent) {
+		return true
+	}
+
+	if macos1014Agent.MatchString(userAgent) {
+		safari := macosSafariAgent.MatchString(userAgent) && !chromiumAgent.MatchString(userAgent)
+		if safari || macosEmbeddedAgent.MatchString(userAgent) {
+			return true
+		}
+	}
+
+	if chromium5166Agent.MatchString(userAgent) {
+		return true
+	}
+
+	m := ucBrowserAgent.FindStringSubmatch(userAgent)
+	if m == nil {
+		return false
+	}
+
+	version := make([]int, 3)
+	for i := range version {
+		version[i], _ = strconv.Atoi(m[i+1])
+	}
+
+	if version[0] != 12 {
+		return version[0] < 12
+	}
+
+	if version[1] != 13 {
+		return version[1] < 13
+	}
+
+	return version[2] < 2
+}
+
+// TokenFromHeader returns a function that extracts the token from a request header.
+// A `Bearer` prefix is removed.
+func TokenFromHeader(header string) func(c *fiber.Ctx) (string, error) {
+	return func(c *fiber.Ctx) (string, error) {
+		token := strings.TrimSpace(c.Get(header))
+
+		if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+			token = strings.TrimSpace(token[7:])
+		}
+
+		if token == "" {
+			return "", ErrMissingCookie
+		}
+
+		return token, nil
+	}
+}
+
+// TokenFromCookieOrHeader returns a function that extracts the token from the cookie
+// and falls back to the request header. This is used by apps that are embedded in
+// iframes where third-party cookies may be dropped.
+func TokenFromCookieOrHeader(cookie, header string) func(c *fiber.Ctx) (string, error) {
+	fromCookie := TokenFromCookie(cookie)
+	fromHeader := TokenFromHeader(header)
+
+	return func(c *fiber.Ctx) (string, error) {
+		token, err := fromCookie(c)
+		if err == nil {
+			return token, nil
+		}
+
+		return fromHeader(c)
+	}
+}
+
+// setSessionCookie writes the session cookie to the response. If `SameSite=None` is
+// configured the cookie is marked `Secure`, while the attribute is omitted for user agents
+// that are incompatible with it. If a TokenHeader is configured the token is also
+// returned in the response header.
+func (cfg Config) setSessionCookie(c *fiber.Ctx, token string, expires time.Time) {
+	cfg.writeSessionCookie(c, token, expires)
+
+	if cfg.TokenHeader != "" {
+		c.Set(cfg.TokenHeader, token)
+	}
+}
+
+// clearSessionCookie removes the session cookie with the attributes it has been set with,
+// so that browsers also remove `__Host-` and `__Secure-` cookies.
+func (cfg Config) clearSessionCookie(c *fiber.Ctx) {
+	cfg.writeSessionCookie(c, "", fasthttp.CookieExpireDelete)
+}
+
+// writeSessionCookie writes the session cookie with the attributes of the config to the response.
+func (cfg Config) writeSessionCookie(c *fiber.Ctx, token string, expires time.Time) {
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	path := cfg.CookiePath
+	if path == "" {
+		path = "/"
+	}
+
+	cookie.SetKey(cfg.CookieName)
+	cookie.SetValue(token)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(cfg.CookieSecure)
+	cookie.SetExpire(expires)
+	cookie.SetPath(path)
+	cookie.SetDomain(cfg.CookieDomain)
+
+	sameSite := cfg.CookieSameSite
+	if sameSite == fasthttp.CookieSameSiteNoneMode {
+		cookie.SetSecure(true)
+
+		if IsSameSiteNoneIncompatible(c.Get(fiber.HeaderUserAgent)) {
+			sameSite = fasthttp.CookieSameSiteDisabled
+		}
+	}
+	cookie.SetSameSite(sameSite)
+
+	varySession(c)
+	c.Response().Header.SetCookie(cookie)
+}
+
+// writeFlowCookie writes a cookie that carries state of the login flows (e.g. the invitation token)
+// with the domain and the security of the session cookie, but `SameSite=Lax` to survive the redirects.
+func (cfg Config) writeFlowCookie(c *fiber.Ctx, name, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.CookieDomain,
+		Expires:  expires,
+		Secure:   cfg.CookieSecure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// clearFlowCookie removes a cookie of writeFlowCookie with the attributes it has been set with,
+// so that browsers also remove `__Host-` and `__Secure-` cookies.
+func (cfg Config) clearFlowCookie(c *fiber.Ctx, name string) {
+	cfg.writeFlowCookie(c, name, "", fasthttp.CookieExpireDelete)
+}
+
+// varySession sets the Vary header for responses depending on the session cookie.
+// Unless other handlers have set the header already, it does not allocate.
+func varySession(c *fiber.Ctx) {
+	if len(c.Response().Header.Peek(fiber.HeaderVary)) == 0 {
+		c.Response().Header.Set(fiber.HeaderVary, fiber.HeaderCookie+", "+fiber.HeaderUserAgent)
+		return
+	}
+
+	c.Vary(fiber.HeaderCookie, fiber.HeaderUserAgent)
+}
@@ -0,0 +1,37 @@
+package goth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClearFlowCookie(t *testing.T) {
+	cfg := Config{CookieName: "__Host-goth", CookieSecure: true}
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		cfg.clearFlowCookie(c, cfg.invitationCookie())
+		return cfg.setAccountHints(c, nil)
+	})
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cookies := res.Header.Values(fiber.HeaderSetCookie)
+	if len(cookies) != 2 {
+		t.Fatalf("expected 2 cookies, got %v", cookies)
+	}
+
+	// browsers only remove prefixed cookies if they are cleared as secure cookies of the root path
+	for _, cookie := range cookies {
+		if !strings.HasPrefix(cookie, "__Host-goth.") || !strings.Contains(cookie, "path=/;") || !strings.Contains(cookie, "secure") {
+			t.Errorf("unexpected cookie %q", cookie)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/mailer"
+)
+
+var (
+	_ events.Emitter = (*SecurityAlerts)(nil)
+	_ GothHandler    = (*NotificationPreferencesHandler)(nil)
+	_ GothHandler    = (*UpdateNotificationPreferenceHandler)(nil)
+)
+
+// ErrUnknownNotification is thrown if a notification preference is set for an event users are not notified about.
+var ErrUnknownNotification = NewError(http.StatusBadRequest, "unknown security notification")
+
+// DefaultSecurityNotifications are the security events users are notified about by default.
+// New sign-ins are collected for the digest, all other events are alerted immediately.
+var DefaultSecurityNotifications = map[events.Type]adapters.NotificationDelivery{
+	events.LoginSessionIssued:  adapters.NotificationDigest,
+	events.UserPasswordChanged: adapters.NotificationImmediate,
+	events.CredentialDeleted:   adapters.NotificationImmediate,
+	events.AccountLinked:       adapters.NotificationImmediate,
+}
+
+// SecurityAlertsConfig is the configuration of the security alerts.
+type SecurityAlertsConfig struct {
+	// Interval is the interval between digests.
+	//
+	// Optional. Default: 24h
+	Interval time.Duration
+
+	// BatchSize is the maximum number of pending notifications loaded at once.
+	//
+	// Optional. Default: 500
+	BatchSize int
+}
+
+// SecurityAlertsConfigDefault is the default security alerts config.
+var SecurityAlertsConfigDefault = SecurityAlertsConfig{
+	Interval:  24 * time.Hour,
+	BatchSize: 500,
+}
+
+// SecurityAlerts is an events.Emitter that notifies users about the SecurityNotifications via the Mailer.
+// Depending on the notification preferences of the user an alert is sent immediately, or the event
+// is stored via the adapter and sent with the next digest.
+type SecurityAlerts struct {
+	cfg     Config
+	alerts  SecurityAlertsConfig
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	pending sync.WaitGroup
+}
+
+// NewSecurityAlerts creates new security alerts that use the adapter, mailer, templates and security notifications of the config.
+// The alerts must be added to the Events of the config and started to send digests.
+func NewSecurityAlerts(config Config, alerts ...SecurityAlertsConfig) *SecurityAlerts {
+	cfg := SecurityAlertsConfigDefault
+
+	if len(alerts) > 0 {
+		cfg = alerts[0]
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = SecurityAlertsConfigDefault.Interval
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = SecurityAlertsConfigDefault.BatchSize
+	}
+
+	return &SecurityAlerts{cfg: configDefault(config), alerts: cfg}
+}
+
+// Emit notifies the user of the event in the background, if users are notified about the type of the event.
+func (a *SecurityAlerts) Emit(ctx context.Context, e events.Event) {
+	if _, ok := a.cfg.SecurityNotifications[e.Type]; !ok || e.UserID == uuid.Nil || a.cfg.Mailer == nil {
+		return
+	}
+
+	a.pending.Add(1)
+	go func() {
+		defer a.pending.Done()
+
+		if err := a.notify(context.WithoutCancel(ctx), e); err != nil {
+			log.Errorw("failed to notify user about security event", "error", err, "type", e.Type)
+		}
+	}()
+}
+
+// notify sends an alert or stores the event for the digest, depending on the preference of the user.
+func (a *SecurityAlerts) notify(ctx context.Context, e events.Event) error {
+	ip, _ := e.Data["ip_address"].(string)
+
+	switch a.cfg.notificationDelivery(ctx, e.UserID, e.Type) {
+	case adapters.NotificationImmediate:
+		user, err := a.cfg.Adapter.GetUser(ctx, e.UserID)
+		if err != nil {
+			return err
+		}
+
+		return a.send(ctx, mailer.SecurityAlert, user, []mailer.Event{{Type: string(e.Type), Provider: e.Provider, IPAddress: ip, Time: e.Time}})
+	case adapters.NotificationDigest:
+		_, err := a.cfg.Adapter.CreateNotification(ctx, adapters.GothNotification{
+			UserID:     e.UserID,
+			EventType:  string(e.Type),
+			Provider:   e.Provider,
+			IPAddress:  ip,
+			OccurredAt: e.Time,
+		})
+
+		return err
+	}
+
+	return nil
+}
+
+// send sends the template with the events to the user.
+func (a *SecurityAlerts) send(ctx context.Context, name mailer.Template, user adapters.GothUser, evts []mailer.Event) error {
+	return mailer.Send(ctx, a.cfg.Mailer, a.cfg.MailTemplates, name, user.Email, mailer.Data{
+		AppName: a.cfg.AppName,
+		Name:    user.Name,
+		Email:   user.Email,
+		Locale:  user.Locale,
+		Time:    time.Now(),
+		Events:  evts,
+	})
+}
+
+// Start starts sending digests in the background until the context is done, Stop or Shutdown is called.
+func (a *SecurityAlerts) Start(ctx context.Context) {
+	ctx, a.cancel = context.WithCancel(ctx)
+	onShutdownStop(a.Stop)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(a.alerts.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.SendDigests(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops sending digests and waits for running digests and alerts to finish.
+func (a *SecurityAlerts) Stop() {
+	if a.cancel != nil {
+		a.cancel()
+	}
+
+	a.wg.Wait()
+	a.pending.Wait()
+}
+
+// SendDigests sends a digest of the pending notifications to every user. Notifications are
+// deleted once the digest of the user has been sent, failed digests are retried with the next run.
+func (a *SecurityAlerts) SendDigests(ctx context.Context) {
+	for ctx.Err() == nil {
+		notifications, err := a.cfg.Adapter.ListPendingNotifications(ctx, a.alerts.BatchSize)
+		if err != nil {
+			log.Errorw("failed to list pending notifications", "error", err)
+			return
+		}
+
+		if a.sendDigests(ctx, notifications) == 0 || len(notifications) < a.alerts.BatchSize {
+			return
+		}
+	}
+}
+
+// sendDigests sends the digests of the notifications grouped by user and returns the number of sent notifications.
+func (a *SecurityAlerts) sendDigests(ctx context.Context, notifications []adapters.GothNotification) int {
+	users := []uuid.UUID{}
+	byUser := map[uuid.UUID][]adapters.GothNotification{}
+
+	for _, n := range notifications {
+		if _, ok := byUser[n.UserID]; !ok {
+			users = append(users, n.UserID)
+		}
+		byUser[n.UserID] = append(byUser[n.UserID], n)
+	}
+
+	sent := 0
+
+	for _, id := range users {
+		ids := []uuid.UUID{}
+		evts := []mailer.Event{}
+
+		for _, n := range byUser[id] {
+			ids = append(ids, n.ID)
+			evts = append(evts, mailer.Event{Type: n.EventType, Provider: n.Provider, IPAddress: n.IPAddress, Time: n.OccurredAt})
+		}
+
+		user, err := a.cfg.Adapter.GetUser(ctx, id)
+		if err == nil {
+			err = a.send(ctx, mailer.SecurityDigest, user, evts)
+		}
+
+		if err != nil {
+			log.Errorw("failed to send security digest", "error", err, "user_id", id)
+			continue
+		}
+
+		if err := a.cfg.Adapter.DeleteNotifications(ctx, ids...); err != nil {
+			log.Errorw("failed to delete sent notifications", "error", err, "user_id", id)
+			continue
+		}
+
+		sent += len(ids)
+	}
+
+	return sent
+}
+
+// notificationDelivery returns the delivery of the event type for the user. The preference
+// of the user overrides the default delivery of the SecurityNotifications.
+func (cfg Config) notificationDelivery(ctx context.Context, userID uuid.UUID, t events.Type) adapters.NotificationDelivery {
+	delivery := cfg.SecurityNotifications[t]
+
+	preferences, err := cfg.Adapter.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return delivery
+	}
+
+	for _, p := range preferences {
+		if p.EventType == string(t) {
+			return p.Delivery
+		}
+	}
+
+	return delivery
+}
+
+// NotificationPreferencesHandler is the default handler that returns the delivery of every
+// security notification for the current user.
+type NotificationPreferencesHandler struct{}
+
+// NewNotificationPreferencesHandler returns a new default notification preferences handler.
+// The handler must be mounted behind the protect middleware.
+func NewNotificationPreferencesHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return NotificationPreferencesHandler{}.New(cfg)
+}
+
+// New creates a new handler to return the notification preferences.
+func (NotificationPreferencesHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		session, err := SessionFromContext(c)
+		if err != nil {
+			return cfg.handleError(c, "NotificationPreferencesHandler", ErrMissingSession)
+		}
+
+		preferences := map[events.Type]adapters.NotificationDelivery{}
+		for t := range cfg.SecurityNotifications {
+			preferences[t] = cfg.notificationDelivery(c.Context(), session.UserID, t)
+		}
+
+		return c.JSON(preferences)
+	}
+}
+
+// UpdateNotificationPreferenceHandler is the default handler to set the delivery (`delivery`)
+// of a security notification (`event`) for the current user.
+type UpdateNotificationPreferenceHandler struct{}
+
+// NewUpdateNotificationPreferenceHandler returns a new default update notification preference handler.
+// The handler must be mounted behind the protect middleware.
+func NewUpdateNotificationPreferenceHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return UpdateNotificationPreferenceHandler{}.New(cfg)
+}
+
+// New creates a new handler to update a notification preference.
+func (UpdateNotificationPreferenceHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		session, err := SessionFromContext(c)
+		if err != nil {
+			return cfg.handleError(c, "UpdateNotificationPreferenceHandler", ErrMissingSession)
+		}
+
+		params := ParamsFromContext(c)
+
+		t := events.Type(params.Get("event"))
+		if _, ok := cfg.SecurityNotifications[t]; !ok {
+			return cfg.handleError(c, "UpdateNotificationPreferenceHandler", ErrUnknownNotification)
+		}
+
+		delivery := adapters.NotificationDelivery(params.Get("delivery"))
+		switch delivery {
+		case adapters.NotificationOff, adapters.NotificationImmediate, adapters.NotificationDigest:
+		default:
+			return cfg.handleError(c, "UpdateNotificationPreferenceHandler", ErrBadRequest)
+		}
+
+		preference, err := cfg.Adapter.SetNotificationPreference(c.Context(), adapters.GothNotificationPreference{
+			UserID:    session.UserID,
+			EventType: string(t),
+			Delivery:  delivery,
+		})
+		if err != nil {
+			return cfg.handleError(c, "UpdateNotificationPreferenceHandler", err)
+		}
+
+		return c.JSON(preference)
+	}
+}
@@ -0,0 +1,82 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// shutdownHooks are the functions called by Shutdown.
+var shutdownHooks = struct {
+	sync.Mutex
+	hooks []func(ctx context.Context) error
+}{}
+
+// OnShutdown registers a function that is called by Shutdown, e.g. to stop a worker of the app.
+// The background workers of goth register themselves when they are started.
+func OnShutdown(fn func(ctx context.Context) error) {
+	shutdownHooks.Lock()
+	defer shutdownHooks.Unlock()
+
+	shutdownHooks.hooks = append(shutdownHooks.hooks, fn)
+}
+
+// onShutdownStop registers the Stop function of a worker.
+func onShutdownStop(stop func()) {
+	OnShutdown(func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			stop()
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Shutdown stops the started workers (session touches, token refresh, retention, security alerts)
+// and the functions registered with OnShutdown in reverse order, flushes the queued session touches
+// of the TouchWriter and closes the adapter and the flow store, if they implement io.Closer.
+// It should be called after app.ShutdownWithContext, so that no requests are served anymore.
+// Workers that do not stop before the context is done are abandoned.
+func Shutdown(ctx context.Context, config ...Config) error {
+	shutdownHooks.Lock()
+	hooks := shutdownHooks.hooks
+	shutdownHooks.hooks = nil
+	shutdownHooks.Unlock()
+
+	errs := []error{}
+
+	for _, fn := range slices.Backward(hooks) {
+		errs = append(errs, fn(ctx))
+	}
+
+	if len(config) == 0 {
+		return errors.Join(errs...)
+	}
+
+	cfg := configDefault(config...)
+
+	if cfg.TouchWriter != nil {
+		cfg.TouchWriter.Stop()
+	}
+
+	if cfg.Adapter != nil {
+		errs = append(errs, adapters.Close(cfg.Adapter))
+	}
+
+	if c, ok := cfg.FlowStore.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+
+	return errors.Join(errs...)
+}
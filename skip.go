@@ -0,0 +1,160 @@
+package goth
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StaticExtensions are the extensions of common static assets.
+var StaticExtensions = []string{
+	".css", ".js", ".mjs", ".map",
+	".png", ".jpg", ".jpeg", ".gif", ".svg", ".ico", ".webp", ".avif",
+	".woff", ".woff2", ".ttf", ".eot",
+	".txt", ".webmanifest",
+}
+
+// GroupPaths returns the prefixes of the route groups, e.g. to skip them with SkipPaths.
+func GroupPaths(groups ...*fiber.Group) []string {
+	paths := make([]string, 0, len(groups))
+	for _, g := range groups {
+		paths = append(paths, g.Prefix)
+	}
+
+	return paths
+}
+
+// skip returns true if the path matches SkipPaths or SkipExtensions.
+// It is evaluated before any adapter call and does not allocate.
+func (cfg Config) skip(p string) bool {
+	for _, prefix := range cfg.SkipPaths {
+		if hasPathPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	if len(cfg.SkipExtensions) == 0 {
+		return false
+	}
+
+	ext := path.Ext(p)
+	if ext == "" {
+		return false
+	}
+
+	for _, e := range cfg.SkipExtensions {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPathPrefix returns true if the path equals the prefix or continues it with a new segment.
+func hasPathPrefix(p, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return false
+	}
+
+	return p == prefix || (strings.HasPrefix(p, prefix) && p[len(prefix)] == '/')
+}
+
+// SkipPaths returns a Next function that skips requests whose path matches one of the prefixes
+// on segment boundaries, e.g. goth.SkipPaths("/healthz", "/metrics").
+func SkipPaths(prefixes ...string) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		p := c.Path()
+
+		for _, prefix := range prefixes {
+			if hasPathPrefix(p, prefix) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// SkipExtensions returns a Next function that skips requests for files with one of the extensions,
+// e.g. goth.SkipExtensions(goth.StaticExtensions...).
+func SkipExtensions(extensions ...string) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		ext := path.Ext(c.Path())
+		if ext == "" {
+			return false
+		}
+
+		for _, e := range extensions {
+			if strings.EqualFold(ext, e) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// SkipMethods returns a Next function that skips requests with one of the methods,
+// e.g. goth.SkipMethods(fiber.MethodOptions).
+func SkipMethods(methods ...string) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		m := c.Method()
+
+		for _, method := range methods {
+			if strings.EqualFold(m, method) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// SkipHosts returns a Next function that skips requests to one of the hosts (without port),
+// e.g. goth.SkipHosts("status.example.com").
+func SkipHosts(hosts ...string) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		h := c.Hostname()
+		if i := strings.LastIndexByte(h, ':'); i >= 0 && !strings.HasSuffix(h, "]") {
+			h = h[:i]
+		}
+
+		for _, host := range hosts {
+			if strings.EqualFold(h, host) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// SkipAny returns a Next function that skips requests if any of the functions returns true.
+func SkipAny(next ...func(c *fiber.Ctx) bool) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		for _, fn := range next {
+			if fn(c) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// SkipAll returns a Next function that skips requests if all of the functions return true,
+// e.g. to skip a path only for a method.
+func SkipAll(next ...func(c *fiber.Ctx) bool) func(c *fiber.Ctx) bool {
+	return func(c *fiber.Ctx) bool {
+		for _, fn := range next {
+			if !fn(c) {
+				return false
+			}
+		}
+
+		return len(next) > 0
+	}
+}
@@ -0,0 +1,100 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+)
+
+// MinSecretLength is the minimum length of the Secret.
+const MinSecretLength = 32
+
+var (
+	// ErrMissingAdapter is returned by Startup when no adapter is configured.
+	ErrMissingAdapter = errors.New("goth: missing adapter")
+	// ErrPendingMigrations is returned by Startup when the adapter has pending migrations.
+	ErrPendingMigrations = errors.New("goth: pending migrations")
+	// ErrWeakSecret is returned by Startup when the secret is shorter than MinSecretLength.
+	ErrWeakSecret = fmt.Errorf("goth: secret is shorter than %d characters", MinSecretLength)
+	// ErrNoProviders is returned by Startup when no provider is registered.
+	ErrNoProviders = errors.New("goth: no providers registered")
+)
+
+// Startup validates the configuration at boot rather than on the first login.
+// It checks the connectivity of the adapter, detects pending migrations, discovers
+// the endpoints of the registered providers and checks the secrets and cookie attributes. All failures
+// are returned as a single joined error.
+func Startup(ctx context.Context, config ...Config) error {
+	cfg := configDefault(config...)
+	errs := []error{checkSecrets(cfg), CheckCookie(cfg)}
+
+	if cfg.Adapter == nil {
+		errs = append(errs, ErrMissingAdapter)
+	} else {
+		errs = append(errs, checkAdapter(ctx, cfg.Adapter))
+	}
+
+	errs = append(errs, checkProviders(ctx, providers.GetProviders()))
+
+	return errors.Join(errs...)
+}
+
+func checkAdapter(ctx context.Context, adapter adapters.Adapter) error {
+	if err := adapters.Ping(ctx, adapter); err != nil {
+		return fmt.Errorf("goth: adapter is not reachable: %w", err)
+	}
+
+	pending, err := adapters.PendingMigrations(ctx, adapter)
+	if err != nil {
+		return fmt.Errorf("goth: failed to detect pending migrations: %w", err)
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPendingMigrations, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+func checkProviders(ctx context.Context, registered providers.Providers) error {
+	if len(registered) == 0 {
+		return ErrNoProviders
+	}
+
+	ids := make([]string, 0, len(registered))
+	for id := range registered {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	errs := []error{}
+	for _, id := range ids {
+		c, ok := registered[id].(providers.Checker)
+		if !ok {
+			continue
+		}
+
+		if err := c.Check(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("goth: provider %s: %w", id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkSecrets(cfg Config) error {
+	if cfg.Secret == "" {
+		return nil
+	}
+
+	if len(cfg.Secret) < MinSecretLength {
+		return ErrWeakSecret
+	}
+
+	return CheckSecret([]byte(cfg.Secret))
+}
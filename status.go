@@ -0,0 +1,49 @@
+package goth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrUnauthenticated is thrown if a protected route is requested without a valid session.
+var ErrUnauthenticated = NewError(http.StatusUnauthorized, "missing or expired session")
+
+// withStatus replaces the status of unauthenticated (401) and forbidden (403) errors
+// with the UnauthenticatedStatus and ForbiddenStatus of the config.
+func (cfg Config) withStatus(err error) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	switch {
+	case e.Code == http.StatusUnauthorized && cfg.UnauthenticatedStatus != e.Code:
+		return NewError(cfg.UnauthenticatedStatus, e.Message)
+	case e.Code == http.StatusForbidden && cfg.ForbiddenStatus != e.Code:
+		return NewError(cfg.ForbiddenStatus, e.Message)
+	default:
+		return err
+	}
+}
+
+// unauthenticated redirects the navigations of browsers to the LoginURL and passes ErrUnauthenticated
+// to the ErrorHandler otherwise, so that API clients can tell a missing or expired session from a denied request.
+func (cfg Config) unauthenticated(c *fiber.Ctx, handler string, err error) error {
+	if isNavigation(c) {
+		return SafeRedirect(c, cfg.LoginURL, cfg.TrustedOrigins...)
+	}
+
+	return cfg.handleErrorContext(c, ErrorContext{Handler: handler, Category: ErrorCategorySession, Err: err}, ErrUnauthenticated)
+}
+
+// isNavigation reports whether the request is the navigation of a browser.
+func isNavigation(c *fiber.Ctx) bool {
+	if mode := c.Get("Sec-Fetch-Mode"); mode != "" {
+		return mode == "navigate"
+	}
+
+	return !c.XHR() && strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML)
+}
@@ -0,0 +1,204 @@
+package goth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/zeiss/pkg/cast"
+)
+
+var (
+	_ GothHandler = (*SwitchAccountHandler)(nil)
+	_ GothHandler = (*ForgetAccountHandler)(nil)
+)
+
+const (
+	// maxAccountHints is the maximum number of accounts remembered in the hint cookie.
+	maxAccountHints = 5
+	// accountHintsExpiry is the lifetime of the hint cookie.
+	accountHintsExpiry = 365 * 24 * time.Hour
+)
+
+// AccountHint is an account that has previously been used to sign in on the device.
+// The hints are shown on an account chooser, they are not a credential.
+type AccountHint struct {
+	// Name is the name of the user.
+	Name string `json:"name"`
+	// Email is the email of the user.
+	Email string `json:"email"`
+	// Image is the image of the user.
+	Image string `json:"image,omitempty"`
+	// Provider is the provider used to sign in.
+	Provider string `json:"provider"`
+	// LastUsedAt is the time the account has been signed out of.
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// accountHintsCookie returns the name of the cookie listing the previously used accounts.
+func (cfg Config) accountHintsCookie() string {
+	name := cfg.CookieName
+	if name == "" {
+		name = ConfigDefault.CookieName
+	}
+
+	return name + ".accounts"
+}
+
+// accountHintsCodec returns the codec of the hint cookie. AccountHints may be used with a config without defaults.
+func (cfg Config) accountHintsCodec() CookieCodec {
+	if cfg.CookieCodec == nil {
+		return ConfigDefault.CookieCodec
+	}
+
+	return cfg.CookieCodec
+}
+
+// AccountHints returns the previously used accounts of the request, most recently used first.
+func (cfg Config) AccountHints(c *fiber.Ctx) []AccountHint {
+	value := c.Cookies(cfg.accountHintsCookie())
+	if value == "" {
+		return nil
+	}
+
+	var hints []AccountHint
+	if err := cfg.accountHintsCodec().Decode(value, &hints); err != nil {
+		return nil
+	}
+
+	return hints
+}
+
+// rememberAccount adds the account to the hint cookie. The account replaces a hint
+// with the same email and provider and the oldest hints are dropped.
+func (cfg Config) rememberAccount(c *fiber.Ctx, hint AccountHint) error {
+	hints := []AccountHint{hint}
+
+	for _, h := range cfg.AccountHints(c) {
+		if len(hints) >= maxAccountHints {
+			break
+		}
+
+		if strings.EqualFold(h.Email, hint.Email) && h.Provider == hint.Provider {
+			continue
+		}
+
+		hints = append(hints, h)
+	}
+
+	return cfg.setAccountHints(c, hints)
+}
+
+// setAccountHints writes the hint cookie, or clears it if there are no hints.
+func (cfg Config) setAccountHints(c *fiber.Ctx, hints []AccountHint) error {
+	if len(hints) == 0 {
+		cfg.clearFlowCookie(c, cfg.accountHintsCookie())
+		return nil
+	}
+
+	value, err := cfg.accountHintsCodec().Encode(hints)
+	if err != nil {
+		return err
+	}
+
+	cfg.writeFlowCookie(c, cfg.accountHintsCookie(), value, time.Now().Add(accountHintsExpiry))
+
+	return nil
+}
+
+// SwitchAccountHandler is the default handler for the soft sign-out. It ends the current session
+// like the LogoutHandler, but remembers the account in a hint cookie (see Config.AccountHints),
+// so that the login page can offer to choose a previously used account.
+type SwitchAccountHandler struct{}
+
+// NewSwitchAccountHandler returns a new default switch account handler.
+func NewSwitchAccountHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return SwitchAccountHandler{}.New(cfg)
+}
+
+// New creates a new handler to switch the account.
+func (SwitchAccountHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		token, err := cfg.Extractor(c)
+		if err != nil {
+			return cfg.handleError(c, "SwitchAccountHandler", err)
+		}
+
+		session, err := cfg.Adapter.GetSession(c.Context(), token)
+		if err != nil {
+			return cfg.handleError(c, "SwitchAccountHandler", err)
+		}
+
+		user, err := cfg.Adapter.GetUser(c.Context(), session.UserID)
+		if err != nil {
+			return cfg.handleError(c, "SwitchAccountHandler", err)
+		}
+
+		err = cfg.rememberAccount(c, AccountHint{
+			Name:       user.Name,
+			Email:      user.Email,
+			Image:      cast.Value(user.Image),
+			Provider:   session.Provider,
+			LastUsedAt: time.Now(),
+		})
+		if err != nil {
+			log.Errorw("failed to remember account", "error", err)
+		}
+
+		err = cfg.Adapter.DeleteSession(c.Context(), token)
+		if err != nil {
+			return cfg.handleError(c, "SwitchAccountHandler", err)
+		}
+
+		cfg.clearSessionCookie(c)
+
+		return SafeRedirect(c, cfg.LoginURL, cfg.TrustedOrigins...)
+	}
+}
+
+// ForgetAccountHandler is the default handler to remove an account from the hint cookie.
+// The account is identified by the `email` and `provider` params. Without an email all accounts are forgotten.
+type ForgetAccountHandler struct{}
+
+// NewForgetAccountHandler returns a new default forget account handler.
+func NewForgetAccountHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return ForgetAccountHandler{}.New(cfg)
+}
+
+// New creates a new handler to forget an account.
+func (ForgetAccountHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		params := ParamsFromContext(c)
+		email, provider := params.Get("email"), params.Get("provider")
+
+		var hints []AccountHint
+		if email != "" {
+			for _, h := range cfg.AccountHints(c) {
+				if strings.EqualFold(h.Email, email) && (provider == "" || h.Provider == provider) {
+					continue
+				}
+
+				hints = append(hints, h)
+			}
+		}
+
+		if err := cfg.setAccountHints(c, hints); err != nil {
+			return cfg.handleError(c, "ForgetAccountHandler", err)
+		}
+
+		return SafeRedirect(c, cfg.LoginURL, cfg.TrustedOrigins...)
+	}
+}
@@ -0,0 +1,116 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+)
+
+var _ GothHandler = (*SwitchTeamHandler)(nil)
+
+// ErrMissingActiveTeam is thrown if the session has no active team.
+var ErrMissingActiveTeam = NewError(http.StatusBadRequest, "no active team selected")
+
+// ActiveTeamFromContext returns the ID of the team that is active in the session of the request.
+// The membership is validated when switching the team, not on every request.
+func ActiveTeamFromContext(c *fiber.Ctx) (uuid.UUID, error) {
+	session, err := SessionFromContext(c)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if session.ActiveTeamID == nil {
+		return uuid.Nil, ErrMissingActiveTeam
+	}
+
+	return *session.ActiveTeamID, nil
+}
+
+// SwitchTeamHandler is the default handler to switch the active team of the current session.
+// The `team` parameter is the ID or slug of a team the user is a member of. An empty `team` clears the active team.
+type SwitchTeamHandler struct{}
+
+// NewSwitchTeamHandler returns a new default switch team handler.
+// The handler must be mounted behind the protect middleware.
+func NewSwitchTeamHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return SwitchTeamHandler{}.New(cfg)
+}
+
+// New creates a new handler to switch the active team.
+//
+// nolint:gocyclo
+func (SwitchTeamHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		session, err := SessionFromContext(c)
+		if err != nil {
+			return cfg.handleError(c, "SwitchTeamHandler", err)
+		}
+
+		var team *adapters.GothTeam
+
+		if ref := strings.TrimSpace(ParamsFromContext(c).Get("team")); ref != "" {
+			t, err := cfg.memberTeam(c.Context(), session.UserID, ref)
+			if err != nil {
+				return cfg.handleError(c, "SwitchTeamHandler", err)
+			}
+			team = &t
+		}
+
+		session.ActiveTeamID = nil
+		if team != nil {
+			session.ActiveTeamID = &team.ID
+		}
+
+		s, err := cfg.Adapter.UpdateSession(c.Context(), session)
+		if errors.Is(err, ErrSessionConflict) {
+			// a concurrent request has updated the session, retry on the current state
+			s, err = cfg.Adapter.GetSession(c.Context(), session.SessionToken)
+			if err == nil {
+				s.ActiveTeamID = session.ActiveTeamID
+				s, err = cfg.Adapter.UpdateSession(c.Context(), s)
+			}
+		}
+		if err != nil {
+			return cfg.handleError(c, "SwitchTeamHandler", err)
+		}
+		c.Locals(sessionKey, s)
+
+		e := events.New(events.SessionTeamSwitched, session.UserID)
+		e.Data = map[string]any{"session_id": session.ID, "team_id": session.ActiveTeamID}
+		cfg.Events.Emit(c.Context(), e)
+
+		if team == nil {
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		return c.JSON(team)
+	}
+}
+
+// memberTeam returns the team with the ID or slug if the user is a member of it.
+func (cfg Config) memberTeam(ctx context.Context, userID uuid.UUID, ref string) (adapters.GothTeam, error) {
+	teams, err := cfg.Adapter.ListUserTeams(ctx, userID)
+	if err != nil {
+		return adapters.GothTeam{}, err
+	}
+
+	for _, t := range teams {
+		if t.Slug == ref || t.ID.String() == ref {
+			return t, nil
+		}
+	}
+
+	return adapters.GothTeam{}, ErrNotTeamMember
+}
@@ -0,0 +1,151 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// ErrThrottled is thrown if an identity is temporarily locked due to failed attempts.
+var ErrThrottled = NewError(http.StatusTooManyRequests, "too many failed attempts, try again later")
+
+// ThrottleConfig is the configuration of the sign-in throttling.
+type ThrottleConfig struct {
+	// FreeAttempts is the number of failed attempts before delays are enforced.
+	//
+	// Optional. Default: 3
+	FreeAttempts int
+
+	// BaseDelay is the delay after the first throttled attempt. It doubles
+	// with every further failed attempt.
+	//
+	// Optional. Default: 1s
+	BaseDelay time.Duration
+
+	// MaxDelay caps the progressive delay.
+	//
+	// Optional. Default: 1m
+	MaxDelay time.Duration
+
+	// LockAfter is the number of failed attempts after which the identity is locked.
+	//
+	// Optional. Default: 10
+	LockAfter int
+
+	// LockDuration is the duration an identity is locked.
+	//
+	// Optional. Default: 15m
+	LockDuration time.Duration
+}
+
+// ThrottleConfigDefault is the default throttle config.
+var ThrottleConfigDefault = ThrottleConfig{
+	FreeAttempts: 3,
+	BaseDelay:    1 * time.Second,
+	MaxDelay:     1 * time.Minute,
+	LockAfter:    10,
+	LockDuration: 15 * time.Minute,
+}
+
+// Throttler enforces progressive delays and temporary locks on repeated
+// failed attempts of a single identity, independent of the source IP.
+// A nil Throttler does not throttle.
+type Throttler struct {
+	adapter adapters.Adapter
+	cfg     ThrottleConfig
+}
+
+// NewThrottler creates a new throttler that keeps the counters in the adapter.
+func NewThrottler(adapter adapters.Adapter, config ...ThrottleConfig) *Throttler {
+	cfg := ThrottleConfigDefault
+
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.FreeAttempts <= 0 {
+		cfg.FreeAttempts = ThrottleConfigDefault.FreeAttempts
+	}
+
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = ThrottleConfigDefault.BaseDelay
+	}
+
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = ThrottleConfigDefault.MaxDelay
+	}
+
+	if cfg.LockAfter <= 0 {
+		cfg.LockAfter = ThrottleConfigDefault.LockAfter
+	}
+
+	if cfg.LockDuration <= 0 {
+		cfg.LockDuration = ThrottleConfigDefault.LockDuration
+	}
+
+	return &Throttler{adapter: adapter, cfg: cfg}
+}
+
+// ThrottleKey builds the identifier for an identity (e.g. "email", "foo@example.com").
+func ThrottleKey(kind string, parts ...string) string {
+	return strings.ToLower(strings.Join(append([]string{kind}, parts...), ":"))
+}
+
+// Allow returns ErrThrottled if the identity is currently not allowed to attempt a sign-in.
+// Otherwise the attempt is recorded as failed attempt until Reset is called after a successful
+// sign-in. The check and the record are atomic in the adapter, so that concurrent attempts cannot
+// pass the check before their failures have been recorded.
+func (t *Throttler) Allow(ctx context.Context, key string) error {
+	if t == nil {
+		return nil
+	}
+
+	_, err := t.adapter.AttemptThrottle(ctx, key, t.Delay)
+	if errors.Is(err, adapters.ErrThrottled) {
+		return ErrThrottled
+	}
+
+	return err
+}
+
+// Fail records a failed attempt of the identity that has not been checked with Allow.
+func (t *Throttler) Fail(ctx context.Context, key string) error {
+	if t == nil {
+		return nil
+	}
+
+	_, err := t.adapter.IncrementThrottle(ctx, key)
+
+	return err
+}
+
+// Reset clears the failed attempts of the identity after a successful sign-in.
+func (t *Throttler) Reset(ctx context.Context, key string) error {
+	if t == nil {
+		return nil
+	}
+
+	return t.adapter.ResetThrottle(ctx, key)
+}
+
+// Delay returns the duration an identity has to wait after the given number of failures.
+func (t *Throttler) Delay(failures int) time.Duration {
+	if failures >= t.cfg.LockAfter {
+		return t.cfg.LockDuration
+	}
+
+	if failures < t.cfg.FreeAttempts {
+		return 0
+	}
+
+	delay := t.cfg.BaseDelay
+	for i := t.cfg.FreeAttempts; i < failures && delay < t.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, t.cfg.MaxDelay)
+}
@@ -0,0 +1,83 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// throttleAdapter keeps the failed attempts in memory and checks them like the gorm adapter.
+type throttleAdapter struct {
+	adapters.UnimplementedAdapter
+
+	mu        sync.Mutex
+	throttles map[string]adapters.GothThrottle
+}
+
+func (a *throttleAdapter) AttemptThrottle(_ context.Context, identifier string, delay func(failures int) time.Duration) (adapters.GothThrottle, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	throttle := a.throttles[identifier]
+
+	now := time.Now()
+	if now.Before(throttle.LastFailureAt.Add(delay(throttle.Failures))) {
+		return throttle, adapters.ErrThrottled
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	a.throttles[identifier] = throttle
+
+	return throttle, nil
+}
+
+func (a *throttleAdapter) ResetThrottle(_ context.Context, identifier string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.throttles, identifier)
+
+	return nil
+}
+
+func TestThrottlerAllowConcurrent(t *testing.T) {
+	adapter := &throttleAdapter{throttles: map[string]adapters.GothThrottle{}}
+	throttler := NewThrottler(adapter, ThrottleConfig{FreeAttempts: 3, BaseDelay: time.Hour})
+
+	key := ThrottleKey("email", "user@example.com")
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := throttler.Allow(context.Background(), key)
+			if err == nil {
+				allowed.Add(1)
+			} else if !errors.Is(err, ErrThrottled) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 3 {
+		t.Fatalf("expected the free attempts to be allowed, got %d", n)
+	}
+
+	if err := throttler.Reset(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := throttler.Allow(context.Background(), key); err != nil {
+		t.Fatalf("reset identity: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package goth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+	"golang.org/x/oauth2"
+)
+
+// ErrTokenExpired is thrown if the provider token has expired and cannot be refreshed.
+var ErrTokenExpired = NewError(http.StatusUnauthorized, "provider token expired and cannot be refreshed")
+
+// ProviderToken returns a valid OAuth token of the user for the provider from the stored accounts,
+// so that backend jobs can call the provider APIs on behalf of the user long after the login.
+// An expired token is refreshed with the refresh token and the account is updated.
+func ProviderToken(ctx context.Context, adapter adapters.Adapter, userID uuid.UUID, provider string) (*oauth2.Token, error) {
+	account, err := adapter.GetAccount(ctx, userID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	token := AccountToken(account)
+	if token.Valid() {
+		return token, nil
+	}
+
+	account, err = RefreshAccount(ctx, adapter, account)
+	if err != nil {
+		return nil, err
+	}
+
+	return AccountToken(account), nil
+}
+
+// AccountToken returns the OAuth token stored in the account.
+func AccountToken(account adapters.GothAccount) *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  cast.Value(account.AccessToken),
+		RefreshToken: cast.Value(account.RefreshToken),
+		TokenType:    cast.Value(account.TokenType),
+		Expiry:       cast.Value(account.ExpiresAt),
+	}
+}
+
+// RefreshAccount refreshes the token of the account with the provider and updates the account.
+func RefreshAccount(ctx context.Context, adapter adapters.Adapter, account adapters.GothAccount) (adapters.GothAccount, error) {
+	p, err := providers.GetProvider(account.Provider)
+	if err != nil {
+		return adapters.GothAccount{}, err
+	}
+
+	refresher, ok := p.(providers.TokenRefresher)
+	if !ok || cast.Value(account.RefreshToken) == "" {
+		return adapters.GothAccount{}, ErrTokenExpired
+	}
+
+	token, err := refresher.RefreshToken(ctx, cast.Value(account.RefreshToken))
+	if err != nil {
+		account.LastRefreshError = err.Error()
+
+		if _, uerr := adapter.UpdateAccount(ctx, account); uerr != nil {
+			log.Errorw("failed to record refresh error", "account", account.ID, "error", uerr)
+		}
+
+		return adapters.GothAccount{}, err
+	}
+
+	account.AccessToken = cast.Ptr(token.AccessToken)
+	account.ExpiresAt = cast.Ptr(token.Expiry)
+	account.LastRefreshedAt = cast.Ptr(time.Now())
+	account.LastRefreshError = ""
+
+	if token.TokenType != "" {
+		account.TokenType = cast.Ptr(token.TokenType)
+	}
+
+	if token.RefreshToken != "" {
+		account.RefreshToken = cast.Ptr(token.RefreshToken)
+	}
+
+	if scopes := providers.ScopeOf(token); len(scopes) > 0 {
+		account.Scope = cast.Ptr(providers.MergeScopes(scopes))
+	}
+
+	return adapter.UpdateAccount(ctx, account)
+}
@@ -0,0 +1,174 @@
+package goth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// TouchConfig is the configuration of the session touch writer.
+type TouchConfig struct {
+	// Adapter is the adapter storing the sessions.
+	Adapter adapters.Adapter
+
+	// Interval is the interval between flushes.
+	//
+	// Optional. Default: 5s
+	Interval time.Duration
+
+	// BatchSize is the maximum number of sessions written per batch.
+	// Queuing more touches flushes early.
+	//
+	// Optional. Default: 500
+	BatchSize int
+}
+
+// TouchConfigDefault is the default touch config.
+var TouchConfigDefault = TouchConfig{
+	Interval:  5 * time.Second,
+	BatchSize: 500,
+}
+
+// TouchWriter queues the expiry and last activity updates of sessions and writes them
+// in batches in the background (write-behind). Touches of the same session are coalesced.
+// Queued touches are lost if the process exits without Stop or Shutdown.
+type TouchWriter struct {
+	cfg    TouchConfig
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	flush  chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]adapters.SessionTouch
+}
+
+// NewTouchWriter creates a new session touch writer.
+func NewTouchWriter(config TouchConfig) *TouchWriter {
+	cfg := config
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = TouchConfigDefault.Interval
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = TouchConfigDefault.BatchSize
+	}
+
+	return &TouchWriter{
+		cfg:     cfg,
+		flush:   make(chan struct{}, 1),
+		pending: map[string]adapters.SessionTouch{},
+	}
+}
+
+// Touch queues the update of the expiry and last activity of the session.
+func (w *TouchWriter) Touch(sessionToken string, expiresAt, lastSeenAt time.Time) {
+	w.mu.Lock()
+	w.pending[sessionToken] = adapters.SessionTouch{SessionToken: sessionToken, ExpiresAt: expiresAt, LastSeenAt: lastSeenAt}
+	full := len(w.pending) >= w.cfg.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start starts writing in the background until the context is done, Stop or Shutdown is called.
+func (w *TouchWriter) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	onShutdownStop(w.Stop)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-w.flush:
+			}
+
+			if err := w.Flush(ctx); err != nil {
+				log.Errorw("failed to write session touches", "error", err)
+			}
+		}
+	}()
+}
+
+// Flush writes the queued touches in batches. If a batch fails, the unwritten touches are queued
+// again, unless the session has been touched in the meantime.
+func (w *TouchWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]adapters.SessionTouch, len(pending))
+	w.mu.Unlock()
+
+	batch := make([]adapters.SessionTouch, 0, min(len(pending), w.cfg.BatchSize))
+	write := func() error {
+		if err := w.cfg.Adapter.TouchSessions(ctx, batch); err != nil {
+			w.requeue(pending)
+			return err
+		}
+
+		for _, t := range batch {
+			delete(pending, t.SessionToken)
+		}
+		batch = batch[:0]
+
+		return nil
+	}
+
+	for _, t := range pending {
+		batch = append(batch, t)
+
+		if len(batch) < w.cfg.BatchSize {
+			continue
+		}
+
+		if err := write(); err != nil {
+			return err
+		}
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return write()
+}
+
+// requeue queues the unwritten touches again without overwriting newer touches of the sessions.
+func (w *TouchWriter) requeue(touches map[string]adapters.SessionTouch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for token, t := range touches {
+		if _, ok := w.pending[token]; !ok {
+			w.pending[token] = t
+		}
+	}
+}
+
+// Stop stops the writer and flushes the queued touches.
+func (w *TouchWriter) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+
+	w.wg.Wait()
+
+	if err := w.Flush(context.Background()); err != nil {
+		log.Errorw("failed to write session touches", "error", err)
+	}
+}
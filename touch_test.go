@@ -0,0 +1,59 @@
+package goth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+)
+
+// touchAdapter records the written touches and fails while err is set.
+type touchAdapter struct {
+	adapters.UnimplementedAdapter
+	err     error
+	touches map[string]adapters.SessionTouch
+}
+
+func (a *touchAdapter) TouchSessions(_ context.Context, touches []adapters.SessionTouch) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	for _, t := range touches {
+		a.touches[t.SessionToken] = t
+	}
+
+	return nil
+}
+
+func TestTouchWriterFlushFailure(t *testing.T) {
+	adapter := &touchAdapter{err: errors.New("unavailable"), touches: map[string]adapters.SessionTouch{}}
+	w := NewTouchWriter(TouchConfig{Adapter: adapter, BatchSize: 2})
+
+	now := time.Now()
+	for _, token := range []string{"a", "b", "c"} {
+		w.Touch(token, now.Add(time.Hour), now)
+	}
+
+	if err := w.Flush(context.Background()); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+
+	// a newer touch of a queued session is not overwritten by the unwritten touch
+	w.Touch("a", now.Add(2*time.Hour), now)
+
+	adapter.err = nil
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(adapter.touches) != 3 {
+		t.Fatalf("expected 3 written touches, got %d", len(adapter.touches))
+	}
+
+	if !adapter.touches["a"].ExpiresAt.Equal(now.Add(2 * time.Hour)) {
+		t.Fatalf("the newer touch has been overwritten: %v", adapter.touches["a"].ExpiresAt)
+	}
+}
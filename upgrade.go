@@ -0,0 +1,211 @@
+package goth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/events"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+var _ GothHandler = (*ScopeUpgradeHandler)(nil)
+
+var (
+	// ErrScopeUpgradeUnsupported is thrown if the provider does not support incremental authorization.
+	ErrScopeUpgradeUnsupported = NewError(http.StatusNotImplemented, "provider does not support scope upgrades")
+	// ErrMissingScopes is thrown if no scopes have been requested.
+	ErrMissingScopes = NewError(http.StatusBadRequest, "missing scopes")
+	// ErrInvalidState is thrown if the state of a login or an upgrade is invalid, expired or has already been used.
+	ErrInvalidState = NewError(http.StatusBadRequest, "invalid or expired state")
+	// ErrAccountMismatch is thrown if the authorized account is not the linked account.
+	ErrAccountMismatch = NewError(http.StatusForbidden, "authorized account does not match the linked account")
+)
+
+const (
+	upgradeStatePrefix = "upgrade."
+	upgradeExpiry      = 10 * time.Minute
+)
+
+// ScopeUpgradeHandler is the default handler to request additional scopes
+// for an already-linked provider account (e.g. `/auth/github/upgrade?scopes=repo`).
+//
+// The provider redirects to the callback of the provider, which completes the
+// upgrade and merges the new grant into the stored account.
+type ScopeUpgradeHandler struct{}
+
+// NewScopeUpgradeHandler returns a new default scope upgrade handler.
+func NewScopeUpgradeHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return cfg.ScopeUpgradeHandler.New(cfg)
+}
+
+// New creates a new handler to request additional scopes.
+func (ScopeUpgradeHandler) New(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		p, err := providers.GetProvider(c.Params(provider))
+		if err != nil {
+			return ErrMissingProviderName
+		}
+
+		upgrader, ok := p.(providers.ScopeUpgrader)
+		if !ok {
+			return ErrScopeUpgradeUnsupported
+		}
+
+		scopes := providers.ParseScopes(c.Query("scopes"))
+		if len(scopes) == 0 {
+			return ErrMissingScopes
+		}
+
+		session, err := cfg.currentSession(c)
+		if err != nil {
+			return err
+		}
+
+		account, err := cfg.Adapter.GetAccount(c.Context(), session.UserID, p.ID())
+		if err != nil {
+			return err
+		}
+
+		nonce, err := generateRandomString(cfg.Rand, 64)
+		if err != nil {
+			return err
+		}
+
+		s := upgradeStatePrefix + base64.RawURLEncoding.EncodeToString([]byte(strings.Join(scopes, " "))) + "." + base64.RawURLEncoding.EncodeToString(nonce)
+
+		_, err = cfg.Adapter.CreateVerificationToken(c.Context(), adapters.GothVerificationToken{
+			Identifier: upgradeIdentifier(session, p.ID()),
+			Token:      adapters.HashToken(s),
+			ExpiresAt:  time.Now().Add(upgradeExpiry),
+		})
+		if err != nil {
+			return err
+		}
+
+		intent, err := upgrader.BeginUpgrade(c.Context(), account, s, providers.ParseScopes(cast.Value(account.Scope)+" "+strings.Join(scopes, " ")))
+		if err != nil {
+			return err
+		}
+
+		url, err := intent.GetAuthURL()
+		if err != nil {
+			return err
+		}
+
+		return c.Redirect(url, fiber.StatusTemporaryRedirect)
+	}
+}
+
+// isUpgradeState returns true if the state has been issued by the scope upgrade handler.
+func isUpgradeState(s string) bool {
+	return strings.HasPrefix(s, upgradeStatePrefix)
+}
+
+// completeUpgrade completes a scope upgrade and merges the grant into the stored account.
+//
+// nolint:gocyclo
+func (cfg Config) completeUpgrade(c *fiber.Ctx, p providers.Provider, s string) error {
+	upgrader, ok := p.(providers.ScopeUpgrader)
+	if !ok {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrScopeUpgradeUnsupported)
+	}
+
+	session, err := cfg.currentSession(c)
+	if err != nil {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+	}
+
+	_, err = cfg.Adapter.UseVerficationToken(c.Context(), upgradeIdentifier(session, p.ID()), adapters.HashToken(s))
+	if err != nil {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrInvalidState)
+	}
+
+	granted, err := upgrader.CompleteUpgrade(c.Context(), ParamsFromContext(c))
+	if err != nil {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+	}
+
+	account, err := cfg.Adapter.GetAccount(c.Context(), session.UserID, p.ID())
+	if err != nil {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+	}
+
+	if cast.Value(account.ProviderAccountID) != cast.Value(granted.ProviderAccountID) {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", ErrAccountMismatch)
+	}
+
+	scopes := providers.ParseScopes(cast.Value(granted.Scope))
+	if len(scopes) == 0 {
+		scopes = upgradeScopes(s)
+	}
+
+	account.AccessToken = granted.AccessToken
+	account.ExpiresAt = granted.ExpiresAt
+	account.TokenType = granted.TokenType
+	account.Scope = cast.Ptr(providers.MergeScopes(providers.ParseScopes(cast.Value(account.Scope)), scopes))
+
+	if cast.Value(granted.RefreshToken) != "" {
+		account.RefreshToken = granted.RefreshToken
+	}
+
+	account, err = cfg.Adapter.UpdateAccount(c.Context(), account)
+	if err != nil {
+		return cfg.handleError(c, "CompleteAuthCompleteHandler", err)
+	}
+
+	e := events.New(events.AccountScopesUpgraded, session.UserID)
+	e.Provider = p.ID()
+	e.Data = map[string]any{"scope": cast.Value(account.Scope)}
+	cfg.Events.Emit(c.Context(), e)
+
+	return cfg.CompletionFilter(c)
+}
+
+// currentSession returns the session of the request, either from the protect
+// middleware or from the session token of the request. The session is read from the primary,
+// since it may just have been created (e.g. by a reloaded callback).
+func (cfg Config) currentSession(c *fiber.Ctx) (adapters.GothSession, error) {
+	session, err := SessionFromContext(c)
+	if err == nil {
+		return session, nil
+	}
+
+	token, err := cfg.Extractor(c)
+	if err != nil {
+		return adapters.GothSession{}, ErrMissingSession
+	}
+
+	session, err = cfg.Adapter.GetSession(adapters.WithPrimary(c.Context()), token)
+	if err != nil || !session.IsValid() {
+		return adapters.GothSession{}, ErrMissingSession
+	}
+
+	return session, nil
+}
+
+// upgradeScopes returns the scopes requested with the state of an upgrade.
+func upgradeScopes(s string) []string {
+	encoded, _, _ := strings.Cut(strings.TrimPrefix(s, upgradeStatePrefix), ".")
+
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+
+	return providers.ParseScopes(string(b))
+}
+
+func upgradeIdentifier(session adapters.GothSession, providerID string) string {
+	return "scope-upgrade:" + providerID + ":" + session.ID.String()
+}
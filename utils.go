@@ -10,16 +10,15 @@ import (
 	"io"
 )
 
-// GenerateKey Generates an encryption key
+// GenerateKey Generates a base64 encoded encryption key of 256 bits.
+// NewKey returns the typed key material and DeriveKeys derives the keys from a master secret.
 func GenerateKey() string {
-	const keyLen = 32
-	ret := make([]byte, keyLen)
-
-	if _, err := rand.Read(ret); err != nil {
+	k, err := NewKey()
+	if err != nil {
 		panic(err)
 	}
 
-	return base64.StdEncoding.EncodeToString(ret)
+	return k.Base64()
 }
 
 // EncryptCookie Encrypts a cookie value with specific encryption key
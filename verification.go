@@ -0,0 +1,192 @@
+package goth
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/pkg/utilx"
+)
+
+var (
+	// ErrWeakVerificationToken is thrown if a generated verification token does not satisfy the policy.
+	ErrWeakVerificationToken = NewError(http.StatusInternalServerError, "verification token does not satisfy the length or entropy policy")
+	// ErrVerificationRateLimited is thrown if too many verification tokens have been issued for an identifier.
+	ErrVerificationRateLimited = NewError(http.StatusTooManyRequests, "too many verification tokens requested, try again later")
+)
+
+// VerificationConfig is the policy of verification tokens.
+type VerificationConfig struct {
+	// Generator generates new tokens.
+	//
+	// Optional. Default: DefaultSessionTokenGenerator
+	Generator func() (string, error)
+
+	// MinLength is the minimum length of a token.
+	//
+	// Optional. Default: 32
+	MinLength int
+
+	// MinEntropy is the minimum estimated entropy of a token in bits.
+	//
+	// Optional. Default: 128
+	MinEntropy float64
+
+	// RateLimit is the maximum number of tokens issued for an identifier within the RateWindow.
+	//
+	// Optional. Default: 5
+	RateLimit int
+
+	// RateWindow is the window of the rate limit. It restarts with every issued token.
+	//
+	// Optional. Default: 1h
+	RateWindow time.Duration
+}
+
+// VerificationConfigDefault is the default verification config.
+var VerificationConfigDefault = VerificationConfig{
+	Generator:  DefaultSessionTokenGenerator,
+	MinLength:  32,
+	MinEntropy: 128,
+	RateLimit:  5,
+	RateWindow: time.Hour,
+}
+
+// VerificationTokens issues and consumes single-use verification tokens (e.g. for magic links,
+// invitations or password resets). Only the hash of a token is stored and a token is consumed
+// atomically by the adapter, so that it cannot be replayed.
+type VerificationTokens struct {
+	adapter adapters.Adapter
+	cfg     VerificationConfig
+}
+
+// NewVerificationTokens creates new verification tokens that are stored in the adapter.
+// The rate limits are kept in the throttle counters of the adapter.
+func NewVerificationTokens(adapter adapters.Adapter, config ...VerificationConfig) *VerificationTokens {
+	cfg := VerificationConfigDefault
+
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.Generator == nil {
+		cfg.Generator = VerificationConfigDefault.Generator
+	}
+
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = VerificationConfigDefault.MinLength
+	}
+
+	if cfg.MinEntropy <= 0 {
+		cfg.MinEntropy = VerificationConfigDefault.MinEntropy
+	}
+
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = VerificationConfigDefault.RateLimit
+	}
+
+	if cfg.RateWindow <= 0 {
+		cfg.RateWindow = VerificationConfigDefault.RateWindow
+	}
+
+	return &VerificationTokens{adapter: adapter, cfg: cfg}
+}
+
+// Issue generates a new token for the identifier that is valid for the expiry.
+// It returns ErrVerificationRateLimited if too many tokens have been issued for the identifier.
+func (v *VerificationTokens) Issue(ctx context.Context, identifier string, expiry time.Duration) (string, error) {
+	if err := v.allow(ctx, identifier); err != nil {
+		return "", err
+	}
+
+	token, err := v.cfg.Generator()
+	if err != nil {
+		return "", err
+	}
+
+	if err := v.Validate(token); err != nil {
+		return "", err
+	}
+
+	_, err = v.adapter.CreateVerificationToken(ctx, adapters.GothVerificationToken{
+		Identifier: identifier,
+		Token:      adapters.HashToken(token),
+		ExpiresAt:  time.Now().Add(expiry),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Use consumes the token of the identifier. It returns ErrInvalidVerificationToken
+// if the token does not exist, has expired or has already been used.
+func (v *VerificationTokens) Use(ctx context.Context, identifier, token string) error {
+	if utilx.Empty(token) {
+		return ErrInvalidVerificationToken
+	}
+
+	_, err := v.adapter.UseVerficationToken(ctx, identifier, adapters.HashToken(token))
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	return nil
+}
+
+// Validate returns ErrWeakVerificationToken if the token is too short or its estimated entropy too low.
+func (v *VerificationTokens) Validate(token string) error {
+	if len(token) < v.cfg.MinLength || TokenEntropy(token) < v.cfg.MinEntropy {
+		return ErrWeakVerificationToken
+	}
+
+	return nil
+}
+
+// allow enforces the rate limit of the identifier and counts the issued token.
+func (v *VerificationTokens) allow(ctx context.Context, identifier string) error {
+	key := ThrottleKey("verification", identifier)
+
+	throttle, err := v.adapter.GetThrottle(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if time.Since(throttle.LastFailureAt) >= v.cfg.RateWindow && throttle.Failures > 0 {
+		if err := v.adapter.ResetThrottle(ctx, key); err != nil {
+			return err
+		}
+	} else if throttle.Failures >= v.cfg.RateLimit {
+		return ErrVerificationRateLimited
+	}
+
+	_, err = v.adapter.IncrementThrottle(ctx, key)
+
+	return err
+}
+
+// TokenEntropy estimates the entropy of a token in bits from the frequency of its characters.
+func TokenEntropy(token string) float64 {
+	if token == "" {
+		return 0
+	}
+
+	counts := map[rune]int{}
+	n := 0
+
+	for _, r := range token {
+		counts[r]++
+		n++
+	}
+
+	var bits float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		bits -= p * math.Log2(p)
+	}
+
+	return bits * float64(n)
+}
@@ -0,0 +1,110 @@
+package goth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// WellKnownChangePasswordPath is the path password managers use to find the password change form.
+	//
+	// See https://w3c.github.io/webappsec-change-password-url/
+	WellKnownChangePasswordPath = "/.well-known/change-password"
+
+	// WellKnownSecurityTxtPath is the path of the security.txt file.
+	//
+	// See https://www.rfc-editor.org/rfc/rfc9116
+	WellKnownSecurityTxtPath = "/.well-known/security.txt"
+)
+
+// SecurityTxt is the content of the security.txt file.
+type SecurityTxt struct {
+	// Contact is a list of URIs (e.g. "mailto:security@example.com") to report vulnerabilities to.
+	Contact []string
+	// Expires is the date after which the content should be considered stale.
+	Expires time.Time
+	// Encryption is a list of URIs of keys to use for encrypted communication.
+	Encryption []string
+	// Acknowledgments is a list of URIs of pages recognizing security researchers.
+	Acknowledgments []string
+	// PreferredLanguages is a list of language tags (e.g. "en", "de").
+	PreferredLanguages []string
+	// Canonical is a list of URIs the security.txt file is located at.
+	Canonical []string
+	// Policy is a list of URIs of the vulnerability disclosure policy.
+	Policy []string
+	// Hiring is a list of URIs of security related job positions.
+	Hiring []string
+}
+
+// String returns the security.txt file.
+func (s SecurityTxt) String() string {
+	var b strings.Builder
+
+	write := func(field string, values ...string) {
+		for _, v := range values {
+			b.WriteString(field + ": " + v + "\n")
+		}
+	}
+
+	write("Contact", s.Contact...)
+
+	if !s.Expires.IsZero() {
+		write("Expires", s.Expires.UTC().Format(time.RFC3339))
+	}
+
+	write("Encryption", s.Encryption...)
+	write("Acknowledgments", s.Acknowledgments...)
+
+	if len(s.PreferredLanguages) > 0 {
+		write("Preferred-Languages", strings.Join(s.PreferredLanguages, ", "))
+	}
+
+	write("Canonical", s.Canonical...)
+	write("Policy", s.Policy...)
+	write("Hiring", s.Hiring...)
+
+	return b.String()
+}
+
+// NewChangePasswordRedirectHandler returns a handler that redirects password managers
+// to the configured ChangePasswordURL.
+func NewChangePasswordRedirectHandler(config ...Config) fiber.Handler {
+	cfg := configDefault(config...)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.ChangePasswordURL == "" {
+			return fiber.ErrNotFound
+		}
+
+		return c.Redirect(cfg.ChangePasswordURL, fiber.StatusFound)
+	}
+}
+
+// NewSecurityTxtHandler returns a handler that serves the security.txt file.
+func NewSecurityTxtHandler(txt SecurityTxt) fiber.Handler {
+	body := txt.String()
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+
+		return c.SendString(body)
+	}
+}
+
+// RegisterWellKnownRoutes registers the well-known discovery routes on the router.
+// The change-password route is only registered if a ChangePasswordURL is configured
+// and the security.txt route only if SecurityTxt is configured.
+func RegisterWellKnownRoutes(router fiber.Router, config ...Config) {
+	cfg := configDefault(config...)
+
+	if cfg.ChangePasswordURL != "" {
+		router.Get(WellKnownChangePasswordPath, NewChangePasswordRedirectHandler(cfg))
+	}
+
+	if cfg.SecurityTxt != nil {
+		router.Get(WellKnownSecurityTxtPath, NewSecurityTxtHandler(*cfg.SecurityTxt))
+	}
+}
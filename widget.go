@@ -0,0 +1,233 @@
+package goth
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+// WidgetAPIVersion is the version of the widget API. Incompatible changes get a new version.
+const WidgetAPIVersion = "v1"
+
+// WidgetProvider is a provider of the widget API.
+type WidgetProvider struct {
+	// ID is the ID of the provider.
+	ID string `json:"id"`
+	// Name is the display name of the provider.
+	Name string `json:"name"`
+	// Type is the type of the provider (e.g. "oauth2").
+	Type providers.ProviderType `json:"type"`
+	// BeginURL is the path of the endpoint to begin a login with the provider.
+	BeginURL string `json:"begin_url"`
+}
+
+// WidgetLogin is the response of the begin endpoint of the widget API.
+type WidgetLogin struct {
+	// URL is the URL of the provider to navigate to, e.g. in a popup.
+	URL string `json:"url"`
+}
+
+// WidgetUser is the user of the widget API. It does not include the accounts and tokens of the user.
+type WidgetUser struct {
+	// ID is the unique identifier of the user.
+	ID uuid.UUID `json:"id"`
+	// Name is the name of the user.
+	Name string `json:"name"`
+	// Email is the email of the user.
+	Email string `json:"email"`
+	// EmailVerified is true if the email is verified.
+	EmailVerified bool `json:"email_verified"`
+	// Image is the image URL of the user, if any.
+	Image *string `json:"image,omitempty"`
+	// Locale is the preferred language of the user, if any.
+	Locale string `json:"locale,omitempty"`
+}
+
+// WidgetSession is the response of the session endpoint of the widget API, which is polled while a login is pending.
+type WidgetSession struct {
+	// Authenticated is true if the request has a valid session.
+	Authenticated bool `json:"authenticated"`
+	// Session is the current session, if authenticated.
+	Session *SessionInfo `json:"session,omitempty"`
+}
+
+// WidgetError is the error response of the widget API.
+type WidgetError struct {
+	// Status is the HTTP status of the error.
+	Status int `json:"status"`
+	// Message is the message of the error.
+	Message string `json:"message"`
+	// Category is the category of the error (see ErrorCategory).
+	Category ErrorCategory `json:"category,omitempty"`
+	// Provider is the provider of the request, if any.
+	Provider string `json:"provider,omitempty"`
+}
+
+// widgetURLs are the prefixes of the widget APIs registered by RegisterWidgetRoutes.
+var widgetURLs sync.Map
+
+// isWidgetPath returns true if the path is under the prefix of a registered widget API.
+func isWidgetPath(p string) bool {
+	found := false
+
+	widgetURLs.Range(func(prefix, _ any) bool {
+		found = hasPathPrefix(p, prefix.(string))
+		return !found
+	})
+
+	return found
+}
+
+// WidgetPath returns the versioned path of the widget API endpoint (e.g. "/providers").
+func (cfg Config) WidgetPath(endpoint string) string {
+	return strings.TrimSuffix(cfg.WidgetURL, "/") + "/" + WidgetAPIVersion + endpoint
+}
+
+// RegisterWidgetRoutes registers the JSON API for embedded sign-in (e.g. in single-page or mobile apps)
+// on the router under the WidgetURL. Errors are responded as WidgetError. The protect middleware
+// skips the paths under the WidgetURL once the routes are registered, /me protects itself.
+//
+//	GET  <WidgetURL>/v1/providers
+//	GET  <WidgetURL>/v1/providers/:provider/begin
+//	GET  <WidgetURL>/v1/session
+//	GET  <WidgetURL>/v1/me
+//	POST <WidgetURL>/v1/logout
+//	GET  <WidgetURL>/v1/openapi.json
+func RegisterWidgetRoutes(router fiber.Router, config ...Config) {
+	cfg := configDefault(config...)
+	cfg.ErrorHandler = widgetErrorHandler
+	cfg.CompletionFilter = NoContent
+
+	widgetURLs.Store(cfg.WidgetURL, true)
+
+	router.Get(cfg.WidgetPath("/providers"), newWidgetProvidersHandler(cfg))
+	router.Get(cfg.WidgetPath("/providers/:"+provider+"/begin"), newWidgetBeginHandler(cfg))
+	router.Get(cfg.WidgetPath("/session"), newWidgetSessionHandler(cfg))
+	router.Get(cfg.WidgetPath("/me"), NewProtectedHandler(newWidgetMeHandler(cfg), cfg))
+	router.Post(cfg.WidgetPath("/logout"), cfg.LogoutHandler.New(cfg))
+	router.Get(cfg.WidgetPath("/openapi.json"), func(c *fiber.Ctx) error {
+		return c.JSON(WidgetOpenAPI(cfg))
+	})
+}
+
+// widgetErrorHandler responds with the error as WidgetError.
+func widgetErrorHandler(c *fiber.Ctx, err error) error {
+	res := WidgetError{Status: http.StatusBadRequest, Message: err.Error()}
+
+	var e *Error
+	var fe *fiber.Error
+
+	switch {
+	case errors.As(err, &e):
+		res.Status, res.Message = e.Code, e.Message
+	case errors.As(err, &fe):
+		res.Status, res.Message = fe.Code, fe.Message
+	}
+
+	if ec, ok := ErrorContextFromContext(c); ok {
+		res.Category, res.Provider = ec.Category, ec.Provider
+	}
+
+	return c.Status(res.Status).JSON(res)
+}
+
+// newWidgetProvidersHandler returns the handler that lists the registered providers ordered by ID.
+func newWidgetProvidersHandler(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		registered := providers.GetProviders()
+
+		ids := make([]string, 0, len(registered))
+		for id := range registered {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		res := []WidgetProvider{}
+		for _, id := range ids {
+			p := registered[id]
+			res = append(res, WidgetProvider{
+				ID:       p.ID(),
+				Name:     p.Name(),
+				Type:     p.Type(),
+				BeginURL: cfg.WidgetPath("/providers/" + p.ID() + "/begin"),
+			})
+		}
+
+		return c.JSON(res)
+	}
+}
+
+// newWidgetBeginHandler returns the handler that begins a login and responds with the URL of the provider.
+func newWidgetBeginHandler(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		p, err := providers.GetProvider(c.Params(provider))
+		if err != nil {
+			return cfg.handleError(c, "WidgetBeginHandler", NewError(http.StatusNotFound, err.Error()))
+		}
+
+		url, err := cfg.beginAuth(c, p)
+		if err != nil {
+			return cfg.handleError(c, "WidgetBeginHandler", err)
+		}
+
+		return c.JSON(WidgetLogin{URL: url})
+	}
+}
+
+// newWidgetMeHandler returns the handler that responds with the current user.
+func newWidgetMeHandler(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := UserFromContext(c, cfg.Adapter)
+		if err != nil {
+			return cfg.handleError(c, "WidgetMeHandler", ErrMissingSession)
+		}
+
+		return c.JSON(WidgetUser{
+			ID:            user.ID,
+			Name:          user.Name,
+			Email:         user.Email,
+			EmailVerified: cast.Value(user.EmailVerified),
+			Image:         user.Image,
+			Locale:        user.Locale,
+		})
+	}
+}
+
+// newWidgetSessionHandler returns the handler that responds with the current session, if any.
+// It does not fail without a session, so that it can be polled while a login is pending.
+func newWidgetSessionHandler(cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		token, err := cfg.Extractor(c)
+		if err != nil {
+			return c.JSON(WidgetSession{})
+		}
+
+		session, err := cfg.Adapter.GetSession(c.Context(), token)
+		if err != nil || !session.IsValid() || cfg.checkBinding(c, session) != nil {
+			return c.JSON(WidgetSession{})
+		}
+
+		info := sessionInfo(session)
+
+		return c.JSON(WidgetSession{Authenticated: true, Session: &info})
+	}
+}
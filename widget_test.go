@@ -0,0 +1,316 @@
+package goth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/zeiss/fiber-goth/adapters"
+	"github.com/zeiss/fiber-goth/providers"
+	"github.com/zeiss/pkg/cast"
+)
+
+// widgetAdapter has a single user with a session of the token "token".
+type widgetAdapter struct {
+	adapters.UnimplementedAdapter
+
+	mu      sync.Mutex
+	user    adapters.GothUser
+	session *adapters.GothSession
+}
+
+func newWidgetAdapter() *widgetAdapter {
+	user := adapters.GothUser{ID: uuid.New(), Name: "User", Email: "user@example.com", EmailVerified: cast.Ptr(true)}
+
+	return &widgetAdapter{
+		user: user,
+		session: &adapters.GothSession{
+			ID:           uuid.New(),
+			UserID:       user.ID,
+			SessionToken: "token",
+			Provider:     "widget",
+			ExpiresAt:    time.Now().Add(time.Hour),
+		},
+	}
+}
+
+func (a *widgetAdapter) GetSession(_ context.Context, token string) (adapters.GothSession, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session == nil || a.session.SessionToken != token {
+		return adapters.GothSession{}, ErrMissingSession
+	}
+
+	return *a.session, nil
+}
+
+func (a *widgetAdapter) RefreshSession(_ context.Context, session adapters.GothSession) (adapters.GothSession, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.session = &session
+
+	return session, nil
+}
+
+func (a *widgetAdapter) DeleteSession(_ context.Context, token string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session == nil || a.session.SessionToken != token {
+		return ErrMissingSession
+	}
+	a.session = nil
+
+	return nil
+}
+
+func (a *widgetAdapter) GetUser(_ context.Context, id uuid.UUID) (adapters.GothUser, error) {
+	if id != a.user.ID {
+		return adapters.GothUser{}, ErrMissingUser
+	}
+
+	return a.user, nil
+}
+
+// widgetProvider begins logins with a fixed URL.
+type widgetProvider struct {
+	providers.UnimplementedProvider
+}
+
+func (p *widgetProvider) ID() string {
+	return "widget"
+}
+
+func (p *widgetProvider) Name() string {
+	return "Widget"
+}
+
+func (p *widgetProvider) Type() providers.ProviderType {
+	return providers.ProviderTypeOAuth2
+}
+
+func (p *widgetProvider) BeginAuth(_ context.Context, _ adapters.Adapter, state string, _ providers.AuthParams) (providers.AuthIntent, error) {
+	return &widgetIntent{url: "https://provider.example.com/authorize?state=" + state}, nil
+}
+
+type widgetIntent struct {
+	url string
+}
+
+func (i *widgetIntent) GetAuthURL() (string, error) {
+	return i.url, nil
+}
+
+// checkSchema fails the test if the value does not match the schema of the spec.
+func checkSchema(t *testing.T, spec map[string]any, schema map[string]any, value any, at string) {
+	t.Helper()
+
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		schema = spec["components"].(map[string]any)["schemas"].(map[string]any)[name].(map[string]any)
+	}
+
+	if value == nil {
+		if schema["nullable"] != true && schema["$ref"] == nil {
+			t.Errorf("%s: unexpected null", at)
+		}
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			t.Errorf("%s: expected object, got %T", at, value)
+			return
+		}
+
+		properties := schema["properties"].(map[string]any)
+		for _, name := range schema["required"].([]string) {
+			if _, ok := obj[name]; !ok {
+				t.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+
+		for name, v := range obj {
+			s, ok := properties[name]
+			if !ok {
+				t.Errorf("%s: undocumented property %q", at, name)
+				continue
+			}
+			checkSchema(t, spec, s.(map[string]any), v, at+"."+name)
+		}
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			t.Errorf("%s: expected array, got %T", at, value)
+			return
+		}
+
+		for _, v := range arr {
+			checkSchema(t, spec, schema["items"].(map[string]any), v, at+"[]")
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			t.Errorf("%s: expected string, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			t.Errorf("%s: expected boolean, got %T", at, value)
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			t.Errorf("%s: expected number, got %T", at, value)
+		}
+	}
+}
+
+func TestWidgetContract(t *testing.T) {
+	providers.RegisterProvider(&widgetProvider{})
+
+	cfg := Config{Adapter: newWidgetAdapter(), WidgetURL: ConfigDefault.WidgetURL}
+
+	app := fiber.New()
+	RegisterWidgetRoutes(app, cfg)
+
+	spec := WidgetOpenAPI(cfg)
+	paths := spec["paths"].(map[string]any)
+
+	request := func(method, path string, session bool) *http.Response {
+		req := httptest.NewRequest(method, path, nil)
+		if session {
+			req.Header.Set(fiber.HeaderCookie, ConfigDefault.CookieName+"=token")
+		}
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res
+	}
+
+	// check responds with the status and body of the request documented in the spec of the endpoint
+	check := func(method, endpoint, path string, session bool, status int) {
+		t.Helper()
+
+		res := request(method, path, session)
+		if res.StatusCode != status {
+			t.Fatalf("%s %s: expected status %d, got %d", method, path, status, res.StatusCode)
+		}
+
+		op, ok := paths[cfg.WidgetPath(endpoint)].(map[string]any)[strings.ToLower(method)].(map[string]any)
+		if !ok {
+			t.Fatalf("%s %s: undocumented endpoint", method, endpoint)
+		}
+
+		responses := op["responses"].(map[string]any)
+		response, ok := responses[strconv.Itoa(status)].(map[string]any)
+		if !ok {
+			response = responses["default"].(map[string]any)
+		}
+
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, ok := response["content"].(map[string]any)
+		if !ok {
+			if len(body) != 0 {
+				t.Fatalf("%s %s: unexpected body %q", method, path, body)
+			}
+			return
+		}
+
+		if ct := res.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, fiber.MIMEApplicationJSON) {
+			t.Fatalf("%s %s: unexpected content type %q", method, path, ct)
+		}
+
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+
+		schema := content[fiber.MIMEApplicationJSON].(map[string]any)["schema"].(map[string]any)
+		checkSchema(t, spec, schema, value, method+" "+path)
+	}
+
+	check(http.MethodGet, "/providers", cfg.WidgetPath("/providers"), false, http.StatusOK)
+	check(http.MethodGet, "/providers/{provider}/begin", cfg.WidgetPath("/providers/widget/begin"), false, http.StatusOK)
+	check(http.MethodGet, "/providers/{provider}/begin", cfg.WidgetPath("/providers/unknown/begin"), false, http.StatusNotFound)
+	check(http.MethodGet, "/session", cfg.WidgetPath("/session"), false, http.StatusOK)
+	check(http.MethodGet, "/session", cfg.WidgetPath("/session"), true, http.StatusOK)
+	check(http.MethodGet, "/me", cfg.WidgetPath("/me"), false, http.StatusUnauthorized)
+	check(http.MethodGet, "/me", cfg.WidgetPath("/me"), true, http.StatusOK)
+	check(http.MethodPost, "/logout", cfg.WidgetPath("/logout"), true, http.StatusNoContent)
+	check(http.MethodPost, "/logout", cfg.WidgetPath("/logout"), true, http.StatusUnauthorized)
+
+	// every registered route of the widget API is documented and vice versa
+	routes := []string{}
+	for _, r := range app.GetRoutes(true) {
+		if r.Method == http.MethodHead || r.Path == cfg.WidgetPath("/openapi.json") {
+			continue
+		}
+		routes = append(routes, r.Method+" "+strings.ReplaceAll(r.Path, ":"+provider, "{"+provider+"}"))
+	}
+
+	documented := []string{}
+	for path, ops := range paths {
+		for method := range ops.(map[string]any) {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+
+	if strings.Join(routes, "\n") != strings.Join(documented, "\n") {
+		t.Fatalf("routes %v do not match the spec %v", routes, documented)
+	}
+}
+
+func TestProtectMiddlewareWidgetURL(t *testing.T) {
+	cfg := Config{Adapter: newWidgetAdapter(), WidgetURL: "/embed"}
+
+	app := fiber.New()
+	app.Use(NewProtectMiddleware(cfg))
+	app.Use(func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	status := func(path string) int {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res.StatusCode
+	}
+
+	if s := status("/embed/v1/session"); s != http.StatusUnauthorized {
+		t.Fatalf("unregistered widget API: unexpected status %d", s)
+	}
+
+	RegisterWidgetRoutes(fiber.New(), cfg)
+
+	if s := status("/embed/v1/session"); s != http.StatusOK {
+		t.Fatalf("registered widget API: unexpected status %d", s)
+	}
+
+	if s := status("/embedadmin"); s != http.StatusUnauthorized {
+		t.Fatalf("path sharing the prefix: unexpected status %d", s)
+	}
+}